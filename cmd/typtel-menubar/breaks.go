@@ -0,0 +1,36 @@
+//go:build darwin
+// +build darwin
+
+package main
+
+import (
+	"log"
+	"time"
+
+	"github.com/aayushbajaj/typing-telemetry/internal/notify"
+	"github.com/aayushbajaj/typing-telemetry/internal/rsiguard"
+)
+
+// breaks is fed by the keystroke and mouse goroutines and ticked by the stats
+// ticker, so capture never waits on the DB or a notification.
+var breaks = &rsiguard.Reminder{}
+
+// flushBreaks runs break reminders (`typtel breaks on`) on the stats ticker:
+// a notification when a break is due, a record of each break taken or skipped.
+func flushBreaks() {
+	s := store.GetRSISettings()
+	cfg := rsiguard.Config{MicroAfter: s.MicroAfter, MicroBreak: s.MicroBreak, LongAfter: s.LongAfter, LongBreak: s.LongBreak}
+	for _, ev := range breaks.Tick(s.Enabled, cfg, time.Now()) {
+		if ev.Type == rsiguard.Due {
+			if err := notify.Send(rsiguard.Notification(ev, cfg)); err != nil {
+				log.Printf("Failed to send notification: %v", err)
+			}
+			continue
+		}
+		if takenAt, ok := rsiguard.Record(ev, cfg); ok {
+			if err := store.RecordBreak(string(ev.Kind), ev.DueAt, takenAt, ev.Worked); err != nil {
+				log.Printf("Failed to record break: %v", err)
+			}
+		}
+	}
+}
@@ -184,7 +184,10 @@ import (
 	"github.com/aayushbajaj/typing-telemetry/internal/inertia"
 	"github.com/aayushbajaj/typing-telemetry/internal/ingest"
 	"github.com/aayushbajaj/typing-telemetry/internal/keylogger"
+	"github.com/aayushbajaj/typing-telemetry/internal/layout"
+	"github.com/aayushbajaj/typing-telemetry/internal/localapi"
 	"github.com/aayushbajaj/typing-telemetry/internal/mousetracker"
+	"github.com/aayushbajaj/typing-telemetry/internal/notify"
 	"github.com/aayushbajaj/typing-telemetry/internal/storage"
 	"github.com/aayushbajaj/typing-telemetry/internal/wordcounter"
 	"github.com/aayushbajaj/typing-telemetry/pkg/stats"
@@ -204,6 +207,13 @@ var (
 	// appFilter is shared between the keystroke loop and the settings UI so
 	// that toggling strict mode or editing the allowlist takes effect live.
 	appFilter = appfilter.New()
+	// appUsage buffers per-app keystrokes, words and active time between
+	// stats ticks.
+	appUsage = storage.NewAppUsageBuffer()
+	// focus credits counts to the running `typtel sessions` focus session.
+	focus = storage.NewFocusBuffer()
+	// feed collects live activity for the local API's event stream.
+	feed = localapi.NewFeed()
 	// singletonLock holds the flock'd lockfile open for the process lifetime.
 	// It must stay referenced so the fd isn't closed (closing releases the
 	// lock); see acquireSingletonLock.
@@ -337,6 +347,12 @@ func main() {
 		log.Fatalf("Failed to initialize storage: %v", err)
 	}
 	defer store.Close()
+	if err := storage.WriteTrayStatus(); err != nil {
+		log.Printf("Could not record tray status: %v", err)
+	}
+	if err := store.ReloadKeyboardLayout(); err != nil {
+		log.Printf("%v", err)
+	}
 
 	// One-time backfill of historical active typing time from raw keystroke
 	// timestamps, so average-WPM has real history on first launch of v1.4.
@@ -344,6 +360,16 @@ func main() {
 		log.Printf("Active-time backfill failed: %v", err)
 	}
 
+	// If a retention window is set, fold raw keystrokes older than it into
+	// the hourly rollup (see `typtel db compact`). Runs before capture starts.
+	if days := store.GetKeystrokeRetentionDays(); days > 0 {
+		if r, err := store.CompactKeystrokes(days); err != nil {
+			log.Printf("Keystroke compaction failed: %v", err)
+		} else if r.RawRows > 0 {
+			log.Printf("Compacted %d raw keystrokes before %s", r.RawRows, r.Cutoff)
+		}
+	}
+
 	// Lifetime context, cancelled on shutdown to gracefully stop background
 	// goroutines such as the device-ingest listener.
 	ctx, cancelCtx := context.WithCancel(context.Background())
@@ -367,6 +393,30 @@ func main() {
 		log.Printf("[ingest] listening on %s", addr)
 	}
 
+	// Local stats API for status bars and scripts. Opt-in (`typtel api
+	// enable`), read-only, loopback or unix socket only; toggling it requires a
+	// restart.
+	if store.GetSettingBool(storage.SettingLocalAPIEnabled) {
+		addr := store.GetSettingOr(storage.SettingLocalAPIAddr, localapi.DefaultAddr)
+		var events *localapi.Feed
+		if store.GetSettingBool(storage.SettingLocalAPIEvents) {
+			events = feed
+		}
+		srv := localapi.New(store, localapi.Options{
+			Addr:    addr,
+			Live:    speedAcc.live,
+			Feed:    events,
+			Metrics: store.GetSettingBool(storage.SettingLocalAPIMetrics),
+			Version: Version,
+		})
+		go func() {
+			if err := srv.Start(ctx); err != nil {
+				log.Printf("[api] stopped: %v", err)
+			}
+		}()
+		log.Printf("[api] listening on %s", addr)
+	}
+
 	// Start keylogger in background
 	keystrokeChan, err := keylogger.Start()
 	if err != nil {
@@ -379,10 +429,12 @@ func main() {
 	appFilter.SetEnabled(store.IsStrictWordCountEnabled())
 
 	// Process keystrokes in background
-	counter := wordcounter.New()
+	counter := wordcounter.NewWithLayout(store.KeyboardLayout)
 	go func() {
 		var lastSeenBundle string
 		for ev := range keystrokeChan {
+			// Break reminders count every key, filtered app or not.
+			breaks.Activity(time.Now())
 			// Check for odometer hotkey (uses system modifier state, not tracked)
 			if checkOdometerHotkey(ev.Keycode) {
 				toggleOdometer()
@@ -404,6 +456,7 @@ func main() {
 			// semantics.
 			if !appFilter.IsAllowed(lastSeenBundle) {
 				counter.Reset()
+				bigramTracker.Reset()
 				continue
 			}
 
@@ -413,11 +466,26 @@ func main() {
 
 			// Typing speed: credit active time (idle gaps auto-paused) and,
 			// on a completed word, fold in the fastest-pace candidates. Both
-			// are batched in speedAcc and flushed by the stats ticker.
+			// are batched in speedAcc and flushed by the stats ticker, as are
+			// the per-app counts in appUsage.
 			now := time.Now()
 			date := now.Format("2006-01-02")
-			if ms := speedTracker.OnKeystroke(now); ms > 0 {
+			appUsage.AddKeystroke(date, lastSeenBundle)
+			ms := speedTracker.OnKeystroke(now)
+			if ms > 0 {
 				speedAcc.addActive(date, ms)
+				appUsage.AddActive(date, lastSeenBundle, ms)
+			}
+			focus.AddKeystroke(ms)
+			feed.Keystroke(ms)
+			// Bigram latency: modifiers are skipped (Shift must not hide the
+			// letter-to-letter gap), shortcuts break the chain.
+			if ev.CmdHeld() || ev.CtrlHeld() {
+				bigramTracker.Reset()
+			} else if store.KeyboardLayout().Class(ev.Keycode) != layout.Modifier {
+				if b, ok := bigramTracker.OnKey(ev.Keycode, now); ok {
+					speedAcc.recordBigram(b)
+				}
 			}
 
 			if counter.Observe(wordcounter.Event{
@@ -430,7 +498,11 @@ func main() {
 				if err := store.IncrementWordCount(date); err != nil {
 					log.Printf("Failed to increment word count: %v", err)
 				}
-				speedAcc.recordSample(date, speedTracker.OnWord(now))
+				appUsage.AddWord(date, lastSeenBundle)
+				sample := speedTracker.OnWord(now)
+				speedAcc.recordSample(date, sample)
+				focus.AddWord(sample.Burst)
+				feed.Word(sample, now)
 			}
 
 			// Update odometer if active
@@ -456,6 +528,7 @@ func main() {
 			go func() {
 				currentDate := time.Now().Format("2006-01-02")
 				for movement := range mouseChan {
+					breaks.Activity(time.Now())
 					newDate := time.Now().Format("2006-01-02")
 					if newDate != currentDate {
 						currentDate = newDate
@@ -471,6 +544,7 @@ func main() {
 
 			go func() {
 				for range clickChan {
+					breaks.Activity(time.Now())
 					if err := store.RecordMouseClick(); err != nil {
 						log.Printf("Failed to record mouse click: %v", err)
 					}
@@ -539,6 +613,15 @@ func onReady() {
 
 		for range ticker.C {
 			speedAcc.flush(store)
+			if err := appUsage.Flush(store); err != nil {
+				log.Printf("Failed to flush app usage: %v", err)
+			}
+			flushFocus()
+			flushBreaks()
+			// Follow `typtel layout set` without a restart.
+			if err := store.ReloadKeyboardLayout(); err != nil {
+				log.Printf("%v", err)
+			}
 			updateMenuBarTitle()
 			updateStatsDisplay()
 		}
@@ -550,6 +633,35 @@ func onExit() {
 	// Persist any speed measurements still buffered in memory.
 	if store != nil {
 		speedAcc.flush(store)
+		if err := appUsage.Flush(store); err != nil {
+			log.Printf("Failed to flush app usage: %v", err)
+		}
+		flushFocus()
+	}
+	storage.ClearTrayStatus()
+}
+
+// flushFocus writes the running focus session's counts and posts a
+// notification when its goal is reached or when it ends.
+func flushFocus() {
+	events, err := focus.Flush(store)
+	if err != nil {
+		log.Printf("Failed to flush focus session: %v", err)
+	}
+	for _, ev := range events {
+		f := ev.Session
+		var title, body string
+		if ev.Kind == storage.FocusGoalReached {
+			title = "Goal reached: " + f.Tag
+			body = fmt.Sprintf("%d %s in %s", f.Target, f.TargetKind, time.Since(f.StartTime).Round(time.Minute))
+		} else {
+			title = "Session finished: " + f.Tag
+			body = fmt.Sprintf("%d words · %.0f WPM avg · %.0f WPM peak burst",
+				f.Words, stats.AverageWPM(f.Words, f.ActiveMs), f.PeakBurstWPM)
+		}
+		if err := notify.Send(title, body); err != nil {
+			log.Printf("Failed to send notification: %v", err)
+		}
 	}
 }
 
@@ -1861,30 +1973,7 @@ func checkOdometerHotkey(keycode int) bool {
 
 // updateOdometerIfActive updates the odometer's current values if it's active
 func updateOdometerIfActive() {
-	session, _ := store.GetOdometerSession()
-	if session == nil || !session.IsActive {
-		return
-	}
-
-	// Get current totals
-	todayStats, _ := store.GetTodayStats()
-	mouseStats, _ := store.GetTodayMouseStats()
-
-	keystrokes := int64(0)
-	words := int64(0)
-	clicks := int64(0)
-	distance := float64(0)
-
-	if todayStats != nil {
-		keystrokes = todayStats.Keystrokes
-		words = todayStats.Words
-	}
-	if mouseStats != nil {
-		clicks = mouseStats.ClickCount
-		distance = mouseStats.TotalDistance
-	}
-
-	store.UpdateOdometerCurrent(keystrokes, words, clicks, distance)
+	store.RefreshOdometer()
 }
 
 // calculateActiveHours returns the number of hours with activity for a given date
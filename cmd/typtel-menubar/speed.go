@@ -7,8 +7,10 @@ import (
 	"fmt"
 	"log"
 	"sync"
+	"time"
 
 	"github.com/aayushbajaj/typing-telemetry/internal/speedtracker"
+	"github.com/aayushbajaj/typing-telemetry/internal/statsjson"
 	"github.com/aayushbajaj/typing-telemetry/internal/storage"
 )
 
@@ -16,13 +18,23 @@ import (
 // goroutine so the database is written on the stats ticker rather than once per
 // keystroke. Active time is accumulated as additive deltas; fastest paces are
 // kept as running maxima (UpdateFastest is MAX-based, so re-flushing a max is
-// harmless). It is safe for concurrent use.
+// harmless). Bigram latency samples are queued as-is, up to
+// maxPendingBigrams. It is safe for concurrent use.
 type speedState struct {
 	mu        sync.Mutex
 	pendingMs map[string]int64               // date -> un-flushed active ms
 	dailyMax  map[string]speedtracker.Sample // date -> running fastest maxima
+	bigrams   []storage.BigramSample
+
+	// last is the latest word's sample, kept across flushes for the local
+	// API's live speed.
+	last   speedtracker.Sample
+	lastAt time.Time
 }
 
+// maxPendingBigrams bounds the bigram queue if flushes keep failing.
+const maxPendingBigrams = 50000
+
 func newSpeedState() *speedState {
 	return &speedState{
 		pendingMs: make(map[string]int64),
@@ -31,11 +43,12 @@ func newSpeedState() *speedState {
 }
 
 // speedAcc is shared between the keystroke goroutine (writer) and the stats
-// ticker (flusher). speedTracker, by contrast, is owned solely by the
-// keystroke goroutine and needs no locking.
+// ticker (flusher). speedTracker and bigramTracker, by contrast, are owned
+// solely by the keystroke goroutine and need no locking.
 var (
-	speedAcc     = newSpeedState()
-	speedTracker = speedtracker.New()
+	speedAcc      = newSpeedState()
+	speedTracker  = speedtracker.New()
+	bigramTracker = speedtracker.NewBigramTracker()
 )
 
 // addActive credits active typing time for a day.
@@ -62,6 +75,30 @@ func (s *speedState) recordSample(date string, sample speedtracker.Sample) {
 		cur.Minute = sample.Minute
 	}
 	s.dailyMax[date] = cur
+	s.last, s.lastAt = sample, time.Now()
+	s.mu.Unlock()
+}
+
+// live returns today's numbers not yet flushed, for the local API.
+func (s *speedState) live() *statsjson.Live {
+	date := time.Now().Format("2006-01-02")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return &statsjson.Live{
+		Date:       date,
+		ActiveMs:   s.pendingMs[date],
+		Fastest:    s.dailyMax[date],
+		Last:       s.last,
+		LastWordAt: s.lastAt,
+	}
+}
+
+// recordBigram queues one key-to-key transition.
+func (s *speedState) recordBigram(b speedtracker.Bigram) {
+	s.mu.Lock()
+	if len(s.bigrams) < maxPendingBigrams {
+		s.bigrams = append(s.bigrams, storage.BigramSample{Prev: b.Prev, Next: b.Next, Ms: b.Ms})
+	}
 	s.mu.Unlock()
 }
 
@@ -73,8 +110,10 @@ func (s *speedState) flush(store *storage.Store) {
 	s.mu.Lock()
 	pendingMs := s.pendingMs
 	dailyMax := s.dailyMax
+	bigrams := s.bigrams
 	s.pendingMs = make(map[string]int64)
 	s.dailyMax = make(map[string]speedtracker.Sample)
+	s.bigrams = nil
 	s.mu.Unlock()
 
 	for date, ms := range pendingMs {
@@ -87,6 +126,9 @@ func (s *speedState) flush(store *storage.Store) {
 			log.Printf("Failed to flush fastest pace: %v", err)
 		}
 	}
+	if err := store.AddBigramLatencies(bigrams); err != nil {
+		log.Printf("Failed to flush bigram latencies: %v", err)
+	}
 }
 
 // formatWPM renders a words-per-minute value for the menu, showing a placeholder
@@ -0,0 +1,35 @@
+//go:build linux
+
+package main
+
+import (
+	"log"
+	"time"
+
+	"github.com/aayushbajaj/typing-telemetry/internal/notify"
+	"github.com/aayushbajaj/typing-telemetry/internal/rsiguard"
+)
+
+// breaks is fed by the keystroke and mouse goroutines and ticked by the
+// background loop, so capture never waits on the DB or D-Bus.
+var breaks = &rsiguard.Reminder{}
+
+// flushBreaks runs the RSI guard for `typtel breaks on`: it notifies when a
+// break is due and records breaks taken and skipped.
+func flushBreaks() {
+	s := store.GetRSISettings()
+	cfg := rsiguard.Config{MicroAfter: s.MicroAfter, MicroBreak: s.MicroBreak, LongAfter: s.LongAfter, LongBreak: s.LongBreak}
+	for _, ev := range breaks.Tick(s.Enabled, cfg, time.Now()) {
+		if ev.Type == rsiguard.Due {
+			if err := notify.Send(rsiguard.Notification(ev, cfg)); err != nil {
+				log.Printf("notify: %v", err)
+			}
+			continue
+		}
+		if takenAt, ok := rsiguard.Record(ev, cfg); ok {
+			if err := store.RecordBreak(string(ev.Kind), ev.DueAt, takenAt, ev.Worked); err != nil {
+				log.Printf("record break: %v", err)
+			}
+		}
+	}
+}
@@ -5,13 +5,17 @@
 // the inertia accelerating key-repeat, and surfaces live typing statistics in a
 // StatusNotifier tray icon (XFCE, KDE, GNOME-with-appindicator, etc.).
 //
-// Capture and inertia are pure-Go X11 (internal/x11) — no root, no /dev/input,
-// no special group; just a reachable $DISPLAY. The stats pipeline (wordcounter,
-// speedtracker, storage) is shared verbatim with the macOS build.
+// Capture and inertia are pure-Go X11 (internal/x11) by default — no root, no
+// /dev/input, no special group; just a reachable $DISPLAY. Under Wayland, or
+// with --backend evdev, keystrokes are read from /dev/input instead (needs the
+// input group). Mouse travel and clicks are always sampled over X11. The stats
+// pipeline (wordcounter, speedtracker, storage) is shared verbatim with the
+// macOS build.
 package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"log"
 	"os"
@@ -23,11 +27,17 @@ import (
 
 	"fyne.io/systray"
 
+	"github.com/aayushbajaj/typing-telemetry/internal/appfilter"
 	"github.com/aayushbajaj/typing-telemetry/internal/charts"
 	"github.com/aayushbajaj/typing-telemetry/internal/inertia"
 	"github.com/aayushbajaj/typing-telemetry/internal/keylogger"
+	"github.com/aayushbajaj/typing-telemetry/internal/layout"
+	"github.com/aayushbajaj/typing-telemetry/internal/localapi"
+	"github.com/aayushbajaj/typing-telemetry/internal/mousetracker"
+	"github.com/aayushbajaj/typing-telemetry/internal/notify"
 	"github.com/aayushbajaj/typing-telemetry/internal/push"
 	"github.com/aayushbajaj/typing-telemetry/internal/speedtracker"
+	"github.com/aayushbajaj/typing-telemetry/internal/statsjson"
 	"github.com/aayushbajaj/typing-telemetry/internal/storage"
 	"github.com/aayushbajaj/typing-telemetry/internal/wordcounter"
 	"github.com/aayushbajaj/typing-telemetry/pkg/stats"
@@ -40,10 +50,27 @@ var (
 	store *storage.Store
 	speed = &speedAccumulator{}
 
+	// writer batches keystroke and word writes off the capture goroutine; it
+	// is flushed by the background loop and, finally, by shutdown().
+	writer *storage.Batcher
+
+	// appFilter is the strict word-count allowlist; the background loop keeps
+	// it in step with the settings. appUsage buffers per-app counts between
+	// flushes, focus the counts credited to a running focus session.
+	appFilter = appfilter.New()
+	appUsage  = storage.NewAppUsageBuffer()
+	focus     = storage.NewFocusBuffer()
+
 	// Push loop state (opt-in; nil/no-op unless `typtel push enable` was run).
 	pusher     *push.Client
 	pushCancel context.CancelFunc
 
+	// apiCancel stops the local stats API (nil unless `typtel api enable`).
+	// feed collects live activity for its event stream; it is only served
+	// when `typtel api events on` was run.
+	apiCancel context.CancelFunc
+	feed      = localapi.NewFeed()
+
 	// trayReady is set once the system-tray UI registers. On a bare WM with no
 	// StatusNotifier host it stays false and the daemon runs headless.
 	trayReady bool
@@ -56,8 +83,18 @@ func main() {
 	log.SetPrefix("typtel-tray: ")
 	log.SetFlags(log.Ltime)
 
-	if !keylogger.CheckAccessibilityPermissions() {
-		log.Fatal("cannot reach an X display — is DISPLAY set? (this build needs an X11 session)")
+	profile := flag.String("profile", "", "write to this profile instead of the default (see 'typtel profile')")
+	backend := flag.String("backend", "", "capture backend: auto, x11 or evdev (default: the capture_backend setting)")
+	var devices []string
+	flag.Func("input-device", "evdev device to read, e.g. /dev/input/event3 (repeatable; default: every keyboard)", func(v string) error {
+		devices = append(devices, v)
+		return nil
+	})
+	flag.Parse()
+	if *profile != "" {
+		if err := storage.UseProfile(*profile); err != nil {
+			log.Fatal(err)
+		}
 	}
 
 	var err error
@@ -66,13 +103,52 @@ func main() {
 		log.Fatalf("failed to open store: %v", err)
 	}
 	speed.store = store
+	log.Printf("writing to profile %q", storage.ActiveProfile())
+	if err := store.ReloadKeyboardLayout(); err != nil {
+		log.Printf("warning: %v", err)
+	}
+	if err := storage.WriteTrayStatus(); err != nil {
+		log.Printf("warning: could not record tray status: %v", err)
+	}
+
+	// When the user has opted into a retention window, fold raw keystrokes
+	// older than it into the hourly rollup before capture starts, so the one
+	// big transaction never stalls live RecordKeystroke calls.
+	if days := store.GetKeystrokeRetentionDays(); days > 0 {
+		if r, err := store.CompactKeystrokes(days); err != nil {
+			log.Printf("warning: keystroke compaction failed: %v", err)
+		} else if r.RawRows > 0 {
+			log.Printf("compacted %d raw keystrokes before %s", r.RawRows, r.Cutoff)
+		}
+	}
+
+	writer = store.NewBatcher(storage.BatcherConfig{})
 
-	keystrokeChan, err := keylogger.Start()
+	// Flags win over the capture_backend / evdev_devices settings.
+	opts := keylogger.Options{Backend: *backend, Devices: devices}
+	if opts.Backend == "" {
+		opts.Backend = store.GetCaptureBackend()
+	}
+	if len(opts.Devices) == 0 {
+		opts.Devices = store.GetEvdevDevices()
+	}
+	opts.Backend, err = keylogger.ResolveBackend(opts.Backend)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if opts.Backend == keylogger.BackendX11 && !keylogger.CheckAccessibilityPermissions() {
+		log.Fatal("cannot reach an X display — is DISPLAY set? (or run with --backend evdev)")
+	}
+	keystrokeChan, err := keylogger.StartWith(opts)
 	if err != nil {
 		log.Fatalf("failed to start keylogger: %v", err)
 	}
+	log.Printf("capturing keystrokes with the %s backend", opts.Backend)
+	syncAppFilter()
 	go processKeystrokes(keystrokeChan)
 
+	startMouseTracking()
+
 	// Start inertia if it was left enabled in settings.
 	if cfg := inertiaConfig(); cfg.Enabled {
 		if err := inertia.Start(cfg); err != nil {
@@ -86,6 +162,7 @@ func main() {
 	// Off by default: with no push settings this block is a no-op and never
 	// touches the network.
 	startPushLoop()
+	startLocalAPI()
 
 	// Background loop (always runs, tray or not): flush batched stats and apply
 	// inertia settings changed out-of-band — e.g. by the `typtel inertia` CLI
@@ -115,21 +192,51 @@ func main() {
 	shutdown() // the tray's Quit was selected
 }
 
-// processKeystrokes is the shared keystroke pipeline: record each key, credit
-// active typing time, and count completed words with their fastest-pace
-// candidates. Mirrors cmd/typtel-menubar's loop, minus the macOS-only per-app
-// filtering / odometer / mouse paths.
+// processKeystrokes is the shared keystroke pipeline: drop keys from apps
+// outside the strict-mode allowlist, record each key, credit active typing
+// time, time key-to-key transitions, and count completed words with their
+// fastest-pace candidates, attributing keys, words and time to the active
+// app. Mirrors cmd/typtel-menubar's loop; the odometer, which the menubar
+// advances per key, is advanced by backgroundLoop from the flushed totals.
 func processKeystrokes(ch <-chan keylogger.KeystrokeEvent) {
-	counter := wordcounter.New()
+	counter := wordcounter.NewWithLayout(store.KeyboardLayout)
 	tracker := speedtracker.New()
+	bigrams := speedtracker.NewBigramTracker()
 	for ev := range ch {
-		if err := store.RecordKeystroke(ev.Keycode); err != nil {
-			log.Printf("record keystroke: %v", err)
+		// Break reminders count every key, filtered app or not.
+		breaks.Activity(time.Now())
+		// Unlike macOS, the active window is often unknown (a Wayland
+		// window, the desktop), so the current answer is used as-is rather
+		// than the last app seen. Frontmost caches briefly and the seen-app
+		// list is written by the batcher, so neither waits on X or the DB.
+		app := appfilter.Frontmost()
+		writer.RecordSeenApp(app)
+		if !appFilter.IsAllowed(app) {
+			counter.Reset()
+			bigrams.Reset()
+			continue
 		}
+
+		writer.RecordKeystroke(ev.Keycode)
 		now := time.Now()
 		date := now.Format("2006-01-02")
-		if ms := tracker.OnKeystroke(now); ms > 0 {
+		appUsage.AddKeystroke(date, app)
+		ms := tracker.OnKeystroke(now)
+		if ms > 0 {
 			speed.addActive(date, ms)
+			appUsage.AddActive(date, app, ms)
+		}
+		focus.AddKeystroke(ms)
+		feed.Keystroke(ms)
+		// Modifiers are skipped rather than breaking the chain, so Shift for
+		// a capital doesn't hide the letter-to-letter transition; shortcuts do
+		// break it.
+		if ev.CmdHeld() || ev.CtrlHeld() {
+			bigrams.Reset()
+		} else if store.KeyboardLayout().Class(ev.Keycode) != layout.Modifier {
+			if b, ok := bigrams.OnKey(ev.Keycode, now); ok {
+				writer.RecordBigram(b.Prev, b.Next, b.Ms)
+			}
 		}
 		if counter.Observe(wordcounter.Event{
 			Keycode:   ev.Keycode,
@@ -138,14 +245,94 @@ func processKeystrokes(ch <-chan keylogger.KeystrokeEvent) {
 			OptHeld:   ev.OptHeld(),
 			ShiftHeld: ev.ShiftHeld(),
 		}) {
-			if err := store.IncrementWordCount(date); err != nil {
-				log.Printf("increment words: %v", err)
+			writer.IncrementWordCount(date)
+			appUsage.AddWord(date, app)
+			sample := tracker.OnWord(now)
+			speed.recordSample(date, sample)
+			focus.AddWord(sample.Burst)
+			feed.Word(sample, now)
+		}
+	}
+}
+
+// startMouseTracking records pointer distance and clicks into mouse_daily,
+// as cmd/typtel-menubar does, when mouse tracking is enabled. The pointer is
+// sampled over X11, so it needs a display even with the evdev key backend.
+func startMouseTracking() {
+	if !store.IsMouseTrackingEnabled() {
+		log.Println("mouse tracking is disabled")
+		return
+	}
+	mouseChan, clickChan, err := mousetracker.Start()
+	if err != nil {
+		log.Printf("warning: mouse tracking failed to start: %v", err)
+		return
+	}
+
+	pos := mousetracker.GetCurrentPosition()
+	if err := store.SetMidnightPosition(time.Now().Format("2006-01-02"), pos.X, pos.Y); err != nil {
+		log.Printf("set midnight position: %v", err)
+	}
+
+	go func() {
+		currentDate := time.Now().Format("2006-01-02")
+		for m := range mouseChan {
+			breaks.Activity(time.Now())
+			if date := time.Now().Format("2006-01-02"); date != currentDate {
+				currentDate = date
+				if err := store.SetMidnightPosition(date, m.X, m.Y); err != nil {
+					log.Printf("set midnight position: %v", err)
+				}
+			}
+			if err := store.RecordMouseMovement(m.X, m.Y, m.Distance); err != nil {
+				log.Printf("record mouse movement: %v", err)
+			}
+		}
+	}()
+	go func() {
+		for range clickChan {
+			breaks.Activity(time.Now())
+			if err := store.RecordMouseClick(); err != nil {
+				log.Printf("record mouse click: %v", err)
 			}
-			speed.recordSample(date, tracker.OnWord(now))
+		}
+	}()
+}
+
+// flushFocus writes the counts credited to the running focus session and
+// sends a desktop notification when its goal is reached or, once it is
+// stopped with `typtel sessions stop`, a summary.
+func flushFocus() {
+	events, err := focus.Flush(store)
+	if err != nil {
+		log.Printf("flush focus session: %v", err)
+	}
+	for _, ev := range events {
+		title, body := focusNotification(ev)
+		if err := notify.Send(title, body); err != nil {
+			log.Printf("notify: %v", err)
 		}
 	}
 }
 
+func focusNotification(ev storage.FocusEvent) (title, body string) {
+	f := ev.Session
+	if ev.Kind == storage.FocusGoalReached {
+		return fmt.Sprintf("Goal reached: %s", f.Tag),
+			fmt.Sprintf("%d %s in %s", f.Target, f.TargetKind, time.Since(f.StartTime).Round(time.Minute))
+	}
+	body = fmt.Sprintf("%d words · %.0f WPM avg · %.0f WPM peak burst\n%s",
+		f.Words, stats.AverageWPM(f.Words, f.ActiveMs), f.PeakBurstWPM,
+		f.EndTime.Sub(f.StartTime).Round(time.Second))
+	return fmt.Sprintf("Session finished: %s", f.Tag), body
+}
+
+// syncAppFilter loads the strict word-count settings into appFilter.
+func syncAppFilter() {
+	appFilter.SetAllowlist(store.GetWordCountAllowlist())
+	appFilter.SetEnabled(store.IsStrictWordCountEnabled())
+}
+
 func inertiaConfig() inertia.Config {
 	s := store.GetInertiaSettings()
 	return inertia.Config{
@@ -175,6 +362,36 @@ func startPushLoop() {
 	log.Printf("[push] enabled -> %s as %s", cfg.BaseURL, cfg.DeviceID) // never log the token
 }
 
+// startLocalAPI serves read-only stats JSON for status bars and scripts if
+// `typtel api enable` was run. Off by default; the address is re-read only on
+// restart.
+func startLocalAPI() {
+	if !store.GetSettingBool(storage.SettingLocalAPIEnabled) {
+		return
+	}
+	addr := store.GetSettingOr(storage.SettingLocalAPIAddr, localapi.DefaultAddr)
+	var events *localapi.Feed
+	if store.GetSettingBool(storage.SettingLocalAPIEvents) {
+		events = feed
+	}
+	var ctx context.Context
+	ctx, apiCancel = context.WithCancel(context.Background())
+	srv := localapi.New(store, localapi.Options{
+		Addr:    addr,
+		Live:    speed.live,
+		Feed:    events,
+		Metrics: store.GetSettingBool(storage.SettingLocalAPIMetrics),
+		Push:    pusher,
+		Version: Version,
+	})
+	go func() {
+		if err := srv.Start(ctx); err != nil {
+			log.Printf("[api] stopped: %v", err)
+		}
+	}()
+	log.Printf("[api] listening on %s", addr)
+}
+
 // Inertia radio-group menu items, keyed by their setting value, so a generic
 // radio helper can tick exactly one and untick the rest (systray has only
 // checkboxes — same emulation as the macOS menubar).
@@ -220,12 +437,16 @@ func onReady() {
 	mWords := systray.AddMenuItem("Words today: —", "")
 	mWPM := systray.AddMenuItem("Avg WPM: —", "")
 	mFast := systray.AddMenuItem("Fastest WPM: —", "")
-	for _, m := range []*systray.MenuItem{mKeys, mWords, mWPM, mFast} {
+	mMouse := systray.AddMenuItem("Mouse today: —", "")
+	for _, m := range []*systray.MenuItem{mKeys, mWords, mWPM, mFast, mMouse} {
 		m.Disable()
 	}
 
 	systray.AddSeparator()
 	mCharts := systray.AddMenuItem("View Charts…", "Open the stats dashboard in your browser")
+	// The odometer can also be started and stopped by `typtel odometer`; the
+	// refresh below keeps this item's title in step either way.
+	mOdometer := systray.AddMenuItem("Start Odometer", "Measure a typing session")
 
 	// --- Inertia settings ---
 	// NOTE: keep these one level deep only. The XFCE StatusNotifier/DBusMenu
@@ -269,6 +490,16 @@ func onReady() {
 		mFast.SetTitle(fmt.Sprintf("Fastest WPM: %.0f", fastest))
 		systray.SetTooltip(fmt.Sprintf("typtel — %d keys · %d words · %.0f wpm",
 			st.Keystrokes, st.Words, wpm))
+		if ms, err := store.GetTodayMouseStats(); err == nil {
+			mMouse.SetTitle(fmt.Sprintf("Mouse today: %.0f ft · %d clicks",
+				mousetracker.PixelsToFeet(ms.TotalDistance), ms.ClickCount))
+		}
+		if od, err := store.GetOdometerSession(); err == nil && od.IsActive {
+			mOdometer.SetTitle(fmt.Sprintf("Stop Odometer (%d keys · %s)",
+				od.CurrentKeystrokes-od.StartKeystrokes, time.Since(od.StartTime).Round(time.Minute)))
+		} else {
+			mOdometer.SetTitle("Start Odometer")
+		}
 	}
 
 	go func() {
@@ -286,6 +517,12 @@ func onReady() {
 			openCharts()
 		}
 	}()
+	go func() {
+		for range mOdometer.ClickedCh {
+			toggleOdometer()
+			refresh()
+		}
+	}()
 	go func() {
 		for range mInertiaEnable.ClickedCh {
 			toggleInertiaEnabled(mInertiaEnable)
@@ -357,15 +594,34 @@ var (
 )
 
 // backgroundLoop runs regardless of the tray UI: every couple of seconds it
-// flushes batched speed stats and, if the inertia settings changed out-of-band
-// (the `typtel inertia` CLI from a WM keybind), applies them to the running
-// system. This is the path that makes the CLI take effect on bare WMs with no
-// system tray, where the tray's own ticker never starts.
+// flushes batched speed, per-app and focus-session stats, runs break
+// reminders, advances a running odometer session, follows keyboard-layout and
+// strict-mode changes made with `typtel layout set` / `typtel apps` and, if
+// the inertia settings changed out-of-band (the `typtel inertia` CLI from a WM
+// keybind), applies them to the running system. This is the path that makes the CLI take effect on bare
+// WMs with no system tray, where the tray's own ticker never starts.
 func backgroundLoop() {
 	t := time.NewTicker(2 * time.Second)
 	defer t.Stop()
+	var lastDropped uint64
 	for range t.C {
 		speed.flush()
+		if err := appUsage.Flush(store); err != nil {
+			log.Printf("flush app usage: %v", err)
+		}
+		flushFocus()
+		flushBreaks()
+		syncAppFilter()
+		if err := store.RefreshOdometer(); err != nil {
+			log.Printf("refresh odometer: %v", err)
+		}
+		if st := writer.Stats(); st.Dropped > lastDropped {
+			log.Printf("warning: write buffer full — dropped %d events so far (%d buffered)", st.Dropped, st.Buffered)
+			lastDropped = st.Dropped
+		}
+		if err := store.ReloadKeyboardLayout(); err != nil {
+			log.Printf("warning: %v", err)
+		}
 		s := store.GetInertiaSettings()
 		if haveLastInertia && s == lastInertia {
 			continue
@@ -409,6 +665,27 @@ func toggleInertiaEnabled(item *systray.MenuItem) {
 	}
 }
 
+// toggleOdometer stops the running odometer session, saving it to the
+// history, or starts an unlabelled one.
+func toggleOdometer() {
+	od, err := store.GetOdometerSession()
+	if err != nil {
+		log.Printf("odometer: %v", err)
+		return
+	}
+	if od.IsActive {
+		if err := store.RefreshOdometer(); err != nil {
+			log.Printf("odometer: %v", err)
+		}
+		err = store.StopOdometer()
+	} else {
+		err = store.StartOdometer()
+	}
+	if err != nil {
+		log.Printf("odometer: %v", err)
+	}
+}
+
 // toggleSetting flips a boolean setting and syncs the checkbox.
 func toggleSetting(item *systray.MenuItem, get func() bool, set func(bool) error) {
 	v := !get()
@@ -425,7 +702,7 @@ func toggleSetting(item *systray.MenuItem, get func() bool, set func(bool) error
 
 // openCharts generates the rich charts dashboard and opens it in a browser.
 func openCharts() {
-	path, err := charts.Generate(store, charts.Options{})
+	path, err := charts.Generate(store, charts.Options{PixelsToFeet: mousetracker.PixelsToFeet})
 	if err != nil {
 		log.Printf("charts: %v", err)
 		return
@@ -442,12 +719,28 @@ var shutdownOnce sync.Once
 
 func shutdown() {
 	shutdownOnce.Do(func() {
+		// Commit buffered keystrokes and words first so the final push below
+		// sees today's complete counts.
+		if writer != nil {
+			if err := writer.Close(); err != nil {
+				log.Printf("flush keystrokes: %v", err)
+			}
+			st := writer.Stats()
+			log.Printf("keystroke writer: %d committed, %d dropped, %d unflushed", st.Flushed, st.Dropped, st.Buffered)
+		}
 		speed.flush()
+		if err := appUsage.Flush(store); err != nil {
+			log.Printf("flush app usage: %v", err)
+		}
+		flushFocus()
 		// Final synchronous push so the day's last counts land before we close
 		// the store. Runs after speed.flush() so active_ms is current.
 		if pushCancel != nil {
 			pushCancel()
 		}
+		if apiCancel != nil {
+			apiCancel()
+		}
 		if pusher != nil {
 			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 			if err := pusher.PushToday(ctx, store); err != nil {
@@ -456,10 +749,12 @@ func shutdown() {
 			cancel()
 		}
 		keylogger.Stop()
+		mousetracker.Stop()
 		inertia.Stop() // restores X auto-repeat
 		if store != nil {
 			store.Close()
 		}
+		storage.ClearTrayStatus()
 		log.Println("stopped")
 	})
 }
@@ -477,6 +772,11 @@ type speedAccumulator struct {
 	window   float64
 	minute   float64
 	dirty    bool
+
+	// last is the latest word's sample, kept across flushes for the local
+	// API's live speed.
+	last   speedtracker.Sample
+	lastAt time.Time
 }
 
 func (a *speedAccumulator) addActive(date string, ms int64) {
@@ -494,9 +794,23 @@ func (a *speedAccumulator) recordSample(date string, s speedtracker.Sample) {
 	a.window = maxf(a.window, s.Window)
 	a.minute = maxf(a.minute, s.Minute)
 	a.dirty = true
+	a.last, a.lastAt = s, time.Now()
 	a.mu.Unlock()
 }
 
+// live returns the numbers not yet flushed, for the local API.
+func (a *speedAccumulator) live() *statsjson.Live {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return &statsjson.Live{
+		Date:       a.date,
+		ActiveMs:   a.activeMs,
+		Fastest:    speedtracker.Sample{Burst: a.burst, Window: a.window, Minute: a.minute},
+		Last:       a.last,
+		LastWordAt: a.lastAt,
+	}
+}
+
 // rollIfNeededLocked flushes the previous day's pending totals when the date
 // rolls over (e.g. across midnight). Caller holds a.mu.
 func (a *speedAccumulator) rollIfNeededLocked(date string) {
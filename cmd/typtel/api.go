@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aayushbajaj/typing-telemetry/internal/localapi"
+	"github.com/aayushbajaj/typing-telemetry/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+var apiAddr string
+
+var apiCmd = &cobra.Command{
+	Use:   "api",
+	Short: "Local read-only stats API served by the capture daemon",
+	Long: `When enabled, typtel-tray (Linux) or the menubar app (macOS) serves your
+stats as JSON on a loopback port or a unix socket, so status bars and scripts
+can poll it instead of running 'typtel today --json'. Speed numbers include
+what the daemon hasn't written to the database yet.
+
+  GET /v1/today          same document as 'typtel today --json'
+  GET /v1/stats          same document as 'typtel stats --json'
+  GET /v1/speed          the "speed" section, plus the last word's pace
+  GET /v1/hourly/<date>  keystrokes per hour (YYYY-MM-DD or "today")
+  GET /v1/odometer       the running odometer session
+  GET /v1/events         live event stream (opt-in: 'typtel api events on')
+  GET /v1/health
+  GET /metrics           Prometheus metrics (opt-in: 'typtel api metrics on')
+
+  typtel api enable                          # 127.0.0.1:8890
+  typtel api enable --addr unix:/run/user/1000/typtel.sock
+  curl -s localhost:8890/v1/today | jq .words
+
+Changes take effect when the daemon restarts.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withStore(func(s *storage.Store) error {
+			addr := s.GetSettingOr(storage.SettingLocalAPIAddr, localapi.DefaultAddr)
+			if !s.GetSettingBool(storage.SettingLocalAPIEnabled) {
+				fmt.Printf("Local API: disabled (address %s)\n", addr)
+				return nil
+			}
+			fmt.Printf("Local API: enabled on %s (event stream %s, metrics %s)\n", addr,
+				onOff(s.GetSettingBool(storage.SettingLocalAPIEvents)),
+				onOff(s.GetSettingBool(storage.SettingLocalAPIMetrics)))
+			if err := probeLocalAPI(addr); err != nil {
+				fmt.Printf("  not reachable (%v) — is the daemon running, and restarted since enabling?\n", err)
+			} else {
+				fmt.Println("  daemon is serving")
+			}
+			return nil
+		})
+	},
+}
+
+var apiEnableCmd = &cobra.Command{
+	Use:   "enable",
+	Short: "Enable the local stats API",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if apiAddr != "" {
+			if err := localapi.ValidateAddr(apiAddr); err != nil {
+				return err
+			}
+		}
+		return withStore(func(s *storage.Store) error {
+			if apiAddr != "" {
+				if err := s.SetSetting(storage.SettingLocalAPIAddr, apiAddr); err != nil {
+					return err
+				}
+			}
+			if err := s.SetSettingBool(storage.SettingLocalAPIEnabled, true); err != nil {
+				return err
+			}
+			addr := s.GetSettingOr(storage.SettingLocalAPIAddr, localapi.DefaultAddr)
+			fmt.Printf("Local API enabled on %s. Restart typtel-tray or the menubar app for this to take effect.\n", addr)
+			return nil
+		})
+	},
+}
+
+var apiDisableCmd = &cobra.Command{
+	Use:   "disable",
+	Short: "Disable the local stats API",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withStore(func(s *storage.Store) error {
+			if err := s.SetSettingBool(storage.SettingLocalAPIEnabled, false); err != nil {
+				return err
+			}
+			fmt.Println("Local API disabled. Restart typtel-tray or the menubar app for this to take effect.")
+			return nil
+		})
+	},
+}
+
+var apiEventsCmd = &cobra.Command{
+	Use:   "events <on|off>",
+	Short: "Turn the live event stream (GET /v1/events) on or off",
+	Long: `The event stream sends server-sent events: "word" for each completed word
+with its burst, window and minute WPM, "tick" every second with that second's
+keystrokes and active ms, and "odometer" when the odometer session changes.
+It carries counts and paces only, never which keys were pressed.
+
+  curl -N localhost:8890/v1/events`,
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: []string{"on", "off"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var on bool
+		switch args[0] {
+		case "on":
+			on = true
+		case "off":
+		default:
+			return fmt.Errorf("want on or off, got %q", args[0])
+		}
+		return withStore(func(s *storage.Store) error {
+			if err := s.SetSettingBool(storage.SettingLocalAPIEvents, on); err != nil {
+				return err
+			}
+			fmt.Printf("Event stream %s. Restart typtel-tray or the menubar app for this to take effect.\n", onOff(on))
+			if on && !s.GetSettingBool(storage.SettingLocalAPIEnabled) {
+				fmt.Println("The local API itself is disabled; run 'typtel api enable' too.")
+			}
+			return nil
+		})
+	},
+}
+
+var apiMetricsCmd = &cobra.Command{
+	Use:   "metrics <on|off>",
+	Short: "Turn the Prometheus endpoint (GET /metrics) on or off",
+	Long: `Serves metrics in the Prometheus text format for scraping:
+
+  typtel_keystrokes_total, typtel_words_total, typtel_letters_total,
+  typtel_modifiers_total, typtel_special_total, typtel_mouse_clicks_total
+                                     lifetime counts on this machine
+  typtel_today_active_seconds        active typing time today
+  typtel_today_fastest_wpm{method}   today's fastest burst, window and minute pace
+  typtel_device_today_keystrokes{device,name}, typtel_device_today_words,
+  typtel_device_today_active_seconds today's counts from each external device
+  typtel_push_requests_total{result} pushes to the host (typtel-tray with push on)
+  typtel_push_last_success_timestamp_seconds
+
+A scrape config for the default address:
+
+  - job_name: typtel
+    static_configs:
+      - targets: ["localhost:8890"]`,
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: []string{"on", "off"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var on bool
+		switch args[0] {
+		case "on":
+			on = true
+		case "off":
+		default:
+			return fmt.Errorf("want on or off, got %q", args[0])
+		}
+		return withStore(func(s *storage.Store) error {
+			if err := s.SetSettingBool(storage.SettingLocalAPIMetrics, on); err != nil {
+				return err
+			}
+			fmt.Printf("Metrics endpoint %s. Restart typtel-tray or the menubar app for this to take effect.\n", onOff(on))
+			if on && !s.GetSettingBool(storage.SettingLocalAPIEnabled) {
+				fmt.Println("The local API itself is disabled; run 'typtel api enable' too.")
+			}
+			return nil
+		})
+	},
+}
+
+func init() {
+	apiEnableCmd.Flags().StringVar(&apiAddr, "addr", "", "Loopback host:port, or unix:<path> for a socket (default "+localapi.DefaultAddr+")")
+	apiCmd.AddCommand(apiEnableCmd)
+	apiCmd.AddCommand(apiDisableCmd)
+	apiCmd.AddCommand(apiEventsCmd)
+	apiCmd.AddCommand(apiMetricsCmd)
+}
+
+func onOff(b bool) string {
+	if b {
+		return "on"
+	}
+	return "off"
+}
+
+// probeLocalAPI checks the daemon answers /v1/health on addr.
+func probeLocalAPI(addr string) error {
+	network, target, url := "tcp", addr, "http://"+addr+"/v1/health"
+	if path, ok := strings.CutPrefix(addr, "unix:"); ok {
+		network, target, url = "unix", path, "http://localhost/v1/health"
+	}
+	client := &http.Client{
+		Timeout: 2 * time.Second,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, network, target)
+			},
+		},
+	}
+	resp, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("health check returned %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aayushbajaj/typing-telemetry/internal/storage"
+	"github.com/aayushbajaj/typing-telemetry/pkg/stats"
+	"github.com/spf13/cobra"
+)
+
+// Flags for apps.
+var (
+	appsSince string
+	appsTop   int
+)
+
+var appsCmd = &cobra.Command{
+	Use:   "apps",
+	Short: "Show which applications you type in the most",
+	Long: `The capture daemon attributes every keystroke, word and second of active
+typing to the frontmost application: its bundle ID on macOS
+(com.apple.Safari), the WM_CLASS class of the active window on Linux
+(firefox). Keys typed while the app can't be determined — e.g. into a
+Wayland-native window — are counted in your totals but not here.
+
+--since takes a number of days or weeks (7d, 2w), a date (2025-06-01), or
+"all".
+
+  typtel apps                  # top 20 over the last 7 days
+  typtel apps --since 30d -n 5
+
+Strict mode counts keystrokes only from allowlisted apps and drops the rest
+entirely. Its settings are shared with the macOS Settings window:
+
+  typtel apps allow            # list the allowlist and the apps seen so far
+  typtel apps allow kitty code
+  typtel apps deny code
+  typtel apps strict on`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runApps()
+	},
+}
+
+var appsStrictCmd = &cobra.Command{
+	Use:       "strict <on|off>",
+	Short:     "Count keystrokes only from allowlisted apps",
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: []string{"on", "off"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var enabled bool
+		switch args[0] {
+		case "on":
+			enabled = true
+		case "off":
+		default:
+			return fmt.Errorf("want on or off, got %q", args[0])
+		}
+		return withStore(func(s *storage.Store) error {
+			if err := s.SetStrictWordCountEnabled(enabled); err != nil {
+				return err
+			}
+			return printAllowlist(s)
+		})
+	},
+}
+
+var appsAllowCmd = &cobra.Command{
+	Use:   "allow [app-id...]",
+	Short: "Add apps to the strict-mode allowlist (none: show it)",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withStore(func(s *storage.Store) error {
+			if len(args) > 0 {
+				list := s.GetWordCountAllowlist()
+				for _, id := range args {
+					if !slices.Contains(list, id) {
+						list = append(list, id)
+					}
+				}
+				if err := s.SetWordCountAllowlist(list); err != nil {
+					return err
+				}
+			}
+			return printAllowlist(s)
+		})
+	},
+}
+
+var appsDenyCmd = &cobra.Command{
+	Use:   "deny <app-id...>",
+	Short: "Remove apps from the strict-mode allowlist",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withStore(func(s *storage.Store) error {
+			var list []string
+			for _, id := range s.GetWordCountAllowlist() {
+				if !slices.Contains(args, id) {
+					list = append(list, id)
+				}
+			}
+			if err := s.SetWordCountAllowlist(list); err != nil {
+				return err
+			}
+			return printAllowlist(s)
+		})
+	},
+}
+
+func init() {
+	appsCmd.Flags().StringVar(&appsSince, "since", "7d", "Period to report: Nd, Nw, a YYYY-MM-DD date, or all")
+	appsCmd.Flags().IntVarP(&appsTop, "top", "n", 20, "Number of apps to list (0 = all)")
+	appsCmd.AddCommand(appsStrictCmd)
+	appsCmd.AddCommand(appsAllowCmd)
+	appsCmd.AddCommand(appsDenyCmd)
+}
+
+// parseSince turns a --since value into the first date to include
+// ("2006-01-02", empty for all time) and a label for the report header.
+// "7d" covers today and the six days before it.
+func parseSince(v string, now time.Time) (since, label string, err error) {
+	v = strings.TrimSpace(strings.ToLower(v))
+	switch v {
+	case "", "all":
+		return "", "all time", nil
+	case "today":
+		return now.Format("2006-01-02"), "today", nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", v, now.Location()); err == nil {
+		return t.Format("2006-01-02"), "since " + v, nil
+	}
+	if len(v) >= 2 {
+		n, err := strconv.Atoi(v[:len(v)-1])
+		if err == nil && n > 0 {
+			days := n
+			switch v[len(v)-1] {
+			case 'w':
+				days = n * 7
+				fallthrough
+			case 'd':
+				return now.AddDate(0, 0, -(days - 1)).Format("2006-01-02"),
+					fmt.Sprintf("last %d days", days), nil
+			}
+		}
+	}
+	return "", "", fmt.Errorf("invalid --since %q: want Nd, Nw, a YYYY-MM-DD date, or all", v)
+}
+
+func runApps() error {
+	since, label, err := parseSince(appsSince, time.Now())
+	if err != nil {
+		return err
+	}
+	store, err := storage.New()
+	if err != nil {
+		return fmt.Errorf("failed to open storage: %w", err)
+	}
+	defer store.Close()
+
+	usage, err := store.GetAppUsage(since)
+	if err != nil {
+		return err
+	}
+	if len(usage) == 0 {
+		fmt.Printf("No per-app typing recorded (%s).\n", label)
+		return nil
+	}
+
+	var total int64
+	for _, u := range usage {
+		total += u.Keystrokes
+	}
+	fmt.Printf("Top apps, %s (%s keystrokes)\n\n", label, formatNum(total))
+	fmt.Printf("%4s  %-32s %10s %7s %8s %9s %8s\n", "#", "APP", "KEYSTROKES", "SHARE", "WORDS", "ACTIVE", "WPM")
+	for i, u := range usage {
+		if appsTop > 0 && i >= appsTop {
+			break
+		}
+		share := 0.0
+		if total > 0 {
+			share = float64(u.Keystrokes) / float64(total) * 100
+		}
+		wpm := "-"
+		if w := stats.AverageWPM(u.Words, u.ActiveMs); w > 0 {
+			wpm = fmt.Sprintf("%.0f", w)
+		}
+		fmt.Printf("%4d  %-32s %10s %6.1f%% %8s %9s %8s\n", i+1, truncate(u.AppID, 32),
+			formatNum(u.Keystrokes), share, formatNum(u.Words),
+			(time.Duration(u.ActiveMs) * time.Millisecond).Round(time.Second), wpm)
+	}
+	if store.IsStrictWordCountEnabled() {
+		fmt.Println("\nStrict mode is on: only allowlisted apps are counted.")
+	}
+	return nil
+}
+
+func printAllowlist(s *storage.Store) error {
+	mode := "off"
+	if s.IsStrictWordCountEnabled() {
+		mode = "on"
+	}
+	allow := s.GetWordCountAllowlist()
+	fmt.Printf("strict:  %s\n", mode)
+	fmt.Printf("allowed: %s\n", dashIfEmpty(strings.Join(allow, ", ")))
+
+	var others []string
+	for _, id := range s.GetWordCountAppsSeen() {
+		if !slices.Contains(allow, id) {
+			others = append(others, id)
+		}
+	}
+	fmt.Printf("seen:    %s\n", dashIfEmpty(strings.Join(others, ", ")))
+	return nil
+}
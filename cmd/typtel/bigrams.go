@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/aayushbajaj/typing-telemetry/internal/keyboard"
+	"github.com/aayushbajaj/typing-telemetry/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+// Flags for bigrams.
+var (
+	bigramsSlowest  int
+	bigramsMinCount int64
+)
+
+var bigramsCmd = &cobra.Command{
+	Use:   "bigrams",
+	Short: "Show the slowest key-to-key transitions",
+	Long: `The capture daemon times every transition between consecutive keys
+(modifiers are skipped; pauses over a second and shortcuts break the chain)
+and keeps a count, mean and 90th-percentile latency per key pair. This lists
+the pairs with the slowest p90 — the transitions worth drilling.
+
+Pairs seen fewer than --min-count times are left out as noise. Practise the
+slowest ones with "typtel test --bigrams".
+
+  typtel bigrams --slowest 20
+  typtel bigrams --slowest 50 --min-count 100`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runBigrams()
+	},
+}
+
+func init() {
+	bigramsCmd.Flags().IntVar(&bigramsSlowest, "slowest", 20, "Number of pairs to list")
+	bigramsCmd.Flags().Int64Var(&bigramsMinCount, "min-count", 20, "Ignore pairs seen fewer times than this")
+}
+
+func runBigrams() error {
+	store, err := storage.New()
+	if err != nil {
+		return fmt.Errorf("failed to open storage: %w", err)
+	}
+	defer store.Close()
+
+	pairs, err := store.GetSlowestBigrams(bigramsSlowest, bigramsMinCount)
+	if err != nil {
+		return err
+	}
+	if len(pairs) == 0 {
+		fmt.Printf("No key pairs seen at least %d times yet — keep typing with the daemon running.\n", bigramsMinCount)
+		return nil
+	}
+
+	fmt.Printf("%4s  %-28s %9s %9s %9s\n", "#", "PAIR", "COUNT", "MEAN", "P90")
+	for i, p := range pairs {
+		pair := keyboard.Name(p.Prev) + " → " + keyboard.Name(p.Next)
+		fmt.Printf("%4d  %-28s %9s %7.0fms %7dms\n", i+1, pair, formatNum(p.Count), p.MeanMs, p.P90Ms)
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aayushbajaj/typing-telemetry/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+var breaksSince string
+
+var breaksCmd = &cobra.Command{
+	Use:   "breaks",
+	Short: "Break reminders (RSI guard) and how often you take them",
+	Long: `When break reminders are on, the capture daemon (typtel-tray or the
+menubar app) tracks continuous keyboard and mouse activity and sends a desktop
+notification when a break is due: a short micro-break after every stretch of
+typing, and a longer break away from the keyboard every so often. Any pause at
+least as long as the break counts as taking it.
+
+  typtel breaks on
+  typtel breaks set micro 20m 20s   # every 20 minutes, pause 20 seconds
+  typtel breaks set long 1h 5m      # every hour, pause 5 minutes
+  typtel breaks set micro 0 20s     # no micro-break reminders
+  typtel breaks                     # settings and compliance, last 7 days
+  typtel breaks --since 30d
+
+Compliance counts reminders you acted on ("taken") against those you typed
+through ("skipped"), plus long breaks taken before a reminder was due.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		since, label, err := parseSince(breaksSince, time.Now())
+		if err != nil {
+			return err
+		}
+		return withStore(func(s *storage.Store) error {
+			printBreakSettings(s)
+			compliance, err := s.GetBreakCompliance(since)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("\nCompliance, %s:\n", label)
+			fmt.Printf("  %-6s %8s %6s %8s %6s %11s %12s\n", "KIND", "PROMPTED", "TAKEN", "SKIPPED", "RATE", "UNPROMPTED", "AVG WORKED")
+			for _, c := range compliance {
+				rate := "-"
+				if c.Prompted > 0 {
+					rate = fmt.Sprintf("%.0f%%", c.Rate()*100)
+				}
+				worked := "-"
+				if c.AvgWorked > 0 {
+					worked = compactDuration(c.AvgWorked.Round(time.Minute))
+				}
+				fmt.Printf("  %-6s %8d %6d %8d %6s %11d %12s\n", c.Kind, c.Prompted, c.Taken, c.Skipped, rate, c.Unprompted, worked)
+			}
+			return nil
+		})
+	},
+}
+
+var breaksOnCmd = &cobra.Command{
+	Use:   "on",
+	Short: "Turn break reminders on",
+	Args:  cobra.NoArgs,
+	RunE:  func(cmd *cobra.Command, args []string) error { return setBreaksEnabled(true) },
+}
+
+var breaksOffCmd = &cobra.Command{
+	Use:   "off",
+	Short: "Turn break reminders off",
+	Args:  cobra.NoArgs,
+	RunE:  func(cmd *cobra.Command, args []string) error { return setBreaksEnabled(false) },
+}
+
+var breaksSetCmd = &cobra.Command{
+	Use:   "set <micro|long> <every> <pause>",
+	Short: "Set how often a break is due and how long a pause counts as one",
+	Long: `Durations use Go syntax: 45s, 20m, 1h30m. An interval of 0 turns off
+reminders of that kind. A micro-break must be shorter than a long break.`,
+	Args: cobra.ExactArgs(3),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		every, err := time.ParseDuration(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid interval %q: %w", args[1], err)
+		}
+		pause, err := time.ParseDuration(args[2])
+		if err != nil {
+			return fmt.Errorf("invalid pause %q: %w", args[2], err)
+		}
+		return withStore(func(s *storage.Store) error {
+			if err := s.SetRSIBreak(args[0], every, pause); err != nil {
+				return err
+			}
+			printBreakSettings(s)
+			return nil
+		})
+	},
+}
+
+func init() {
+	breaksCmd.Flags().StringVar(&breaksSince, "since", "7d", "Period to report: Nd, Nw, a YYYY-MM-DD date, or all")
+	breaksCmd.AddCommand(breaksOnCmd)
+	breaksCmd.AddCommand(breaksOffCmd)
+	breaksCmd.AddCommand(breaksSetCmd)
+}
+
+func setBreaksEnabled(enabled bool) error {
+	return withStore(func(s *storage.Store) error {
+		if err := s.SetRSIEnabled(enabled); err != nil {
+			return err
+		}
+		printBreakSettings(s)
+		return nil
+	})
+}
+
+func printBreakSettings(s *storage.Store) {
+	r := s.GetRSISettings()
+	state := "off"
+	if r.Enabled {
+		state = "on"
+	}
+	fmt.Printf("Break reminders: %s\n", state)
+	show := func(kind string, every, pause time.Duration) {
+		if every <= 0 {
+			fmt.Printf("  %-6s never\n", kind)
+			return
+		}
+		fmt.Printf("  %-6s every %s, pause %s\n", kind, compactDuration(every), compactDuration(pause))
+	}
+	show(storage.BreakMicro, r.MicroAfter, r.MicroBreak)
+	show(storage.BreakLong, r.LongAfter, r.LongBreak)
+}
+
+// compactDuration drops the zero tails Duration.String adds: 1h0m0s is "1h",
+// 5m0s is "5m".
+func compactDuration(d time.Duration) string {
+	out := d.String()
+	if strings.HasSuffix(out, "m0s") {
+		out = strings.TrimSuffix(out, "0s")
+	}
+	if strings.HasSuffix(out, "h0m") {
+		out = strings.TrimSuffix(out, "0m")
+	}
+	return out
+}
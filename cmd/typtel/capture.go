@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aayushbajaj/typing-telemetry/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+var captureCmd = &cobra.Command{
+	Use:   "capture",
+	Short: "Choose how typtel-tray captures keystrokes on Linux",
+	Long: `typtel-tray can capture keystrokes two ways:
+
+  x11    Poll the X server. Needs no permissions, but only sees keys typed
+         into X11 windows — nothing under Wayland.
+  evdev  Read keyboard events from /dev/input. Works under X11, Wayland and
+         on the console, but needs read access to the device nodes:
+         sudo usermod -aG input $USER, then log in again.
+  auto   evdev in a Wayland session or without $DISPLAY, otherwise x11.
+
+The evdev backend reads every keyboard in /proc/bus/input/devices unless
+devices are listed. Mouse travel and clicks are sampled over X11 whichever
+backend is used. Restart typtel-tray to apply; its --backend and
+--input-device flags override these settings for one run.
+
+  typtel capture                          # show the settings
+  typtel capture backend evdev
+  typtel capture devices /dev/input/by-id/usb-Keychron_K3-event-kbd
+  typtel capture devices                  # back to every keyboard
+  typtel capture mouse off`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withStore(printCapture)
+	},
+}
+
+var captureBackendCmd = &cobra.Command{
+	Use:   "backend <auto|x11|evdev>",
+	Short: "Set the capture backend",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withStore(func(s *storage.Store) error {
+			if err := s.SetCaptureBackend(args[0]); err != nil {
+				return err
+			}
+			return printCapture(s)
+		})
+	},
+}
+
+var captureDevicesCmd = &cobra.Command{
+	Use:   "devices [path...]",
+	Short: "Set the evdev devices to read (none: every keyboard)",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withStore(func(s *storage.Store) error {
+			if err := s.SetEvdevDevices(args); err != nil {
+				return err
+			}
+			return printCapture(s)
+		})
+	},
+}
+
+var captureMouseCmd = &cobra.Command{
+	Use:       "mouse <on|off>",
+	Short:     "Turn mouse tracking on or off",
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: []string{"on", "off"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var enabled bool
+		switch args[0] {
+		case "on":
+			enabled = true
+		case "off":
+		default:
+			return fmt.Errorf("want on or off, got %q", args[0])
+		}
+		return withStore(func(s *storage.Store) error {
+			if err := s.SetMouseTrackingEnabled(enabled); err != nil {
+				return err
+			}
+			return printCapture(s)
+		})
+	},
+}
+
+func init() {
+	captureCmd.AddCommand(captureBackendCmd)
+	captureCmd.AddCommand(captureDevicesCmd)
+	captureCmd.AddCommand(captureMouseCmd)
+}
+
+func printCapture(s *storage.Store) error {
+	devices := "every keyboard"
+	if d := s.GetEvdevDevices(); len(d) > 0 {
+		devices = strings.Join(d, ", ")
+	}
+	fmt.Printf("backend: %s\n", s.GetCaptureBackend())
+	fmt.Printf("devices: %s (evdev only)\n", devices)
+	mouse := "off"
+	if s.IsMouseTrackingEnabled() {
+		mouse = "on"
+	}
+	fmt.Printf("mouse:   %s\n", mouse)
+	fmt.Println("Restart typtel-tray to apply changes.")
+	return nil
+}
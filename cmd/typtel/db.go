@@ -0,0 +1,208 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/aayushbajaj/typing-telemetry/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+// Flags for the db subcommands.
+var (
+	compactDryRun    bool
+	compactOlderThan int
+	compactNoVacuum  bool
+	migrateStatus    bool
+)
+
+var dbCmd = &cobra.Command{
+	Use:   "db",
+	Short: "Database maintenance (compaction, retention, migrations)",
+	Long: `Maintenance for the typtel SQLite database.
+
+The raw keystrokes table grows by one row per key. "typtel db compact" folds
+rows older than the retention window into an hourly-per-keycode rollup and
+deletes the originals; hourly charts and stats read both transparently.
+Retention is off until set with "typtel db retention"; only then do the
+daemons compact at startup.
+
+  typtel db compact --dry-run    # report what would be compacted and freed
+  typtel db compact              # compact using the saved retention window
+  typtel db compact --older-than 30
+  typtel db retention 30         # keep 30 days of raw keystrokes
+  typtel db migrate --status     # applied and pending schema migrations`,
+}
+
+var dbCompactCmd = &cobra.Command{
+	Use:   "compact",
+	Short: "Roll up old raw keystrokes into hourly counts and delete them",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runDBCompact()
+	},
+}
+
+var dbRetentionCmd = &cobra.Command{
+	Use:   "retention [days]",
+	Short: "Show or set how many days of raw keystrokes to keep",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runDBRetention(args)
+	},
+}
+
+var dbMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Apply pending schema migrations (--status to only report them)",
+	Long: `Schema changes are numbered migrations recorded in the schema_version
+table. Every typtel binary applies pending steps when it opens the database, so
+this is rarely needed by hand; --status shows where a database stands without
+changing it. A database written by a newer typtel is refused rather than
+opened.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runDBMigrate()
+	},
+}
+
+func init() {
+	dbCompactCmd.Flags().BoolVar(&compactDryRun, "dry-run", false, "Report what would be compacted without changing anything")
+	dbCompactCmd.Flags().IntVar(&compactOlderThan, "older-than", 0, "Compact rows older than this many days (default: saved retention)")
+	dbCompactCmd.Flags().BoolVar(&compactNoVacuum, "no-vacuum", false, "Skip VACUUM after compacting (the file will not shrink)")
+
+	dbMigrateCmd.Flags().BoolVar(&migrateStatus, "status", false, "Report applied and pending migrations without applying them")
+
+	dbCmd.AddCommand(dbCompactCmd)
+	dbCmd.AddCommand(dbMigrateCmd)
+	dbCmd.AddCommand(dbRetentionCmd)
+}
+
+func runDBCompact() error {
+	store, err := storage.New()
+	if err != nil {
+		return fmt.Errorf("failed to open storage: %w", err)
+	}
+	defer store.Close()
+
+	days := compactOlderThan
+	if days <= 0 {
+		days = store.GetKeystrokeRetentionDays()
+	}
+	if days <= 0 {
+		fmt.Println("Keystroke retention is off; set it with 'typtel db retention <days>' or pass --older-than.")
+		return nil
+	}
+
+	var report storage.CompactReport
+	if compactDryRun {
+		report, err = store.PlanCompaction(days)
+	} else {
+		report, err = store.CompactKeystrokes(days)
+	}
+	if err != nil {
+		return fmt.Errorf("compact: %w", err)
+	}
+
+	verb := "Compacted"
+	if report.DryRun {
+		verb = "Would compact"
+	}
+	fmt.Printf("%s raw keystrokes before %s (older than %d days)\n", verb, report.Cutoff, days)
+	fmt.Printf("  Days:          %d\n", report.Days)
+	fmt.Printf("  Raw rows:      %s\n", formatNum(report.RawRows))
+	fmt.Printf("  Rollup rows:   %s\n", formatNum(report.RollupRows))
+	fmt.Printf("  Database size: %s\n", formatBytes(report.DBBytes))
+	fmt.Printf("  Est. freed:    ~%s\n", formatBytes(report.EstimatedSaved))
+
+	if report.DryRun || report.RawRows == 0 || compactNoVacuum {
+		return nil
+	}
+	if err := store.Vacuum(); err != nil {
+		// The rows are already compacted; a busy daemon only delays the shrink.
+		fmt.Printf("  Vacuum skipped: %v (re-run 'typtel db compact' later to shrink the file)\n", err)
+		return nil
+	}
+	if size, err := store.DatabaseSize(); err == nil {
+		fmt.Printf("  Size after:    %s\n", formatBytes(size))
+	}
+	return nil
+}
+
+func runDBRetention(args []string) error {
+	return withStore(func(s *storage.Store) error {
+		if len(args) == 1 {
+			days, err := strconv.Atoi(args[0])
+			if err != nil {
+				return fmt.Errorf("retention must be an integer number of days: %q", args[0])
+			}
+			if err := s.SetKeystrokeRetentionDays(days); err != nil {
+				return err
+			}
+		}
+		days := s.GetKeystrokeRetentionDays()
+		if days <= 0 {
+			fmt.Println("keystroke retention: off (raw keystrokes kept forever)")
+			return nil
+		}
+		fmt.Printf("keystroke retention: %d days\n", days)
+		return nil
+	})
+}
+
+func runDBMigrate() error {
+	infos, err := storage.MigrationStatus()
+	if err != nil {
+		return fmt.Errorf("migration status: %w", err)
+	}
+
+	pending := 0
+	for _, m := range infos {
+		if !m.Applied {
+			pending++
+		}
+	}
+
+	if migrateStatus {
+		fmt.Printf("%-4s %-8s %-26s %s\n", "VER", "STATE", "APPLIED_AT", "NAME")
+		for _, m := range infos {
+			state := "applied"
+			if !m.Applied {
+				state = "pending"
+			}
+			fmt.Printf("%-4d %-8s %-26s %s\n", m.Version, state, dashIfEmpty(m.AppliedAt), m.Name)
+		}
+		fmt.Printf("\n%d applied, %d pending (latest: v%d)\n", len(infos)-pending, pending, storage.LatestSchemaVersion())
+		return nil
+	}
+
+	// Opening the store applies every pending step.
+	store, err := storage.New()
+	if err != nil {
+		return fmt.Errorf("failed to open storage: %w", err)
+	}
+	defer store.Close()
+
+	version, err := store.SchemaVersion()
+	if err != nil {
+		return fmt.Errorf("schema version: %w", err)
+	}
+	if pending == 0 {
+		fmt.Printf("Schema is up to date (v%d)\n", version)
+		return nil
+	}
+	fmt.Printf("Applied %d migration(s); schema is now at v%d\n", pending, version)
+	return nil
+}
+
+// formatBytes renders a byte count with a binary unit suffix.
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
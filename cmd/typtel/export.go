@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/aayushbajaj/typing-telemetry/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+// Flags for export/import.
+var (
+	exportOutput    string
+	exportSecrets   bool
+	importOverwrite bool
+	importDryRun    bool
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export history and settings as a portable JSON-lines bundle",
+	Long: `Write daily totals, mouse stats, per-app totals, odometer history, focus
+sessions, the break log, typing-test results and history, bigram latencies,
+device feeds and settings as a versioned JSON-lines bundle. Restore it on
+another machine with "typtel import".
+
+Bearer tokens (device_ingest_token, push_token) are left out unless
+--include-secrets is given. Settings that describe this machine (push
+device identity, capture backend and evdev devices, local API) never travel.
+Raw per-key rows are not exported.
+
+  typtel export -o typtel-backup.jsonl
+  typtel export | gzip > typtel-backup.jsonl.gz`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runExport()
+	},
+}
+
+var importCmd = &cobra.Command{
+	Use:   "import <bundle.jsonl|->",
+	Short: "Merge a bundle from 'typtel export' into this database",
+	Long: `Merge a bundle written by "typtel export". Import never clobbers newer
+data: per-date counters keep the larger of the two values, odometer and focus
+sessions, breaks and typing tests already present are skipped, bigram
+latencies come from the side with more samples, and local settings are kept
+unless --overwrite-settings is given. Importing the same bundle twice is a
+no-op.
+
+  typtel import typtel-backup.jsonl --dry-run
+  gunzip -c typtel-backup.jsonl.gz | typtel import -`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runImport(args[0])
+	},
+}
+
+func init() {
+	exportCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "Write the bundle to this file instead of stdout")
+	exportCmd.Flags().BoolVar(&exportSecrets, "include-secrets", false, "Include ingest/push bearer tokens")
+	importCmd.Flags().BoolVar(&importOverwrite, "overwrite-settings", false, "Let bundle settings replace local values")
+	importCmd.Flags().BoolVar(&importDryRun, "dry-run", false, "Report what would be merged without writing")
+}
+
+func runExport() error {
+	store, err := storage.New()
+	if err != nil {
+		return fmt.Errorf("failed to open storage: %w", err)
+	}
+	defer store.Close()
+
+	var w io.Writer = os.Stdout
+	if exportOutput != "" {
+		f, err := os.Create(exportOutput)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	sum, err := store.Export(w, storage.ExportOptions{IncludeSecrets: exportSecrets})
+	if err != nil {
+		return fmt.Errorf("export: %w", err)
+	}
+
+	// The summary goes to stderr so stdout stays a clean bundle.
+	for _, kind := range sum.Kinds() {
+		fmt.Fprintf(os.Stderr, "  %-18s %d\n", kind, sum.Applied[kind])
+	}
+	if exportOutput != "" {
+		fmt.Fprintf(os.Stderr, "Wrote %s\n", exportOutput)
+	}
+	return nil
+}
+
+func runImport(path string) error {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	store, err := storage.New()
+	if err != nil {
+		return fmt.Errorf("failed to open storage: %w", err)
+	}
+	defer store.Close()
+
+	sum, err := store.Import(r, storage.ImportOptions{
+		OverwriteSettings: importOverwrite,
+		DryRun:            importDryRun,
+	})
+	if err != nil {
+		return fmt.Errorf("import: %w", err)
+	}
+
+	if importDryRun {
+		fmt.Println("Dry run — nothing was written.")
+	}
+	fmt.Printf("%-18s %8s %8s\n", "KIND", "MERGED", "SKIPPED")
+	for _, kind := range sum.Kinds() {
+		fmt.Printf("%-18s %8d %8d\n", kind, sum.Applied[kind], sum.Skipped[kind])
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aayushbajaj/typing-telemetry/internal/goals"
+	"github.com/aayushbajaj/typing-telemetry/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+var goalsCmd = &cobra.Command{
+	Use:   "goals",
+	Short: "Set daily and weekly goals and show progress toward them",
+	Long: `Goals are targets for words, keystrokes or minutes of active typing, per day
+or per week (Monday to Sunday). Progress shows the percentage done and, at
+the rate you've typed so far, when you'll get there. The streak counts days
+on which every daily goal was met.
+
+  typtel goals set daily words 1500
+  typtel goals set daily active_minutes 60
+  typtel goals set weekly keystrokes 250000
+  typtel goals                   # progress
+  typtel goals clear daily words # or: clear daily, clear (everything)
+
+Progress also appears in 'typtel today', its --json output, the dashboard
+and the charts page.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withStore(func(s *storage.Store) error {
+			r, err := goals.Build(s, time.Now())
+			if err != nil {
+				return err
+			}
+			if r.IsZero() {
+				fmt.Println("No goals set. Try 'typtel goals set daily words 1000'.")
+				return nil
+			}
+			printGoalReport(r, time.Now())
+			return nil
+		})
+	},
+}
+
+var goalsSetCmd = &cobra.Command{
+	Use:   "set <daily|weekly> <words|keystrokes|active_minutes> <target>",
+	Short: "Set a goal (a target of 0 clears it)",
+	Args:  cobra.ExactArgs(3),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		target, err := strconv.ParseInt(args[2], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid target %q: want a whole number", args[2])
+		}
+		return withStore(func(s *storage.Store) error {
+			if err := s.SetGoal(args[0], args[1], target); err != nil {
+				return err
+			}
+			printGoalSettings(s)
+			return nil
+		})
+	},
+}
+
+var goalsClearCmd = &cobra.Command{
+	Use:   "clear [daily|weekly] [metric]",
+	Short: "Clear goals: all of them, one period's, or one",
+	Args:  cobra.MaximumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		periods := []string{storage.GoalPeriodDaily, storage.GoalPeriodWeekly}
+		metrics := storage.GoalMetrics
+		if len(args) > 0 {
+			periods = args[:1]
+		}
+		if len(args) > 1 {
+			metrics = args[1:]
+		}
+		return withStore(func(s *storage.Store) error {
+			for _, p := range periods {
+				for _, m := range metrics {
+					if err := s.SetGoal(p, m, 0); err != nil {
+						return err
+					}
+				}
+			}
+			printGoalSettings(s)
+			return nil
+		})
+	},
+}
+
+func init() {
+	goalsCmd.AddCommand(goalsSetCmd)
+	goalsCmd.AddCommand(goalsClearCmd)
+}
+
+func printGoalSettings(s *storage.Store) {
+	for _, period := range []string{storage.GoalPeriodDaily, storage.GoalPeriodWeekly} {
+		targets := s.GetGoals(period)
+		var parts []string
+		for _, m := range storage.GoalMetrics {
+			if t := storage.GoalTarget(targets, m); t > 0 {
+				parts = append(parts, fmt.Sprintf("%s %s", formatNum(t), goals.Label(m)))
+			}
+		}
+		fmt.Printf("%-7s %s\n", period+":", dashIfEmpty(strings.Join(parts, ", ")))
+	}
+}
+
+// printGoalReport prints one line per goal, then the streak.
+func printGoalReport(r *goals.Report, now time.Time) {
+	show := func(name string, p *goals.Period) {
+		if p == nil {
+			return
+		}
+		for _, g := range p.Goals {
+			fmt.Printf("%-7s %-11s %9s / %-9s %4.0f%%  %s\n", name, goals.Label(g.Metric),
+				formatNum(g.Current), formatNum(g.Target), g.Percent, goalOutlook(g, now))
+		}
+	}
+	show("today", r.Daily)
+	show("week", r.Weekly)
+	if r.Daily != nil {
+		fmt.Printf("streak  %d days (longest %d)\n", r.Streak.Current, r.Streak.Longest)
+	}
+}
+
+func goalOutlook(g goals.Metric, now time.Time) string {
+	switch {
+	case g.Met:
+		return "done"
+	case g.ProjectedFinish == "":
+		return "not started"
+	}
+	t, err := time.Parse(time.RFC3339, g.ProjectedFinish)
+	if err != nil {
+		return ""
+	}
+	t = t.Local()
+	if !g.OnTrack {
+		return "behind pace"
+	}
+	if y, m, d := t.Date(); y == now.Year() && m == now.Month() && d == now.Day() {
+		return "on pace for " + t.Format("15:04")
+	}
+	return "on pace for " + t.Format("Mon 15:04")
+}
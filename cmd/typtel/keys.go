@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aayushbajaj/typing-telemetry/internal/keyboard"
+	"github.com/aayushbajaj/typing-telemetry/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+// Flags for keys.
+var (
+	keysTop  int
+	keysDays int
+)
+
+var keysCmd = &cobra.Command{
+	Use:   "keys",
+	Short: "Show the most-pressed keys and the per-hand / per-finger split",
+	Long: `Count presses per physical key and print the top N, followed by how the
+load splits across hands and fingers (standard touch-typing assignment on an
+ANSI board). The charts page ("typtel v") draws the same data as a keyboard
+heatmap.
+
+  typtel keys                  # top 20 over the last 30 days
+  typtel keys -n 50 --days 0   # top 50, all time`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runKeys()
+	},
+}
+
+func init() {
+	keysCmd.Flags().IntVarP(&keysTop, "top", "n", 20, "Number of keys to list")
+	keysCmd.Flags().IntVar(&keysDays, "days", 30, "Look back this many days, including today (0 = all time)")
+}
+
+func runKeys() error {
+	store, err := storage.New()
+	if err != nil {
+		return fmt.Errorf("failed to open storage: %w", err)
+	}
+	defer store.Close()
+
+	since := ""
+	period := "all time"
+	if keysDays > 0 {
+		since = time.Now().AddDate(0, 0, -(keysDays - 1)).Format("2006-01-02")
+		period = fmt.Sprintf("last %d days", keysDays)
+	}
+	freqs, err := store.GetKeycodeFrequencies(since)
+	if err != nil {
+		return err
+	}
+	if len(freqs) == 0 {
+		fmt.Printf("No keystrokes recorded (%s).\n", period)
+		return nil
+	}
+
+	counts := make(map[int]int64, len(freqs))
+	for _, f := range freqs {
+		counts[f.Keycode] = f.Count
+	}
+	split := keyboard.SplitCounts(counts)
+
+	fmt.Printf("Top keys, %s (%s keystrokes)\n\n", period, formatNum(split.Total))
+	fmt.Printf("%4s  %-16s %7s %12s %7s\n", "#", "KEY", "KEYCODE", "PRESSES", "SHARE")
+	for i, f := range freqs {
+		if keysTop > 0 && i >= keysTop {
+			break
+		}
+		fmt.Printf("%4d  %-16s %7d %12s %6.1f%%\n", i+1, keyboard.Name(f.Keycode), f.Keycode,
+			formatNum(f.Count), float64(f.Count)/float64(split.Total)*100)
+	}
+
+	share := func(n int64) float64 { return float64(n) / float64(split.Total) * 100 }
+	fmt.Printf("\nHands:   left %.1f%%  right %.1f%%  thumbs %.1f%%  other %.1f%%\n",
+		share(split.Hands[keyboard.HandLeft]), share(split.Hands[keyboard.HandRight]),
+		share(split.Hands[keyboard.HandEither]), share(split.Hands[keyboard.HandNone]))
+	fmt.Println("Fingers:")
+	for _, fk := range keyboard.FingerOrder {
+		if n := split.Fingers[fk]; n > 0 {
+			fmt.Printf("  %-14s %12s %6.1f%%\n", fk, formatNum(n), share(n))
+		}
+	}
+	return nil
+}
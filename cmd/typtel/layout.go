@@ -0,0 +1,177 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aayushbajaj/typing-telemetry/internal/keyboard"
+	"github.com/aayushbajaj/typing-telemetry/internal/layout"
+	"github.com/aayushbajaj/typing-telemetry/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+// On ISO boards macOS sends isoKeycode (kVK_ISO_Section) from the top-left
+// key, where ANSI boards send graveKeycode, and graveKeycode from the extra
+// key right of left Shift. show draws ISO layouts that way round.
+const (
+	isoKeycode   = 10
+	graveKeycode = 50
+)
+
+var layoutCmd = &cobra.Command{
+	Use:   "layout",
+	Short: "Choose the keyboard layout used to classify keys",
+	Long: `Keystrokes are recorded by physical key position. The keyboard layout says
+what each position types, which decides the letters / special split in your
+stats and which keys count toward a word. Pick the layout you type on:
+
+  typtel layout                 # list layouts; * marks the active one
+  typtel layout set dvorak
+  typtel layout show colemak    # what each key types
+
+Layouts not built in can be described in JSON and imported; see
+docs/reference/settings.md. A running tray daemon picks up a change within
+a few seconds. Counts already recorded are not reclassified.
+
+  typtel layout import workman.json`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runLayoutList()
+	},
+}
+
+var layoutListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List built-in and imported layouts",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runLayoutList()
+	},
+}
+
+var layoutSetCmd = &cobra.Command{
+	Use:   "set <name>",
+	Short: "Make a layout the active one",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runLayoutSet(args[0])
+	},
+}
+
+var layoutShowCmd = &cobra.Command{
+	Use:   "show [name]",
+	Short: "Print what each key types on a layout (default: the active one)",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := ""
+		if len(args) == 1 {
+			name = args[0]
+		}
+		return runLayoutShow(name)
+	},
+}
+
+var layoutImportCmd = &cobra.Command{
+	Use:   "import <file.json>",
+	Short: "Validate a JSON layout and add it to the available layouts",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		l, err := storage.ImportKeyboardLayout(args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Imported layout %q. Activate it with 'typtel layout set %s'.\n", l.Name, l.Name)
+		return nil
+	},
+}
+
+func init() {
+	layoutCmd.AddCommand(layoutListCmd)
+	layoutCmd.AddCommand(layoutSetCmd)
+	layoutCmd.AddCommand(layoutShowCmd)
+	layoutCmd.AddCommand(layoutImportCmd)
+}
+
+func runLayoutList() error {
+	store, err := storage.New()
+	if err != nil {
+		return fmt.Errorf("failed to open storage: %w", err)
+	}
+	defer store.Close()
+
+	// A broken user layout is reported but doesn't hide the rest.
+	layouts, listErr := storage.KeyboardLayouts()
+	active := store.GetKeyboardLayoutName()
+
+	fmt.Printf("  %-14s %-8s %s\n", "LAYOUT", "SOURCE", "DESCRIPTION")
+	for _, l := range layouts {
+		mark := " "
+		if l.Name == active {
+			mark = "*"
+		}
+		source := "built-in"
+		if !l.Builtin {
+			source = "user"
+		}
+		fmt.Printf("%s %-14s %-8s %s\n", mark, l.Name, source, dashIfEmpty(l.Description))
+	}
+	if err := store.ReloadKeyboardLayout(); err != nil {
+		fmt.Printf("\nwarning: %v\n", err)
+	}
+	if listErr != nil {
+		fmt.Printf("\nwarning: %v\n", listErr)
+	}
+	return nil
+}
+
+func runLayoutSet(name string) error {
+	store, err := storage.New()
+	if err != nil {
+		return fmt.Errorf("failed to open storage: %w", err)
+	}
+	defer store.Close()
+
+	if err := store.SetKeyboardLayout(name); err != nil {
+		return err
+	}
+	fmt.Printf("Keyboard layout is now %q.\n", name)
+	return nil
+}
+
+func runLayoutShow(name string) error {
+	var l *layout.Layout
+	if name == "" {
+		store, err := storage.New()
+		if err != nil {
+			return fmt.Errorf("failed to open storage: %w", err)
+		}
+		defer store.Close()
+		l = store.KeyboardLayout()
+	} else {
+		var err error
+		if l, err = storage.ResolveKeyboardLayout(name); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("%s — %s\n\n", l.Name, dashIfEmpty(l.Description))
+	iso := l.Char(isoKeycode) != ""
+	rows := make([][]string, int(keyboard.Height))
+	for _, k := range keyboard.ANSI {
+		code := k.Code
+		if iso && code == graveKeycode {
+			code = isoKeycode
+		}
+		if c := l.Char(code); c != "" {
+			rows[int(k.Y)] = append(rows[int(k.Y)], c)
+		}
+	}
+	for i, row := range rows {
+		if len(row) > 0 {
+			fmt.Printf("  %s%s\n", strings.Repeat(" ", i), strings.Join(row, " "))
+		}
+	}
+	if c := l.Char(graveKeycode); iso && c != "" {
+		fmt.Printf("\n  right of left Shift: %s\n", c)
+	}
+	return nil
+}
@@ -1,12 +1,16 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"runtime"
+	"slices"
+	"time"
 
 	"github.com/aayushbajaj/typing-telemetry/internal/charts"
+	"github.com/aayushbajaj/typing-telemetry/internal/goals"
 	"github.com/aayushbajaj/typing-telemetry/internal/storage"
 	"github.com/aayushbajaj/typing-telemetry/internal/tui"
 	"github.com/aayushbajaj/typing-telemetry/pkg/stats"
@@ -22,6 +26,11 @@ var (
 	testFile      string
 	testWordCount int
 	testLanguage  string
+	testBigrams   bool
+	testTime      int
+	testWeak      bool
+	testCode      string
+	testIndent    bool
 
 	// JSON output flag for `today` and `stats` (machine-readable surface
 	// consumed by other tools like macos-watchdog).
@@ -45,12 +54,21 @@ SEE YOUR TYPING STATS
   typtel stats                 Today + this week, plus typing speed (WPM)
   typtel devices show <id>     Per-day table for an external device,
                                with letters/modifiers/special/words/active time
+  typtel keys                  Most-pressed keys, with the per-hand/finger split
+  typtel apps --since 7d       Apps you type in the most
+  typtel odometer start <label>  Measure a session (stop / status / history)
+  typtel sessions start <tag> --words 500  Focus session with a goal
+  typtel goals set daily words 1500  Daily/weekly goals (progress in today, TUI, charts)
+  typtel breaks on             Break reminders while you type (RSI guard)
+  typtel api enable            Serve stats JSON on localhost for status bars
   typtel v                     Open the charts/heatmap in a browser
 
 PRACTICE
   typtel test                  Interactive typing speed test (25 words)
   typtel test -w 50            Longer test (50 words)
   typtel test -l au            Use AU English spelling (saved as default)
+  typtel test --bigrams        Drill words heavy in your slowest key pairs
+  typtel bigrams               Your slowest key-to-key transitions
     in-test keys: tab=new words  esc=options  enter=start  ctrl+c=quit
 
 DEVICE FEEDS (optional — external devices that push their own stats)
@@ -58,8 +76,23 @@ DEVICE FEEDS (optional — external devices that push their own stats)
   typtel devices token         Print the ingest bearer token
   typtel devices enable        Enable the device ingest API
 
+MAINTENANCE
+  typtel export -o backup.jsonl  Export history and settings as a portable bundle
+  typtel import backup.jsonl     Merge a bundle (keeps the larger count per day)
+  typtel db compact --dry-run  Report how much old raw keystroke data can be rolled up
+  typtel db compact            Roll up raw keystrokes past the retention window
+  typtel layout set dvorak     Classify keys for your keyboard layout
+  typtel capture backend evdev Capture from /dev/input (Linux, Wayland)
+
+PROFILES (separate histories, each with its own database)
+  typtel profile list          Profiles, and which one typtel-tray is writing to
+  typtel profile create work   Create a profile
+  typtel profile switch work   Make it the default for new commands and the tray
+  typtel --profile work today  Use a profile for one command
+
   typtel help <command>        Detailed help for any command
   typtel version               Version info`,
+	PersistentPreRunE: applyProfileFlag,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		return runTUI()
 	},
@@ -105,7 +138,12 @@ Examples:
   typtel test                    # Default 25-word test
   typtel test -w 50              # 50-word test
   typtel test -f words.txt       # Use custom word list
-  typtel test -f passage.txt -w 100  # 100 words from custom file`,
+  typtel test -f passage.txt -w 100  # 100 words from custom file
+  typtel test --bigrams          # Drill words with your slowest key pairs
+  typtel test -t 60              # 60-second test
+  typtel test --weak             # Drill words with the letters you miss most
+  typtel test --code go          # Type Go snippets
+  typtel test --code ./src       # Type snippets of your own code`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		return runTypingTest()
 	},
@@ -134,9 +172,16 @@ var versionCmd = &cobra.Command{
 }
 
 func init() {
+	rootCmd.PersistentFlags().StringVar(&profileFlag, "profile", "", "Use this profile's database instead of the default")
+
 	testCmd.Flags().StringVarP(&testFile, "file", "f", "", "Path to text file with words/passages")
 	testCmd.Flags().IntVarP(&testWordCount, "words", "w", 25, "Number of words in the test")
 	testCmd.Flags().StringVarP(&testLanguage, "language", "l", "", "Language variant: us, au (saved as default)")
+	testCmd.Flags().BoolVar(&testBigrams, "bigrams", false, "Favour words containing your slowest key pairs (see 'typtel bigrams')")
+	testCmd.Flags().BoolVar(&testWeak, "weak", false, "Favour words containing the letters you miss most (see 'typtel test weak')")
+	testCmd.Flags().IntVarP(&testTime, "time", "t", 0, "Time-limited test of 15, 30, 60 or 120 seconds instead of a word count")
+	testCmd.Flags().StringVar(&testCode, "code", "", "Type code: a file, a directory, or built-in samples (all, go, python, js, shell)")
+	testCmd.Flags().BoolVar(&testIndent, "type-indent", false, "In code tests, type leading indentation instead of skipping it")
 
 	todayCmd.Flags().BoolVar(&jsonOutput, "json", false, "Emit machine-readable JSON instead of text")
 	statsCmd.Flags().BoolVar(&jsonOutput, "json", false, "Emit machine-readable JSON instead of text")
@@ -147,10 +192,24 @@ func init() {
 	rootCmd.AddCommand(todayCmd)
 	rootCmd.AddCommand(testCmd)
 	rootCmd.AddCommand(viewCmd)
+	rootCmd.AddCommand(keysCmd)
+	rootCmd.AddCommand(bigramsCmd)
+	rootCmd.AddCommand(appsCmd)
+	rootCmd.AddCommand(odometerCmd)
+	rootCmd.AddCommand(sessionsCmd)
+	rootCmd.AddCommand(goalsCmd)
+	rootCmd.AddCommand(breaksCmd)
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(devicesCmd)
 	rootCmd.AddCommand(pushCmd)
+	rootCmd.AddCommand(apiCmd)
 	rootCmd.AddCommand(inertiaCmd)
+	rootCmd.AddCommand(dbCmd)
+	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(importCmd)
+	rootCmd.AddCommand(profileCmd)
+	rootCmd.AddCommand(layoutCmd)
+	rootCmd.AddCommand(captureCmd)
 }
 
 func main() {
@@ -187,6 +246,23 @@ func runTUI() error {
 }
 
 func runTypingTest() error {
+	if testTime != 0 && !slices.Contains(tui.TimeLimits, testTime) {
+		return fmt.Errorf("--time must be one of 15, 30, 60 or 120 seconds, not %d", testTime)
+	}
+	if testBigrams && (testTime != 0 || testWeak) {
+		return errors.New("--bigrams can't be combined with --time or --weak")
+	}
+	if testCode != "" && (testFile != "" || testBigrams || testTime != 0 || testWeak) {
+		return errors.New("--code can't be combined with --file, --bigrams, --time or --weak")
+	}
+	var codeSnippets []string
+	if testCode != "" {
+		var err error
+		if codeSnippets, err = tui.LoadCodeSnippets(testCode); err != nil {
+			return fmt.Errorf("--code: %w", err)
+		}
+	}
+
 	store, err := storage.New()
 	if err != nil {
 		return fmt.Errorf("failed to open storage: %w", err)
@@ -204,10 +280,23 @@ func runTypingTest() error {
 		store.SetTypingTestLanguage(lang)
 	}
 
-	p := tea.NewProgram(
-		tui.NewTypingTestWithStore(testFile, testWordCount, store),
-		tea.WithAltScreen(),
-	)
+	model := tui.NewTypingTestWithStore(testFile, testWordCount, store)
+	if testBigrams {
+		model.SetTestType("bigrams")
+	}
+	if testTime != 0 {
+		model.SetTimeLimit(testTime)
+	}
+	if testWeak {
+		model.SetWeakKeys(true)
+	}
+	if testIndent {
+		model.SetSkipIndent(false)
+	}
+	if codeSnippets != nil {
+		model.SetCodeSnippets(codeSnippets)
+	}
+	p := tea.NewProgram(model, tea.WithAltScreen())
 	_, err = p.Run()
 	return err
 }
@@ -312,8 +401,18 @@ func showToday() error {
 		return fmt.Errorf("failed to get today's stats: %w", err)
 	}
 
-	// Output format suitable for menu bar scripts
+	// Output format suitable for menu bar scripts: the count alone on the
+	// first line. Goal progress follows only once goals are configured.
 	fmt.Printf("%d\n", today.Keystrokes)
+
+	now := time.Now()
+	report, err := goals.Build(store, now)
+	if err != nil {
+		return fmt.Errorf("failed to get goal progress: %w", err)
+	}
+	if !report.IsZero() {
+		printGoalReport(report, now)
+	}
 	return nil
 }
 
@@ -2,6 +2,7 @@ package main
 
 import (
 	"testing"
+	"time"
 )
 
 func TestRootCmdExists(t *testing.T) {
@@ -68,6 +69,11 @@ func TestTestCmdFlags(t *testing.T) {
 	if wordsFlag != nil && wordsFlag.DefValue != "25" {
 		t.Errorf("words flag default = %q, want '25'", wordsFlag.DefValue)
 	}
+
+	timeFlag := testCmd.Flags().Lookup("time")
+	if timeFlag == nil || timeFlag.Shorthand != "t" {
+		t.Error("testCmd should have a 'time' flag with shorthand 't'")
+	}
 }
 
 func TestViewCmdExists(t *testing.T) {
@@ -196,3 +202,262 @@ func TestFormatNumEdgeCases(t *testing.T) {
 		})
 	}
 }
+
+func TestDBCompactCmdFlags(t *testing.T) {
+	for _, name := range []string{"dry-run", "older-than", "no-vacuum"} {
+		if dbCompactCmd.Flags().Lookup(name) == nil {
+			t.Errorf("db compact should have a %q flag", name)
+		}
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		input    int64
+		expected string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1024, "1.0 KiB"},
+		{1536, "1.5 KiB"},
+		{300 * 1024 * 1024, "300.0 MiB"},
+	}
+	for _, tt := range tests {
+		if got := formatBytes(tt.input); got != tt.expected {
+			t.Errorf("formatBytes(%d) = %q, want %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestDBMigrateCmdHasStatusFlag(t *testing.T) {
+	if dbMigrateCmd.Flags().Lookup("status") == nil {
+		t.Error("db migrate should have a 'status' flag")
+	}
+}
+
+func TestExportImportCmdsExist(t *testing.T) {
+	if exportCmd.Flags().Lookup("output") == nil {
+		t.Error("export should have an 'output' flag")
+	}
+	for _, name := range []string{"dry-run", "overwrite-settings"} {
+		if importCmd.Flags().Lookup(name) == nil {
+			t.Errorf("import should have a %q flag", name)
+		}
+	}
+}
+
+func TestProfileFlagAndCmds(t *testing.T) {
+	if rootCmd.PersistentFlags().Lookup("profile") == nil {
+		t.Error("root command should have a persistent 'profile' flag")
+	}
+	for _, name := range []string{"list", "create", "switch"} {
+		if cmd, _, err := profileCmd.Find([]string{name}); err != nil || cmd == profileCmd {
+			t.Errorf("profile should have a %q subcommand", name)
+		}
+	}
+}
+
+func TestKeysCmdFlags(t *testing.T) {
+	top := keysCmd.Flags().Lookup("top")
+	if top == nil || top.Shorthand != "n" || top.DefValue != "20" {
+		t.Errorf("keys should have a 'top' (-n) flag defaulting to 20, got %+v", top)
+	}
+	if keysCmd.Flags().Lookup("days") == nil {
+		t.Error("keys should have a 'days' flag")
+	}
+}
+
+func TestBigramsCmdAndTestFlag(t *testing.T) {
+	if f := bigramsCmd.Flags().Lookup("slowest"); f == nil || f.DefValue != "20" {
+		t.Errorf("bigrams should have a 'slowest' flag defaulting to 20, got %+v", f)
+	}
+	if testCmd.Flags().Lookup("bigrams") == nil {
+		t.Error("test should have a 'bigrams' flag")
+	}
+}
+
+func TestLayoutCmds(t *testing.T) {
+	for _, name := range []string{"list", "set", "show", "import"} {
+		if cmd, _, err := layoutCmd.Find([]string{name}); err != nil || cmd == layoutCmd {
+			t.Errorf("layout should have a %q subcommand", name)
+		}
+	}
+}
+
+func TestCaptureCmds(t *testing.T) {
+	for _, name := range []string{"backend", "devices", "mouse"} {
+		if cmd, _, err := captureCmd.Find([]string{name}); err != nil || cmd == captureCmd {
+			t.Errorf("capture should have a %q subcommand", name)
+		}
+	}
+}
+
+func TestAppsCmds(t *testing.T) {
+	if f := appsCmd.Flags().Lookup("since"); f == nil || f.DefValue != "7d" {
+		t.Errorf("apps should have a 'since' flag defaulting to 7d, got %+v", f)
+	}
+	for _, name := range []string{"strict", "allow", "deny"} {
+		if cmd, _, err := appsCmd.Find([]string{name}); err != nil || cmd == appsCmd {
+			t.Errorf("apps should have a %q subcommand", name)
+		}
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2025, 3, 10, 15, 0, 0, 0, time.Local)
+	tests := []struct {
+		in, since string
+		wantErr   bool
+	}{
+		{"7d", "2025-03-04", false},
+		{"1d", "2025-03-10", false},
+		{"2w", "2025-02-25", false},
+		{"today", "2025-03-10", false},
+		{"all", "", false},
+		{"2025-01-01", "2025-01-01", false},
+		{"0d", "", true},
+		{"7x", "", true},
+		{"soon", "", true},
+	}
+	for _, tt := range tests {
+		since, _, err := parseSince(tt.in, now)
+		if (err != nil) != tt.wantErr || since != tt.since {
+			t.Errorf("parseSince(%q) = %q, %v; want %q (error %v)", tt.in, since, err, tt.since, tt.wantErr)
+		}
+	}
+}
+
+func TestOdometerCmds(t *testing.T) {
+	for _, name := range []string{"start", "stop", "status", "history", "reset"} {
+		if cmd, _, err := odometerCmd.Find([]string{name}); err != nil || cmd == odometerCmd {
+			t.Errorf("odometer should have a %q subcommand", name)
+		}
+	}
+	if odometerStopCmd.Flags().Lookup("label") == nil {
+		t.Error("odometer stop should have a 'label' flag")
+	}
+}
+
+func TestSessionsCmds(t *testing.T) {
+	for _, name := range []string{"start", "stop", "status"} {
+		if cmd, _, err := sessionsCmd.Find([]string{name}); err != nil || cmd == sessionsCmd {
+			t.Errorf("sessions should have a %q subcommand", name)
+		}
+	}
+	for _, flag := range []string{"tag", "since", "limit"} {
+		if sessionsCmd.Flags().Lookup(flag) == nil {
+			t.Errorf("sessions should have a %q flag", flag)
+		}
+	}
+	for _, flag := range []string{"words", "minutes"} {
+		if sessionsStartCmd.Flags().Lookup(flag) == nil {
+			t.Errorf("sessions start should have a %q flag", flag)
+		}
+	}
+}
+
+func TestGoalsCmds(t *testing.T) {
+	for _, name := range []string{"set", "clear"} {
+		if cmd, _, err := goalsCmd.Find([]string{name}); err != nil || cmd == goalsCmd {
+			t.Errorf("goals should have a %q subcommand", name)
+		}
+	}
+}
+
+func TestBreaksCmds(t *testing.T) {
+	for _, name := range []string{"on", "off", "set"} {
+		if cmd, _, err := breaksCmd.Find([]string{name}); err != nil || cmd == breaksCmd {
+			t.Errorf("breaks should have a %q subcommand", name)
+		}
+	}
+	if f := breaksCmd.Flags().Lookup("since"); f == nil || f.DefValue != "7d" {
+		t.Error("breaks --since should default to 7d")
+	}
+}
+
+func TestCompactDuration(t *testing.T) {
+	cases := map[time.Duration]string{
+		20 * time.Second:               "20s",
+		5 * time.Minute:                "5m",
+		time.Hour:                      "1h",
+		90 * time.Minute:               "1h30m",
+		5*time.Minute + 30*time.Second: "5m30s",
+	}
+	for d, want := range cases {
+		if got := compactDuration(d); got != want {
+			t.Errorf("compactDuration(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
+
+func TestAPICmds(t *testing.T) {
+	for _, name := range []string{"enable", "disable", "events", "metrics"} {
+		if cmd, _, err := apiCmd.Find([]string{name}); err != nil || cmd == apiCmd {
+			t.Errorf("api should have a %q subcommand", name)
+		}
+	}
+	if apiEnableCmd.Flags().Lookup("addr") == nil {
+		t.Error("api enable should have an 'addr' flag")
+	}
+}
+
+func TestPushBackfillCmd(t *testing.T) {
+	if cmd, _, err := pushCmd.Find([]string{"backfill"}); err != nil || cmd != pushBackfillCmd {
+		t.Error("push should have a 'backfill' subcommand")
+	}
+	if pushBackfillCmd.Flags().Lookup("since") == nil {
+		t.Error("push backfill should have a 'since' flag")
+	}
+	if pushEnableCmd.Flags().Lookup("retry-days") == nil {
+		t.Error("push enable should have a 'retry-days' flag")
+	}
+}
+
+func TestTestHistoryCmd(t *testing.T) {
+	if cmd, _, err := testCmd.Find([]string{"history"}); err != nil || cmd != testHistoryCmd {
+		t.Error("test should have a 'history' subcommand")
+	}
+	if cmd, _, err := testCmd.Find([]string{"replay"}); err != nil || cmd != testReplayCmd {
+		t.Error("test should have a 'replay' subcommand")
+	}
+	if cmd, _, err := testCmd.Find([]string{"weak"}); err != nil || cmd != testWeakCmd {
+		t.Error("test should have a 'weak' subcommand")
+	}
+	for _, flag := range []string{"limit", "min-attempts", "json"} {
+		if testWeakCmd.Flags().Lookup(flag) == nil {
+			t.Errorf("test weak should have a %q flag", flag)
+		}
+	}
+	for _, flag := range []string{"weak", "code", "type-indent"} {
+		if testCmd.Flags().Lookup(flag) == nil {
+			t.Errorf("test should have a %q flag", flag)
+		}
+	}
+	for _, flag := range []string{"since", "mode", "limit", "json"} {
+		if testHistoryCmd.Flags().Lookup(flag) == nil {
+			t.Errorf("test history should have a %q flag", flag)
+		}
+	}
+}
+
+func TestParseTestMode(t *testing.T) {
+	cases := map[string]string{
+		"":                 "",
+		"25":               "mode_25_no_punct",
+		"50p":              "mode_50_punct",
+		"mode_10_no_punct": "mode_10_no_punct",
+		"30s":              "mode_30s_no_punct",
+		"60sp":             "mode_60s_punct",
+		"code":             "mode_code",
+	}
+	for in, want := range cases {
+		if got, err := parseTestMode(in); err != nil || got != want {
+			t.Errorf("parseTestMode(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	for _, bad := range []string{"p", "abc", "0", "-5", "s", "0s"} {
+		if _, err := parseTestMode(bad); err == nil {
+			t.Errorf("parseTestMode(%q) should fail", bad)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aayushbajaj/typing-telemetry/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+// Flags for odometer.
+var (
+	odometerStopLabel    string
+	odometerHistoryN     int
+	odometerHistoryLabel string
+	odometerResetHistory bool
+)
+
+var odometerCmd = &cobra.Command{
+	Use:   "odometer",
+	Short: "Measure keystrokes, words and mouse use over a session",
+	Long: `The odometer measures what you type (and click) between a start and a
+stop — a task, a meeting, a writing sprint. Sessions can carry a label, and
+finished sessions are kept in a history that the charts page also shows.
+
+A capture daemon (typtel-tray or the macOS menu bar) must be running for the
+counts to move. These commands are easy to bind to window-manager keys:
+
+  typtel odometer start "code review"
+  typtel odometer                  # status of the running session
+  typtel odometer stop
+  typtel odometer history --label "code review"`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withStore(printOdometerStatus)
+	},
+}
+
+var odometerStartCmd = &cobra.Command{
+	Use:   "start [label]",
+	Short: "Start a session, optionally labelled",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		label := ""
+		if len(args) == 1 {
+			label = args[0]
+		}
+		return withStore(func(s *storage.Store) error {
+			session, err := s.GetOdometerSession()
+			if err != nil {
+				return err
+			}
+			if session.IsActive {
+				return fmt.Errorf("a session is already running (started %s); stop it first",
+					session.StartTime.Local().Format("Jan 2 15:04"))
+			}
+			if err := s.StartLabeledOdometer(label); err != nil {
+				return err
+			}
+			fmt.Printf("Odometer started%s.\n", labelSuffix(label))
+			return nil
+		})
+	},
+}
+
+var odometerStopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Stop the running session and add it to the history",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withStore(func(s *storage.Store) error {
+			if err := s.RefreshOdometer(); err != nil {
+				return err
+			}
+			if cmd.Flags().Changed("label") {
+				if err := s.SetOdometerLabel(odometerStopLabel); err != nil {
+					return err
+				}
+			}
+			session, err := s.GetOdometerSession()
+			if err != nil {
+				return err
+			}
+			if !session.IsActive {
+				return storage.ErrOdometerInactive
+			}
+			if err := s.StopOdometer(); err != nil {
+				return err
+			}
+			fmt.Printf("Odometer stopped%s after %s.\n", labelSuffix(session.Label),
+				time.Since(session.StartTime).Round(time.Second))
+			printOdometerCounts(session)
+			return nil
+		})
+	},
+}
+
+var odometerStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the running session",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withStore(printOdometerStatus)
+	},
+}
+
+var odometerHistoryCmd = &cobra.Command{
+	Use:   "history",
+	Short: "List finished sessions, most recent first",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withStore(printOdometerHistory)
+	},
+}
+
+var odometerResetCmd = &cobra.Command{
+	Use:   "reset",
+	Short: "Discard the running session without saving it",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withStore(func(s *storage.Store) error {
+			if err := s.ResetOdometer(); err != nil {
+				return err
+			}
+			fmt.Println("Odometer reset.")
+			if odometerResetHistory {
+				if err := s.ClearOdometerHistory(); err != nil {
+					return err
+				}
+				fmt.Println("Session history cleared.")
+			}
+			return nil
+		})
+	},
+}
+
+func init() {
+	odometerStopCmd.Flags().StringVar(&odometerStopLabel, "label", "", "Label the session (replaces the label given at start)")
+	odometerHistoryCmd.Flags().IntVarP(&odometerHistoryN, "limit", "n", 20, "Number of sessions to list (0 = all)")
+	odometerHistoryCmd.Flags().StringVar(&odometerHistoryLabel, "label", "", "Only list sessions with this label")
+	odometerResetCmd.Flags().BoolVar(&odometerResetHistory, "history", false, "Also delete every finished session")
+
+	odometerCmd.AddCommand(odometerStartCmd)
+	odometerCmd.AddCommand(odometerStopCmd)
+	odometerCmd.AddCommand(odometerStatusCmd)
+	odometerCmd.AddCommand(odometerHistoryCmd)
+	odometerCmd.AddCommand(odometerResetCmd)
+}
+
+func labelSuffix(label string) string {
+	if label == "" {
+		return ""
+	}
+	return fmt.Sprintf(" (%q)", label)
+}
+
+func printOdometerStatus(s *storage.Store) error {
+	if err := s.RefreshOdometer(); err != nil {
+		return err
+	}
+	session, err := s.GetOdometerSession()
+	if err != nil {
+		return err
+	}
+	if !session.IsActive {
+		fmt.Println("No odometer session is running. Start one with 'typtel odometer start [label]'.")
+		return nil
+	}
+	fmt.Printf("Running%s since %s (%s)\n", labelSuffix(session.Label),
+		session.StartTime.Local().Format("Jan 2 15:04"), time.Since(session.StartTime).Round(time.Second))
+	printOdometerCounts(session)
+	return nil
+}
+
+func printOdometerCounts(session *storage.OdometerSession) {
+	feet := (session.CurrentDistance - session.StartDistance) / pixelsPerInch / 12
+	fmt.Printf("  keystrokes  %d\n", session.CurrentKeystrokes-session.StartKeystrokes)
+	fmt.Printf("  words       %d\n", session.CurrentWords-session.StartWords)
+	fmt.Printf("  clicks      %d\n", session.CurrentClicks-session.StartClicks)
+	fmt.Printf("  distance    %.0f ft\n", feet)
+}
+
+func printOdometerHistory(s *storage.Store) error {
+	history, err := s.GetOdometerHistory()
+	if err != nil {
+		return err
+	}
+	var shown []storage.OdometerHistoryEntry
+	for _, h := range history {
+		if odometerHistoryLabel != "" && h.Label != odometerHistoryLabel {
+			continue
+		}
+		if odometerHistoryN > 0 && len(shown) >= odometerHistoryN {
+			break
+		}
+		shown = append(shown, h)
+	}
+	if len(shown) == 0 {
+		if odometerHistoryLabel != "" {
+			fmt.Printf("No finished sessions labelled %q.\n", odometerHistoryLabel)
+			return nil
+		}
+		fmt.Println("No finished sessions yet.")
+		return nil
+	}
+
+	fmt.Printf("%-20s %-16s %9s %10s %7s %7s %9s\n", "LABEL", "START", "DURATION", "KEYSTROKES", "WORDS", "CLICKS", "DISTANCE")
+	for _, h := range shown {
+		fmt.Printf("%-20s %-16s %9s %10s %7s %7s %6.0f ft\n",
+			truncate(dashIfEmpty(h.Label), 20),
+			h.StartTime.Local().Format("2006-01-02 15:04"),
+			h.EndTime.Sub(h.StartTime).Round(time.Second),
+			formatNum(h.Keystrokes), formatNum(h.Words), formatNum(h.Clicks),
+			h.Distance/pixelsPerInch/12)
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/aayushbajaj/typing-telemetry/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+// profileFlag (--profile, on every command) picks the profile for this
+// invocation only; "typtel profile switch" changes the default.
+var profileFlag string
+
+var profileCmd = &cobra.Command{
+	Use:   "profile",
+	Short: "List, create and switch between separate typtel histories",
+	Long: `Profiles keep separate histories — each has its own database — so work
+and personal typing can be tracked apart on one machine.
+
+"default" is the database typtel has always used. Run a single command
+against another profile with --profile, or make it the default for new
+commands and daemons with "typtel profile switch". A running tray daemon
+keeps writing to the profile it started with until it is restarted.
+
+The data directory is ~/.local/share/typtel, or $TYPTEL_DATA_DIR if set.
+
+  typtel profile list
+  typtel profile create work
+  typtel profile switch work
+  typtel --profile personal today`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runProfileList()
+	},
+}
+
+var profileListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List profiles and show which one the tray is writing to",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runProfileList()
+	},
+}
+
+var profileCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Create an empty profile",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := storage.CreateProfile(args[0]); err != nil {
+			return err
+		}
+		fmt.Printf("Created profile %q. Use it with --profile %s or 'typtel profile switch %s'.\n", args[0], args[0], args[0])
+		return nil
+	},
+}
+
+var profileSwitchCmd = &cobra.Command{
+	Use:   "switch <name>",
+	Short: "Make a profile the default for new commands and daemons",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runProfileSwitch(args[0])
+	},
+}
+
+func init() {
+	profileCmd.AddCommand(profileListCmd)
+	profileCmd.AddCommand(profileCreateCmd)
+	profileCmd.AddCommand(profileSwitchCmd)
+}
+
+// applyProfileFlag is the root command's PersistentPreRunE.
+func applyProfileFlag(cmd *cobra.Command, args []string) error {
+	if profileFlag == "" {
+		return nil
+	}
+	return storage.UseProfile(profileFlag)
+}
+
+func runProfileList() error {
+	profiles, err := storage.ListProfiles()
+	if err != nil {
+		return err
+	}
+	tray, err := storage.RunningTray()
+	if err != nil {
+		return err
+	}
+	active := storage.ActiveProfile()
+
+	fmt.Printf("  %-16s %10s  %s\n", "PROFILE", "SIZE", "DATABASE")
+	for _, p := range profiles {
+		mark := " "
+		if p.Name == active {
+			mark = "*"
+		}
+		size := "-"
+		if p.Size > 0 {
+			size = formatBytes(p.Size)
+		}
+		note := ""
+		if tray != nil && tray.Profile == p.Name {
+			note = "  ← tray"
+		}
+		fmt.Printf("%s %-16s %10s  %s%s\n", mark, p.Name, size, p.DBPath, note)
+	}
+
+	fmt.Println()
+	if tray != nil {
+		fmt.Printf("%s (pid %d) is writing to %q.\n", tray.Program, tray.PID, tray.Profile)
+	} else {
+		fmt.Println("No tray daemon (typtel-tray / typtel-menubar) is running.")
+	}
+	return nil
+}
+
+func runProfileSwitch(name string) error {
+	if err := storage.SwitchProfile(name); err != nil {
+		return err
+	}
+	fmt.Printf("Default profile is now %q.\n", name)
+
+	tray, err := storage.RunningTray()
+	if err == nil && tray != nil && tray.Profile != name {
+		fmt.Printf("%s (pid %d) is still writing to %q; restart it to pick up the switch.\n", tray.Program, tray.PID, tray.Profile)
+	}
+	return nil
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/aayushbajaj/typing-telemetry/internal/push"
 	"github.com/aayushbajaj/typing-telemetry/internal/storage"
@@ -15,6 +16,9 @@ var (
 	pushToken string
 	pushID    string
 	pushName  string
+
+	pushRetryDays int
+	pushSince     string
 )
 
 var pushCmd = &cobra.Command{
@@ -65,6 +69,25 @@ var pushNowCmd = &cobra.Command{
 	},
 }
 
+var pushBackfillCmd = &cobra.Command{
+	Use:   "backfill",
+	Short: "Re-send past days to the host (e.g. after it lost data or the device was offline)",
+	Long: `Backfill pushes every day with activity since --since, oldest first, whether
+or not the host already has it. Counts are absolute totals, so re-sending a
+day the host already holds is harmless.
+
+The daemon already re-sends unacknowledged days within the retry window
+(push_retry_days, default 7) on its own; backfill is for anything older.
+
+  typtel push backfill --since 2026-01-01
+  typtel push backfill --since 90d
+  typtel push backfill                       # all history`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runPushBackfill()
+	},
+}
+
 func init() {
 	for _, c := range []*cobra.Command{pushEnableCmd, pushNowCmd} {
 		c.Flags().StringVar(&pushURL, "url", "", "Host base URL, e.g. http://100.93.238.15:8889")
@@ -72,7 +95,10 @@ func init() {
 		c.Flags().StringVar(&pushID, "id", "", "This device's id (must match [a-z0-9-]{1,32})")
 		c.Flags().StringVar(&pushName, "name", "", "Friendly name shown on the host (optional)")
 	}
-	pushCmd.AddCommand(pushEnableCmd, pushDisableCmd, pushStatusCmd, pushNowCmd)
+	pushEnableCmd.Flags().IntVar(&pushRetryDays, "retry-days", 0,
+		fmt.Sprintf("Days back (today included) re-sent until the host acknowledges them (default %d)", storage.DefaultPushRetryDays))
+	pushBackfillCmd.Flags().StringVar(&pushSince, "since", "", "First day to send: YYYY-MM-DD, Nd, Nw or all (default all)")
+	pushCmd.AddCommand(pushEnableCmd, pushDisableCmd, pushStatusCmd, pushNowCmd, pushBackfillCmd)
 }
 
 // effectiveConfig merges stored push settings with any flags supplied this run
@@ -106,11 +132,17 @@ func runPushEnable() error {
 	if _, err := push.New(cfg); err != nil {
 		return err
 	}
+	if pushRetryDays < 0 {
+		return fmt.Errorf("--retry-days must be at least 1")
+	}
 
 	store.SetSetting(storage.SettingPushBaseURL, cfg.BaseURL)
 	store.SetSetting(storage.SettingPushToken, cfg.Token)
 	store.SetSetting(storage.SettingPushDeviceID, cfg.DeviceID)
 	store.SetSetting(storage.SettingPushDeviceName, cfg.Name)
+	if pushRetryDays > 0 {
+		store.SetPushRetryDays(pushRetryDays)
+	}
 	if err := store.SetSettingBool(storage.SettingPushEnabled, true); err != nil {
 		return err
 	}
@@ -161,6 +193,32 @@ func runPushStatus() error {
 	fmt.Printf("  id:    %s\n", orDash(cfg.DeviceID))
 	fmt.Printf("  name:  %s\n", orDash(cfg.Name))
 	fmt.Printf("  token: %s\n", maskToken(cfg.Token))
+
+	window := store.GetPushRetryDays()
+	fmt.Printf("  retry: last %d days\n", window)
+	client, err := push.New(cfg)
+	if err != nil {
+		return nil // half-configured: nothing can be queued yet
+	}
+	pending, err := client.Pending(store, time.Now(), window)
+	if err != nil {
+		return err
+	}
+	if len(pending) == 0 {
+		fmt.Println("  queue: all days acknowledged by the host")
+		return nil
+	}
+	fmt.Printf("  queue: %d day(s) not yet acknowledged\n", len(pending))
+	for _, d := range pending {
+		line := "    " + d.Date
+		if d.State.Attempts > 0 {
+			line += fmt.Sprintf("  %d failed attempt(s), last: %s", d.State.Attempts, d.State.LastError)
+			if !d.State.NextAttempt.IsZero() {
+				line += ", next " + d.State.NextAttempt.Local().Format("15:04")
+			}
+		}
+		fmt.Println(line)
+	}
 	return nil
 }
 
@@ -189,6 +247,34 @@ func runPushNow() error {
 	return nil
 }
 
+func runPushBackfill() error {
+	since, label, err := parseSince(pushSince, time.Now())
+	if err != nil {
+		return err
+	}
+	return withStore(func(store *storage.Store) error {
+		cfg := effectiveConfig(store)
+		client, err := push.New(cfg)
+		if err != nil {
+			return err
+		}
+		ctx := context.Background()
+		hctx, cancel := context.WithTimeout(ctx, push.DefaultTimeout)
+		defer cancel()
+		if err := client.Health(hctx); err != nil {
+			return fmt.Errorf("host not reachable: %w", err)
+		}
+		n, err := client.Backfill(ctx, store, since, func(date string) {
+			fmt.Printf("  %s\n", date)
+		})
+		if err != nil {
+			return fmt.Errorf("backfill stopped after %d day(s): %w", n, err)
+		}
+		fmt.Printf("Pushed %d day(s) (%s) to %s.\n", n, label, cfg.BaseURL)
+		return nil
+	})
+}
+
 // maskToken shows only the last 4 characters of a token.
 func maskToken(t string) string {
 	if t == "" {
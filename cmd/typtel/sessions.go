@@ -0,0 +1,209 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aayushbajaj/typing-telemetry/internal/storage"
+	"github.com/aayushbajaj/typing-telemetry/pkg/stats"
+	"github.com/spf13/cobra"
+)
+
+// Flags for sessions.
+var (
+	sessionsTag     string
+	sessionsSince   string
+	sessionsLimit   int
+	sessionsWords   int64
+	sessionsMinutes int64
+)
+
+var sessionsCmd = &cobra.Command{
+	Use:   "sessions",
+	Short: "Tagged focus sessions with optional word or time goals",
+	Long: `A focus session is a named stretch of work — "writing RFC", "code review" —
+with an optional goal: a number of words, or minutes of active typing. The
+capture daemon credits what you type to the running session, sends a desktop
+notification when the goal is reached, and another with a summary (words,
+average WPM, peak burst) when the session is stopped.
+
+  typtel sessions start "writing RFC" --words 800
+  typtel sessions status
+  typtel sessions stop
+  typtel sessions                    # recent sessions
+  typtel sessions --tag "writing RFC" --since 30d   # totals for one tag
+
+--since takes a number of days or weeks (7d, 2w), a date (2025-06-01), or
+"all".`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withStore(printSessions)
+	},
+}
+
+var sessionsStartCmd = &cobra.Command{
+	Use:   "start <tag>",
+	Short: "Start a focus session, optionally with a goal",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		kind, target := storage.FocusTargetNone, int64(0)
+		switch {
+		case sessionsWords > 0 && sessionsMinutes > 0:
+			return errors.New("give either --words or --minutes, not both")
+		case sessionsWords > 0:
+			kind, target = storage.FocusTargetWords, sessionsWords
+		case sessionsMinutes > 0:
+			kind, target = storage.FocusTargetMinutes, sessionsMinutes
+		}
+		return withStore(func(s *storage.Store) error {
+			f, err := s.StartFocusSession(args[0], kind, target)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Started %q%s.\n", f.Tag, goalSuffix(f))
+			return nil
+		})
+	},
+}
+
+var sessionsStopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Stop the running session and print its summary",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withStore(func(s *storage.Store) error {
+			f, err := s.StopFocusSession()
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Stopped %q after %s.\n", f.Tag, f.EndTime.Sub(f.StartTime).Round(time.Second))
+			printFocusSummary(f)
+			return nil
+		})
+	},
+}
+
+var sessionsStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the running session",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withStore(func(s *storage.Store) error {
+			f, err := s.ActiveFocusSession()
+			if err != nil {
+				return err
+			}
+			if f == nil {
+				fmt.Println("No focus session is running. Start one with 'typtel sessions start <tag>'.")
+				return nil
+			}
+			fmt.Printf("Running %q since %s (%s)\n", f.Tag,
+				f.StartTime.Local().Format("Jan 2 15:04"), time.Since(f.StartTime).Round(time.Second))
+			printFocusSummary(f)
+			return nil
+		})
+	},
+}
+
+func init() {
+	sessionsCmd.Flags().StringVar(&sessionsTag, "tag", "", "Aggregate the sessions with this tag")
+	sessionsCmd.Flags().StringVar(&sessionsSince, "since", "all", "Period to report: Nd, Nw, a YYYY-MM-DD date, or all")
+	sessionsCmd.Flags().IntVarP(&sessionsLimit, "limit", "n", 20, "Number of sessions to list (0 = all)")
+	sessionsStartCmd.Flags().Int64Var(&sessionsWords, "words", 0, "Goal: words to type")
+	sessionsStartCmd.Flags().Int64Var(&sessionsMinutes, "minutes", 0, "Goal: minutes of active typing")
+
+	sessionsCmd.AddCommand(sessionsStartCmd)
+	sessionsCmd.AddCommand(sessionsStopCmd)
+	sessionsCmd.AddCommand(sessionsStatusCmd)
+}
+
+func goalSuffix(f *storage.FocusSession) string {
+	if f.TargetKind == storage.FocusTargetNone {
+		return ""
+	}
+	return fmt.Sprintf(", goal %d %s", f.Target, f.TargetKind)
+}
+
+// Counts reach the store on the daemon's flush tick, so a summary printed
+// right after typing can trail the notification by a couple of seconds.
+func printFocusSummary(f *storage.FocusSession) {
+	fmt.Printf("  words       %d\n", f.Words)
+	fmt.Printf("  keystrokes  %d\n", f.Keystrokes)
+	fmt.Printf("  active      %s\n", (time.Duration(f.ActiveMs) * time.Millisecond).Round(time.Second))
+	fmt.Printf("  avg WPM     %.0f\n", stats.AverageWPM(f.Words, f.ActiveMs))
+	fmt.Printf("  peak burst  %.0f WPM\n", f.PeakBurstWPM)
+	if f.TargetKind != storage.FocusTargetNone {
+		state := "not reached"
+		if !f.GoalReachedAt.IsZero() {
+			state = "reached " + f.GoalReachedAt.Local().Format("15:04")
+		}
+		fmt.Printf("  goal        %d/%d %s (%s)\n", f.Progress(), f.Target, f.TargetKind, state)
+	}
+}
+
+func printSessions(s *storage.Store) error {
+	since, label, err := parseSince(sessionsSince, time.Now())
+	if err != nil {
+		return err
+	}
+	if sessionsTag != "" {
+		return printSessionTag(s, since, label)
+	}
+
+	sessions, err := s.GetFocusSessions("", since, sessionsLimit)
+	if err != nil {
+		return err
+	}
+	if len(sessions) == 0 {
+		fmt.Printf("No focus sessions (%s).\n", label)
+		return nil
+	}
+	fmt.Printf("%-20s %-16s %9s %7s %5s %6s %-14s\n", "TAG", "START", "DURATION", "WORDS", "WPM", "BURST", "GOAL")
+	for _, f := range sessions {
+		end := f.EndTime
+		if f.Running() {
+			end = time.Now()
+		}
+		goal := "-"
+		if f.TargetKind != storage.FocusTargetNone {
+			goal = fmt.Sprintf("%d/%d %s", f.Progress(), f.Target, f.TargetKind)
+			if !f.GoalReachedAt.IsZero() {
+				goal += " met"
+			}
+		}
+		if f.Running() {
+			goal += " (running)"
+		}
+		fmt.Printf("%-20s %-16s %9s %7s %5.0f %6.0f %-14s\n",
+			truncate(f.Tag, 20),
+			f.StartTime.Local().Format("2006-01-02 15:04"),
+			end.Sub(f.StartTime).Round(time.Second),
+			formatNum(f.Words), stats.AverageWPM(f.Words, f.ActiveMs), f.PeakBurstWPM, goal)
+	}
+	return nil
+}
+
+func printSessionTag(s *storage.Store, since, label string) error {
+	all, err := s.GetFocusTagStats(sessionsTag, since)
+	if err != nil {
+		return err
+	}
+	if len(all) == 0 {
+		fmt.Printf("No focus sessions tagged %q (%s).\n", sessionsTag, label)
+		return nil
+	}
+	st := all[0]
+	fmt.Printf("%q, %s\n\n", st.Tag, label)
+	fmt.Printf("  sessions    %d\n", st.Sessions)
+	fmt.Printf("  time        %s\n", st.Elapsed.Round(time.Minute))
+	fmt.Printf("  active      %s\n", (time.Duration(st.ActiveMs) * time.Millisecond).Round(time.Second))
+	fmt.Printf("  words       %s\n", formatNum(st.Words))
+	fmt.Printf("  keystrokes  %s\n", formatNum(st.Keystrokes))
+	fmt.Printf("  avg WPM     %.0f\n", stats.AverageWPM(st.Words, st.ActiveMs))
+	fmt.Printf("  peak burst  %.0f WPM\n", st.PeakBurstWPM)
+	if st.WithTarget > 0 {
+		fmt.Printf("  goals met   %d of %d\n", st.GoalsMet, st.WithTarget)
+	}
+	return nil
+}
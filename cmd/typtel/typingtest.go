@@ -0,0 +1,267 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aayushbajaj/typing-telemetry/internal/storage"
+	"github.com/aayushbajaj/typing-telemetry/internal/tui"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/cobra"
+)
+
+// Flags for test history.
+var (
+	testHistorySince string
+	testHistoryMode  string
+	testHistoryLimit int
+	testHistoryJSON  bool
+
+	testWeakLimit       int
+	testWeakMinAttempts int64
+	testWeakJSON        bool
+)
+
+var testHistoryCmd = &cobra.Command{
+	Use:   "history",
+	Short: "List completed typing tests",
+	Long: `List completed typing tests, most recent first, with net and raw WPM,
+accuracy, CPM and uncorrected errors.
+
+  typtel test history
+  typtel test history --mode 50p --since 30d
+  typtel test history --json
+
+--mode takes a word count or, for time tests, seconds with an "s", each with
+a trailing "p" for punctuation (25, 50p, 30s, 60sp), or a full mode key
+(mode_25_no_punct). --since takes a number of days or weeks
+(7d, 2w), a date (2025-06-01), or "all". The charts page ('typtel v') plots
+the same history.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withStore(printTestHistory)
+	},
+}
+
+var testReplayCmd = &cobra.Command{
+	Use:   "replay <id>",
+	Short: "Replay a past typing test keystroke by keystroke",
+	Long: `Animate a completed typing test at the speed it was typed, mistakes and
+corrections included, ending on its results. Test ids are listed by
+'typtel test history'. Tests taken before replays were recorded can't be
+replayed.
+
+In the replay: space pauses, r restarts, q quits.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil || id <= 0 {
+			return fmt.Errorf("invalid test id %q", args[0])
+		}
+		return withStore(func(s *storage.Store) error {
+			r, err := s.GetTypingTest(id)
+			if err != nil {
+				return err
+			}
+			if r == nil {
+				return fmt.Errorf("no typing test %d (see 'typtel test history')", id)
+			}
+			if len(r.Keystrokes) == 0 {
+				return fmt.Errorf("typing test %d has no recorded keystrokes to replay", id)
+			}
+			_, err = tea.NewProgram(tui.NewReplay(r), tea.WithAltScreen()).Run()
+			return err
+		})
+	},
+}
+
+var testWeakCmd = &cobra.Command{
+	Use:   "weak",
+	Short: "List the characters you miss most in typing tests",
+	Long: `Every character typed in a typing test is counted against the character
+the test expected, across all tests. This lists the characters with the
+highest miss rate, and what you most often type instead.
+
+Characters typed fewer than --min-attempts times are left out as noise.
+Drill the weakest letters with "typtel test --weak".
+
+  typtel test weak
+  typtel test weak -n 20 --min-attempts 50`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withStore(printWeakChars)
+	},
+}
+
+func init() {
+	testWeakCmd.Flags().IntVarP(&testWeakLimit, "limit", "n", 10, "Number of characters to list (0 = all)")
+	testWeakCmd.Flags().Int64Var(&testWeakMinAttempts, "min-attempts", 20, "Ignore characters typed fewer times than this")
+	testWeakCmd.Flags().BoolVar(&testWeakJSON, "json", false, "Emit machine-readable JSON instead of text")
+
+	testHistoryCmd.Flags().StringVar(&testHistorySince, "since", "all", "Period to list: Nd, Nw, a YYYY-MM-DD date, or all")
+	testHistoryCmd.Flags().StringVar(&testHistoryMode, "mode", "", "Only tests of this mode, e.g. 25, 50p, 30s or code")
+	testHistoryCmd.Flags().IntVarP(&testHistoryLimit, "limit", "n", 20, "Number of tests to list (0 = all)")
+	testHistoryCmd.Flags().BoolVar(&testHistoryJSON, "json", false, "Emit machine-readable JSON instead of text")
+
+	testCmd.AddCommand(testHistoryCmd)
+	testCmd.AddCommand(testReplayCmd)
+	testCmd.AddCommand(testWeakCmd)
+}
+
+// parseTestMode turns a --mode value into a mode key: "25" and "50p" are
+// shorthand for 25 words without and 50 words with punctuation, "30s" and
+// "60sp" the same for time tests; anything starting with "mode_" is taken as
+// a key already.
+func parseTestMode(v string) (string, error) {
+	if v == "" || strings.HasPrefix(v, "mode_") {
+		return v, nil
+	}
+	if v == "code" {
+		return storage.TypingTestMode{Code: true}.ModeKey(), nil
+	}
+	mode := storage.TypingTestMode{}
+	n, punct := strings.CutSuffix(v, "p")
+	mode.Punctuation = punct
+	n, timed := strings.CutSuffix(n, "s")
+	count, err := strconv.Atoi(n)
+	if err != nil || count <= 0 {
+		return "", fmt.Errorf("invalid --mode %q (want e.g. 25, 50p, 30s, code or mode_25_no_punct)", v)
+	}
+	if timed {
+		mode.Seconds = count
+	} else {
+		mode.WordCount = count
+	}
+	return mode.ModeKey(), nil
+}
+
+// testHistoryEntry is one test in `typtel test history --json`.
+type testHistoryEntry struct {
+	ID                int64     `json:"id"`
+	CompletedAt       string    `json:"completed_at"`
+	Mode              string    `json:"mode"`
+	TestType          string    `json:"test_type"`
+	Layout            string    `json:"layout"`
+	Language          string    `json:"language"`
+	DurationSeconds   float64   `json:"duration_seconds"`
+	RawWPM            float64   `json:"raw_wpm"`
+	NetWPM            float64   `json:"net_wpm"`
+	Accuracy          float64   `json:"accuracy"`
+	CPM               int       `json:"cpm"`
+	Errors            int       `json:"errors"`
+	UncorrectedErrors int       `json:"uncorrected_errors"`
+	WPMSeries         []float64 `json:"wpm_series"`
+}
+
+func printTestHistory(s *storage.Store) error {
+	since, label, err := parseSince(testHistorySince, time.Now())
+	if err != nil {
+		return err
+	}
+	mode, err := parseTestMode(testHistoryMode)
+	if err != nil {
+		return err
+	}
+	tests, err := s.GetTypingTests(mode, since, testHistoryLimit)
+	if err != nil {
+		return err
+	}
+
+	if testHistoryJSON {
+		out := make([]testHistoryEntry, 0, len(tests))
+		for _, r := range tests {
+			series := r.WPMSeries
+			if series == nil {
+				series = []float64{}
+			}
+			out = append(out, testHistoryEntry{
+				ID:                r.ID,
+				CompletedAt:       r.CompletedAt.Format(time.RFC3339),
+				Mode:              r.Mode,
+				TestType:          r.TestType,
+				Layout:            r.Layout,
+				Language:          r.Language,
+				DurationSeconds:   r.Duration.Seconds(),
+				RawWPM:            r.RawWPM,
+				NetWPM:            r.NetWPM,
+				Accuracy:          r.Accuracy,
+				CPM:               r.CPM,
+				Errors:            r.Errors,
+				UncorrectedErrors: r.UncorrectedErrors,
+				WPMSeries:         series,
+			})
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
+	}
+
+	if len(tests) == 0 {
+		fmt.Printf("No typing tests (%s). Take one with 'typtel test'.\n", label)
+		return nil
+	}
+	fmt.Printf("%5s  %-16s %-18s %-8s %6s %6s %6s %5s %5s\n",
+		"ID", "COMPLETED", "MODE", "LAYOUT", "WPM", "RAW", "ACC", "CPM", "UNCOR")
+	for _, r := range tests {
+		fmt.Printf("%5d  %-16s %-18s %-8s %6.1f %6.1f %5.1f%% %5d %5d\n",
+			r.ID,
+			r.CompletedAt.Local().Format("2006-01-02 15:04"),
+			truncate(r.Mode, 18),
+			orDash(r.Layout),
+			r.NetWPM, r.RawWPM, r.Accuracy, r.CPM, r.UncorrectedErrors)
+	}
+	return nil
+}
+
+// weakCharEntry is one character in `typtel test weak --json`.
+type weakCharEntry struct {
+	Char     string           `json:"char"`
+	Attempts int64            `json:"attempts"`
+	Misses   int64            `json:"misses"`
+	MissRate float64          `json:"miss_rate"`
+	TypedAs  map[string]int64 `json:"typed_as"`
+}
+
+func printWeakChars(s *storage.Store) error {
+	stats, err := s.GetTypingCharStats()
+	if err != nil {
+		return err
+	}
+	weak := storage.WeakestChars(stats, testWeakMinAttempts, testWeakLimit)
+
+	if testWeakJSON {
+		out := make([]weakCharEntry, 0, len(weak))
+		for _, c := range weak {
+			typedAs := make(map[string]int64, len(c.Subs))
+			for _, sub := range c.Subs {
+				typedAs[sub.Typed] = sub.Count
+			}
+			out = append(out, weakCharEntry{
+				Char: c.Char, Attempts: c.Attempts, Misses: c.Misses,
+				MissRate: c.MissRate(), TypedAs: typedAs,
+			})
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
+	}
+
+	if len(weak) == 0 {
+		fmt.Printf("No missed characters typed at least %d times yet — take a few tests with 'typtel test'.\n", testWeakMinAttempts)
+		return nil
+	}
+	fmt.Printf("%4s  %-6s %9s %7s %6s  %s\n", "#", "KEY", "ATTEMPTS", "MISSES", "MISS", "TYPED INSTEAD")
+	for i, c := range weak {
+		var subs []string
+		for _, sub := range c.Subs[:min(len(c.Subs), 3)] {
+			subs = append(subs, fmt.Sprintf("%s ×%d", tui.CharLabel(sub.Typed), sub.Count))
+		}
+		fmt.Printf("%4d  %-6s %9s %7s %5.1f%%  %s\n", i+1, tui.CharLabel(c.Char),
+			formatNum(c.Attempts), formatNum(c.Misses), c.MissRate()*100, strings.Join(subs, ", "))
+	}
+	return nil
+}
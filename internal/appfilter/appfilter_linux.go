@@ -0,0 +1,128 @@
+//go:build linux
+
+package appfilter
+
+// On Linux the frontmost application is the window the window manager names
+// in the root window's _NET_ACTIVE_WINDOW property (EWMH), identified by the
+// class half of its WM_CLASS property — the same name desktop files use in
+// StartupWMClass ("firefox", "kitty", "Code"). Wayland compositors don't
+// expose the focused window to clients; there Frontmost returns "" unless the
+// focused window is an XWayland one.
+
+import (
+	"bytes"
+	"sync"
+	"time"
+
+	"github.com/jezek/xgb"
+	"github.com/jezek/xgb/xproto"
+)
+
+// reconnectDelay spaces out connection attempts when no X server is
+// reachable, since Frontmost is called once per keystroke.
+const reconnectDelay = 5 * time.Second
+
+// frontmostTTL is how long Frontmost reuses its last answer. Each lookup is
+// two X round trips on the capture path; a focus change still shows up
+// within a fraction of a second.
+const frontmostTTL = 250 * time.Millisecond
+
+var (
+	connMu       sync.Mutex
+	conn         *xgb.Conn
+	root         xproto.Window
+	activeAtom   xproto.Atom
+	lastConnFail time.Time
+
+	lastClass string    // Frontmost's last answer
+	lastAt    time.Time // when it was looked up; zero if none is cached
+)
+
+// connectLocked opens the shared X connection if it isn't open. Caller holds
+// connMu.
+func connectLocked() bool {
+	if conn != nil {
+		return true
+	}
+	if !lastConnFail.IsZero() && time.Since(lastConnFail) < reconnectDelay {
+		return false
+	}
+	c, err := xgb.NewConn()
+	if err != nil {
+		lastConnFail = time.Now()
+		return false
+	}
+	name := "_NET_ACTIVE_WINDOW"
+	reply, err := xproto.InternAtom(c, true, uint16(len(name)), name).Reply()
+	if err != nil || reply.Atom == xproto.AtomNone {
+		// No EWMH window manager: nothing says which window is active.
+		c.Close()
+		lastConnFail = time.Now()
+		return false
+	}
+	conn, root, activeAtom = c, xproto.Setup(c).DefaultScreen(c).Root, reply.Atom
+	return true
+}
+
+// Frontmost returns the WM_CLASS class of the active X11 window (e.g.
+// "firefox"), or "" if it cannot be determined. Answers are cached for
+// frontmostTTL. Safe to call from any goroutine.
+func Frontmost() string {
+	connMu.Lock()
+	defer connMu.Unlock()
+	now := time.Now()
+	if !lastAt.IsZero() && now.Sub(lastAt) < frontmostTTL {
+		return lastClass
+	}
+	if !connectLocked() {
+		return ""
+	}
+	class, err := activeClass()
+	if err != nil {
+		// The server went away; reconnect on a later call.
+		conn.Close()
+		conn = nil
+		lastConnFail = now
+		lastAt = time.Time{}
+		return ""
+	}
+	lastClass, lastAt = class, now
+	return class
+}
+
+func activeClass() (string, error) {
+	active, err := xproto.GetProperty(conn, false, root, activeAtom, xproto.AtomWindow, 0, 1).Reply()
+	if err != nil {
+		return "", err
+	}
+	if active.Format != 32 || len(active.Value) < 4 {
+		return "", nil
+	}
+	win := xproto.Window(xgb.Get32(active.Value))
+	if win == 0 {
+		return "", nil // the desktop, or nothing focused
+	}
+	// The window can close between the two requests; treat a failed lookup as
+	// unknown rather than as a dead connection.
+	cls, err := xproto.GetProperty(conn, false, win, xproto.AtomWmClass, xproto.AtomString, 0, 256).Reply()
+	if err != nil {
+		if _, ok := err.(xproto.WindowError); ok {
+			return "", nil
+		}
+		return "", err
+	}
+	_, class := parseWMClass(cls.Value)
+	return class, nil
+}
+
+// parseWMClass splits a WM_CLASS value — two NUL-terminated strings, instance
+// then class — into its parts. A value with only one string uses it for both.
+func parseWMClass(b []byte) (instance, class string) {
+	parts := bytes.Split(bytes.TrimRight(b, "\x00"), []byte{0})
+	instance = string(parts[0])
+	class = instance
+	if len(parts) > 1 {
+		class = string(parts[1])
+	}
+	return instance, class
+}
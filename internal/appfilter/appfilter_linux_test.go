@@ -0,0 +1,24 @@
+//go:build linux
+
+package appfilter
+
+import "testing"
+
+func TestParseWMClass(t *testing.T) {
+	tests := []struct {
+		in              string
+		instance, class string
+	}{
+		{"Navigator\x00firefox\x00", "Navigator", "firefox"},
+		{"code\x00Code\x00", "code", "Code"},
+		{"xterm\x00", "xterm", "xterm"},
+		{"kitty\x00kitty", "kitty", "kitty"},
+		{"", "", ""},
+	}
+	for _, tt := range tests {
+		instance, class := parseWMClass([]byte(tt.in))
+		if instance != tt.instance || class != tt.class {
+			t.Errorf("parseWMClass(%q) = %q, %q; want %q, %q", tt.in, instance, class, tt.instance, tt.class)
+		}
+	}
+}
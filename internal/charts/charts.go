@@ -12,13 +12,17 @@
 package charts
 
 import (
+	"encoding/json"
 	"fmt"
+	"html"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
 	"time"
 
+	"github.com/aayushbajaj/typing-telemetry/internal/goals"
+	"github.com/aayushbajaj/typing-telemetry/internal/keyboard"
 	"github.com/aayushbajaj/typing-telemetry/internal/storage"
 	"github.com/aayushbajaj/typing-telemetry/pkg/stats"
 )
@@ -59,6 +63,7 @@ func Generate(store *storage.Store, opts Options) (string, error) {
 		totalModifiers  int64
 		totalSpecial    int64
 		heatmapHTML     string
+		keyboardHTML    string
 		// Derived stats
 		avgKeystrokes  float64
 		avgWordsActive float64
@@ -110,7 +115,7 @@ func Generate(store *storage.Store, opts Options) (string, error) {
 			data.totalModifiers += stat.Modifiers
 			data.totalSpecial += stat.Special
 
-			dd := stats.DayData{Date: t, Keystrokes: stat.Keystrokes, Words: stat.Words}
+			dd := stats.DayData{Date: t, Keystrokes: stat.Keystrokes, Words: stat.Words, ActiveMs: stat.ActiveMs}
 			dayData = append(dayData, dd)
 			if dd.Words > peakWords.Words {
 				peakWords = dd
@@ -165,6 +170,17 @@ func Generate(store *storage.Store, opts Options) (string, error) {
 		}
 
 		data.heatmapHTML = generateHeatmapHTML(hourlyData, days)
+
+		since := time.Now().AddDate(0, 0, -(days - 1)).Format("2006-01-02")
+		freqs, err := store.GetKeycodeFrequencies(since)
+		if err != nil {
+			return nil, err
+		}
+		counts := make(map[int]int64, len(freqs))
+		for _, f := range freqs {
+			counts[f.Keycode] = f.Count
+		}
+		data.keyboardHTML = generateKeyboardHTML(counts)
 		return data, nil
 	}
 
@@ -230,8 +246,12 @@ func Generate(store *storage.Store, opts Options) (string, error) {
 			historyJSON.WriteString(",")
 		}
 		duration := entry.EndTime.Sub(entry.StartTime)
-		historyJSON.WriteString(fmt.Sprintf(`{"id":%d,"startTime":"%s","endTime":"%s","keystrokes":%d,"words":%d,"clicks":%d,"distanceFeet":%.2f,"durationSecs":%d}`,
+		// The label is user text and lands in innerHTML: escape it here,
+		// then encode it as JSON like any other string in the page.
+		label, _ := json.Marshal(html.EscapeString(entry.Label))
+		historyJSON.WriteString(fmt.Sprintf(`{"id":%d,"label":%s,"startTime":"%s","endTime":"%s","keystrokes":%d,"words":%d,"clicks":%d,"distanceFeet":%.2f,"durationSecs":%d}`,
 			entry.ID,
+			label,
 			entry.StartTime.Format("Jan 2, 2006 3:04 PM"),
 			entry.EndTime.Format("Jan 2, 2006 3:04 PM"),
 			entry.Keystrokes,
@@ -243,6 +263,20 @@ func Generate(store *storage.Store, opts Options) (string, error) {
 	}
 	historyJSON.WriteString("]")
 
+	goalsJSON := "null"
+	if report, err := goals.Build(store, time.Now()); err == nil && !report.IsZero() {
+		if b, err := json.Marshal(report); err == nil {
+			goalsJSON = string(b)
+		}
+	}
+
+	typingTestsJSON := "[]"
+	if tests, err := store.GetTypingTests("", "", maxChartedTests); err == nil && len(tests) > 0 {
+		if b, err := json.Marshal(typingTestPoints(tests)); err == nil {
+			typingTestsJSON = string(b)
+		}
+	}
+
 	// Determine if key types section should be visible
 	keyTypesDisplay := "none"
 	if showKeyTypes {
@@ -385,6 +419,51 @@ func Generate(store *storage.Store, opts Options) (string, error) {
             height: 15px;
             border-radius: 2px;
         }
+        .keyboard-svg {
+            display: block;
+            width: 100%%;
+            max-width: 900px;
+            margin: 0 auto;
+        }
+        .keyboard-svg rect { stroke: rgba(255,255,255,0.12); }
+        .keyboard-svg text {
+            fill: #ddd;
+            font-size: 12px;
+            text-anchor: middle;
+            dominant-baseline: central;
+            pointer-events: none;
+        }
+        .hand-split {
+            text-align: center;
+            color: #aaa;
+            font-size: 0.95em;
+            margin: 20px 0 10px;
+        }
+        .finger-split {
+            max-width: 600px;
+            margin: 0 auto;
+        }
+        .finger-row {
+            display: flex;
+            align-items: center;
+            gap: 10px;
+            margin: 4px 0;
+            font-size: 12px;
+            color: #888;
+        }
+        .finger-label { width: 100px; text-align: right; }
+        .finger-bar-track {
+            flex: 1;
+            height: 12px;
+            background: rgba(255,255,255,0.05);
+            border-radius: 3px;
+        }
+        .finger-bar {
+            height: 100%%;
+            border-radius: 3px;
+            background: linear-gradient(90deg, #3d6b4f, #7bc96f);
+        }
+        .finger-share { width: 50px; }
         .stats-summary {
             display: flex;
             justify-content: center;
@@ -476,6 +555,28 @@ func Generate(store *storage.Store, opts Options) (string, error) {
         .tooltip-content strong {
             color: #fff;
         }
+        .goal-row {
+            display: grid;
+            grid-template-columns: 160px 1fr 150px;
+            align-items: center;
+            gap: 15px;
+            margin: 10px 0;
+            color: #aaa;
+        }
+        .goal-bar {
+            height: 12px;
+            background: #1a1a2e;
+            border-radius: 6px;
+            overflow: hidden;
+        }
+        .goal-fill {
+            height: 100%%;
+            background: linear-gradient(90deg, #3d6b4f, #7bc96f);
+        }
+        .goal-note {
+            color: #888;
+            font-size: 0.9em;
+        }
         .odometer-display {
             display: none;
             max-width: 800px;
@@ -612,6 +713,20 @@ func Generate(store *storage.Store, opts Options) (string, error) {
         </div>
     </div>
 
+    <div class="heatmap-container" id="goalsSection" style="display: none;">
+        <div class="heatmap-box">
+            <h2>🎯 Goals</h2>
+            <div id="goalsContainer"></div>
+        </div>
+    </div>
+
+    <div class="charts-container" id="typingTestsSection" style="display: none; grid-template-columns: 1fr;">
+        <div class="chart-box">
+            <h2>⌨️ Typing Test Progression</h2>
+            <canvas id="typingTestsChart"></canvas>
+        </div>
+    </div>
+
     <div class="stats-summary" id="keyTypesStats" style="display: %[1]s;">
         <div class="stat-item">
             <div class="stat-value" id="totalLetters" style="background: linear-gradient(90deg, #7bc96f, #4caf50); -webkit-background-clip: text; -webkit-text-fill-color: transparent;">-</div>
@@ -672,6 +787,14 @@ func Generate(store *storage.Store, opts Options) (string, error) {
         </div>
     </div>
 
+    <div class="heatmap-container" id="keyboardSection" style="margin-top: 30px;">
+        <div class="heatmap-box">
+            <h2>Keyboard Heatmap</h2>
+            <div id="keyboardContainer">
+            </div>
+        </div>
+    </div>
+
     <div class="odometer-display" id="odometerDisplay">
         <div class="odometer-box">
             <h2>⏱️ Current Session</h2>
@@ -717,6 +840,7 @@ func Generate(store *storage.Store, opts Options) (string, error) {
                 <table class="odometer-table" id="historyTable">
                     <thead>
                         <tr>
+                            <th>Label</th>
                             <th>Start</th>
                             <th>End</th>
                             <th>Duration</th>
@@ -763,7 +887,8 @@ func Generate(store *storage.Store, opts Options) (string, error) {
                 longestStreak: %d,
                 activeDays: %d,
                 days: 7,
-                heatmap: `+"`%s`"+`
+                heatmap: `+"`%s`"+`,
+                keyboard: `+"`%s`"+`
             },
             monthly: {
                 labels: [%s],
@@ -792,7 +917,8 @@ func Generate(store *storage.Store, opts Options) (string, error) {
                 longestStreak: %d,
                 activeDays: %d,
                 days: 30,
-                heatmap: `+"`%s`"+`
+                heatmap: `+"`%s`"+`,
+                keyboard: `+"`%s`"+`
             },
             yearly: {
                 labels: [%s],
@@ -821,7 +947,8 @@ func Generate(store *storage.Store, opts Options) (string, error) {
                 longestStreak: %d,
                 activeDays: %d,
                 days: 365,
-                heatmap: `+"`%s`"+`
+                heatmap: `+"`%s`"+`,
+                keyboard: `+"`%s`"+`
             },
             odometer: {
                 isActive: %t,
@@ -831,7 +958,9 @@ func Generate(store *storage.Store, opts Options) (string, error) {
                 clicks: %d,
                 distanceFeet: %.2f,
                 history: %s
-            }
+            },
+            goals: %s,
+            typingTests: %s
         };
 
         const unitLabels = { feet: 'feet', cars: 'car lengths', fields: 'frisbee fields' };
@@ -892,6 +1021,7 @@ func Generate(store *storage.Store, opts Options) (string, error) {
                 document.getElementById('keyTypesStats').style.display = 'none';
                 document.querySelectorAll('.charts-container').forEach(el => el.style.display = 'none');
                 document.getElementById('heatmapSection').style.display = 'none';
+                document.getElementById('keyboardSection').style.display = 'none';
                 document.getElementById('odometerDisplay').style.display = 'block';
                 updateOdometerDisplay();
                 return;
@@ -903,6 +1033,7 @@ func Generate(store *storage.Store, opts Options) (string, error) {
             if (keyTypesStats) keyTypesStats.style.display = keyTypesStats.getAttribute('data-visible') === 'true' ? 'flex' : 'none';
             document.querySelectorAll('.charts-container').forEach(el => el.style.display = 'grid');
             document.getElementById('heatmapSection').style.display = 'block';
+            document.getElementById('keyboardSection').style.display = 'block';
             document.getElementById('odometerDisplay').style.display = 'none';
 
             const d = data[period];
@@ -1009,6 +1140,7 @@ func Generate(store *storage.Store, opts Options) (string, error) {
             });
 
             document.getElementById('heatmapContainer').innerHTML = d.heatmap;
+            document.getElementById('keyboardContainer').innerHTML = d.keyboard;
         }
 
         function formatDuration(totalSeconds) {
@@ -1022,6 +1154,77 @@ func Generate(store *storage.Store, opts Options) (string, error) {
             return durationStr || '0s';
         }
 
+        // Goals are today's and this week's progress, independent of the
+        // period selector.
+        function renderGoals() {
+            const g = data.goals;
+            if (!g || (!g.daily && !g.weekly)) return;
+            const labels = { words: 'words', keystrokes: 'keystrokes', active_minutes: 'active min' };
+            let rows = '';
+            [['Today', g.daily], ['This week', g.weekly]].forEach(function(p) {
+                if (!p[1]) return;
+                p[1].goals.forEach(function(m) {
+                    let note = m.met ? 'done' : 'not started';
+                    if (!m.met && m.projected_finish) {
+                        const t = new Date(m.projected_finish);
+                        const opts = p[1] === g.weekly
+                            ? { weekday: 'short', hour: '2-digit', minute: '2-digit' }
+                            : { hour: '2-digit', minute: '2-digit' };
+                        note = m.on_track ? 'on pace for ' + t.toLocaleString([], opts) : 'behind pace';
+                    }
+                    rows += '<div class="goal-row"><div>' + p[0] + ' · ' + labels[m.metric] + '</div>' +
+                        '<div class="goal-bar"><div class="goal-fill" style="width: ' + Math.min(100, m.percent).toFixed(0) + '%%"></div></div>' +
+                        '<div>' + formatNumber(m.current) + ' / ' + formatNumber(m.target) +
+                        ' <span class="goal-note">' + note + '</span></div></div>';
+                });
+            });
+            if (g.daily) {
+                rows += '<div class="goal-note">Goal streak: ' + g.streak.current + (g.streak.current === 1 ? ' day' : ' days') +
+                    ' (longest ' + g.streak.longest + ')</div>';
+            }
+            document.getElementById('goalsContainer').innerHTML = rows;
+            document.getElementById('goalsSection').style.display = 'block';
+        }
+
+        // Typing tests are plotted oldest first, one point per test, with a
+        // rolling average over the last 10; like goals they ignore the period
+        // selector.
+        function renderTypingTests() {
+            const tests = data.typingTests;
+            if (!tests || tests.length === 0) return;
+            const avg = tests.map(function(_, i) {
+                const window = tests.slice(Math.max(0, i - 9), i + 1);
+                return window.reduce(function(sum, t) { return sum + t.wpm; }, 0) / window.length;
+            });
+            new Chart(document.getElementById('typingTestsChart'), {
+                type: 'line',
+                data: {
+                    labels: tests.map(function(t) { return new Date(t.at).toLocaleDateString([], { month: 'short', day: 'numeric' }); }),
+                    datasets: [
+                        { label: 'Net WPM', data: tests.map(function(t) { return t.wpm; }), borderColor: 'rgba(0, 210, 255, 1)', backgroundColor: 'rgba(0, 210, 255, 0.2)', showLine: false, pointRadius: 3 },
+                        { label: 'Average of last 10', data: avg, borderColor: 'rgba(122, 201, 111, 1)', pointRadius: 0, tension: 0.3 }
+                    ]
+                },
+                options: {
+                    responsive: true,
+                    plugins: {
+                        legend: { display: true, labels: { color: '#888' } },
+                        tooltip: {
+                            callbacks: {
+                                afterLabel: function(ctx) {
+                                    if (ctx.datasetIndex !== 0) return '';
+                                    const t = tests[ctx.dataIndex];
+                                    return t.mode + ' · ' + t.accuracy.toFixed(1) + '%% acc · raw ' + t.raw.toFixed(1);
+                                }
+                            }
+                        }
+                    },
+                    scales: chartConfig.scales
+                }
+            });
+            document.getElementById('typingTestsSection').style.display = 'grid';
+        }
+
         function updateOdometerDisplay() {
             const od = data.odometer;
             const unit = document.getElementById('unitSelect').value;
@@ -1062,7 +1265,8 @@ func Generate(store *storage.Store, opts Options) (string, error) {
                 noHistoryMsg.style.display = 'none';
                 od.history.forEach(function(entry) {
                     var row = document.createElement('tr');
-                    row.innerHTML = '<td>' + entry.startTime + '</td>' +
+                    row.innerHTML = '<td>' + (entry.label || '-') + '</td>' +
+                        '<td>' + entry.startTime + '</td>' +
                         '<td>' + entry.endTime + '</td>' +
                         '<td class="odometer-value">' + formatDuration(entry.durationSecs) + '</td>' +
                         '<td class="odometer-value">' + formatNumber(entry.keystrokes) + '</td>' +
@@ -1085,6 +1289,8 @@ func Generate(store *storage.Store, opts Options) (string, error) {
             }
         })();
 
+        renderGoals();
+        renderTypingTests();
         updateCharts();
     </script>
 </body>
@@ -1119,6 +1325,7 @@ func Generate(store *storage.Store, opts Options) (string, error) {
 		weeklyData.longestStreak,
 		weeklyData.activeDays,
 		weeklyData.heatmapHTML,
+		weeklyData.keyboardHTML,
 		strings.Join(monthlyData.labels, ","),
 		strings.Join(monthlyData.keystrokeData, ","),
 		strings.Join(monthlyData.wordData, ","),
@@ -1147,6 +1354,7 @@ func Generate(store *storage.Store, opts Options) (string, error) {
 		monthlyData.longestStreak,
 		monthlyData.activeDays,
 		monthlyData.heatmapHTML,
+		monthlyData.keyboardHTML,
 		strings.Join(yearlyData.labels, ","),
 		strings.Join(yearlyData.keystrokeData, ","),
 		strings.Join(yearlyData.wordData, ","),
@@ -1175,6 +1383,7 @@ func Generate(store *storage.Store, opts Options) (string, error) {
 		yearlyData.longestStreak,
 		yearlyData.activeDays,
 		yearlyData.heatmapHTML,
+		yearlyData.keyboardHTML,
 		odometerIsActive,
 		odometerStartTime,
 		odometerKeystrokes,
@@ -1182,6 +1391,8 @@ func Generate(store *storage.Store, opts Options) (string, error) {
 		odometerClicks,
 		odometerDistanceFeet,
 		historyJSON.String(),
+		goalsJSON,
+		typingTestsJSON,
 	)
 
 	dataDir, err := storage.LogDir()
@@ -1196,6 +1407,35 @@ func Generate(store *storage.Store, opts Options) (string, error) {
 	return htmlPath, nil
 }
 
+// maxChartedTests caps how many of the most recent typing tests the
+// progression plot shows.
+const maxChartedTests = 500
+
+// typingTestPoint is one typing test as the progression plot reads it.
+type typingTestPoint struct {
+	At       string  `json:"at"`
+	Mode     string  `json:"mode"`
+	WPM      float64 `json:"wpm"`
+	Raw      float64 `json:"raw"`
+	Accuracy float64 `json:"accuracy"`
+}
+
+// typingTestPoints turns tests, most recent first as the store returns them,
+// into plot points oldest first.
+func typingTestPoints(tests []storage.TypingTestResult) []typingTestPoint {
+	points := make([]typingTestPoint, len(tests))
+	for i, r := range tests {
+		points[len(tests)-1-i] = typingTestPoint{
+			At:       r.CompletedAt.Format(time.RFC3339),
+			Mode:     r.Mode,
+			WPM:      r.NetWPM,
+			Raw:      r.RawWPM,
+			Accuracy: r.Accuracy,
+		}
+	}
+	return points
+}
+
 func generateHourLabels() string {
 	var labels []string
 	for h := 0; h < 24; h++ {
@@ -1264,3 +1504,76 @@ func getHeatmapColor(value, max int64) string {
 	}
 	return "#7bc96f"
 }
+
+// keyboardUnit is the size of one key unit in the keyboard SVG, in pixels.
+const keyboardUnit = 48
+
+// generateKeyboardHTML draws the ANSI board as an SVG with each key coloured
+// by its press count, followed by the per-hand and per-finger split. The
+// result is embedded in a JS template literal, so labels are entity-escaped
+// rather than written raw (` and \ would otherwise break the literal).
+func generateKeyboardHTML(counts map[int]int64) string {
+	var maxVal int64 = 1
+	for _, k := range keyboard.ANSI {
+		if counts[k.Code] > maxVal {
+			maxVal = counts[k.Code]
+		}
+	}
+	split := keyboard.SplitCounts(counts)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg class="keyboard-svg" viewBox="0 0 %d %d" xmlns="http://www.w3.org/2000/svg">`,
+		int(keyboard.Width*keyboardUnit), int(keyboard.Height*keyboardUnit))
+	for _, k := range keyboard.ANSI {
+		n := counts[k.Code]
+		x := k.X*keyboardUnit + 2
+		y := k.Y*keyboardUnit + 2
+		w := k.W*keyboardUnit - 4
+		h := float64(keyboardUnit - 4)
+		fmt.Fprintf(&b, `<g><title>%s: %d presses (%s)</title>`,
+			svgText(keyboard.Name(k.Code)), n, sharePercent(n, split.Total))
+		fmt.Fprintf(&b, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" rx="6" fill="%s"/>`,
+			x, y, w, h, getHeatmapColor(n, maxVal))
+		fmt.Fprintf(&b, `<text x="%.1f" y="%.1f">%s</text></g>`, x+w/2, y+h/2, svgText(k.Label))
+	}
+	b.WriteString(`</svg>`)
+
+	fmt.Fprintf(&b, `<div class="hand-split">Left hand %s · Right hand %s · Thumbs (space) %s · Other %s</div>`,
+		sharePercent(split.Hands[keyboard.HandLeft], split.Total),
+		sharePercent(split.Hands[keyboard.HandRight], split.Total),
+		sharePercent(split.Hands[keyboard.HandEither], split.Total),
+		sharePercent(split.Hands[keyboard.HandNone], split.Total))
+
+	var maxFinger int64 = 1
+	for _, n := range split.Fingers {
+		if n > maxFinger {
+			maxFinger = n
+		}
+	}
+	b.WriteString(`<div class="finger-split">`)
+	for _, fk := range keyboard.FingerOrder {
+		n := split.Fingers[fk]
+		fmt.Fprintf(&b,
+			`<div class="finger-row" title="%d presses"><div class="finger-label">%s</div><div class="finger-bar-track"><div class="finger-bar" style="width: %.1f%%;"></div></div><div class="finger-share">%s</div></div>`,
+			n, fk, float64(n)/float64(maxFinger)*100, sharePercent(n, split.Total))
+	}
+	b.WriteString(`</div>`)
+	return b.String()
+}
+
+// sharePercent formats n as a percentage of total.
+func sharePercent(n, total int64) string {
+	if total == 0 {
+		return "0%"
+	}
+	return fmt.Sprintf("%.1f%%", float64(n)/float64(total)*100)
+}
+
+// svgText escapes s for SVG text inside a JS template literal.
+func svgText(s string) string {
+	s = html.EscapeString(s)
+	s = strings.ReplaceAll(s, "`", "&#96;")
+	s = strings.ReplaceAll(s, `\`, "&#92;")
+	s = strings.ReplaceAll(s, "$", "&#36;")
+	return s
+}
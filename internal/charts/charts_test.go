@@ -121,3 +121,40 @@ func TestGenerateHeatmapHTMLDatesSorted(t *testing.T) {
 		t.Error("Expected dates to be in sorted order")
 	}
 }
+
+func TestGenerateKeyboardHTML(t *testing.T) {
+	result := generateKeyboardHTML(map[int]int64{0: 90, 49: 10})
+	if !strings.HasPrefix(result, `<svg class="keyboard-svg"`) {
+		t.Error("Expected the keyboard SVG first")
+	}
+	if !strings.Contains(result, "A: 90 presses (90.0%)") {
+		t.Error("Expected per-key press count and share in the key title")
+	}
+	if !strings.Contains(result, "left pinky") || !strings.Contains(result, "Left hand 90.0%") {
+		t.Error("Expected per-hand and per-finger split")
+	}
+	// The HTML lands inside a JS template literal.
+	if strings.ContainsAny(result, "`\\") || strings.Contains(result, "${") {
+		t.Error("Keyboard HTML must not contain raw backticks, backslashes or ${")
+	}
+}
+
+func TestSharePercent(t *testing.T) {
+	if got := sharePercent(1, 0); got != "0%" {
+		t.Errorf("sharePercent(1, 0) = %q", got)
+	}
+	if got := sharePercent(1, 4); got != "25.0%" {
+		t.Errorf("sharePercent(1, 4) = %q", got)
+	}
+}
+
+func TestTypingTestPoints(t *testing.T) {
+	tests := []storage.TypingTestResult{
+		{NetWPM: 90, Mode: "mode_25_no_punct"},
+		{NetWPM: 80, Mode: "mode_50_punct"},
+	}
+	points := typingTestPoints(tests)
+	if len(points) != 2 || points[0].WPM != 80 || points[1].WPM != 90 || points[0].Mode != "mode_50_punct" {
+		t.Errorf("typingTestPoints = %+v, want oldest first", points)
+	}
+}
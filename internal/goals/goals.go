@@ -0,0 +1,170 @@
+// Package goals reports progress toward the daily and weekly goals configured
+// with `typtel goals set`: percent complete, a projected finish time at the
+// current rate, and streaks of days on which every daily goal was met. It is
+// the single source for `typtel today`, its JSON output, the TUI dashboard
+// and the charts page.
+package goals
+
+import (
+	"time"
+
+	"github.com/aayushbajaj/typing-telemetry/internal/storage"
+	"github.com/aayushbajaj/typing-telemetry/pkg/stats"
+)
+
+// streakDays is how far back goal streaks are computed.
+const streakDays = 365
+
+// Metric is the progress of one goal.
+type Metric struct {
+	Metric  string  `json:"metric"` // storage.GoalMetric*
+	Target  int64   `json:"target"`
+	Current int64   `json:"current"`
+	Percent float64 `json:"percent"`
+	Met     bool    `json:"met"`
+	// ProjectedFinish is when the goal will be met at the period's rate so
+	// far (RFC 3339); empty when met or nothing has been typed yet.
+	ProjectedFinish string `json:"projected_finish,omitempty"`
+	// OnTrack is true when the goal is met or projected to be met before the
+	// period ends.
+	OnTrack bool `json:"on_track"`
+}
+
+// Period is the progress of one period's goals.
+type Period struct {
+	Start string   `json:"start"` // first date, YYYY-MM-DD
+	End   string   `json:"end"`   // last date, YYYY-MM-DD
+	Goals []Metric `json:"goals"`
+}
+
+// Streak counts consecutive days on which every daily goal was met. Today
+// only extends the current streak once its goals are met; until then the run
+// ending yesterday still counts.
+type Streak struct {
+	Current int `json:"current"`
+	Longest int `json:"longest"` // over the past year
+}
+
+// Report is the progress toward every configured goal. Daily and Weekly are
+// nil when the period has no goals.
+type Report struct {
+	Daily  *Period `json:"daily,omitempty"`
+	Weekly *Period `json:"weekly,omitempty"`
+	Streak Streak  `json:"streak"`
+}
+
+// IsZero reports whether no goals are configured.
+func (r *Report) IsZero() bool { return r.Daily == nil && r.Weekly == nil }
+
+// Build measures progress at now against the goals stored in store.
+func Build(store *storage.Store, now time.Time) (*Report, error) {
+	daily := store.GetGoals(storage.GoalPeriodDaily)
+	weekly := store.GetGoals(storage.GoalPeriodWeekly)
+	r := &Report{}
+	if daily.IsZero() && weekly.IsZero() {
+		return r, nil
+	}
+
+	// One query over the streak window; days with no row count as zero.
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	first := midnight.AddDate(0, 0, -(streakDays - 1))
+	hist, err := store.GetDayStatsRange(first.Format("2006-01-02"), midnight.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	byDate := make(map[string]storage.DailyStats, len(hist))
+	for _, d := range hist {
+		byDate[d.Date] = d
+	}
+	days := make([]stats.DayData, streakDays)
+	for i := range days {
+		date := first.AddDate(0, 0, i)
+		d := byDate[date.Format("2006-01-02")]
+		days[i] = stats.DayData{Date: date, Keystrokes: d.Keystrokes, Words: d.Words, ActiveMs: d.ActiveMs}
+	}
+	today := days[streakDays-1]
+
+	if !daily.IsZero() {
+		// Project from the first hour with activity, so a late start isn't
+		// averaged over the hours before it.
+		start := midnight
+		hourly, err := store.GetHourlyStats(now.Format("2006-01-02"))
+		if err != nil {
+			return nil, err
+		}
+		for _, h := range hourly {
+			if h.Keystrokes > 0 {
+				start = midnight.Add(time.Duration(h.Hour) * time.Hour)
+				break
+			}
+		}
+		r.Daily = &Period{
+			Start: midnight.Format("2006-01-02"),
+			End:   midnight.Format("2006-01-02"),
+			Goals: measure(daily, today, start, now, midnight.AddDate(0, 0, 1)),
+		}
+		r.Streak.Current, r.Streak.Longest = stats.GoalStreaks(days, daily)
+	}
+
+	if !weekly.IsZero() {
+		sinceMonday := (int(now.Weekday()) + 6) % 7
+		monday := midnight.AddDate(0, 0, -sinceMonday)
+		var week stats.DayData
+		for _, d := range days[streakDays-1-sinceMonday:] {
+			week.Keystrokes += d.Keystrokes
+			week.Words += d.Words
+			week.ActiveMs += d.ActiveMs
+		}
+		r.Weekly = &Period{
+			Start: monday.Format("2006-01-02"),
+			End:   monday.AddDate(0, 0, 6).Format("2006-01-02"),
+			Goals: measure(weekly, week, monday, now, monday.AddDate(0, 0, 7)),
+		}
+	}
+	return r, nil
+}
+
+// measure reports each set goal in targets against the totals in d. The rate
+// for projections is measured from start to now; end is when the period
+// closes.
+func measure(targets stats.Goals, d stats.DayData, start, now, end time.Time) []Metric {
+	var out []Metric
+	for _, metric := range storage.GoalMetrics {
+		target := storage.GoalTarget(targets, metric)
+		if target <= 0 {
+			continue
+		}
+		var current int64
+		switch metric {
+		case storage.GoalMetricKeystrokes:
+			current = d.Keystrokes
+		case storage.GoalMetricWords:
+			current = d.Words
+		case storage.GoalMetricActiveMinutes:
+			current = d.ActiveMs / 60000
+		}
+		p := stats.Progress(current, target, start, now)
+		m := Metric{
+			Metric:  metric,
+			Target:  target,
+			Current: current,
+			Percent: p.Percent,
+			Met:     p.Met,
+			OnTrack: p.Met || (!p.Projected.IsZero() && p.Projected.Before(end)),
+		}
+		if !p.Projected.IsZero() {
+			m.ProjectedFinish = p.Projected.Format(time.RFC3339)
+		}
+		out = append(out, m)
+	}
+	return out
+}
+
+// Label returns a short human name for a metric: "words", "keystrokes",
+// "active min".
+func Label(metric string) string {
+	if metric == storage.GoalMetricActiveMinutes {
+		return "active min"
+	}
+	return metric
+}
@@ -0,0 +1,107 @@
+package goals
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aayushbajaj/typing-telemetry/internal/storage"
+)
+
+func TestBuild(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	store, err := storage.New()
+	if err != nil {
+		t.Fatalf("store: %v", err)
+	}
+	defer store.Close()
+
+	now := time.Now()
+	r, err := Build(store, now)
+	if err != nil || !r.IsZero() {
+		t.Fatalf("no goals: %+v, %v", r, err)
+	}
+
+	if err := store.SetGoal(storage.GoalPeriodDaily, storage.GoalMetricWords, 3); err != nil {
+		t.Fatalf("SetGoal: %v", err)
+	}
+	if err := store.SetGoal(storage.GoalPeriodWeekly, storage.GoalMetricKeystrokes, 1000); err != nil {
+		t.Fatalf("SetGoal: %v", err)
+	}
+	if err := store.SetGoal("monthly", storage.GoalMetricWords, 3); err == nil {
+		t.Error("unknown period should be rejected")
+	}
+	for _, date := range []string{
+		now.AddDate(0, 0, -2).Format("2006-01-02"),
+		now.AddDate(0, 0, -1).Format("2006-01-02"),
+	} {
+		for i := 0; i < 3; i++ {
+			if err := store.IncrementWordCount(date); err != nil {
+				t.Fatalf("IncrementWordCount: %v", err)
+			}
+		}
+	}
+	if err := store.IncrementWordCount(now.Format("2006-01-02")); err != nil {
+		t.Fatalf("IncrementWordCount: %v", err)
+	}
+
+	r, err = Build(store, now)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if r.Daily == nil || len(r.Daily.Goals) != 1 {
+		t.Fatalf("daily: %+v", r.Daily)
+	}
+	if g := r.Daily.Goals[0]; g.Metric != storage.GoalMetricWords || g.Current != 1 || g.Met {
+		t.Errorf("daily words: %+v", g)
+	}
+	// Today isn't met yet, so the streak is the two days before it.
+	if r.Streak.Current != 2 || r.Streak.Longest != 2 {
+		t.Errorf("streak: %+v", r.Streak)
+	}
+	if r.Weekly == nil || len(r.Weekly.Goals) != 1 || r.Weekly.Goals[0].Target != 1000 {
+		t.Fatalf("weekly: %+v", r.Weekly)
+	}
+	if start, _ := time.Parse("2006-01-02", r.Weekly.Start); start.Weekday() != time.Monday {
+		t.Errorf("week starts %s, want a Monday", r.Weekly.Start)
+	}
+}
+
+// Build measures from the now it is given, and fills days with no data.
+func TestBuildAtPastDateWithGaps(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	store, err := storage.New()
+	if err != nil {
+		t.Fatalf("store: %v", err)
+	}
+	defer store.Close()
+
+	if err := store.SetGoal(storage.GoalPeriodDaily, storage.GoalMetricWords, 3); err != nil {
+		t.Fatalf("SetGoal: %v", err)
+	}
+	if err := store.SetGoal(storage.GoalPeriodWeekly, storage.GoalMetricWords, 100); err != nil {
+		t.Fatalf("SetGoal: %v", err)
+	}
+	// Sunday, then nothing on Monday, then Tuesday and Wednesday.
+	for _, date := range []string{"2025-03-09", "2025-03-11", "2025-03-12"} {
+		for i := 0; i < 3; i++ {
+			if err := store.IncrementWordCount(date); err != nil {
+				t.Fatalf("IncrementWordCount: %v", err)
+			}
+		}
+	}
+
+	now := time.Date(2025, 3, 12, 15, 0, 0, 0, time.Local)
+	r, err := Build(store, now)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if r.Daily == nil || r.Daily.Start != "2025-03-12" || !r.Daily.Goals[0].Met {
+		t.Fatalf("daily: %+v", r.Daily)
+	}
+	if r.Streak.Current != 2 || r.Streak.Longest != 2 {
+		t.Errorf("streak: %+v, want 2 and 2 (Monday breaks it)", r.Streak)
+	}
+	if r.Weekly == nil || r.Weekly.Start != "2025-03-10" || r.Weekly.Goals[0].Current != 6 {
+		t.Errorf("weekly: %+v, want Monday 2025-03-10 with 6 words", r.Weekly)
+	}
+}
@@ -14,6 +14,7 @@ import (
 	"crypto/subtle"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net"
 	"net/http"
 	"regexp"
@@ -26,6 +27,18 @@ import (
 // so 8 KiB is generous slack for whitespace/formatting.
 const maxBodyBytes = 8 << 10
 
+// MaxBatchDays caps the days in one batch PUT; a client with more sends them
+// in several. maxBatchBodyBytes allows for that many at the per-day slack.
+const (
+	MaxBatchDays      = 500
+	maxBatchBodyBytes = 512 << 10
+)
+
+// APIVersion is reported by /v1/health so clients can tell which routes a
+// host has. 2 added the batch PUT /v1/devices/{id}/days; hosts before it
+// report no api field.
+const APIVersion = 2
+
 var (
 	deviceIDRe = regexp.MustCompile(`^[a-z0-9-]{1,32}$`)
 	dateRe     = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
@@ -63,6 +76,7 @@ func (s *Server) Handler() http.Handler {
 	mux.HandleFunc("GET /v1/health", s.handleHealth)
 
 	mux.HandleFunc("PUT /v1/devices/{id}/days/{date}", s.guard(s.handlePutDay))
+	mux.HandleFunc("PUT /v1/devices/{id}/days", s.guard(s.handlePutDays))
 	mux.HandleFunc("GET /v1/devices/{id}/days/{date}", s.guard(s.handleGetDay))
 	mux.HandleFunc("GET /v1/devices/{id}/days", s.guard(s.handleGetDays))
 	mux.HandleFunc("DELETE /v1/devices/{id}/days/{date}", s.guard(s.handleDeleteDay))
@@ -144,7 +158,7 @@ func (s *Server) guard(next http.HandlerFunc) http.HandlerFunc {
 }
 
 func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
-	writeJSON(w, http.StatusOK, map[string]any{"ok": true, "version": s.ver})
+	writeJSON(w, http.StatusOK, map[string]any{"ok": true, "version": s.ver, "api": APIVersion})
 }
 
 func (s *Server) handlePutDay(w http.ResponseWriter, r *http.Request) {
@@ -157,25 +171,100 @@ func (s *Server) handlePutDay(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	// 4. Absolute counts can never be negative.
-	if c.Keystrokes < 0 || c.Letters < 0 || c.Modifiers < 0 || c.Special < 0 ||
-		c.Words < 0 || c.ActiveMs < 0 {
+	if negativeCounts(c) {
 		http.Error(w, "negative counts", http.StatusBadRequest)
 		return
 	}
+	name, ok := putName(w, r)
+	if !ok {
+		return
+	}
 	id := r.PathValue("id")
 	if err := s.store.UpsertDeviceDay(id, r.PathValue("date"), c); err != nil {
 		http.Error(w, "storage error", http.StatusInternalServerError)
 		return
 	}
-	// Optional friendly name (v1.5.0): a pushing device may include ?name= so it
-	// shows by name instead of bare id. Backward-compatible — devices that send
-	// no name (e.g. the reMarkable client) are unaffected. UpsertDevice only
-	// overwrites the name when non-empty.
-	if name := r.URL.Query().Get("name"); name != "" {
-		if len(name) > 64 || hasControlChars(name) {
-			http.Error(w, "bad name", http.StatusBadRequest)
+	s.finishPut(w, id, name)
+}
+
+// BatchDay is one entry of a batch PUT body.
+type BatchDay struct {
+	Date   string                  `json:"date"`
+	Counts storage.DeviceDayCounts `json:"counts"`
+}
+
+// handlePutDays takes a JSON array of {date, counts} and replaces every day in
+// one transaction. Each entry is checked like handlePutDay's path and body; a
+// single bad entry (or a date given twice) rejects the whole batch.
+func (s *Server) handlePutDays(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxBatchBodyBytes)
+	var batch []BatchDay
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&batch); err != nil {
+		http.Error(w, "bad body", http.StatusBadRequest)
+		return
+	}
+	if len(batch) > MaxBatchDays {
+		http.Error(w, fmt.Sprintf("too many days (max %d)", MaxBatchDays), http.StatusRequestEntityTooLarge)
+		return
+	}
+	days := make([]storage.DeviceDay, 0, len(batch))
+	seen := make(map[string]bool, len(batch))
+	for i, d := range batch {
+		if !dateRe.MatchString(d.Date) {
+			http.Error(w, fmt.Sprintf("bad date in day %d", i), http.StatusBadRequest)
+			return
+		}
+		if seen[d.Date] {
+			http.Error(w, fmt.Sprintf("duplicate date in day %d", i), http.StatusBadRequest)
+			return
+		}
+		seen[d.Date] = true
+		if negativeCounts(d.Counts) {
+			http.Error(w, fmt.Sprintf("negative counts in day %d", i), http.StatusBadRequest)
+			return
+		}
+		days = append(days, storage.DeviceDay{Date: d.Date, DeviceDayCounts: d.Counts})
+	}
+	name, ok := putName(w, r)
+	if !ok {
+		return
+	}
+	id := r.PathValue("id")
+	if len(days) > 0 {
+		if err := s.store.UpsertDeviceDays(id, days); err != nil {
+			http.Error(w, "storage error", http.StatusInternalServerError)
 			return
 		}
+	}
+	s.finishPut(w, id, name)
+}
+
+func negativeCounts(c storage.DeviceDayCounts) bool {
+	return c.Keystrokes < 0 || c.Letters < 0 || c.Modifiers < 0 || c.Special < 0 ||
+		c.Words < 0 || c.ActiveMs < 0
+}
+
+// putName returns a PUT's optional ?name=, answering 400 if it is unusable.
+// It is checked before anything is written.
+//
+// Optional friendly name (v1.5.0): a pushing device may include ?name= so it
+// shows by name instead of bare id. Backward-compatible — devices that send no
+// name (e.g. the reMarkable client) are unaffected.
+func putName(w http.ResponseWriter, r *http.Request) (string, bool) {
+	name := r.URL.Query().Get("name")
+	if len(name) > 64 || hasControlChars(name) {
+		http.Error(w, "bad name", http.StatusBadRequest)
+		return "", false
+	}
+	return name, true
+}
+
+// finishPut stores a PUT's name, if any, and answers 204. UpsertDevice only
+// overwrites the name when non-empty.
+func (s *Server) finishPut(w http.ResponseWriter, id, name string) {
+	if name != "" {
 		if err := s.store.UpsertDevice(id, name); err != nil {
 			http.Error(w, "storage error", http.StatusInternalServerError)
 			return
@@ -8,6 +8,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/aayushbajaj/typing-telemetry/internal/storage"
 )
@@ -59,7 +60,7 @@ func TestHealthNoAuth(t *testing.T) {
 	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
 		t.Fatalf("decode: %v", err)
 	}
-	if got["ok"] != true || got["version"] != "1.4142" {
+	if got["ok"] != true || got["version"] != "1.4142" || got["api"] != float64(APIVersion) {
 		t.Fatalf("unexpected health body: %+v", got)
 	}
 }
@@ -142,6 +143,59 @@ func TestOversizedBodyRejected(t *testing.T) {
 	}
 }
 
+func TestPutDaysBatch(t *testing.T) {
+	srv, store := newTestServer(t, nil)
+	url := srv.URL + "/v1/devices/ferrari/days"
+
+	batch := []BatchDay{
+		{Date: "2026-06-12", Counts: storage.DeviceDayCounts{Keystrokes: 100, Words: 20}},
+		{Date: "2026-06-13", Counts: storage.DeviceDayCounts{Keystrokes: 200, Words: 40}},
+	}
+	buf, _ := json.Marshal(batch)
+	resp := do(t, http.MethodPut, url+"?name=Ferrari", testToken, bytes.NewReader(buf))
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("batch PUT status = %d, want 204", resp.StatusCode)
+	}
+	days, err := store.GetDeviceDays("ferrari", "")
+	if err != nil || len(days) != 2 || days[0].Keystrokes != 200 || days[1].Words != 20 {
+		t.Fatalf("stored days = %+v, %v", days, err)
+	}
+	if devices, _ := store.ListDevices(); len(devices) != 1 || devices[0].Name != "Ferrari" {
+		t.Fatalf("devices = %+v", devices)
+	}
+
+	// One bad entry rejects the batch; nothing is written.
+	for name, body := range map[string]string{
+		"bad date":    `[{"date":"2026-06-14","counts":{"keystrokes":1}},{"date":"2026-6-15","counts":{}}]`,
+		"negative":    `[{"date":"2026-06-14","counts":{"keystrokes":1}},{"date":"2026-06-15","counts":{"words":-1}}]`,
+		"duplicate":   `[{"date":"2026-06-14","counts":{"keystrokes":1}},{"date":"2026-06-14","counts":{}}]`,
+		"unknown key": `[{"date":"2026-06-14","counts":{"keystrokes":1},"extra":1}]`,
+		"not a list":  `{"date":"2026-06-14","counts":{"keystrokes":1}}`,
+	} {
+		resp := do(t, http.MethodPut, url, testToken, strings.NewReader(body))
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", name, resp.StatusCode)
+		}
+	}
+	if got, _ := store.GetDeviceDay("ferrari", "2026-06-14"); got != nil {
+		t.Fatalf("rejected batch wrote 2026-06-14: %+v", got)
+	}
+
+	// Over the day cap.
+	big := make([]BatchDay, MaxBatchDays+1)
+	for i := range big {
+		big[i].Date = time.Date(2020, 1, 1+i, 0, 0, 0, 0, time.UTC).Format("2006-01-02")
+	}
+	buf, _ = json.Marshal(big)
+	resp = do(t, http.MethodPut, url, testToken, bytes.NewReader(buf))
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Fatalf("oversized batch status = %d, want 413", resp.StatusCode)
+	}
+}
+
 func TestCRUDHappyPath(t *testing.T) {
 	srv, _ := newTestServer(t, nil)
 	base := srv.URL + "/v1/devices/ferrari/days/2026-06-13"
@@ -0,0 +1,231 @@
+// Package keyboard describes the physical keyboard typtel reasons about: where
+// each key sits on an ANSI board, what it is called, and which hand and finger
+// conventionally strike it in touch typing.
+//
+// Keys are identified by macOS virtual keycodes — the keycode space every
+// capture backend translates into before storage (see internal/keylogger).
+// The package is pure data plus a few lookups; the charts page draws its
+// keyboard heatmap from ANSI, and `typtel keys` names keys with Name.
+package keyboard
+
+import "fmt"
+
+// Hand is the hand that strikes a key. Either is for the space bar, which
+// both thumbs share.
+type Hand int
+
+const (
+	HandNone Hand = iota // arrows, fn: off the touch-typing block
+	HandLeft
+	HandRight
+	HandEither
+)
+
+func (h Hand) String() string {
+	switch h {
+	case HandLeft:
+		return "left"
+	case HandRight:
+		return "right"
+	case HandEither:
+		return "thumbs"
+	default:
+		return "other"
+	}
+}
+
+// Finger is the finger that strikes a key.
+type Finger int
+
+const (
+	FingerNone Finger = iota
+	Pinky
+	Ring
+	Middle
+	Index
+	Thumb
+)
+
+func (f Finger) String() string {
+	switch f {
+	case Pinky:
+		return "pinky"
+	case Ring:
+		return "ring"
+	case Middle:
+		return "middle"
+	case Index:
+		return "index"
+	case Thumb:
+		return "thumb"
+	default:
+		return "other"
+	}
+}
+
+// Key is one physical key. X, Y and W are in key units (1 = a letter key),
+// measured from the top-left of the board.
+type Key struct {
+	Code   int
+	Label  string
+	X, Y   float64
+	W      float64
+	Hand   Hand
+	Finger Finger
+}
+
+// Width and Height are the size of the ANSI board in key units.
+const (
+	Width  = 15.0
+	Height = 5.0
+)
+
+type keySpec struct {
+	code   int
+	label  string
+	w      float64
+	hand   Hand
+	finger Finger
+}
+
+const (
+	lh = HandLeft
+	rh = HandRight
+)
+
+// ansiRows is the Mac ANSI layout, row by row. Widths add up to Width.
+var ansiRows = [][]keySpec{
+	{
+		{50, "`", 1, lh, Pinky}, {18, "1", 1, lh, Pinky}, {19, "2", 1, lh, Ring}, {20, "3", 1, lh, Middle},
+		{21, "4", 1, lh, Index}, {23, "5", 1, lh, Index}, {22, "6", 1, rh, Index}, {26, "7", 1, rh, Index},
+		{28, "8", 1, rh, Middle}, {25, "9", 1, rh, Ring}, {29, "0", 1, rh, Pinky}, {27, "-", 1, rh, Pinky},
+		{24, "=", 1, rh, Pinky}, {51, "delete", 2, rh, Pinky},
+	},
+	{
+		{48, "tab", 1.5, lh, Pinky}, {12, "Q", 1, lh, Pinky}, {13, "W", 1, lh, Ring}, {14, "E", 1, lh, Middle},
+		{15, "R", 1, lh, Index}, {17, "T", 1, lh, Index}, {16, "Y", 1, rh, Index}, {32, "U", 1, rh, Index},
+		{34, "I", 1, rh, Middle}, {31, "O", 1, rh, Ring}, {35, "P", 1, rh, Pinky}, {33, "[", 1, rh, Pinky},
+		{30, "]", 1, rh, Pinky}, {42, "\\", 1.5, rh, Pinky},
+	},
+	{
+		{57, "caps", 1.75, lh, Pinky}, {0, "A", 1, lh, Pinky}, {1, "S", 1, lh, Ring}, {2, "D", 1, lh, Middle},
+		{3, "F", 1, lh, Index}, {5, "G", 1, lh, Index}, {4, "H", 1, rh, Index}, {38, "J", 1, rh, Index},
+		{40, "K", 1, rh, Middle}, {37, "L", 1, rh, Ring}, {41, ";", 1, rh, Pinky}, {39, "'", 1, rh, Pinky},
+		{36, "return", 2.25, rh, Pinky},
+	},
+	{
+		{56, "shift", 2.25, lh, Pinky}, {6, "Z", 1, lh, Pinky}, {7, "X", 1, lh, Ring}, {8, "C", 1, lh, Middle},
+		{9, "V", 1, lh, Index}, {11, "B", 1, lh, Index}, {45, "N", 1, rh, Index}, {46, "M", 1, rh, Index},
+		{43, ",", 1, rh, Middle}, {47, ".", 1, rh, Ring}, {44, "/", 1, rh, Pinky}, {60, "shift", 2.75, rh, Pinky},
+	},
+	{
+		{63, "fn", 1, HandNone, FingerNone}, {59, "ctrl", 1, lh, Pinky}, {58, "opt", 1, lh, Pinky},
+		{55, "cmd", 1.25, lh, Thumb}, {49, "space", 5, HandEither, Thumb}, {54, "cmd", 1.25, rh, Thumb},
+		{61, "opt", 1, rh, Pinky}, {123, "←", 0.875, HandNone, FingerNone}, {126, "↑", 0.875, HandNone, FingerNone},
+		{125, "↓", 0.875, HandNone, FingerNone}, {124, "→", 0.875, HandNone, FingerNone},
+	},
+}
+
+// ANSI is every key on the board, in row order.
+var ANSI []Key
+
+var byCode = map[int]Key{}
+
+// keyNames names keys whose board label is ambiguous (left/right pairs) or
+// not plain ASCII, and keys that are captured but not drawn on the board.
+var keyNames = map[int]string{
+	56: "shift (left)", 60: "shift (right)", 55: "cmd (left)", 54: "cmd (right)",
+	58: "opt (left)", 61: "opt (right)", 59: "ctrl (left)", 62: "ctrl (right)",
+	123: "left arrow", 124: "right arrow", 125: "down arrow", 126: "up arrow",
+	10: "ISO key", 53: "esc", 117: "fwd-delete", 76: "enter", 115: "home", 119: "end", 116: "page up", 121: "page down",
+	122: "F1", 120: "F2", 99: "F3", 118: "F4", 96: "F5", 97: "F6",
+	98: "F7", 100: "F8", 101: "F9", 109: "F10", 103: "F11", 111: "F12",
+}
+
+// linuxUnmappedBase is the offset the Linux capture backend adds to evdev
+// codes it has no macOS equivalent for (see internal/keylogger).
+const linuxUnmappedBase = 0x20000
+
+func init() {
+	for row, specs := range ansiRows {
+		x := 0.0
+		for _, s := range specs {
+			k := Key{Code: s.code, Label: s.label, X: x, Y: float64(row), W: s.w, Hand: s.hand, Finger: s.finger}
+			ANSI = append(ANSI, k)
+			byCode[k.Code] = k
+			x += s.w
+		}
+	}
+}
+
+// Lookup returns the ANSI key for a keycode, if it is on the board.
+func Lookup(code int) (Key, bool) {
+	k, ok := byCode[code]
+	return k, ok
+}
+
+// Name returns a short human name for a keycode.
+func Name(code int) string {
+	if n, ok := keyNames[code]; ok {
+		return n
+	}
+	if k, ok := byCode[code]; ok {
+		return k.Label
+	}
+	if code >= linuxUnmappedBase {
+		return fmt.Sprintf("evdev %d", code-linuxUnmappedBase)
+	}
+	return fmt.Sprintf("key %d", code)
+}
+
+// FingerKey identifies one finger of one hand.
+type FingerKey struct {
+	Hand   Hand
+	Finger Finger
+}
+
+func (f FingerKey) String() string {
+	if f.Hand == HandEither {
+		return "thumbs"
+	}
+	if f.Finger == FingerNone {
+		return "other"
+	}
+	return f.Hand.String() + " " + f.Finger.String()
+}
+
+// Split is keystroke counts attributed to hands and fingers.
+type Split struct {
+	Total   int64
+	Hands   map[Hand]int64
+	Fingers map[FingerKey]int64
+}
+
+// SplitCounts attributes per-keycode counts to hands and fingers. Keys off the
+// ANSI board count toward HandNone / "other".
+func SplitCounts(counts map[int]int64) Split {
+	s := Split{Hands: map[Hand]int64{}, Fingers: map[FingerKey]int64{}}
+	for code, n := range counts {
+		k, ok := byCode[code]
+		if !ok {
+			k = Key{Hand: HandNone, Finger: FingerNone}
+		}
+		s.Total += n
+		s.Hands[k.Hand] += n
+		fk := FingerKey{Hand: k.Hand, Finger: k.Finger}
+		if k.Finger == FingerNone {
+			fk = FingerKey{}
+		}
+		s.Fingers[fk] += n
+	}
+	return s
+}
+
+// FingerOrder lists fingers left pinky to right pinky, then "other": the
+// order a per-finger chart reads naturally in.
+var FingerOrder = []FingerKey{
+	{HandLeft, Pinky}, {HandLeft, Ring}, {HandLeft, Middle}, {HandLeft, Index}, {HandLeft, Thumb},
+	{HandEither, Thumb},
+	{HandRight, Thumb}, {HandRight, Index}, {HandRight, Middle}, {HandRight, Ring}, {HandRight, Pinky},
+	{},
+}
@@ -0,0 +1,60 @@
+package keyboard
+
+import "testing"
+
+func TestANSIRowsFillTheBoard(t *testing.T) {
+	for row, specs := range ansiRows {
+		var w float64
+		for _, s := range specs {
+			w += s.w
+		}
+		if w != Width {
+			t.Errorf("row %d is %.3f units wide, want %.0f", row, w, Width)
+		}
+	}
+	if len(byCode) != len(ANSI) {
+		t.Errorf("duplicate keycodes on the board: %d keys, %d codes", len(ANSI), len(byCode))
+	}
+}
+
+func TestName(t *testing.T) {
+	tests := []struct {
+		code int
+		want string
+	}{
+		{0, "A"},
+		{49, "space"},
+		{56, "shift (left)"},
+		{54, "cmd (right)"},
+		{53, "esc"},
+		{0x20000 + 59, "evdev 59"},
+		{999, "key 999"},
+	}
+	for _, tt := range tests {
+		if got := Name(tt.code); got != tt.want {
+			t.Errorf("Name(%d) = %q, want %q", tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestSplitCounts(t *testing.T) {
+	s := SplitCounts(map[int]int64{
+		0:   10, // A: left pinky
+		38:  5,  // J: right index
+		49:  7,  // space: thumbs
+		123: 2,  // left arrow: other
+		53:  1,  // esc: not on the board
+	})
+	if s.Total != 25 {
+		t.Fatalf("total: want 25, got %d", s.Total)
+	}
+	if s.Hands[HandLeft] != 10 || s.Hands[HandRight] != 5 || s.Hands[HandEither] != 7 || s.Hands[HandNone] != 3 {
+		t.Fatalf("hands: %v", s.Hands)
+	}
+	if s.Fingers[FingerKey{HandLeft, Pinky}] != 10 || s.Fingers[FingerKey{HandRight, Index}] != 5 || s.Fingers[FingerKey{}] != 3 {
+		t.Fatalf("fingers: %v", s.Fingers)
+	}
+	if got := (FingerKey{HandLeft, Pinky}).String(); got != "left pinky" {
+		t.Errorf("FingerKey.String() = %q", got)
+	}
+}
@@ -0,0 +1,258 @@
+//go:build linux
+
+package keylogger
+
+// evdev capture backend: read key events straight from the kernel's input
+// devices (/dev/input/event*) instead of polling the X server. It works the
+// same under X11, Wayland and on a bare console, and costs nothing while no
+// key is pressed, but it needs read access to the device nodes — usually
+// membership in the "input" group.
+//
+// Each device node yields a stream of struct input_event records. Key events
+// (EV_KEY) carry an evdev key code and a value: 1 for press, 0 for release, 2
+// for kernel auto-repeat. Only presses are reported, so a held key counts once,
+// as on the other backends. Codes go through the same evdevToMac table as the
+// X11 backend.
+//
+// A device path may also be a regular file holding a recorded stream (for
+// example `cat /dev/input/event3 > typing.evdev`); it is read to the end and
+// then that reader stops. Tests use this to replay captures.
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// Capture backends for Options.Backend.
+const (
+	BackendAuto  = "auto"
+	BackendX11   = "x11"
+	BackendEvdev = "evdev"
+)
+
+// Options selects and configures the capture backend.
+type Options struct {
+	// Backend is "auto" (or empty), "x11" or "evdev"; see ResolveBackend.
+	Backend string
+	// Devices are evdev device paths to read. Empty means every keyboard
+	// listed in /proc/bus/input/devices. Ignored by the x11 backend.
+	Devices []string
+}
+
+// ResolveBackend turns a configured backend name into the one Start will use.
+// "auto" picks evdev in a Wayland session or with no X display (X11 polling
+// can't see keys typed into Wayland windows) and x11 otherwise, since x11
+// needs no extra permissions.
+func ResolveBackend(name string) (string, error) {
+	switch name {
+	case "", BackendAuto:
+		if os.Getenv("WAYLAND_DISPLAY") != "" || os.Getenv("DISPLAY") == "" {
+			return BackendEvdev, nil
+		}
+		return BackendX11, nil
+	case BackendX11, BackendEvdev:
+		return name, nil
+	}
+	return "", fmt.Errorf("unknown capture backend %q (want auto, x11 or evdev)", name)
+}
+
+// EvdevDevice is one keyboard found by FindKeyboards.
+type EvdevDevice struct {
+	Path string
+	Name string
+}
+
+const procInputDevices = "/proc/bus/input/devices"
+
+// FindKeyboards lists the input devices that look like keyboards.
+func FindKeyboards() ([]EvdevDevice, error) {
+	f, err := os.Open(procInputDevices)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return parseInputDevices(f)
+}
+
+// parseInputDevices reads the /proc/bus/input/devices format: blank-line
+// separated blocks of "X: ..." lines. A block is a keyboard if it has an
+// event handler and its key bitmap includes A, Z and Space — which rules out
+// power buttons, lid switches and mice that only report a few keys.
+func parseInputDevices(r io.Reader) ([]EvdevDevice, error) {
+	var (
+		devices []EvdevDevice
+		name    string
+		event   string
+		keys    string
+	)
+	flush := func() {
+		if event != "" && hasTypingKeys(keys) {
+			devices = append(devices, EvdevDevice{Path: filepath.Join("/dev/input", event), Name: name})
+		}
+		name, event, keys = "", "", ""
+	}
+
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		switch {
+		case line == "":
+			flush()
+		case strings.HasPrefix(line, "N: Name="):
+			name = strings.Trim(strings.TrimPrefix(line, "N: Name="), `"`)
+		case strings.HasPrefix(line, "H: Handlers="):
+			for _, h := range strings.Fields(strings.TrimPrefix(line, "H: Handlers=")) {
+				if strings.HasPrefix(h, "event") {
+					event = h
+				}
+			}
+		case strings.HasPrefix(line, "B: KEY="):
+			keys = strings.TrimPrefix(line, "B: KEY=")
+		}
+	}
+	flush()
+	return devices, sc.Err()
+}
+
+// hasTypingKeys reports whether a KEY= capability bitmap (hex words, most
+// significant first, 64 bits each) has KEY_A (30), KEY_Z (44) and KEY_SPACE
+// (57) — all in the lowest word.
+func hasTypingKeys(bitmap string) bool {
+	words := strings.Fields(bitmap)
+	if len(words) == 0 {
+		return false
+	}
+	low, err := strconv.ParseUint(words[len(words)-1], 16, 64)
+	if err != nil {
+		return false
+	}
+	const want = 1<<30 | 1<<44 | 1<<57
+	return low&want == want
+}
+
+// Event types and key values from <linux/input-event-codes.h>.
+const (
+	evKey      = 0x01
+	keyRelease = 0
+	keyPress   = 1
+)
+
+// inputEvent is struct input_event. syscall.Timeval has the platform's
+// layout, so the record size matches the kernel's (24 bytes on 64-bit).
+type inputEvent struct {
+	Time  syscall.Timeval
+	Type  uint16
+	Code  uint16
+	Value int32
+}
+
+// evdevModifierFlags maps evdev modifier key codes to flag bits.
+var evdevModifierFlags = map[uint16]uint64{
+	42: flagShift, 54: flagShift,
+	29: flagCtrl, 97: flagCtrl,
+	56: flagOpt, 100: flagOpt,
+	125: flagCmd, 126: flagCmd,
+}
+
+// evdevState tracks held modifiers across every device being read, so Shift
+// on one keyboard applies to a key on another, as it does on screen.
+type evdevState struct {
+	mu   sync.Mutex
+	held map[uint16]bool
+}
+
+func newEvdevState() *evdevState {
+	return &evdevState{held: map[uint16]bool{}}
+}
+
+func (s *evdevState) flags() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var f uint64
+	for code := range s.held {
+		f |= evdevModifierFlags[code]
+	}
+	return f
+}
+
+// read decodes input_event records from r until EOF or a read error, calling
+// emit for each key press. EOF (end of a recorded stream) returns nil.
+func (s *evdevState) read(r io.Reader, emit func(KeystrokeEvent)) error {
+	br := bufio.NewReader(r)
+	for {
+		var ev inputEvent
+		if err := binary.Read(br, binary.NativeEndian, &ev); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return nil
+			}
+			return err
+		}
+		if ev.Type != evKey {
+			continue
+		}
+		if _, isMod := evdevModifierFlags[ev.Code]; isMod {
+			s.mu.Lock()
+			if ev.Value == keyRelease {
+				delete(s.held, ev.Code)
+			} else {
+				s.held[ev.Code] = true
+			}
+			s.mu.Unlock()
+		}
+		if ev.Value != keyPress {
+			continue // release or auto-repeat
+		}
+		emit(KeystrokeEvent{Keycode: translateEvdev(int(ev.Code)), Flags: s.flags()})
+	}
+}
+
+// openEvdevDevices opens the configured devices, or every detected keyboard
+// when none are configured. Configured devices must all open; detected ones
+// are skipped if they can't be opened, as long as at least one can.
+func openEvdevDevices(paths []string) ([]*os.File, error) {
+	configured := len(paths) > 0
+	if !configured {
+		kbs, err := FindKeyboards()
+		if err != nil {
+			return nil, fmt.Errorf("finding keyboards: %w", err)
+		}
+		for _, kb := range kbs {
+			paths = append(paths, kb.Path)
+		}
+		if len(paths) == 0 {
+			return nil, errors.New("no keyboards found in " + procInputDevices)
+		}
+	}
+
+	var (
+		files []*os.File
+		errs  []error
+	)
+	for _, p := range paths {
+		f, err := os.Open(p)
+		if err != nil {
+			if errors.Is(err, os.ErrPermission) {
+				err = fmt.Errorf("%w (add yourself to the input group: sudo usermod -aG input $USER, then log in again)", err)
+			}
+			errs = append(errs, err)
+			continue
+		}
+		files = append(files, f)
+	}
+	if len(files) == 0 || (configured && len(errs) > 0) {
+		for _, f := range files {
+			f.Close()
+		}
+		return nil, errors.Join(errs...)
+	}
+	return files, nil
+}
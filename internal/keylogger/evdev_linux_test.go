@@ -0,0 +1,135 @@
+//go:build linux
+
+package keylogger
+
+import (
+	"encoding/binary"
+	"os"
+	"testing"
+	"time"
+	"unsafe"
+)
+
+// testdata/typing.evdev is a recorded input_event stream (64-bit,
+// little-endian) of: Shift+H, I, Space, H, I held into auto-repeat, Ctrl+C.
+// Each key event is framed by the MSC_SCAN and SYN_REPORT events a real
+// keyboard sends.
+func skipUnlessRecordingLayout(t *testing.T) {
+	t.Helper()
+	if unsafe.Sizeof(inputEvent{}) != 24 || binary.NativeEndian.Uint16([]byte{1, 0}) != 1 {
+		t.Skip("testdata/typing.evdev was recorded on a 64-bit little-endian machine")
+	}
+}
+
+func TestEvdevReadRecordedStream(t *testing.T) {
+	skipUnlessRecordingLayout(t)
+	f, err := os.Open("testdata/typing.evdev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var got []KeystrokeEvent
+	if err := newEvdevState().read(f, func(ev KeystrokeEvent) { got = append(got, ev) }); err != nil {
+		t.Fatalf("read: %v", err)
+	}
+
+	// Presses only: auto-repeat and releases are dropped; modifiers are
+	// reported as keys too, as on the other backends.
+	want := []struct {
+		keycode int
+		flags   uint64
+	}{
+		{56, flagShift}, // left shift
+		{4, flagShift},  // H
+		{34, 0},         // I
+		{49, 0},         // space
+		{4, 0},          // h
+		{34, 0},         // i (held; repeats ignored)
+		{59, flagCtrl},  // left ctrl
+		{8, flagCtrl},   // C
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d events, want %d: %+v", len(got), len(want), got)
+	}
+	for i, w := range want {
+		if got[i].Keycode != w.keycode || got[i].Flags != w.flags {
+			t.Errorf("event %d = {%d %#x}, want {%d %#x}", i, got[i].Keycode, got[i].Flags, w.keycode, w.flags)
+		}
+	}
+}
+
+func TestStartWithEvdevReplaysDeviceFile(t *testing.T) {
+	skipUnlessRecordingLayout(t)
+	ch, err := StartWith(Options{Backend: BackendEvdev, Devices: []string{"testdata/typing.evdev"}})
+	if err != nil {
+		t.Fatalf("StartWith: %v", err)
+	}
+	defer Stop()
+
+	if _, err := StartWith(Options{Backend: BackendEvdev}); err == nil {
+		t.Error("a second Start should fail while running")
+	}
+
+	var n int
+	timeout := time.After(2 * time.Second)
+	for n < 8 {
+		select {
+		case <-ch:
+			n++
+		case <-timeout:
+			t.Fatalf("got %d events before timing out, want 8", n)
+		}
+	}
+}
+
+func TestStartWithMissingDevice(t *testing.T) {
+	if _, err := StartWith(Options{Backend: BackendEvdev, Devices: []string{"testdata/missing"}}); err == nil {
+		Stop()
+		t.Fatal("a configured device that can't be opened should fail Start")
+	}
+}
+
+func TestResolveBackend(t *testing.T) {
+	t.Setenv("WAYLAND_DISPLAY", "wayland-0")
+	t.Setenv("DISPLAY", ":0")
+	if b, _ := ResolveBackend(""); b != BackendEvdev {
+		t.Errorf("auto under Wayland = %q, want evdev", b)
+	}
+	t.Setenv("WAYLAND_DISPLAY", "")
+	if b, _ := ResolveBackend(BackendAuto); b != BackendX11 {
+		t.Errorf("auto under X11 = %q, want x11", b)
+	}
+	if b, _ := ResolveBackend(BackendEvdev); b != BackendEvdev {
+		t.Errorf("explicit evdev = %q", b)
+	}
+	if _, err := ResolveBackend("wayland"); err == nil {
+		t.Error("unknown backends should be rejected")
+	}
+}
+
+func TestParseInputDevices(t *testing.T) {
+	f, err := os.Open("testdata/proc-bus-input-devices")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	kbs, err := parseInputDevices(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The power button and the mouse have event handlers but no letter keys.
+	want := []EvdevDevice{
+		{Path: "/dev/input/event3", Name: "AT Translated Set 2 keyboard"},
+		{Path: "/dev/input/event12", Name: "Keychron K3"},
+	}
+	if len(kbs) != len(want) {
+		t.Fatalf("got %+v, want %+v", kbs, want)
+	}
+	for i := range want {
+		if kbs[i] != want[i] {
+			t.Errorf("keyboard %d = %+v, want %+v", i, kbs[i], want[i])
+		}
+	}
+}
@@ -5,10 +5,12 @@ package keylogger
 import "github.com/aayushbajaj/typing-telemetry/internal/x11"
 
 // X11 keycodes are the Linux evdev key code plus 8. The downstream pipeline
-// (wordcounter, storage.ClassifyKeycode) is written against macOS virtual
-// keycodes, so we translate evdev -> macOS here. Only the keys those consumers
-// care about need entries: letters, the digit row, common punctuation, the
-// whitespace/edit keys (space, return, tab, backspace), and the modifiers.
+// (wordcounter, the keyboard layouts in internal/layout) is written against
+// macOS virtual keycodes, so we translate evdev -> macOS here. Only the keys
+// those consumers care about need entries: letters, the digit row, common
+// punctuation, the whitespace/edit keys (space, return, tab, backspace), and
+// the modifiers. The evdev backend reads evdev codes directly and shares the
+// same table.
 //
 // evdev codes come from <linux/input-event-codes.h>; macOS values match the
 // constants in internal/wordcounter and the classification in storage.
@@ -43,7 +45,7 @@ var evdevToMac = map[int]int{
 	14:  51,  // backspace -> Delete
 	111: 117, // delete (forward) -> ForwardDel
 
-	// Modifiers (classified as "modifier" by storage.ClassifyKeycode)
+	// Modifiers (classified as "modifier" on every layout)
 	42: 56, 54: 60, // left/right shift
 	29: 59, 97: 62, // left/right control
 	56: 58, 100: 61, // left/right alt -> option
@@ -56,7 +58,11 @@ var evdevToMac = map[int]int{
 // still register as a "special" keystroke without ever being mistaken for a
 // content or whitespace key.
 func translate(xcode uint8) int {
-	ev := int(xcode) - xKeycodeOffset
+	return translateEvdev(int(xcode) - xKeycodeOffset)
+}
+
+// translateEvdev converts an evdev key code into a macOS-space keycode.
+func translateEvdev(ev int) int {
 	if mac, ok := evdevToMac[ev]; ok {
 		return mac
 	}
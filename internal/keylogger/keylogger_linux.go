@@ -7,12 +7,14 @@ package keylogger
 // CheckAccessibilityPermissions, GetCurrentModifiers, PlaySound) so the rest of
 // the codebase — wordcounter, speedtracker, storage — is reused unchanged.
 //
-// Capture is done by polling the X server's global key-state bitmap
-// (internal/x11) and reporting each key's down-transition. Two details make the
+// There are two capture backends (see Options). The x11 backend polls the X
+// server's global key-state bitmap (internal/x11) and reports each key's
+// down-transition; the evdev backend (evdev_linux.go) reads /dev/input, which
+// also works under Wayland. For the x11 backend two details make the
 // downstream pipeline work without modification:
 //
 //   - Keycodes are translated from X/evdev space into the macOS virtual
-//     keycodes that wordcounter and storage.ClassifyKeycode are written
+//     keycodes that wordcounter and internal/layout are written
 //     against, via evdevToMac below.
 //   - Held keys and inertia's synthetic key-down repeats never produce a new
 //     down-transition (the state bit is already set), so each physical press
@@ -21,6 +23,8 @@ package keylogger
 
 import (
 	"errors"
+	"log"
+	"os"
 	"sync"
 
 	"github.com/aayushbajaj/typing-telemetry/internal/x11"
@@ -84,6 +88,8 @@ var (
 	mu            sync.Mutex
 	keystrokeChan chan KeystrokeEvent
 	poller        *x11.Poller
+	evdevFiles    []*os.File
+	evdev         *evdevState
 	running       bool
 )
 
@@ -93,13 +99,23 @@ func CheckAccessibilityPermissions() bool {
 	return x11.Available()
 }
 
-// GetCurrentModifiers queries the live modifier state from the X server.
+// GetCurrentModifiers returns the live modifier state: tracked from the key
+// stream when capturing with evdev, otherwise queried from the X server.
 func GetCurrentModifiers() ModifierFlags {
-	m, ok := x11.QueryState()
-	if !ok {
-		return ModifierFlags{}
+	mu.Lock()
+	st := evdev
+	mu.Unlock()
+
+	var f uint64
+	if st != nil {
+		f = st.flags()
+	} else {
+		m, ok := x11.QueryState()
+		if !ok {
+			return ModifierFlags{}
+		}
+		f = flagsFromKeymap(m)
 	}
-	f := flagsFromKeymap(m)
 	return ModifierFlags{
 		Cmd:   f&flagCmd != 0,
 		Ctrl:  f&flagCtrl != 0,
@@ -108,39 +124,73 @@ func GetCurrentModifiers() ModifierFlags {
 	}
 }
 
-// Start begins capturing keystrokes and returns a channel of KeystrokeEvents.
+// Start begins capturing keystrokes with the automatically chosen backend and
+// returns a channel of KeystrokeEvents.
 func Start() (<-chan KeystrokeEvent, error) {
+	return StartWith(Options{})
+}
+
+// StartWith begins capturing keystrokes with the given backend options.
+func StartWith(opts Options) (<-chan KeystrokeEvent, error) {
+	backend, err := ResolveBackend(opts.Backend)
+	if err != nil {
+		return nil, err
+	}
+
 	mu.Lock()
 	defer mu.Unlock()
 
 	if running {
 		return nil, errors.New("keylogger already running")
 	}
-	if !x11.Available() {
-		return nil, errors.New("cannot connect to X display (is DISPLAY set?)")
-	}
 
-	keystrokeChan = make(chan KeystrokeEvent, 1000)
-	p, err := x11.StartPoller(x11.DefaultPollInterval, onTransition)
-	if err != nil {
-		close(keystrokeChan)
-		keystrokeChan = nil
-		return nil, err
+	switch backend {
+	case BackendEvdev:
+		files, err := openEvdevDevices(opts.Devices)
+		if err != nil {
+			return nil, err
+		}
+		keystrokeChan = make(chan KeystrokeEvent, 1000)
+		evdevFiles = files
+		evdev = newEvdevState()
+		for _, f := range files {
+			go func(state *evdevState, f *os.File) {
+				// Stop closes the file to end the read; anything else means
+				// the device stopped delivering keys (unplugged, revoked).
+				if err := state.read(f, send); err != nil && !errors.Is(err, os.ErrClosed) {
+					log.Printf("warning: evdev read from %s stopped: %v", f.Name(), err)
+				}
+			}(evdev, f)
+		}
+	default:
+		if !x11.Available() {
+			return nil, errors.New("cannot connect to X display (is DISPLAY set?)")
+		}
+		keystrokeChan = make(chan KeystrokeEvent, 1000)
+		p, err := x11.StartPoller(x11.DefaultPollInterval, onTransition)
+		if err != nil {
+			close(keystrokeChan)
+			keystrokeChan = nil
+			return nil, err
+		}
+		poller = p
 	}
-	poller = p
 	running = true
 	return keystrokeChan, nil
 }
 
 // onTransition is invoked by the poller for every key-state change. Only
-// down-transitions are counted; the channel send happens under mu so Stop can
-// close the channel safely.
+// down-transitions are counted.
 func onTransition(code uint8, down bool, state x11.Keymap) {
 	if !down {
 		return
 	}
-	ev := KeystrokeEvent{Keycode: translate(code), Flags: flagsFromKeymap(state)}
+	send(KeystrokeEvent{Keycode: translate(code), Flags: flagsFromKeymap(state)})
+}
 
+// send delivers an event from either backend. The channel send happens under
+// mu so Stop can close the channel safely.
+func send(ev KeystrokeEvent) {
 	mu.Lock()
 	ch := keystrokeChan
 	if ch != nil {
@@ -163,6 +213,12 @@ func Stop() {
 		poller.Stop()
 		poller = nil
 	}
+	// Closing the device files unblocks the evdev readers, which then exit.
+	for _, f := range evdevFiles {
+		f.Close()
+	}
+	evdevFiles = nil
+	evdev = nil
 	if keystrokeChan != nil {
 		close(keystrokeChan)
 		keystrokeChan = nil
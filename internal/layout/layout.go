@@ -0,0 +1,298 @@
+// Package layout maps physical keys to what they type. Capture backends record
+// physical positions (macOS virtual keycodes, see internal/keylogger), so
+// deciding whether a key is a letter — for the letters/modifiers/special split
+// and for word counting — depends on the layout the user types on: the key
+// left of L is ";" on QWERTY but "s" on Dvorak and "o" on Colemak.
+//
+// Built-in layouts are registered at init. Users can add their own as JSON
+// (see Parse); storage keeps them under <data root>/layouts.
+package layout
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Class is the coarse type of a key, as stored in daily_summary.
+type Class string
+
+const (
+	Letter   Class = "letter"
+	Modifier Class = "modifier"
+	Special  Class = "special"
+)
+
+// Default is the layout used when none is configured.
+const Default = "qwerty"
+
+var (
+	ErrUnknownLayout     = errors.New("unknown keyboard layout")
+	ErrInvalidLayoutName = errors.New("layout names are 1-32 characters of a-z, 0-9, '-' or '_'")
+)
+
+var nameRe = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+// Key is what one physical key produces, unshifted.
+type Key struct {
+	Char  string // empty for keys that type nothing (Return, arrows, modifiers)
+	Class Class
+}
+
+// Layout maps keycodes to keys. Keycodes it doesn't list are Special and type
+// nothing.
+type Layout struct {
+	Name        string
+	Description string
+	Builtin     bool
+	keys        map[int]Key
+}
+
+// Key returns what a keycode produces on this layout.
+func (l *Layout) Key(code int) Key {
+	if k, ok := l.keys[code]; ok {
+		return k
+	}
+	return Key{Class: Special}
+}
+
+// Class returns the class of a keycode on this layout.
+func (l *Layout) Class(code int) Class { return l.Key(code).Class }
+
+// Char returns the character a keycode types on this layout, or "".
+func (l *Layout) Char(code int) string { return l.Key(code).Char }
+
+// IsContent reports whether a keycode types a visible character — a letter,
+// digit or punctuation mark that can be part of a word.
+func (l *Layout) IsContent(code int) bool {
+	k := l.Key(code)
+	return k.Char != "" && k.Class != Modifier
+}
+
+// modifierCodes are Shift, Control, Option, Command (left and right), fn and
+// Caps Lock. They are the same on every layout.
+var modifierCodes = []int{56, 60, 59, 62, 58, 61, 55, 54, 63, 57}
+
+// rowCodes are the keycodes of the four character rows, left to right,
+// starting at the "1" key, the tab-row letter, and so on. Keys outside these
+// rows that type characters (50, and 10 on ISO boards) are given per layout.
+var rowCodes = [4][]int{
+	{18, 19, 20, 21, 23, 22, 26, 28, 25, 29, 27, 24},
+	{12, 13, 14, 15, 17, 16, 32, 34, 31, 35, 33, 30, 42},
+	{0, 1, 2, 3, 5, 4, 38, 40, 37, 41, 39},
+	{6, 7, 8, 9, 11, 45, 46, 43, 47, 44},
+}
+
+type builtinSpec struct {
+	name, description string
+	rows              [4]string
+	extra             map[int]string
+}
+
+var builtins = []builtinSpec{
+	{
+		name:        "qwerty",
+		description: "US QWERTY (ANSI)",
+		rows:        [4]string{"1234567890-=", `qwertyuiop[]\`, "asdfghjkl;'", "zxcvbnm,./"},
+		extra:       map[int]string{50: "`"},
+	},
+	{
+		name:        "dvorak",
+		description: "US Dvorak (ANSI)",
+		rows:        [4]string{"1234567890[]", `',.pyfgcrl/=\`, "aoeuidhtns-", ";qjkxbmwvz"},
+		extra:       map[int]string{50: "`"},
+	},
+	{
+		name:        "colemak",
+		description: "Colemak (ANSI)",
+		rows:        [4]string{"1234567890-=", `qwfpgjluy;[]\`, "arstdhneio'", "zxcvbkm,./"},
+		extra:       map[int]string{50: "`"},
+	},
+	{
+		name:        "qwerty-uk",
+		description: "British QWERTY (ISO)",
+		rows:        [4]string{"1234567890-=", `qwertyuiop[]\`, "asdfghjkl;'", "zxcvbnm,./"},
+		extra:       map[int]string{10: "§", 50: "`"},
+	},
+	{
+		name:        "qwertz-de",
+		description: "German QWERTZ (ISO)",
+		rows:        [4]string{"1234567890ß´", "qwertzuiopü+#", "asdfghjklöä", "yxcvbnm,.-"},
+		extra:       map[int]string{10: "^", 50: "<"},
+	},
+	{
+		name:        "azerty-fr",
+		description: "French AZERTY (ISO)",
+		rows:        [4]string{`&é"'(§è!çà)-`, "azertyuiop^$`", "qsdfghjklmù", "wxcvbn,;:="},
+		extra:       map[int]string{10: "@", 50: "<"},
+	},
+}
+
+var registry = map[string]*Layout{}
+
+func init() {
+	for _, b := range builtins {
+		chars := map[int]string{}
+		for i, row := range b.rows {
+			runes := []rune(row)
+			if len(runes) != len(rowCodes[i]) {
+				panic(fmt.Sprintf("layout %s: row %d has %d keys, want %d", b.name, i, len(runes), len(rowCodes[i])))
+			}
+			for j, r := range runes {
+				chars[rowCodes[i][j]] = string(r)
+			}
+		}
+		for code, c := range b.extra {
+			chars[code] = c
+		}
+		l := build(b.name, b.description, chars, nil)
+		l.Builtin = true
+		registry[l.Name] = l
+	}
+}
+
+// build assembles a layout from per-keycode characters, deriving each key's
+// class from its character unless classes overrides it.
+func build(name, description string, chars map[int]string, classes map[int]Class) *Layout {
+	l := &Layout{Name: name, Description: description, keys: map[int]Key{}}
+	for _, code := range modifierCodes {
+		l.keys[code] = Key{Class: Modifier}
+	}
+	for code, c := range chars {
+		l.keys[code] = Key{Char: c, Class: classOf(c)}
+	}
+	for code, cl := range classes {
+		k := l.keys[code]
+		k.Class = cl
+		l.keys[code] = k
+	}
+	return l
+}
+
+func classOf(char string) Class {
+	r, _ := utf8.DecodeRuneInString(char)
+	if unicode.IsLetter(r) {
+		return Letter
+	}
+	return Special
+}
+
+// Get returns a built-in layout by name.
+func Get(name string) (*Layout, error) {
+	if l, ok := registry[name]; ok {
+		return l, nil
+	}
+	return nil, fmt.Errorf("%q: %w", name, ErrUnknownLayout)
+}
+
+// QWERTY returns the default layout.
+func QWERTY() *Layout { return registry[Default] }
+
+// Builtins returns the built-in layouts, sorted by name.
+func Builtins() []*Layout {
+	out := make([]*Layout, 0, len(registry))
+	for _, l := range registry {
+		out = append(out, l)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// ValidateName reports whether name can be used for a user layout.
+func ValidateName(name string) error {
+	if !nameRe.MatchString(name) {
+		return fmt.Errorf("%q: %w", name, ErrInvalidLayoutName)
+	}
+	return nil
+}
+
+// File is the JSON form of a user layout. Keys maps keycodes to the unshifted
+// character they type and is applied on top of Base (default "qwerty"); an
+// empty string removes a key's character. Classes overrides the class derived
+// from the character ("letter" if it is a letter, otherwise "special").
+//
+//	{
+//	  "name": "workman",
+//	  "description": "Workman (ANSI)",
+//	  "base": "qwerty",
+//	  "keys": {"13": "d", "14": "r", "15": "w"},
+//	  "classes": {"41": "letter"}
+//	}
+type File struct {
+	Name        string            `json:"name"`
+	Description string            `json:"description,omitempty"`
+	Base        string            `json:"base,omitempty"`
+	Keys        map[string]string `json:"keys,omitempty"`
+	Classes     map[string]Class  `json:"classes,omitempty"`
+}
+
+// Parse builds a layout from its JSON form. The name must not shadow a
+// built-in layout.
+func Parse(data []byte) (*Layout, error) {
+	var f File
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, err
+	}
+	if err := ValidateName(f.Name); err != nil {
+		return nil, err
+	}
+	if _, ok := registry[f.Name]; ok {
+		return nil, fmt.Errorf("%q is a built-in layout; pick another name", f.Name)
+	}
+	if f.Base == "" {
+		f.Base = Default
+	}
+	base, err := Get(f.Base)
+	if err != nil {
+		return nil, fmt.Errorf("base: %w", err)
+	}
+
+	chars := map[int]string{}
+	classes := map[int]Class{}
+	for code, k := range base.keys {
+		if k.Char != "" {
+			chars[code] = k.Char
+		}
+	}
+	for s, c := range f.Keys {
+		code, err := parseKeycode(s)
+		if err != nil {
+			return nil, err
+		}
+		if utf8.RuneCountInString(c) > 1 {
+			return nil, fmt.Errorf("key %d: %q is more than one character", code, c)
+		}
+		if c == "" {
+			delete(chars, code)
+		} else {
+			chars[code] = c
+		}
+	}
+	for s, cl := range f.Classes {
+		code, err := parseKeycode(s)
+		if err != nil {
+			return nil, err
+		}
+		switch cl {
+		case Letter, Modifier, Special:
+		default:
+			return nil, fmt.Errorf("key %d: class %q is not letter, modifier or special", code, cl)
+		}
+		classes[code] = cl
+	}
+	return build(f.Name, f.Description, chars, classes), nil
+}
+
+func parseKeycode(s string) (int, error) {
+	code, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || code < 0 {
+		return 0, fmt.Errorf("%q is not a keycode", s)
+	}
+	return code, nil
+}
@@ -0,0 +1,143 @@
+package layout
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestQWERTYClasses(t *testing.T) {
+	l := QWERTY()
+	tests := []struct {
+		code    int
+		class   Class
+		char    string
+		content bool
+	}{
+		{0, Letter, "a", true},
+		{41, Special, ";", true},
+		{18, Special, "1", true},
+		{50, Special, "`", true},
+		{56, Modifier, "", false},
+		{62, Modifier, "", false},
+		{49, Special, "", false}, // space: whitespace, handled by the word counter
+		{123, Special, "", false},
+		{10, Special, "", false}, // ISO-only key
+	}
+	for _, tt := range tests {
+		if got := l.Class(tt.code); got != tt.class {
+			t.Errorf("Class(%d) = %q, want %q", tt.code, got, tt.class)
+		}
+		if got := l.Char(tt.code); got != tt.char {
+			t.Errorf("Char(%d) = %q, want %q", tt.code, got, tt.char)
+		}
+		if got := l.IsContent(tt.code); got != tt.content {
+			t.Errorf("IsContent(%d) = %v, want %v", tt.code, got, tt.content)
+		}
+	}
+}
+
+func TestBuiltinLayoutsMovePunctuationAndLetters(t *testing.T) {
+	tests := []struct {
+		layout string
+		code   int
+		char   string
+		class  Class
+	}{
+		{"dvorak", 41, "s", Letter},
+		{"dvorak", 12, "'", Special},
+		{"dvorak", 39, "-", Special},
+		{"colemak", 41, "o", Letter},
+		{"colemak", 35, ";", Special},
+		{"qwertz-de", 16, "z", Letter},
+		{"qwertz-de", 41, "ö", Letter},
+		{"qwertz-de", 27, "ß", Letter},
+		{"azerty-fr", 0, "q", Letter},
+		{"azerty-fr", 46, ",", Special},
+		{"azerty-fr", 19, "é", Letter},
+		{"qwerty-uk", 10, "§", Special},
+	}
+	for _, tt := range tests {
+		l, err := Get(tt.layout)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := l.Char(tt.code); got != tt.char {
+			t.Errorf("%s: Char(%d) = %q, want %q", tt.layout, tt.code, got, tt.char)
+		}
+		if got := l.Class(tt.code); got != tt.class {
+			t.Errorf("%s: Class(%d) = %q, want %q", tt.layout, tt.code, got, tt.class)
+		}
+	}
+}
+
+func TestEveryBuiltinHasTheSameLetterCount(t *testing.T) {
+	for _, l := range Builtins() {
+		letters := 0
+		for code := range l.keys {
+			if l.Class(code) == Letter {
+				letters++
+			}
+		}
+		// 26 Latin letters, plus the national letters on ISO-DE (ä ö ü ß)
+		// and the accented letters AZERTY types unshifted (é è ç à ù).
+		want := map[string]int{"qwertz-de": 30, "azerty-fr": 31}[l.Name]
+		if want == 0 {
+			want = 26
+		}
+		if letters != want {
+			t.Errorf("%s has %d letter keys, want %d", l.Name, letters, want)
+		}
+	}
+}
+
+func TestGetUnknown(t *testing.T) {
+	if _, err := Get("nope"); !errors.Is(err, ErrUnknownLayout) {
+		t.Fatalf("want ErrUnknownLayout, got %v", err)
+	}
+}
+
+func TestParse(t *testing.T) {
+	l, err := Parse([]byte(`{
+		"name": "workman",
+		"base": "qwerty",
+		"keys": {"13": "d", "41": "", "10": "λ"},
+		"classes": {"57": "special"}
+	}`))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if l.Name != "workman" || l.Builtin {
+		t.Fatalf("layout = %+v", l)
+	}
+	if l.Char(13) != "d" || l.Class(13) != Letter {
+		t.Errorf("13 = %+v", l.Key(13))
+	}
+	if l.IsContent(41) {
+		t.Error("an empty character should remove the key's character")
+	}
+	if l.Class(10) != Letter {
+		t.Errorf("non-Latin letters are letters, got %q", l.Class(10))
+	}
+	if l.Class(57) != Special {
+		t.Errorf("class override ignored: %q", l.Class(57))
+	}
+	if l.Char(0) != "a" {
+		t.Error("unlisted keys should come from the base layout")
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	for _, doc := range []string{
+		`{"name": "Bad Name"}`,
+		`{"name": "dvorak"}`,
+		`{"name": "x", "base": "nope"}`,
+		`{"name": "x", "keys": {"q": "a"}}`,
+		`{"name": "x", "keys": {"0": "ab"}}`,
+		`{"name": "x", "classes": {"0": "vowel"}}`,
+		`not json`,
+	} {
+		if _, err := Parse([]byte(doc)); err == nil {
+			t.Errorf("Parse(%s) should fail", doc)
+		}
+	}
+}
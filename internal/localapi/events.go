@@ -0,0 +1,151 @@
+package localapi
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/aayushbajaj/typing-telemetry/internal/speedtracker"
+	"github.com/aayushbajaj/typing-telemetry/internal/statsjson"
+	"github.com/aayushbajaj/typing-telemetry/internal/storage"
+)
+
+// Event stream (GET /v1/events, server-sent events). Three event types:
+//
+//	word      one per completed word: its burst, window and minute WPM
+//	tick      once a second: keystrokes and active ms in that second
+//	odometer  when the odometer session starts, stops or its counts move
+//
+// Only counts and paces are ever sent, never which keys were pressed.
+
+// subscriberBuffer is how many events a slow client may fall behind before
+// events are dropped for it.
+const subscriberBuffer = 64
+
+// WordEvent is the data of a "word" event.
+type WordEvent struct {
+	At        string  `json:"at"` // RFC3339, millisecond precision
+	BurstWPM  float64 `json:"burst_wpm"`
+	WindowWPM float64 `json:"window_wpm"`
+	MinuteWPM float64 `json:"minute_wpm"`
+}
+
+// TickEvent is the data of a "tick" event.
+type TickEvent struct {
+	At         string `json:"at"`
+	Keystrokes int64  `json:"keystrokes"`
+	ActiveMs   int64  `json:"active_ms"`
+}
+
+type event struct {
+	name string
+	data any
+}
+
+// Feed collects live activity from the capture goroutine and fans it out to
+// event-stream clients. Keystroke and Word are cheap and never block, so they
+// can be called for every key whether or not anyone is listening. It is safe
+// for concurrent use.
+type Feed struct {
+	mu         sync.Mutex
+	keystrokes int64
+	activeMs   int64
+	subs       map[chan event]struct{}
+}
+
+// NewFeed returns an empty Feed.
+func NewFeed() *Feed {
+	return &Feed{subs: make(map[chan event]struct{})}
+}
+
+// Keystroke counts one key, with the active typing time it added.
+func (f *Feed) Keystroke(activeMs int64) {
+	f.mu.Lock()
+	f.keystrokes++
+	f.activeMs += activeMs
+	f.mu.Unlock()
+}
+
+// Word publishes a completed word's speed sample.
+func (f *Feed) Word(s speedtracker.Sample, at time.Time) {
+	f.publish(event{"word", WordEvent{
+		At:        at.Format(timeFormat),
+		BurstWPM:  s.Burst,
+		WindowWPM: s.Window,
+		MinuteWPM: s.Minute,
+	}})
+}
+
+const timeFormat = "2006-01-02T15:04:05.000Z07:00"
+
+func (f *Feed) publish(ev event) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for ch := range f.subs {
+		select {
+		case ch <- ev:
+		default: // client is behind; drop rather than stall capture
+		}
+	}
+}
+
+func (f *Feed) subscribe() (<-chan event, func()) {
+	ch := make(chan event, subscriberBuffer)
+	f.mu.Lock()
+	f.subs[ch] = struct{}{}
+	f.mu.Unlock()
+	return ch, func() {
+		f.mu.Lock()
+		delete(f.subs, ch)
+		f.mu.Unlock()
+	}
+}
+
+func (f *Feed) listening() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.subs) > 0
+}
+
+// tick publishes the second's rollup and resets it.
+func (f *Feed) tick(now time.Time) {
+	f.mu.Lock()
+	ev := TickEvent{At: now.Format(timeFormat), Keystrokes: f.keystrokes, ActiveMs: f.activeMs}
+	f.keystrokes, f.activeMs = 0, 0
+	f.mu.Unlock()
+	f.publish(event{"tick", ev})
+}
+
+// run emits tick events every second and odometer events on change until ctx
+// is done. The odometer is only read while a client is connected.
+func (f *Feed) run(ctx context.Context, store *storage.Store) {
+	t := time.NewTicker(time.Second)
+	defer t.Stop()
+	var lastOdo statsjson.OdometerJSON
+	haveOdo := false
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-t.C:
+			f.tick(now)
+			if !f.listening() {
+				haveOdo = false
+				continue
+			}
+			odo, err := statsjson.Odometer(store, now)
+			if err != nil {
+				continue
+			}
+			// DurationSec moves every second; only real changes are news.
+			// Clients get the current state when they connect, so the first
+			// read just sets the baseline.
+			cmp := odo
+			cmp.DurationSec = lastOdo.DurationSec
+			if haveOdo && cmp != lastOdo {
+				f.publish(event{"odometer", odo})
+			}
+			lastOdo, haveOdo = odo, true
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package localapi
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aayushbajaj/typing-telemetry/internal/speedtracker"
+	"github.com/aayushbajaj/typing-telemetry/internal/statsjson"
+	"github.com/aayushbajaj/typing-telemetry/internal/storage"
+)
+
+func TestFeedTick(t *testing.T) {
+	f := NewFeed()
+	events, unsubscribe := f.subscribe()
+	defer unsubscribe()
+
+	for i := 0; i < 3; i++ {
+		f.Keystroke(100)
+	}
+	now := time.Now()
+	f.tick(now)
+	f.tick(now.Add(time.Second))
+
+	first, second := (<-events).data.(TickEvent), (<-events).data.(TickEvent)
+	if first.Keystrokes != 3 || first.ActiveMs != 300 {
+		t.Errorf("first tick = %+v, want 3 keys / 300 ms", first)
+	}
+	if second.Keystrokes != 0 || second.ActiveMs != 0 {
+		t.Errorf("second tick = %+v, want the rollup reset", second)
+	}
+}
+
+func TestFeedNeverBlocks(t *testing.T) {
+	f := NewFeed()
+	_, unsubscribe := f.subscribe() // never read
+	defer unsubscribe()
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < subscriberBuffer*2; i++ {
+			f.Word(speedtracker.Sample{Burst: 80}, time.Now())
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("publishing to a stalled client blocked")
+	}
+}
+
+// newEventServer is newTestServer with the event stream enabled.
+func newEventServer(t *testing.T) (*httptest.Server, *storage.Store, *Feed) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	store, err := storage.New()
+	if err != nil {
+		t.Fatalf("storage.New: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	feed := NewFeed()
+	srv := httptest.NewServer(New(store, Options{Feed: feed, Version: "test"}).Handler())
+	t.Cleanup(srv.Close)
+	return srv, store, feed
+}
+
+// readEvent returns the next event's name and data line.
+func readEvent(t *testing.T, r *bufio.Reader) (name, data string) {
+	t.Helper()
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("read event: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		case line == "" && name != "":
+			return name, data
+		}
+	}
+}
+
+func TestEventStream(t *testing.T) {
+	srv, _, feed := newEventServer(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/v1/events", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+	r := bufio.NewReader(resp.Body)
+
+	// The current odometer state arrives on connect.
+	if name, data := readEvent(t, r); name != "odometer" || !strings.Contains(data, `"active":false`) {
+		t.Fatalf("first event = %s %s", name, data)
+	}
+
+	feed.Word(speedtracker.Sample{Burst: 95, Window: 70, Minute: 60}, time.Now())
+	name, data := readEvent(t, r)
+	if name != "word" {
+		t.Fatalf("event = %q, want word", name)
+	}
+	var w WordEvent
+	if err := json.Unmarshal([]byte(data), &w); err != nil {
+		t.Fatal(err)
+	}
+	if w.BurstWPM != 95 || w.WindowWPM != 70 || w.MinuteWPM != 60 || w.At == "" {
+		t.Errorf("word = %+v", w)
+	}
+}
+
+func TestEventsOffWithoutFeed(t *testing.T) {
+	srv, _ := newTestServer(t, nil)
+	if code := getJSON(t, srv.URL+"/v1/events", nil); code != http.StatusNotFound {
+		t.Errorf("events without a feed: status %d, want 404", code)
+	}
+}
+
+func TestFeedRunPublishesOdometerChanges(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	store, err := storage.New()
+	if err != nil {
+		t.Fatalf("storage.New: %v", err)
+	}
+	defer store.Close()
+
+	f := NewFeed()
+	events, unsubscribe := f.subscribe()
+	defer unsubscribe()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go f.run(ctx, store)
+
+	// The first second's poll sets the baseline; once the second tick is
+	// out, it has run. Then start a session.
+	for i := 0; i < 2; i++ {
+		if ev := <-events; ev.name != "tick" {
+			t.Fatalf("event %d = %q, want tick", i, ev.name)
+		}
+	}
+	if err := store.StartLabeledOdometer("draft"); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.After(5 * time.Second)
+	for {
+		select {
+		case ev := <-events:
+			if ev.name != "odometer" {
+				continue
+			}
+			odo := ev.data.(statsjson.OdometerJSON)
+			if !odo.Active || odo.Label != "draft" {
+				t.Errorf("odometer event = %+v", odo)
+			}
+			return
+		case <-deadline:
+			t.Fatal("no odometer event after the session started")
+		}
+	}
+}
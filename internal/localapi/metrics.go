@@ -0,0 +1,135 @@
+package localapi
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// metricsContentType is the Prometheus text exposition format, version 0.0.4.
+const metricsContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// labelEscaper escapes label values per the text exposition format.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// metricsWriter writes one metric family at a time. Families must not be
+// interleaved, so each family's HELP and TYPE come right before its samples.
+type metricsWriter struct {
+	w *bufio.Writer
+}
+
+func (m metricsWriter) family(name, typ, help string) {
+	fmt.Fprintf(m.w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+// sample writes name with labels given as alternating key, value pairs.
+func (m metricsWriter) sample(name string, v float64, labels ...string) {
+	m.w.WriteString(name)
+	if len(labels) > 0 {
+		m.w.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				m.w.WriteByte(',')
+			}
+			fmt.Fprintf(m.w, `%s="%s"`, labels[i], labelEscaper.Replace(labels[i+1]))
+		}
+		m.w.WriteByte('}')
+	}
+	m.w.WriteByte(' ')
+	m.w.WriteString(strconv.FormatFloat(v, 'f', -1, 64))
+	m.w.WriteByte('\n')
+}
+
+func (m metricsWriter) single(name, typ, help string, v float64) {
+	m.family(name, typ, help)
+	m.sample(name, v)
+}
+
+// handleMetrics serves GET /metrics for Prometheus: lifetime counters, today's
+// active time and fastest paces (including the daemon's unflushed numbers),
+// today's counts for each external device, and the push loop's outcomes.
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	date := now.Format("2006-01-02")
+
+	totals, err := s.store.GetTotals()
+	if err != nil {
+		http.Error(w, "storage error", http.StatusInternalServerError)
+		return
+	}
+	today, err := s.store.GetSpeedAggregate(date)
+	if err != nil {
+		http.Error(w, "storage error", http.StatusInternalServerError)
+		return
+	}
+	devices, err := s.store.ListDevices()
+	if err != nil {
+		http.Error(w, "storage error", http.StatusInternalServerError)
+		return
+	}
+	pendingMs, pendingFastest := s.liveNow().PendingFor(date)
+
+	w.Header().Set("Content-Type", metricsContentType)
+	bw := bufio.NewWriter(w)
+	defer bw.Flush()
+	m := metricsWriter{bw}
+
+	m.single("typtel_keystrokes_total", "counter", "Keystrokes recorded on this machine.", float64(totals.Keystrokes))
+	m.single("typtel_words_total", "counter", "Words recorded on this machine.", float64(totals.Words))
+	m.single("typtel_letters_total", "counter", "Letter keystrokes recorded on this machine.", float64(totals.Letters))
+	m.single("typtel_modifiers_total", "counter", "Modifier keystrokes recorded on this machine.", float64(totals.Modifiers))
+	m.single("typtel_special_total", "counter", "Special keystrokes recorded on this machine.", float64(totals.Special))
+	m.single("typtel_mouse_clicks_total", "counter", "Mouse clicks recorded on this machine.", float64(totals.MouseClicks))
+
+	m.single("typtel_today_active_seconds", "gauge", "Active typing time today.",
+		float64(today.ActiveMs+pendingMs)/1000)
+	m.family("typtel_today_fastest_wpm", "gauge", "Fastest typing pace today by measure.")
+	m.sample("typtel_today_fastest_wpm", max(today.FastestBurstWPM, pendingFastest.Burst), "method", "burst")
+	m.sample("typtel_today_fastest_wpm", max(today.FastestWindowWPM, pendingFastest.Window), "method", "window")
+	m.sample("typtel_today_fastest_wpm", max(today.FastestMinuteWPM, pendingFastest.Minute), "method", "minute")
+
+	// Devices without a row for today are left out rather than reported as
+	// zero, so a device that stops pushing drops off its graph.
+	type deviceSample struct {
+		id, name            string
+		keys, words, active float64
+	}
+	var samples []deviceSample
+	for _, d := range devices {
+		c, err := s.store.GetDeviceDay(d.DeviceID, date)
+		if err != nil || c == nil {
+			continue
+		}
+		samples = append(samples, deviceSample{d.DeviceID, d.Name,
+			float64(c.Keystrokes), float64(c.Words), float64(c.ActiveMs) / 1000})
+	}
+	if len(samples) > 0 {
+		m.family("typtel_device_today_keystrokes", "gauge", "Keystrokes today reported by an external device.")
+		for _, d := range samples {
+			m.sample("typtel_device_today_keystrokes", d.keys, "device", d.id, "name", d.name)
+		}
+		m.family("typtel_device_today_words", "gauge", "Words today reported by an external device.")
+		for _, d := range samples {
+			m.sample("typtel_device_today_words", d.words, "device", d.id, "name", d.name)
+		}
+		m.family("typtel_device_today_active_seconds", "gauge", "Active typing time today reported by an external device.")
+		for _, d := range samples {
+			m.sample("typtel_device_today_active_seconds", d.active, "device", d.id, "name", d.name)
+		}
+	}
+
+	if s.opts.Push != nil {
+		ps := s.opts.Push.Stats()
+		m.family("typtel_push_requests_total", "counter", "Day pushes to the host by outcome.")
+		m.sample("typtel_push_requests_total", float64(ps.Succeeded), "result", "success")
+		m.sample("typtel_push_requests_total", float64(ps.Failed), "result", "failure")
+		var last float64
+		if !ps.LastSuccess.IsZero() {
+			last = float64(ps.LastSuccess.Unix())
+		}
+		m.single("typtel_push_last_success_timestamp_seconds", "gauge", "When a push last succeeded, 0 if never.", last)
+	}
+}
@@ -0,0 +1,110 @@
+package localapi
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aayushbajaj/typing-telemetry/internal/push"
+	"github.com/aayushbajaj/typing-telemetry/internal/speedtracker"
+	"github.com/aayushbajaj/typing-telemetry/internal/statsjson"
+	"github.com/aayushbajaj/typing-telemetry/internal/storage"
+)
+
+func getMetrics(t *testing.T, url string) string {
+	t.Helper()
+	resp, err := http.Get(url + "/metrics")
+	if err != nil {
+		t.Fatalf("GET /metrics: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("metrics status = %d", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != metricsContentType {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body)
+}
+
+func TestMetricsOffByDefault(t *testing.T) {
+	srv, _ := newTestServer(t, nil)
+	resp, err := http.Get(srv.URL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("/metrics without Metrics: status = %d, want 404", resp.StatusCode)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	store, err := storage.New()
+	if err != nil {
+		t.Fatalf("storage.New: %v", err)
+	}
+	defer store.Close()
+
+	date := time.Now().Format("2006-01-02")
+	for i := 0; i < 3; i++ {
+		if err := store.RecordKeystroke(4); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.RecordMouseClick(); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.UpsertDevice("work-laptop", `Work "Mac"`); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.UpsertDeviceDay("work-laptop", date, storage.DeviceDayCounts{Keystrokes: 500, Words: 90, ActiveMs: 120000}); err != nil {
+		t.Fatal(err)
+	}
+
+	host := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer host.Close()
+	client, err := push.New(push.Config{BaseURL: host.URL, Token: "t", DeviceID: "me"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.PutDay(context.Background(), date, storage.DeviceDayCounts{}); err != nil {
+		t.Fatal(err)
+	}
+
+	live := func() *statsjson.Live {
+		return &statsjson.Live{Date: date, ActiveMs: 1500, Fastest: speedtracker.Sample{Burst: 110}}
+	}
+	srv := httptest.NewServer(New(store, Options{Live: live, Metrics: true, Push: client}).Handler())
+	defer srv.Close()
+
+	body := getMetrics(t, srv.URL)
+	for _, want := range []string{
+		"# TYPE typtel_keystrokes_total counter\ntyptel_keystrokes_total 3\n",
+		"typtel_mouse_clicks_total 1\n",
+		"typtel_today_active_seconds 1.5\n",
+		`typtel_today_fastest_wpm{method="burst"} 110` + "\n",
+		`typtel_device_today_keystrokes{device="work-laptop",name="Work \"Mac\""} 500` + "\n",
+		`typtel_device_today_active_seconds{device="work-laptop",name="Work \"Mac\""} 120` + "\n",
+		`typtel_push_requests_total{result="success"} 1` + "\n",
+		`typtel_push_requests_total{result="failure"} 0` + "\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics missing %q\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "typtel_push_last_success_timestamp_seconds 0\n") {
+		t.Errorf("last push success not reported:\n%s", body)
+	}
+}
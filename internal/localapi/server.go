@@ -0,0 +1,296 @@
+// Package localapi serves typtel's statistics as read-only JSON from the
+// running capture daemon (typtel-tray or the menubar app), so status bars and
+// scripts can poll without opening the SQLite database each time. The
+// documents are the ones `typtel today --json` and `typtel stats --json`
+// print (see internal/statsjson), with the daemon's unflushed speed numbers
+// folded in. Optionally it also streams live activity as server-sent events
+// (events.go) and exposes Prometheus metrics (metrics.go).
+//
+// There is no authentication: the listener only ever binds a loopback address
+// or a unix socket (created 0600), and every route is a GET. Requests must
+// also name the server as localhost, 127.0.0.1 or [::1] in their Host header,
+// so a web page can't read the API through DNS rebinding.
+package localapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/aayushbajaj/typing-telemetry/internal/push"
+	"github.com/aayushbajaj/typing-telemetry/internal/statsjson"
+	"github.com/aayushbajaj/typing-telemetry/internal/storage"
+)
+
+// DefaultAddr is the listen address when local_api_addr is unset. The device
+// ingest API uses 8889.
+const DefaultAddr = "127.0.0.1:8890"
+
+// unixPrefix marks a unix-socket address: "unix:/path/to/typtel.sock".
+const unixPrefix = "unix:"
+
+var dateRe = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+
+// Options configures a Server. Only Addr is required.
+type Options struct {
+	Addr string
+
+	// Live, if set, returns the daemon's unflushed speed numbers. It is
+	// called once per request and must be safe for concurrent use.
+	Live func() *statsjson.Live
+
+	// Feed, if set, enables GET /v1/events.
+	Feed *Feed
+
+	// Metrics enables GET /metrics. Push, if set, is the daemon's push
+	// client, whose outcomes are reported there.
+	Metrics bool
+	Push    *push.Client
+
+	Version string
+}
+
+// Server is the local stats listener. Construct it with New and run it with
+// Start.
+type Server struct {
+	store *storage.Store
+	opts  Options
+}
+
+// New builds a Server.
+func New(store *storage.Store, opts Options) *Server {
+	return &Server{store: store, opts: opts}
+}
+
+// Handler returns the routed http.Handler. Exposed so tests can wrap it in an
+// httptest.Server without binding a real port.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/health", s.handleHealth)
+	mux.HandleFunc("GET /v1/today", s.handleToday)
+	mux.HandleFunc("GET /v1/stats", s.handleStats)
+	mux.HandleFunc("GET /v1/hourly/{date}", s.handleHourly)
+	mux.HandleFunc("GET /v1/speed", s.handleSpeed)
+	mux.HandleFunc("GET /v1/odometer", s.handleOdometer)
+	if s.opts.Feed != nil {
+		mux.HandleFunc("GET /v1/events", s.handleEvents)
+	}
+	if s.opts.Metrics {
+		mux.HandleFunc("GET /metrics", s.handleMetrics)
+	}
+	return s.checkHost(mux)
+}
+
+// loopbackHosts are the Host header names the API answers to.
+var loopbackHosts = map[string]bool{"localhost": true, "127.0.0.1": true, "::1": true}
+
+// checkHost rejects requests whose Host header isn't a loopback name with the
+// configured port. A rebinding attack reaches the listener under the
+// attacker's own domain name, which this refuses. A unix socket (or an Addr
+// with no fixed port) has no port to match, so any port is accepted.
+func (s *Server) checkHost(next http.Handler) http.Handler {
+	var port string
+	if !strings.HasPrefix(s.opts.Addr, unixPrefix) {
+		if _, p, err := net.SplitHostPort(s.opts.Addr); err == nil && p != "0" {
+			port = p
+		}
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, p, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host, p = strings.TrimSuffix(strings.TrimPrefix(r.Host, "["), "]"), ""
+		}
+		if !loopbackHosts[strings.ToLower(host)] || (port != "" && p != port) {
+			http.Error(w, "forbidden host", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Start binds the listener and serves until ctx is cancelled, at which point
+// it gracefully shuts down.
+func (s *Server) Start(ctx context.Context) error {
+	ln, err := Listen(s.opts.Addr)
+	if err != nil {
+		return err
+	}
+	srv := &http.Server{
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+		// Event streams never finish on their own; tie them to ctx so
+		// Shutdown doesn't wait them out.
+		BaseContext: func(net.Listener) context.Context { return ctx },
+	}
+	if s.opts.Feed != nil {
+		go s.opts.Feed.run(ctx, s.store)
+	}
+
+	errc := make(chan error, 1)
+	go func() {
+		err := srv.Serve(ln)
+		if errors.Is(err, http.ErrServerClosed) {
+			err = nil
+		}
+		errc <- err
+	}()
+
+	select {
+	case <-ctx.Done():
+		shutCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return srv.Shutdown(shutCtx)
+	case err := <-errc:
+		return err
+	}
+}
+
+// Listen opens addr: "unix:<path>" for a unix socket, otherwise host:port on
+// a loopback address. A stale socket file left by a crashed daemon is
+// replaced.
+func Listen(addr string) (net.Listener, error) {
+	if err := ValidateAddr(addr); err != nil {
+		return nil, err
+	}
+	if path, ok := strings.CutPrefix(addr, unixPrefix); ok {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		ln, err := net.Listen("unix", path)
+		if err != nil {
+			return nil, err
+		}
+		if err := os.Chmod(path, 0o600); err != nil {
+			ln.Close()
+			return nil, err
+		}
+		return ln, nil
+	}
+	return net.Listen("tcp", addr)
+}
+
+// ValidateAddr checks that addr is a unix socket or a loopback host:port.
+func ValidateAddr(addr string) error {
+	if path, ok := strings.CutPrefix(addr, unixPrefix); ok {
+		if path == "" {
+			return fmt.Errorf("empty unix socket path")
+		}
+		return nil
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid address %q: %w", addr, err)
+	}
+	if host == "localhost" {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return nil
+	}
+	return fmt.Errorf("refusing to serve stats on %q: bind a loopback address (127.0.0.1) or a unix: socket", addr)
+}
+
+func (s *Server) liveNow() *statsjson.Live {
+	if s.opts.Live == nil {
+		return nil
+	}
+	return s.opts.Live()
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{"ok": true, "version": s.opts.Version})
+}
+
+func (s *Server) handleToday(w http.ResponseWriter, r *http.Request) {
+	doc, err := statsjson.Today(s.store, time.Now(), s.liveNow())
+	respond(w, doc, err)
+}
+
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+	doc, err := statsjson.Stats(s.store, time.Now(), s.liveNow())
+	respond(w, doc, err)
+}
+
+func (s *Server) handleHourly(w http.ResponseWriter, r *http.Request) {
+	date := r.PathValue("date")
+	if date == "today" {
+		date = time.Now().Format("2006-01-02")
+	}
+	if !dateRe.MatchString(date) {
+		http.Error(w, "bad date", http.StatusBadRequest)
+		return
+	}
+	doc, err := statsjson.Hourly(s.store, date)
+	respond(w, doc, err)
+}
+
+func (s *Server) handleSpeed(w http.ResponseWriter, r *http.Request) {
+	doc, err := statsjson.Speed(s.store, time.Now(), s.liveNow())
+	respond(w, doc, err)
+}
+
+func (s *Server) handleOdometer(w http.ResponseWriter, r *http.Request) {
+	doc, err := statsjson.Odometer(s.store, time.Now())
+	respond(w, doc, err)
+}
+
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	events, unsubscribe := s.opts.Feed.subscribe()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	if odo, err := statsjson.Odometer(s.store, time.Now()); err == nil {
+		writeEvent(w, event{"odometer", odo})
+	}
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case ev := <-events:
+			if err := writeEvent(w, ev); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func writeEvent(w io.Writer, ev event) error {
+	data, err := json.Marshal(ev.data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.name, data)
+	return err
+}
+
+func respond(w http.ResponseWriter, doc any, err error) {
+	if err != nil {
+		http.Error(w, "storage error", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, doc)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,219 @@
+package localapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aayushbajaj/typing-telemetry/internal/speedtracker"
+	"github.com/aayushbajaj/typing-telemetry/internal/statsjson"
+	"github.com/aayushbajaj/typing-telemetry/internal/storage"
+)
+
+// newTestServer builds an httptest.Server backed by a temp-DB store. Pointing
+// $HOME at a temp dir keeps storage.New() off the real database.
+func newTestServer(t *testing.T, live func() *statsjson.Live) (*httptest.Server, *storage.Store) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	store, err := storage.New()
+	if err != nil {
+		t.Fatalf("storage.New: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	srv := httptest.NewServer(New(store, Options{Live: live, Version: "test"}).Handler())
+	t.Cleanup(srv.Close)
+	return srv, store
+}
+
+func getJSON(t *testing.T, url string, v any) int {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("GET %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusOK && v != nil {
+		if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+			t.Fatalf("decode %s: %v", url, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func TestTodayAndStats(t *testing.T) {
+	srv, store := newTestServer(t, nil)
+	for i := 0; i < 3; i++ {
+		if err := store.RecordKeystroke(4); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var today statsjson.TodayJSON
+	if code := getJSON(t, srv.URL+"/v1/today", &today); code != http.StatusOK {
+		t.Fatalf("today status = %d", code)
+	}
+	if today.Keystrokes != 3 || today.Date != time.Now().Format("2006-01-02") {
+		t.Errorf("today = %+v", today)
+	}
+
+	var stats statsjson.StatsJSON
+	if code := getJSON(t, srv.URL+"/v1/stats", &stats); code != http.StatusOK {
+		t.Fatalf("stats status = %d", code)
+	}
+	if stats.Today.Keystrokes != 3 || len(stats.Week) == 0 || stats.Speed.Live != nil {
+		t.Errorf("stats = %+v", stats)
+	}
+}
+
+func TestSpeedIncludesLive(t *testing.T) {
+	date := time.Now().Format("2006-01-02")
+	srv, store := newTestServer(t, func() *statsjson.Live {
+		return &statsjson.Live{
+			Date:       date,
+			ActiveMs:   60000,
+			Fastest:    speedtracker.Sample{Burst: 120, Window: 80, Minute: 70},
+			Last:       speedtracker.Sample{Burst: 90, Window: 60, Minute: 50},
+			LastWordAt: time.Now(),
+		}
+	})
+	if err := store.UpdateFastest(date, 100, 90, 60); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 40; i++ {
+		if err := store.IncrementWordCount(date); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var speed statsjson.SpeedJSON
+	if code := getJSON(t, srv.URL+"/v1/speed", &speed); code != http.StatusOK {
+		t.Fatalf("speed status = %d", code)
+	}
+	// 40 words over the one unflushed minute.
+	if speed.AvgWPM["day"] != 40 {
+		t.Errorf("avg day = %v, want 40", speed.AvgWPM["day"])
+	}
+	f := speed.Fastest
+	if f.BurstWPM != 120 || f.WindowWPM != 90 || f.MinuteWPM != 70 {
+		t.Errorf("fastest = %+v, want stored and pending maxima", f)
+	}
+	if speed.Live == nil || speed.Live.BurstWPM != 90 || speed.Live.LastWordAt == "" {
+		t.Errorf("live = %+v", speed.Live)
+	}
+}
+
+func TestHourly(t *testing.T) {
+	srv, _ := newTestServer(t, nil)
+
+	var hourly statsjson.HourlyJSON
+	if code := getJSON(t, srv.URL+"/v1/hourly/2025-01-02", &hourly); code != http.StatusOK {
+		t.Fatalf("hourly status = %d", code)
+	}
+	if hourly.Date != "2025-01-02" || len(hourly.Hours) != 24 {
+		t.Errorf("hourly = %+v", hourly)
+	}
+	if code := getJSON(t, srv.URL+"/v1/hourly/today", &hourly); code != http.StatusOK || hourly.Date != time.Now().Format("2006-01-02") {
+		t.Errorf("hourly/today: status %d, date %q", code, hourly.Date)
+	}
+	if code := getJSON(t, srv.URL+"/v1/hourly/yesterday", nil); code != http.StatusBadRequest {
+		t.Errorf("bad date status = %d, want 400", code)
+	}
+}
+
+func TestOdometer(t *testing.T) {
+	srv, store := newTestServer(t, nil)
+
+	var odo statsjson.OdometerJSON
+	if getJSON(t, srv.URL+"/v1/odometer", &odo); odo.Active {
+		t.Fatalf("odometer should be inactive: %+v", odo)
+	}
+	if err := store.StartLabeledOdometer("essay"); err != nil {
+		t.Fatal(err)
+	}
+	getJSON(t, srv.URL+"/v1/odometer", &odo)
+	if !odo.Active || odo.Label != "essay" || odo.StartTime == "" {
+		t.Errorf("odometer = %+v", odo)
+	}
+}
+
+func TestReadOnly(t *testing.T) {
+	srv, _ := newTestServer(t, nil)
+	req, _ := http.NewRequest(http.MethodPost, srv.URL+"/v1/today", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("POST status = %d, want 405", resp.StatusCode)
+	}
+}
+
+func TestRejectsForeignHost(t *testing.T) {
+	h := New(nil, Options{Addr: "127.0.0.1:8890"}).Handler()
+	for host, want := range map[string]int{
+		"127.0.0.1:8890":    http.StatusOK,
+		"localhost:8890":    http.StatusOK,
+		"LOCALHOST:8890":    http.StatusOK,
+		"[::1]:8890":        http.StatusOK,
+		"evil.example:8890": http.StatusForbidden, // DNS rebinding
+		"127.0.0.1.nip.io":  http.StatusForbidden,
+		"127.0.0.1:9999":    http.StatusForbidden,
+		"localhost":         http.StatusForbidden,
+		"":                  http.StatusForbidden,
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/v1/health", nil)
+		req.Host = host
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != want {
+			t.Errorf("Host %q: status = %d, want %d", host, rec.Code, want)
+		}
+	}
+
+	// A unix socket has no port to match.
+	h = New(nil, Options{Addr: "unix:/tmp/typtel.sock"}).Handler()
+	req := httptest.NewRequest(http.MethodGet, "/v1/health", nil)
+	req.Host = "localhost"
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("unix socket, Host localhost: status = %d, want 200", rec.Code)
+	}
+}
+
+func TestValidateAddr(t *testing.T) {
+	for addr, ok := range map[string]bool{
+		"127.0.0.1:8890":        true,
+		"localhost:8890":        true,
+		"[::1]:8890":            true,
+		"unix:/tmp/typtel.sock": true,
+		"0.0.0.0:8890":          false,
+		"192.168.1.5:8890":      false,
+		"8890":                  false,
+		"unix:":                 false,
+	} {
+		if err := ValidateAddr(addr); (err == nil) != ok {
+			t.Errorf("ValidateAddr(%q) = %v, want ok=%v", addr, err, ok)
+		}
+	}
+}
+
+func TestListenUnix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api.sock")
+	for i := 0; i < 2; i++ { // the second time replaces the stale socket
+		ln, err := Listen(unixPrefix + path)
+		if err != nil {
+			t.Fatalf("Listen: %v", err)
+		}
+		if i == 0 {
+			// Leave the socket file behind, as a crash would.
+			ln.(interface{ SetUnlinkOnClose(bool) }).SetUnlinkOnClose(false)
+		}
+		ln.Close()
+	}
+}
@@ -0,0 +1,253 @@
+//go:build linux
+
+package mousetracker
+
+// Linux mouse tracking. It mirrors the public surface of the darwin
+// CGEventTap implementation (Start/Stop, MouseMovement/MouseClick,
+// GetCurrentPosition, GetAveragePPI, PixelsToFeet, ...) so typtel-tray records
+// mouse_daily exactly like the menu-bar app.
+//
+// The pointer is sampled over X11 (internal/x11) at ~60 Hz. Each sample that
+// moved is reported with its straight-line distance from the previous one; a
+// click is a down-transition of the left, middle or right button (the wheel
+// "buttons" 4 and 5 are scrolling, not clicks). Distances are in root-window
+// pixels, converted to physical units with the PPI the X server reports for
+// its monitors.
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/aayushbajaj/typing-telemetry/internal/x11"
+	"github.com/jezek/xgb/xproto"
+)
+
+// MousePosition represents a mouse position with coordinates
+type MousePosition struct {
+	X float64
+	Y float64
+}
+
+// MouseMovement represents a mouse movement event with distance traveled
+type MouseMovement struct {
+	X        float64
+	Y        float64
+	Distance float64 // Euclidean distance from last position
+}
+
+// MouseClick represents a mouse click event
+type MouseClick struct{}
+
+// clickButtons are the buttons whose press counts as a click.
+const clickButtons = xproto.ButtonMask1 | xproto.ButtonMask2 | xproto.ButtonMask3
+
+var (
+	mouseChan    chan MouseMovement
+	clickChan    chan MouseClick
+	mu           sync.Mutex
+	poller       *x11.Poller
+	running      bool
+	lastX, lastY float64
+	lastButtons  uint16
+	initialized  bool
+)
+
+// onPointer is called by the poller with every changed pointer sample.
+func onPointer(p x11.Pointer) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if mouseChan == nil {
+		return
+	}
+	x, y := float64(p.X), float64(p.Y)
+	if !initialized {
+		lastX, lastY, lastButtons = x, y, p.Buttons
+		initialized = true
+		return
+	}
+
+	if dx, dy := x-lastX, y-lastY; dx != 0 || dy != 0 {
+		select {
+		case mouseChan <- MouseMovement{X: x, Y: y, Distance: math.Sqrt(dx*dx + dy*dy)}:
+		default:
+			// Channel full, drop event
+		}
+	}
+	if pressed := p.Buttons &^ lastButtons & clickButtons; pressed != 0 {
+		// One click per button pressed since the last sample.
+		for b := pressed; b != 0; b &= b - 1 {
+			select {
+			case clickChan <- MouseClick{}:
+			default:
+			}
+		}
+	}
+	lastX, lastY, lastButtons = x, y, p.Buttons
+}
+
+// CheckAccessibilityPermissions reports whether mouse tracking is possible —
+// i.e. whether an X server is reachable. There is no permission prompt.
+func CheckAccessibilityPermissions() bool {
+	return x11.Available()
+}
+
+// GetCurrentPosition returns the current mouse cursor position
+func GetCurrentPosition() MousePosition {
+	p, ok := x11.QueryPointer()
+	if !ok {
+		return MousePosition{}
+	}
+	return MousePosition{X: float64(p.X), Y: float64(p.Y)}
+}
+
+// Start begins capturing mouse movements and clicks, returns channels for both
+func Start() (<-chan MouseMovement, <-chan MouseClick, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if running {
+		return nil, nil, errors.New("mouse tracker already running")
+	}
+	if !x11.Available() {
+		return nil, nil, errors.New("cannot connect to X display (is DISPLAY set?)")
+	}
+
+	mouseChan = make(chan MouseMovement, 1000)
+	clickChan = make(chan MouseClick, 1000)
+	initialized = false
+
+	p, err := x11.StartPointerPoller(x11.DefaultPointerInterval, onPointer)
+	if err != nil {
+		close(mouseChan)
+		close(clickChan)
+		mouseChan, clickChan = nil, nil
+		return nil, nil, err
+	}
+	poller = p
+	running = true
+	return mouseChan, clickChan, nil
+}
+
+// Stop stops the mouse tracker
+func Stop() {
+	mu.Lock()
+	p := poller
+	poller = nil
+	mu.Unlock()
+	// Stop the poller outside mu: its goroutine may be waiting for mu in
+	// onPointer.
+	if p != nil {
+		p.Stop()
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if mouseChan != nil {
+		close(mouseChan)
+		mouseChan = nil
+	}
+	if clickChan != nil {
+		close(clickChan)
+		clickChan = nil
+	}
+	running = false
+	initialized = false
+}
+
+// ResetForNewDay makes the next movement start from the current position,
+// so no distance is carried across midnight.
+func ResetForNewDay() {
+	mu.Lock()
+	defer mu.Unlock()
+	initialized = false
+}
+
+// DefaultPPI is the fallback PPI when display info cannot be queried
+const DefaultPPI = 100.0
+
+// ppiCacheTTL bounds how often the X server is asked for monitor sizes;
+// PixelsToFeet is called for every figure the UI and charts render.
+const ppiCacheTTL = time.Minute
+
+var (
+	ppiMu         sync.Mutex
+	ppiCached     float64
+	displayCount  int
+	ppiQueried    time.Time
+	queryDisplays = x11.Displays
+)
+
+func refreshDisplays() {
+	ppiMu.Lock()
+	defer ppiMu.Unlock()
+	if !ppiQueried.IsZero() && time.Since(ppiQueried) < ppiCacheTTL {
+		return
+	}
+	ppiQueried = time.Now()
+	displays, ok := queryDisplays()
+	if !ok {
+		ppiCached, displayCount = 0, 0
+		return
+	}
+	ppiCached, displayCount = averagePPI(displays), len(displays)
+}
+
+// averagePPI averages the PPI of the displays that report a plausible
+// physical size. Many X servers report a made-up size (or none) for some
+// outputs; values outside 50-600 PPI are treated as unknown. Returns 0 if no
+// display has a usable size.
+func averagePPI(displays []x11.Display) float64 {
+	var sum float64
+	var n int
+	for _, d := range displays {
+		if d.WidthMM <= 0 || d.HeightMM <= 0 || d.WidthPx <= 0 || d.HeightPx <= 0 {
+			continue
+		}
+		diagPx := math.Hypot(float64(d.WidthPx), float64(d.HeightPx))
+		diagIn := math.Hypot(float64(d.WidthMM), float64(d.HeightMM)) / 25.4
+		ppi := diagPx / diagIn
+		if ppi < 50 || ppi > 600 {
+			continue
+		}
+		sum += ppi
+		n++
+	}
+	if n == 0 {
+		return 0
+	}
+	return sum / float64(n)
+}
+
+// GetAveragePPI returns the average PPI across all connected displays,
+// falling back to DefaultPPI when the X server doesn't report usable sizes.
+func GetAveragePPI() float64 {
+	refreshDisplays()
+	ppiMu.Lock()
+	defer ppiMu.Unlock()
+	if ppiCached > 0 {
+		return ppiCached
+	}
+	return DefaultPPI
+}
+
+// GetDisplayCount returns the number of active displays
+func GetDisplayCount() int {
+	refreshDisplays()
+	ppiMu.Lock()
+	defer ppiMu.Unlock()
+	return displayCount
+}
+
+// PixelsToInches converts pixel distance to inches using the average display PPI
+func PixelsToInches(pixels float64) float64 {
+	ppi := GetAveragePPI()
+	return pixels / ppi
+}
+
+// PixelsToFeet converts pixel distance to feet using the average display PPI
+func PixelsToFeet(pixels float64) float64 {
+	return PixelsToInches(pixels) / 12.0
+}
@@ -0,0 +1,113 @@
+//go:build linux
+
+package mousetracker
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/aayushbajaj/typing-telemetry/internal/x11"
+	"github.com/jezek/xgb/xproto"
+)
+
+// feed runs samples through onPointer with fresh channels and returns what
+// came out.
+func feed(t *testing.T, samples ...x11.Pointer) ([]MouseMovement, int) {
+	t.Helper()
+	mu.Lock()
+	mouseChan = make(chan MouseMovement, 100)
+	clickChan = make(chan MouseClick, 100)
+	initialized = false
+	mu.Unlock()
+	t.Cleanup(func() {
+		mu.Lock()
+		mouseChan, clickChan, initialized = nil, nil, false
+		mu.Unlock()
+	})
+
+	for _, s := range samples {
+		onPointer(s)
+	}
+	var moves []MouseMovement
+	for len(mouseChan) > 0 {
+		moves = append(moves, <-mouseChan)
+	}
+	return moves, len(clickChan)
+}
+
+func TestOnPointerMovement(t *testing.T) {
+	moves, clicks := feed(t,
+		x11.Pointer{X: 100, Y: 100}, // first sample only sets the origin
+		x11.Pointer{X: 103, Y: 104},
+		x11.Pointer{X: 103, Y: 104, Buttons: xproto.ButtonMask4}, // scroll, no movement
+		x11.Pointer{X: 100, Y: 100},
+	)
+	if clicks != 0 {
+		t.Errorf("scrolling should not count as a click, got %d", clicks)
+	}
+	if len(moves) != 2 || moves[0].Distance != 5 || moves[1].Distance != 5 {
+		t.Fatalf("moves = %+v, want two 5px moves", moves)
+	}
+	if moves[1].X != 100 || moves[1].Y != 100 {
+		t.Errorf("move should carry the new position, got %+v", moves[1])
+	}
+}
+
+func TestOnPointerClicks(t *testing.T) {
+	left, right := uint16(xproto.ButtonMask1), uint16(xproto.ButtonMask3)
+	_, clicks := feed(t,
+		x11.Pointer{Buttons: left}, // already held when tracking starts
+		x11.Pointer{},
+		x11.Pointer{Buttons: left},        // click
+		x11.Pointer{X: 10, Buttons: left}, // drag: still one click
+		x11.Pointer{X: 10},
+		x11.Pointer{X: 10, Buttons: left | right}, // both at once: two clicks
+	)
+	if clicks != 3 {
+		t.Fatalf("clicks = %d, want 3", clicks)
+	}
+}
+
+func TestAveragePPI(t *testing.T) {
+	displays := []x11.Display{
+		{WidthPx: 2560, HeightPx: 1440, WidthMM: 597, HeightMM: 336}, // 27" 1440p, ~109 PPI
+		{WidthPx: 1920, HeightPx: 1080, WidthMM: 0, HeightMM: 0},     // size unknown
+		{WidthPx: 1920, HeightPx: 1080, WidthMM: 16, HeightMM: 9},    // bogus size
+	}
+	ppi := averagePPI(displays)
+	if math.Abs(ppi-108.8) > 0.5 {
+		t.Errorf("averagePPI = %.1f, want ~108.8 (unusable sizes ignored)", ppi)
+	}
+	if averagePPI(displays[1:]) != 0 {
+		t.Error("no usable sizes should give 0")
+	}
+}
+
+func TestGetAveragePPIFallsBackAndCaches(t *testing.T) {
+	calls := 0
+	result := []x11.Display{{WidthPx: 1920, HeightPx: 1080}}
+	queryDisplays = func() ([]x11.Display, bool) {
+		calls++
+		return result, true
+	}
+	ppiQueried = time.Time{}
+	t.Cleanup(func() {
+		queryDisplays = x11.Displays
+		ppiQueried = time.Time{}
+	})
+
+	if got := GetAveragePPI(); got != DefaultPPI {
+		t.Errorf("no physical size: GetAveragePPI = %v, want DefaultPPI", got)
+	}
+	if got := GetDisplayCount(); got != 1 {
+		t.Errorf("GetDisplayCount = %d, want 1", got)
+	}
+	result = []x11.Display{{WidthPx: 2560, HeightPx: 1440, WidthMM: 597, HeightMM: 336}}
+	if GetAveragePPI() != DefaultPPI || calls != 1 {
+		t.Errorf("displays should be cached, got %d queries", calls)
+	}
+	if feet := PixelsToFeet(1200); feet != 1 {
+		t.Errorf("PixelsToFeet(1200) at 100 PPI = %v, want 1", feet)
+	}
+}
@@ -0,0 +1,8 @@
+// Package notify shows desktop notifications from the capture daemons: over
+// D-Bus (org.freedesktop.Notifications) on Linux and through Notification
+// Center on macOS. Notifications are best-effort; callers log a failed Send
+// and carry on.
+package notify
+
+// AppName is shown as the notification's source where the platform has one.
+const AppName = "typtel"
@@ -0,0 +1,48 @@
+//go:build linux
+
+package notify
+
+import (
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	busName    = "org.freedesktop.Notifications"
+	objectPath = "/org/freedesktop/Notifications"
+	notifyCall = busName + ".Notify"
+	// expireMs is how long the server should show a notification.
+	expireMs = int32(10000)
+)
+
+var (
+	connMu sync.Mutex
+	conn   *dbus.Conn
+)
+
+// Send shows a notification with the given title and body. It fails if no
+// session bus or notification server is running (e.g. a bare WM without a
+// notification daemon such as dunst).
+func Send(title, body string) error {
+	connMu.Lock()
+	defer connMu.Unlock()
+	if conn == nil {
+		c, err := dbus.ConnectSessionBus()
+		if err != nil {
+			return err
+		}
+		conn = c
+	}
+	// Notify(app_name, replaces_id, app_icon, summary, body, actions, hints,
+	// expire_timeout) -> id
+	call := conn.Object(busName, objectPath).Call(notifyCall, 0,
+		AppName, uint32(0), "input-keyboard", title, body,
+		[]string{}, map[string]dbus.Variant{}, expireMs)
+	if call.Err != nil && !conn.Connected() {
+		// The bus went away; reconnect on the next Send.
+		conn.Close()
+		conn = nil
+	}
+	return call.Err
+}
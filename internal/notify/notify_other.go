@@ -0,0 +1,10 @@
+//go:build !linux && !darwin
+
+package notify
+
+import "errors"
+
+// Send is not supported on this platform.
+func Send(title, body string) error {
+	return errors.New("desktop notifications are not supported on this platform")
+}
@@ -18,10 +18,12 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/aayushbajaj/typing-telemetry/internal/storage"
@@ -43,11 +45,58 @@ type Config struct {
 	Timeout  time.Duration
 }
 
-// Client posts daily aggregates to a host's ingest API.
+// Client posts daily aggregates to a host's ingest API. It is safe for
+// concurrent use.
 type Client struct {
 	cfg   Config
 	base  string
 	httpc *http.Client
+
+	mu    sync.Mutex
+	stats Stats
+	batch batchSupport
+}
+
+// batchSupport is whether the host takes batch PUTs, learnt from /v1/health.
+type batchSupport int
+
+const (
+	batchUnknown batchSupport = iota
+	batchYes
+	batchNo
+)
+
+// batchAPIVersion is the ingest API version that added the batch PUT
+// (ingest.APIVersion). Hosts before it report no api field in /v1/health.
+const batchAPIVersion = 2
+
+// MaxBatchDays mirrors the host's cap on days per batch PUT
+// (ingest.MaxBatchDays); PutDays splits longer lists.
+const MaxBatchDays = 500
+
+// Stats counts a Client's PUTs since it was created.
+type Stats struct {
+	Succeeded   uint64
+	Failed      uint64
+	LastSuccess time.Time // zero until the first success
+}
+
+// Stats returns the PUT outcomes so far.
+func (c *Client) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats
+}
+
+func (c *Client) record(err error) {
+	c.mu.Lock()
+	if err == nil {
+		c.stats.Succeeded++
+		c.stats.LastSuccess = time.Now()
+	} else {
+		c.stats.Failed++
+	}
+	c.mu.Unlock()
 }
 
 // New validates cfg and returns a ready Client.
@@ -76,7 +125,8 @@ func New(cfg Config) (*Client, error) {
 	}, nil
 }
 
-// Health calls the unauthenticated liveness probe; nil means reachable.
+// Health calls the unauthenticated liveness probe; nil means reachable. It
+// also notes whether the host takes batch PUTs.
 func (c *Client) Health(ctx context.Context) error {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.base+"/v1/health", nil)
 	if err != nil {
@@ -90,51 +140,145 @@ func (c *Client) Health(ctx context.Context) error {
 	if resp.StatusCode != http.StatusOK {
 		return fmt.Errorf("push: health check returned %s", resp.Status)
 	}
+	var health struct {
+		API int `json:"api"`
+	}
+	_ = json.NewDecoder(io.LimitReader(resp.Body, 4<<10)).Decode(&health)
+	c.mu.Lock()
+	if health.API >= batchAPIVersion {
+		c.batch = batchYes
+	} else {
+		c.batch = batchNo
+	}
+	c.mu.Unlock()
 	return nil
 }
 
+// supportsBatch reports whether the host takes batch PUTs, asking /v1/health
+// the first time.
+func (c *Client) supportsBatch(ctx context.Context) (bool, error) {
+	c.mu.Lock()
+	b := c.batch
+	c.mu.Unlock()
+	if b == batchUnknown {
+		if err := c.Health(ctx); err != nil {
+			return false, err
+		}
+		c.mu.Lock()
+		b = c.batch
+		c.mu.Unlock()
+	}
+	return b == batchYes, nil
+}
+
 // PutDay uploads one day's absolute counts. A non-empty Config.Name is sent as
 // a ?name= query so the host can show a friendly name instead of the bare id.
-func (c *Client) PutDay(ctx context.Context, date string, counts storage.DeviceDayCounts) error {
+func (c *Client) PutDay(ctx context.Context, date string, counts storage.DeviceDayCounts) (err error) {
+	defer func() { c.record(err) }()
 	body, err := json.Marshal(counts)
 	if err != nil {
 		return err
 	}
-	endpoint := fmt.Sprintf("%s/v1/devices/%s/days/%s", c.base, c.cfg.DeviceID, date)
-	if c.cfg.Name != "" {
-		endpoint += "?name=" + url.QueryEscape(c.cfg.Name)
-	}
-	req, err := http.NewRequestWithContext(ctx, http.MethodPut, endpoint, bytes.NewReader(body))
+	resp, err := c.do(ctx, http.MethodPut, fmt.Sprintf("%s/v1/devices/%s/days/%s", c.base, c.cfg.DeviceID, date), body)
 	if err != nil {
 		return err
 	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+c.cfg.Token)
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		// Deliberately omit the body/token from the error.
+		return fmt.Errorf("push: PUT day returned %s", resp.Status)
+	}
+	return nil
+}
 
-	resp, err := c.httpc.Do(req)
+// PutDays uploads many days' absolute counts, in requests of up to
+// MaxBatchDays days that the host writes in one transaction each. Hosts
+// without the batch route get one PutDay per day instead. It returns how many
+// of days, from the start, the host acknowledged.
+func (c *Client) PutDays(ctx context.Context, days []storage.DeviceDay) (int, error) {
+	batch, err := c.supportsBatch(ctx)
+	if err != nil {
+		return 0, err
+	}
+	sent := 0
+	for sent < len(days) {
+		if !batch {
+			d := days[sent]
+			if err := c.PutDay(ctx, d.Date, d.DeviceDayCounts); err != nil {
+				return sent, err
+			}
+			sent++
+			continue
+		}
+		n := min(len(days)-sent, MaxBatchDays)
+		if err := c.putBatch(ctx, days[sent:sent+n]); err != nil {
+			return sent, err
+		}
+		sent += n
+	}
+	return sent, nil
+}
+
+// batchDay is one entry of a batch PUT body (ingest.BatchDay).
+type batchDay struct {
+	Date   string                  `json:"date"`
+	Counts storage.DeviceDayCounts `json:"counts"`
+}
+
+func (c *Client) putBatch(ctx context.Context, days []storage.DeviceDay) (err error) {
+	defer func() { c.record(err) }()
+	entries := make([]batchDay, len(days))
+	for i, d := range days {
+		entries[i] = batchDay{Date: d.Date, Counts: d.DeviceDayCounts}
+	}
+	body, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+	resp, err := c.do(ctx, http.MethodPut, fmt.Sprintf("%s/v1/devices/%s/days", c.base, c.cfg.DeviceID), body)
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusNoContent {
-		// Deliberately omit the body/token from the error.
-		return fmt.Errorf("push: PUT day returned %s", resp.Status)
+		return fmt.Errorf("push: batch PUT returned %s", resp.Status)
 	}
 	return nil
 }
 
+// do sends an authenticated JSON request, adding ?name= when configured.
+func (c *Client) do(ctx context.Context, method, endpoint string, body []byte) (*http.Response, error) {
+	if c.cfg.Name != "" {
+		endpoint += "?name=" + url.QueryEscape(c.cfg.Name)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.cfg.Token)
+	return c.httpc.Do(req)
+}
+
 // PushToday uploads today's local aggregates.
 func (c *Client) PushToday(ctx context.Context, store *storage.Store) error {
 	return c.PushDay(ctx, store, time.Now().Format("2006-01-02"))
 }
 
-// PushDay uploads the local aggregates for a specific YYYY-MM-DD date.
+// PushDay uploads the local aggregates for a specific YYYY-MM-DD date and
+// records the outcome for the retry queue (see Sync).
 func (c *Client) PushDay(ctx context.Context, store *storage.Store, date string) error {
 	stats, err := store.GetDayStats(date)
 	if err != nil {
 		return err
 	}
-	return c.PutDay(ctx, date, toCounts(stats))
+	states, err := store.GetPushDays(date)
+	if err != nil {
+		return err
+	}
+	counts := toCounts(stats)
+	d := PendingDay{Date: date, Counts: counts, Hash: Hash(counts), State: states[date]}
+	return c.send(ctx, store, d, time.Now(), LoopConfig{}.withDefaults().backoff)
 }
 
 // toCounts maps a local DailyStats to the wire shape (1:1 fields).
@@ -169,39 +313,55 @@ func LoadConfig(store *storage.Store) (cfg Config, enabled bool, err error) {
 // LoopConfig tunes RunLoop.
 type LoopConfig struct {
 	Interval time.Duration // push cadence; defaults to 45s when <= 0
-	Logf     func(string, ...any)
+
+	// Window is how many days back, today included, are re-sent until the
+	// host acknowledges them; <= 0 reads push_retry_days on every tick.
+	Window int
+
+	// MaxBackoff caps the retry delay of a failing day, which starts at
+	// Interval and doubles per failure; defaults to DefaultMaxBackoff.
+	MaxBackoff time.Duration
+
+	Logf func(string, ...any)
 }
 
-// RunLoop periodically pushes today's counts until ctx is cancelled. It pushes
-// once immediately, then on each tick. When the local date rolls over it pushes
-// the previous day one last time before switching to the new day, so the final
-// totals for a day are not stranded. Errors are logged (if Logf is set) and the
-// loop continues — pushing is best-effort.
-func RunLoop(ctx context.Context, store *storage.Store, c *Client, lc LoopConfig) {
+func (lc LoopConfig) withDefaults() LoopConfig {
 	if lc.Interval <= 0 {
 		lc.Interval = 45 * time.Second
 	}
-	logf := lc.Logf
-	if logf == nil {
-		logf = func(string, ...any) {}
+	if lc.MaxBackoff <= 0 {
+		lc.MaxBackoff = DefaultMaxBackoff
+	}
+	if lc.Logf == nil {
+		lc.Logf = func(string, ...any) {}
 	}
+	return lc
+}
 
-	lastDate := time.Now().Format("2006-01-02")
-	push := func() {
-		now := time.Now().Format("2006-01-02")
-		if now != lastDate {
-			// Flush the day that just ended before moving on.
-			if err := c.PushDay(ctx, store, lastDate); err != nil {
-				logf("[push] %s: %v", lastDate, err)
-			}
-			lastDate = now
-		}
-		if err := c.PushDay(ctx, store, now); err != nil {
-			logf("[push] %s: %v", now, err)
+// backoff is the delay before retrying a day that has failed attempts times.
+func (lc LoopConfig) backoff(attempts int) time.Duration {
+	d := lc.Interval
+	for i := 1; i < attempts && d < lc.MaxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, lc.MaxBackoff)
+}
+
+// RunLoop periodically syncs the retry window until ctx is cancelled: once
+// immediately, then on each tick. Each day is pushed whenever its counts
+// differ from what the host last acknowledged, so today is sent as it grows,
+// a day that ended is sent once more with its final totals, and days missed
+// while the host was unreachable are caught up when it returns. Errors are
+// logged (if Logf is set) and the loop continues — pushing is best-effort.
+func RunLoop(ctx context.Context, store *storage.Store, c *Client, lc LoopConfig) {
+	lc = lc.withDefaults()
+	pushOnce := func() {
+		if _, err := c.Sync(ctx, store, time.Now(), lc); err != nil {
+			lc.Logf("[push] %v", err)
 		}
 	}
 
-	push()
+	pushOnce()
 	ticker := time.NewTicker(lc.Interval)
 	defer ticker.Stop()
 	for {
@@ -209,7 +369,7 @@ func RunLoop(ctx context.Context, store *storage.Store, c *Client, lc LoopConfig
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			push()
+			pushOnce()
 		}
 	}
 }
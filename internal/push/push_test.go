@@ -2,7 +2,12 @@ package push
 
 import (
 	"context"
+	"fmt"
+	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -100,6 +105,9 @@ func TestHealthAndPushToday(t *testing.T) {
 	if devices[0].Name != "Kali Box" {
 		t.Fatalf("device name = %q, want %q", devices[0].Name, "Kali Box")
 	}
+	if st := c.Stats(); st.Succeeded != 1 || st.Failed != 0 || st.LastSuccess.IsZero() {
+		t.Errorf("stats = %+v, want one success", st)
+	}
 }
 
 func TestWrongTokenRejected(t *testing.T) {
@@ -111,8 +119,190 @@ func TestWrongTokenRejected(t *testing.T) {
 	if err := c.PutDay(context.Background(), todayStr(), storage.DeviceDayCounts{Keystrokes: 1}); err == nil {
 		t.Fatal("expected auth error with wrong token, got nil")
 	}
+	if st := c.Stats(); st.Succeeded != 0 || st.Failed != 1 {
+		t.Errorf("stats = %+v, want one failure", st)
+	}
 }
 
 func todayStr() string {
 	return time.Now().Format("2006-01-02")
 }
+
+func TestSyncRetriesWithBackoff(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	hostStore, err := storage.New()
+	if err != nil {
+		t.Fatalf("host store: %v", err)
+	}
+	defer hostStore.Close()
+	var down atomic.Bool
+	h := ingest.New(hostStore, testToken, "", nil, "test").Handler()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			http.Error(w, "down", http.StatusServiceUnavailable)
+			return
+		}
+		h.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+
+	t.Setenv("HOME", t.TempDir())
+	devStore, err := storage.New()
+	if err != nil {
+		t.Fatalf("device store: %v", err)
+	}
+	defer devStore.Close()
+	if err := devStore.RecordKeystroke(0); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := New(Config{BaseURL: ts.URL, Token: testToken, DeviceID: "kali"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	lc := LoopConfig{Interval: time.Minute, Window: 7}
+	// Two days later: today is no longer today, but still in the window.
+	now := time.Now().AddDate(0, 0, 2)
+
+	down.Store(true)
+	if n, err := c.Sync(ctx, devStore, now, lc); err == nil || n != 0 {
+		t.Fatalf("sync while down = %d, %v; want an error", n, err)
+	}
+	// Backing off: the next tick doesn't retry.
+	if n, err := c.Sync(ctx, devStore, now.Add(30*time.Second), lc); err != nil || n != 0 {
+		t.Fatalf("sync during backoff = %d, %v; want 0, nil", n, err)
+	}
+	if st := c.Stats(); st.Failed != 1 {
+		t.Fatalf("failed pushes = %d, want 1", st.Failed)
+	}
+
+	down.Store(false)
+	if n, err := c.Sync(ctx, devStore, now.Add(time.Minute), lc); err != nil || n != 1 {
+		t.Fatalf("sync after backoff = %d, %v; want 1, nil", n, err)
+	}
+	if got, _ := hostStore.GetDeviceDay("kali", todayStr()); got == nil || got.Keystrokes != 1 {
+		t.Fatalf("host day = %+v, want 1 keystroke", got)
+	}
+	// Acknowledged and unchanged: nothing to send.
+	if n, err := c.Sync(ctx, devStore, now.Add(2*time.Minute), lc); err != nil || n != 0 {
+		t.Fatalf("sync when clean = %d, %v; want 0, nil", n, err)
+	}
+	// New activity makes the day dirty again.
+	if err := devStore.RecordKeystroke(0); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := c.Sync(ctx, devStore, now.Add(3*time.Minute), lc); err != nil || n != 1 {
+		t.Fatalf("sync after change = %d, %v; want 1, nil", n, err)
+	}
+
+	// Backfill re-sends regardless of what the host has.
+	var sent []string
+	n, err := c.Backfill(ctx, devStore, "", func(date string) { sent = append(sent, date) })
+	if err != nil || n != 1 || len(sent) != 1 || sent[0] != todayStr() {
+		t.Fatalf("backfill = %d, %v, %v", n, err, sent)
+	}
+	if got, _ := hostStore.GetDeviceDay("kali", todayStr()); got == nil || got.Keystrokes != 2 {
+		t.Fatalf("host day = %+v, want 2 keystrokes", got)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	lc := LoopConfig{Interval: time.Minute, MaxBackoff: 10 * time.Minute}.withDefaults()
+	for attempts, want := range map[int]time.Duration{
+		1:  time.Minute,
+		2:  2 * time.Minute,
+		4:  8 * time.Minute,
+		5:  10 * time.Minute,
+		50: 10 * time.Minute,
+	} {
+		if got := lc.backoff(attempts); got != want {
+			t.Errorf("backoff(%d) = %v, want %v", attempts, got, want)
+		}
+	}
+}
+
+// seedDays imports daily_summary rows with the given keystrokes per date.
+func seedDays(t *testing.T, store *storage.Store, days map[string]int) {
+	t.Helper()
+	var b strings.Builder
+	fmt.Fprintf(&b, `{"kind":"header","data":{"format":%q,"version":%d,"schema_version":%d}}`+"\n",
+		storage.BundleFormat, storage.BundleVersion, storage.LatestSchemaVersion())
+	for date, n := range days {
+		fmt.Fprintf(&b, `{"kind":"daily_summary","data":{"date":%q,"keystrokes":%d,"letters":%d}}`+"\n", date, n, n)
+	}
+	if _, err := store.Import(strings.NewReader(b.String()), storage.ImportOptions{}); err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+}
+
+// recordingHost is an ingest host that logs each request's method and path,
+// optionally posing as a host from before the batch route.
+func recordingHost(t *testing.T, old bool) (string, *storage.Store, *[]string) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	hostStore, err := storage.New()
+	if err != nil {
+		t.Fatalf("host store: %v", err)
+	}
+	t.Cleanup(func() { hostStore.Close() })
+	h := ingest.New(hostStore, testToken, "", nil, "test").Handler()
+	var mu sync.Mutex
+	var reqs []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		reqs = append(reqs, r.Method+" "+r.URL.Path)
+		mu.Unlock()
+		if old {
+			if r.URL.Path == "/v1/health" {
+				w.Write([]byte(`{"ok":true,"version":"1.5.0"}`))
+				return
+			}
+			if r.Method == http.MethodPut && strings.HasSuffix(r.URL.Path, "/days") {
+				http.NotFound(w, r)
+				return
+			}
+		}
+		h.ServeHTTP(w, r)
+	}))
+	t.Cleanup(ts.Close)
+	return ts.URL, hostStore, &reqs
+}
+
+func TestBackfillBatches(t *testing.T) {
+	for _, old := range []bool{false, true} {
+		base, hostStore, reqs := recordingHost(t, old)
+		t.Setenv("HOME", t.TempDir())
+		devStore, err := storage.New()
+		if err != nil {
+			t.Fatal(err)
+		}
+		seedDays(t, devStore, map[string]int{"2026-01-01": 10, "2026-01-02": 20, "2026-01-03": 30})
+
+		c, err := New(Config{BaseURL: base, Token: testToken, DeviceID: "kali"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var sent []string
+		n, err := c.Backfill(context.Background(), devStore, "2026-01-02", func(date string) { sent = append(sent, date) })
+		devStore.Close()
+		if err != nil || n != 2 {
+			t.Fatalf("old=%v: backfill = %d, %v", old, n, err)
+		}
+		if strings.Join(sent, ",") != "2026-01-02,2026-01-03" {
+			t.Errorf("old=%v: progress = %v", old, sent)
+		}
+		days, _ := hostStore.GetDeviceDays("kali", "")
+		if len(days) != 2 || days[0].Keystrokes != 30 || days[1].Keystrokes != 20 {
+			t.Errorf("old=%v: host days = %+v", old, days)
+		}
+
+		want := "GET /v1/health,PUT /v1/devices/kali/days"
+		if old {
+			want = "GET /v1/health,PUT /v1/devices/kali/days/2026-01-02,PUT /v1/devices/kali/days/2026-01-03"
+		}
+		if got := strings.Join(*reqs, ","); got != want {
+			t.Errorf("old=%v: requests = %s, want %s", old, got, want)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package push
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aayushbajaj/typing-telemetry/internal/storage"
+)
+
+// DefaultMaxBackoff caps the delay between retries of a day that keeps
+// failing.
+const DefaultMaxBackoff = time.Hour
+
+// Target identifies where c pushes: this device's id at the host. Days the
+// host acknowledged are pushed again after switching to another target.
+func (c *Client) Target() string {
+	return c.cfg.DeviceID + "@" + c.base
+}
+
+// Hash fingerprints a day's counts, so a day is only re-sent when they change.
+func Hash(counts storage.DeviceDayCounts) string {
+	b, _ := json.Marshal(counts)
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:8])
+}
+
+// PendingDay is a date whose current counts the host hasn't acknowledged.
+type PendingDay struct {
+	Date   string
+	Counts storage.DeviceDayCounts
+	Hash   string
+	State  storage.PushDay // zero if the day was never attempted
+}
+
+// Pending returns the days of the window days ending at now, oldest first,
+// whose counts the host hasn't acknowledged. Days without activity that were
+// never pushed are left out.
+func (c *Client) Pending(store *storage.Store, now time.Time, window int) ([]PendingDay, error) {
+	first := now.AddDate(0, 0, -(window - 1)).Format("2006-01-02")
+	states, err := store.GetPushDays(first)
+	if err != nil {
+		return nil, err
+	}
+	var out []PendingDay
+	for i := window - 1; i >= 0; i-- {
+		date := now.AddDate(0, 0, -i).Format("2006-01-02")
+		stats, err := store.GetDayStats(date)
+		if err != nil {
+			return nil, err
+		}
+		counts := toCounts(stats)
+		state, seen := states[date]
+		if !seen && counts == (storage.DeviceDayCounts{}) {
+			continue
+		}
+		hash := Hash(counts)
+		if state.Acked(c.Target(), hash) {
+			continue
+		}
+		out = append(out, PendingDay{Date: date, Counts: counts, Hash: hash, State: state})
+	}
+	return out, nil
+}
+
+// Sync pushes the pending days in the retry window, oldest first and in one
+// batch when there are several, skipping days still backing off from an
+// earlier failure. It stops at the first failure, since the host is most
+// likely unreachable, and schedules that day's retry. It returns how many
+// days were pushed.
+func (c *Client) Sync(ctx context.Context, store *storage.Store, now time.Time, lc LoopConfig) (int, error) {
+	lc = lc.withDefaults()
+	window := lc.Window
+	if window <= 0 {
+		window = store.GetPushRetryDays()
+	}
+	days, err := c.Pending(store, now, window)
+	if err != nil {
+		return 0, err
+	}
+	var due []PendingDay
+	for _, d := range days {
+		if !now.Before(d.State.NextAttempt) {
+			due = append(due, d)
+		}
+	}
+	return c.sendDays(ctx, store, due, now, lc.backoff)
+}
+
+// Backfill pushes every day with activity on or after sinceDate (empty = all
+// history), oldest first and in batches of MaxBatchDays, whether or not the
+// host already has it. progress, if set, is called for each day once the host
+// has acknowledged it. It stops at the first failure.
+func (c *Client) Backfill(ctx context.Context, store *storage.Store, sinceDate string, progress func(date string)) (int, error) {
+	days, err := store.GetSelfDays(sinceDate)
+	if err != nil {
+		return 0, err
+	}
+	states, err := store.GetPushDays(sinceDate)
+	if err != nil {
+		return 0, err
+	}
+	backoff := LoopConfig{}.withDefaults().backoff
+	now := time.Now()
+	pushed := 0
+	for end := len(days); end > 0; end -= MaxBatchDays {
+		var chunk []PendingDay
+		for i := end - 1; i >= max(end-MaxBatchDays, 0); i-- {
+			d := PendingDay{Date: days[i].Date, Counts: days[i].DeviceDayCounts, State: states[days[i].Date]}
+			d.Hash = Hash(d.Counts)
+			chunk = append(chunk, d)
+		}
+		n, err := c.sendDays(ctx, store, chunk, now, backoff)
+		pushed += n
+		if progress != nil {
+			for _, d := range chunk[:n] {
+				progress(d.Date)
+			}
+		}
+		if err != nil {
+			return pushed, err
+		}
+	}
+	return pushed, nil
+}
+
+// sendDays pushes days, in one batch PUT where the host supports it, and
+// records each acknowledged hash. At the first failure it records the failure
+// and when to retry against the day that failed, and stops. It returns how
+// many days were pushed.
+func (c *Client) sendDays(ctx context.Context, store *storage.Store, days []PendingDay, now time.Time, backoff func(int) time.Duration) (int, error) {
+	switch len(days) {
+	case 0:
+		return 0, nil
+	case 1:
+		// A single day goes over the per-day route every host has.
+		if err := c.send(ctx, store, days[0], now, backoff); err != nil {
+			return 0, fmt.Errorf("%s: %w", days[0].Date, err)
+		}
+		return 1, nil
+	}
+	batch := make([]storage.DeviceDay, len(days))
+	for i, d := range days {
+		batch[i] = storage.DeviceDay{Date: d.Date, DeviceDayCounts: d.Counts}
+	}
+	n, err := c.PutDays(ctx, batch)
+	for _, d := range days[:n] {
+		if merr := store.MarkDayPushed(d.Date, c.Target(), d.Hash, now); merr != nil && err == nil {
+			err = merr
+		}
+	}
+	if err != nil && n < len(days) {
+		d := days[n]
+		attempts := d.State.Attempts + 1
+		_ = store.MarkDayPushFailed(d.Date, attempts, now.Add(backoff(attempts)), err.Error())
+		err = fmt.Errorf("%s: %w", d.Date, err)
+	}
+	return n, err
+}
+
+// send pushes d and records the outcome: the acknowledged hash on success,
+// otherwise the failure and when to retry.
+func (c *Client) send(ctx context.Context, store *storage.Store, d PendingDay, now time.Time, backoff func(int) time.Duration) error {
+	if err := c.PutDay(ctx, d.Date, d.Counts); err != nil {
+		attempts := d.State.Attempts + 1
+		_ = store.MarkDayPushFailed(d.Date, attempts, now.Add(backoff(attempts)), err.Error())
+		return err
+	}
+	return store.MarkDayPushed(d.Date, c.Target(), d.Hash, now)
+}
@@ -0,0 +1,83 @@
+package rsiguard
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Reminder wraps a Guard for the daemons: the keystroke and mouse goroutines
+// report activity, and a background loop ticks it and acts on the events.
+// Events from Activity are queued for the next Tick, so capture never waits
+// on whatever the loop does with them. The zero value is ready to use, with
+// reminders off until a Tick enables them. A Reminder is goroutine-safe.
+type Reminder struct {
+	mu     sync.Mutex
+	guard  *Guard // nil while reminders are off
+	events []Event
+}
+
+// Activity records keyboard or mouse activity at now.
+func (r *Reminder) Activity(now time.Time) {
+	r.mu.Lock()
+	if r.guard != nil {
+		r.events = append(r.events, r.guard.OnActivity(now)...)
+	}
+	r.mu.Unlock()
+}
+
+// Tick applies cfg and returns the events queued since the last tick plus
+// any that fall due by now. Turning reminders off drops the work tracked so
+// far.
+func (r *Reminder) Tick(enabled bool, cfg Config, now time.Time) []Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !enabled {
+		r.guard, r.events = nil, nil
+		return nil
+	}
+	if r.guard == nil {
+		r.guard = New(cfg)
+	}
+	r.guard.SetConfig(cfg)
+	events := append(r.events, r.guard.Tick(now)...)
+	r.events = nil
+	return events
+}
+
+// Record reports whether ev belongs in the break log, and when the break was
+// taken (zero for a skipped one). Every skipped break is kept. A taken break
+// is kept when it was prompted, or when it followed at least cfg.MicroAfter
+// of work: long pauses after a few keystrokes aren't worth recording.
+func Record(ev Event, cfg Config) (takenAt time.Time, ok bool) {
+	switch ev.Type {
+	case Taken:
+		return ev.At, !ev.DueAt.IsZero() || ev.Worked >= cfg.MicroAfter
+	case Skipped:
+		return time.Time{}, true
+	}
+	return time.Time{}, false
+}
+
+// Notification is the title and body of the prompt for a Due event.
+func Notification(ev Event, cfg Config) (title, body string) {
+	worked := FormatDuration(ev.Worked)
+	if ev.Kind == Long {
+		return "Time for a break",
+			fmt.Sprintf("You've been at the keyboard for %s. Step away for %s.", worked, FormatDuration(cfg.LongBreak))
+	}
+	return "Time for a micro-break",
+		fmt.Sprintf("%s of continuous typing. Look away and relax your hands for %s.", worked, FormatDuration(cfg.MicroBreak))
+}
+
+// FormatDuration renders d as "20 seconds", "25 minutes" or "1h5m".
+func FormatDuration(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%d seconds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%d minutes", int(d.Minutes()))
+	}
+	return strings.TrimSuffix(d.Round(time.Minute).String(), "0s")
+}
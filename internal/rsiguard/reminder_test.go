@@ -0,0 +1,71 @@
+package rsiguard
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReminderOffUntilEnabled(t *testing.T) {
+	var r Reminder
+	r.Activity(at(0))
+	if events := r.Tick(false, testConfig, at(time.Hour)); events != nil {
+		t.Fatalf("disabled reminder returned %+v", events)
+	}
+
+	// Enabled, activity is tracked and queued for the tick.
+	r.Tick(true, testConfig, at(0))
+	for d := time.Duration(0); d <= 10*time.Minute; d += 5 * time.Second {
+		r.Activity(at(d))
+	}
+	events := r.Tick(true, testConfig, at(10*time.Minute))
+	if len(events) != 1 || events[0].Type != Due || events[0].Kind != Micro {
+		t.Fatalf("events = %+v, want a micro-break due", events)
+	}
+
+	// Turning reminders off drops the work tracked so far: the next
+	// micro-break is due 10 minutes after re-enabling, not at 20.
+	r.Tick(false, testConfig, at(11*time.Minute))
+	r.Tick(true, testConfig, at(11*time.Minute))
+	for d := 11 * time.Minute; d <= 20*time.Minute; d += 5 * time.Second {
+		r.Activity(at(d))
+	}
+	if events := r.Tick(true, testConfig, at(20*time.Minute)); len(events) != 0 {
+		t.Fatalf("events after re-enabling = %+v, want none yet", events)
+	}
+}
+
+func TestRecord(t *testing.T) {
+	cases := []struct {
+		name   string
+		ev     Event
+		record bool
+		taken  bool
+	}{
+		{"due", Event{Type: Due, DueAt: at(0)}, false, false},
+		{"prompted taken", Event{Type: Taken, At: at(time.Minute), DueAt: at(0), Worked: time.Minute}, true, true},
+		{"unprompted after work", Event{Type: Taken, At: at(time.Minute), Worked: testConfig.MicroAfter}, true, true},
+		{"unprompted after a few keys", Event{Type: Taken, At: at(time.Minute), Worked: time.Minute}, false, true},
+		{"skipped", Event{Type: Skipped, At: at(0), DueAt: at(0)}, true, false},
+	}
+	for _, c := range cases {
+		takenAt, ok := Record(c.ev, testConfig)
+		if ok != c.record {
+			t.Errorf("%s: record = %v, want %v", c.name, ok, c.record)
+		}
+		if ok && takenAt.IsZero() == c.taken {
+			t.Errorf("%s: takenAt = %v", c.name, takenAt)
+		}
+	}
+}
+
+func TestNotification(t *testing.T) {
+	title, body := Notification(Event{Type: Due, Kind: Long, Worked: 65 * time.Minute}, testConfig)
+	if title != "Time for a break" || !strings.Contains(body, "1h5m") || !strings.Contains(body, "5 minutes") {
+		t.Errorf("long notification = %q, %q", title, body)
+	}
+	title, body = Notification(Event{Type: Due, Kind: Micro, Worked: 10 * time.Minute}, testConfig)
+	if title != "Time for a micro-break" || !strings.Contains(body, "10 minutes") || !strings.Contains(body, "30 seconds") {
+		t.Errorf("micro notification = %q, %q", title, body)
+	}
+}
@@ -0,0 +1,191 @@
+// Package rsiguard decides when a break from typing is due and whether one
+// was taken. It tracks continuous work — keyboard or mouse activity with no
+// pause long enough to count as a break — and prompts for a short micro-break
+// and a longer rest break after configurable stretches of it.
+//
+// Like speedtracker it is pure: no storage, no notifications, no clock of its
+// own. The daemon reports activity and calls Tick periodically, passing the
+// time into each call, and acts on the Events returned. A Guard is not
+// goroutine-safe.
+package rsiguard
+
+import "time"
+
+// Kind is the kind of break.
+type Kind string
+
+const (
+	Micro Kind = "micro"
+	Long  Kind = "long"
+)
+
+// Config sets when breaks are due and what counts as one. A zero After
+// disables prompts for that kind.
+type Config struct {
+	MicroAfter time.Duration // continuous work before a micro-break is due
+	MicroBreak time.Duration // pause that counts as a micro-break
+	LongAfter  time.Duration // work since the last long break before one is due
+	LongBreak  time.Duration // pause that counts as a long break
+}
+
+// DefaultConfig follows the 20-20-20 rule for micro-breaks (a 20-second
+// pause every 20 minutes) and asks for five minutes away every hour.
+var DefaultConfig = Config{
+	MicroAfter: 20 * time.Minute,
+	MicroBreak: 20 * time.Second,
+	LongAfter:  60 * time.Minute,
+	LongBreak:  5 * time.Minute,
+}
+
+// EventType says what happened.
+type EventType int
+
+const (
+	// Due: a break is due now; prompt the user.
+	Due EventType = iota + 1
+	// Taken: a break was taken. Emitted for every long break, and for a
+	// micro-break only when one had been prompted — pauses of micro-break
+	// length happen all the time and are not worth recording on their own.
+	Taken
+	// Skipped: a prompted break was never taken, and it is due again.
+	Skipped
+)
+
+// Event is reported by OnActivity and Tick.
+type Event struct {
+	Type EventType
+	Kind Kind
+	// At is when the break became due (Due, Skipped) or began (Taken).
+	At time.Time
+	// DueAt is when the break was prompted; zero for a Taken break that was
+	// not prompted.
+	DueAt time.Time
+	// Worked is the continuous work that preceded At.
+	Worked time.Duration
+}
+
+type state int
+
+const (
+	idle    state = iota // no work since the last long break (or ever)
+	working              // active, no qualifying pause yet
+	resting              // on a micro-break that may yet become a long one
+)
+
+// Guard tracks work and breaks. The zero value is not usable; call New.
+type Guard struct {
+	cfg   Config
+	state state
+	last  time.Time // last activity
+
+	microStart, longStart time.Time // start of work since each kind's last break
+	microNext, longNext   time.Time // when each kind is next due
+	microDue, longDue     time.Time // outstanding prompts; zero if none
+}
+
+// New returns a Guard with the given configuration.
+func New(cfg Config) *Guard { return &Guard{cfg: cfg} }
+
+// Config returns the current configuration.
+func (g *Guard) Config() Config { return g.cfg }
+
+// SetConfig changes the configuration. Work already tracked is kept; the
+// next due times are recomputed from it.
+func (g *Guard) SetConfig(cfg Config) {
+	if cfg == g.cfg {
+		return
+	}
+	g.cfg = cfg
+	if !g.microStart.IsZero() {
+		g.microNext = g.microStart.Add(cfg.MicroAfter)
+	}
+	if !g.longStart.IsZero() {
+		g.longNext = g.longStart.Add(cfg.LongAfter)
+	}
+}
+
+// Worked returns the continuous work since the last micro-break and since the
+// last long break, as of now. Both are zero while on a break.
+func (g *Guard) Worked(now time.Time) (sinceMicro, sinceLong time.Duration) {
+	if g.state != working || g.pausedFor(now, g.cfg.MicroBreak) {
+		return 0, 0
+	}
+	return now.Sub(g.microStart), now.Sub(g.longStart)
+}
+
+// OnActivity records keyboard or mouse activity at now.
+func (g *Guard) OnActivity(now time.Time) []Event {
+	events := g.settle(now)
+	switch g.state {
+	case idle:
+		g.longStart = now
+		g.longNext = now.Add(g.cfg.LongAfter)
+		fallthrough
+	case resting:
+		g.microStart = now
+		g.microNext = now.Add(g.cfg.MicroAfter)
+		g.state = working
+	}
+	g.last = now
+	return events
+}
+
+// Tick reports breaks that have begun and breaks that have fallen due by now.
+// Call it every few seconds; prompts are at most one tick late.
+func (g *Guard) Tick(now time.Time) []Event {
+	events := g.settle(now)
+	if g.state != working {
+		return events
+	}
+	if g.cfg.LongAfter > 0 && !now.Before(g.longNext) {
+		events = g.prompt(events, Long, &g.longDue, g.longStart, now)
+		g.longNext = now.Add(g.cfg.LongAfter)
+		// A long-break prompt stands in for a micro one.
+		g.microNext = latest(g.microNext, now.Add(g.cfg.MicroAfter))
+		return events
+	}
+	if g.cfg.MicroAfter > 0 && !now.Before(g.microNext) {
+		events = g.prompt(events, Micro, &g.microDue, g.microStart, now)
+		g.microNext = now.Add(g.cfg.MicroAfter)
+	}
+	return events
+}
+
+func (g *Guard) prompt(events []Event, kind Kind, due *time.Time, start, now time.Time) []Event {
+	if !due.IsZero() {
+		events = append(events, Event{Type: Skipped, Kind: kind, At: *due, DueAt: *due, Worked: now.Sub(start)})
+	}
+	*due = now
+	return append(events, Event{Type: Due, Kind: kind, At: now, DueAt: now, Worked: now.Sub(start)})
+}
+
+// settle ends work or a micro-break when the pause since the last activity
+// has grown long enough to count as a break.
+func (g *Guard) settle(now time.Time) []Event {
+	var events []Event
+	if g.state == working && g.pausedFor(now, g.cfg.MicroBreak) {
+		if !g.microDue.IsZero() {
+			events = append(events, Event{Type: Taken, Kind: Micro, At: g.last, DueAt: g.microDue, Worked: g.last.Sub(g.microStart)})
+			g.microDue = time.Time{}
+		}
+		g.state = resting
+	}
+	if g.state == resting && g.pausedFor(now, g.cfg.LongBreak) {
+		events = append(events, Event{Type: Taken, Kind: Long, At: g.last, DueAt: g.longDue, Worked: g.last.Sub(g.longStart)})
+		g.longDue = time.Time{}
+		g.state = idle
+	}
+	return events
+}
+
+// pausedFor reports whether there has been no activity for at least d.
+func (g *Guard) pausedFor(now time.Time, d time.Duration) bool {
+	return d > 0 && now.Sub(g.last) >= d
+}
+
+func latest(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
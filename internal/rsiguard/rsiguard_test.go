@@ -0,0 +1,151 @@
+package rsiguard
+
+import (
+	"testing"
+	"time"
+)
+
+var base = time.Date(2026, 1, 2, 9, 0, 0, 0, time.UTC)
+
+func at(d time.Duration) time.Time { return base.Add(d) }
+
+var testConfig = Config{
+	MicroAfter: 10 * time.Minute,
+	MicroBreak: 30 * time.Second,
+	LongAfter:  30 * time.Minute,
+	LongBreak:  5 * time.Minute,
+}
+
+// work reports activity every 5s over [from, to) and ticks alongside it,
+// returning every event.
+func work(g *Guard, from, to time.Duration) []Event {
+	var events []Event
+	for d := from; d < to; d += 5 * time.Second {
+		events = append(events, g.OnActivity(at(d))...)
+		events = append(events, g.Tick(at(d))...)
+	}
+	return events
+}
+
+func TestMicroBreakPromptedAndTaken(t *testing.T) {
+	g := New(testConfig)
+	events := work(g, 0, 11*time.Minute)
+	if len(events) != 1 || events[0].Type != Due || events[0].Kind != Micro {
+		t.Fatalf("want one micro-break prompt, got %+v", events)
+	}
+	if events[0].At != at(10*time.Minute) || events[0].Worked != 10*time.Minute {
+		t.Errorf("prompt: %+v", events[0])
+	}
+
+	// Last activity at 10:55; a 30s pause is a micro-break.
+	last := 11*time.Minute - 5*time.Second
+	if ev := g.Tick(at(last + 29*time.Second)); len(ev) != 0 {
+		t.Fatalf("29s pause is not a break: %+v", ev)
+	}
+	ev := g.Tick(at(last + 30*time.Second))
+	if len(ev) != 1 || ev[0].Type != Taken || ev[0].Kind != Micro || ev[0].DueAt != at(10*time.Minute) {
+		t.Fatalf("want micro-break taken, got %+v", ev)
+	}
+	if s, l := g.Worked(at(last + 31*time.Second)); s != 0 || l != 0 {
+		t.Errorf("Worked on a break = %v, %v", s, l)
+	}
+
+	// Work resumes: the micro clock restarts, the long one doesn't.
+	g.OnActivity(at(12 * time.Minute))
+	if s, l := g.Worked(at(12*time.Minute + 10*time.Second)); s != 10*time.Second || l != 12*time.Minute+10*time.Second {
+		t.Errorf("Worked = %v, %v", s, l)
+	}
+}
+
+func TestUnpromptedMicroBreakNotReported(t *testing.T) {
+	g := New(testConfig)
+	work(g, 0, 5*time.Minute)
+	if ev := g.Tick(at(6 * time.Minute)); len(ev) != 0 {
+		t.Fatalf("unprompted micro-break should not be reported: %+v", ev)
+	}
+}
+
+func TestSkippedBreakRepromptedAfterInterval(t *testing.T) {
+	g := New(testConfig)
+	events := work(g, 0, 21*time.Minute)
+	var types []EventType
+	for _, e := range events {
+		types = append(types, e.Type)
+	}
+	// Due at 10m, then at 20m the first is skipped and it is due again.
+	want := []EventType{Due, Skipped, Due}
+	if len(types) != len(want) || types[0] != want[0] || types[1] != want[1] || types[2] != want[2] {
+		t.Fatalf("events = %+v", events)
+	}
+	if events[1].DueAt != at(10*time.Minute) || events[2].At != at(20*time.Minute) {
+		t.Errorf("skip/reprompt times: %+v", events[1:])
+	}
+}
+
+func TestLongBreak(t *testing.T) {
+	g := New(testConfig)
+	// Short pauses every 9 minutes keep micro-breaks from falling due.
+	var events []Event
+	for start := time.Duration(0); start < 31*time.Minute; start += 10 * time.Minute {
+		events = append(events, work(g, start, start+9*time.Minute)...)
+		events = append(events, g.Tick(at(start+9*time.Minute+30*time.Second))...)
+	}
+	events = append(events, work(g, 40*time.Minute, 41*time.Minute)...)
+	var due []Event
+	for _, e := range events {
+		if e.Type == Due {
+			due = append(due, e)
+		}
+	}
+	if len(due) != 1 || due[0].Kind != Long {
+		t.Fatalf("want one long-break prompt, got %+v", events)
+	}
+
+	last := 41*time.Minute - 5*time.Second
+	ev := g.Tick(at(last + 5*time.Minute))
+	if len(ev) != 1 || ev[0].Type != Taken || ev[0].Kind != Long || ev[0].DueAt.IsZero() {
+		t.Fatalf("want long break taken, got %+v", ev)
+	}
+	if ev[0].Worked != last {
+		t.Errorf("Worked = %v, want %v", ev[0].Worked, last)
+	}
+
+	// Both clocks restart after a long break.
+	g.OnActivity(at(50 * time.Minute))
+	if s, l := g.Worked(at(50*time.Minute + 10*time.Second)); s != 10*time.Second || l != 10*time.Second {
+		t.Errorf("Worked = %v, %v", s, l)
+	}
+}
+
+func TestLongBreakResolvesMicroPrompt(t *testing.T) {
+	g := New(testConfig)
+	work(g, 0, 11*time.Minute)
+	ev := g.Tick(at(20 * time.Minute))
+	if len(ev) != 2 || ev[0].Kind != Micro || ev[0].Type != Taken || ev[1].Kind != Long || ev[1].Type != Taken {
+		t.Fatalf("want micro and long taken, got %+v", ev)
+	}
+	if !ev[1].DueAt.IsZero() {
+		t.Errorf("the long break was not prompted: %+v", ev[1])
+	}
+}
+
+func TestDisabledKind(t *testing.T) {
+	cfg := testConfig
+	cfg.MicroAfter = 0
+	g := New(cfg)
+	if ev := work(g, 0, 25*time.Minute); len(ev) != 0 {
+		t.Fatalf("micro prompts disabled: %+v", ev)
+	}
+}
+
+func TestSetConfigReschedules(t *testing.T) {
+	g := New(testConfig)
+	work(g, 0, 3*time.Minute)
+	cfg := testConfig
+	cfg.MicroAfter = 2 * time.Minute
+	g.SetConfig(cfg)
+	ev := g.Tick(at(3 * time.Minute))
+	if len(ev) != 1 || ev[0].Type != Due || ev[0].Kind != Micro {
+		t.Fatalf("want prompt under the new config, got %+v", ev)
+	}
+}
@@ -0,0 +1,45 @@
+package speedtracker
+
+import "time"
+
+// BigramMaxMs is the longest key-to-key gap reported as a bigram transition.
+// Slower gaps are hesitations or pauses and break the chain. It matches
+// storage.BigramMaxMs.
+const BigramMaxMs = 1000
+
+// Bigram is one timed transition between consecutive keys.
+type Bigram struct {
+	Prev, Next int
+	Ms         int64
+}
+
+// BigramTracker times transitions between consecutive keystrokes. The caller
+// decides which keys take part: feed content keys to OnKey and call Reset on
+// anything that should break the chain (modifiers, shortcuts). Like Tracker it
+// is not goroutine-safe.
+type BigramTracker struct {
+	hasPrev bool
+	prev    int
+	prevAt  time.Time
+}
+
+// NewBigramTracker returns a fresh BigramTracker.
+func NewBigramTracker() *BigramTracker { return &BigramTracker{} }
+
+// OnKey records keycode pressed at now and returns the transition from the
+// previous key, if there is one within BigramMaxMs.
+func (t *BigramTracker) OnKey(keycode int, now time.Time) (Bigram, bool) {
+	prev, prevAt, had := t.prev, t.prevAt, t.hasPrev
+	t.hasPrev, t.prev, t.prevAt = true, keycode, now
+	if !had {
+		return Bigram{}, false
+	}
+	ms := now.Sub(prevAt).Milliseconds()
+	if ms <= 0 || ms > BigramMaxMs {
+		return Bigram{}, false
+	}
+	return Bigram{Prev: prev, Next: keycode, Ms: ms}, true
+}
+
+// Reset forgets the previous key, so the next OnKey starts a new chain.
+func (t *BigramTracker) Reset() { t.hasPrev = false }
@@ -0,0 +1,31 @@
+package speedtracker
+
+import "testing"
+
+func TestBigramTrackerTimesTransitions(t *testing.T) {
+	tr := NewBigramTracker()
+	if _, ok := tr.OnKey(17, at(0)); ok {
+		t.Fatal("first key has no transition")
+	}
+	b, ok := tr.OnKey(4, at(140))
+	if !ok || b != (Bigram{Prev: 17, Next: 4, Ms: 140}) {
+		t.Fatalf("want t->h in 140ms, got %+v ok=%v", b, ok)
+	}
+}
+
+func TestBigramTrackerBreaksOnPauseAndReset(t *testing.T) {
+	tr := NewBigramTracker()
+	tr.OnKey(17, at(0))
+	if _, ok := tr.OnKey(4, at(BigramMaxMs+1)); ok {
+		t.Fatal("a pause longer than BigramMaxMs is not a transition")
+	}
+	// The paused key still starts the next chain.
+	if b, ok := tr.OnKey(14, at(BigramMaxMs+101)); !ok || b.Prev != 4 || b.Ms != 100 {
+		t.Fatalf("want h->e in 100ms after the pause, got %+v ok=%v", b, ok)
+	}
+
+	tr.Reset()
+	if _, ok := tr.OnKey(0, at(BigramMaxMs+150)); ok {
+		t.Fatal("Reset should break the chain")
+	}
+}
@@ -0,0 +1,367 @@
+// Package statsjson builds the stable JSON documents typtel hands to scripts:
+// `typtel today --json`, `typtel stats --json`, and the same documents served
+// by the daemons' local API (internal/localapi). Field names are snake_case so
+// consumers in shell pipelines and other languages can parse them with
+// conventional tooling. Fields are only ever added, never renamed.
+//
+// The CLI reads everything from the store. A capture daemon also holds speed
+// measurements in memory between flushes; it passes them in as a Live so the
+// API's numbers are current to the last word.
+package statsjson
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aayushbajaj/typing-telemetry/internal/goals"
+	"github.com/aayushbajaj/typing-telemetry/internal/speedtracker"
+	"github.com/aayushbajaj/typing-telemetry/internal/storage"
+	"github.com/aayushbajaj/typing-telemetry/pkg/stats"
+)
+
+// PixelsPerInch matches DefaultPPI used by the charts renderer. Kept as a
+// constant here so the JSON surface stays stable even if the chart code
+// changes its conversion approach later.
+const PixelsPerInch = 100.0
+
+// metersPerInch is the SI conversion factor.
+const metersPerInch = 0.0254
+
+// TodayJSON is the stable schema returned by `typtel today --json`.
+// Mouse distance is reported in both raw pixels (lossless) and metres
+// (human-friendly).
+type TodayJSON struct {
+	Date            string  `json:"date"`
+	Keystrokes      int64   `json:"keystrokes"`
+	Words           int64   `json:"words"`
+	Letters         int64   `json:"letters"`
+	Modifiers       int64   `json:"modifiers"`
+	Special         int64   `json:"special"`
+	MouseClicks     int64   `json:"mouse_clicks"`
+	MouseDistancePx float64 `json:"mouse_distance_px"`
+	MouseDistanceM  float64 `json:"mouse_distance_m"`
+	ActiveHours     int     `json:"active_hours"`
+	AvgWPM          float64 `json:"avg_wpm"`
+
+	// Goals is progress toward the configured goals; omitted when none are
+	// set, so installs without goals see the same document as before.
+	Goals *goals.Report `json:"goals,omitempty"`
+}
+
+// DayJSON is a per-day breakdown used inside StatsJSON.
+type DayJSON struct {
+	Date       string `json:"date"`
+	Keystrokes int64  `json:"keystrokes"`
+	Words      int64  `json:"words"`
+}
+
+// StatsJSON is the stable schema returned by `typtel stats --json`. It
+// covers today plus the trailing 7-day window so a consumer can render a
+// summary without making multiple calls. The week slice is chronological
+// (oldest first) to match what the underlying storage layer returns.
+type StatsJSON struct {
+	Today        TodayJSON `json:"today"`
+	Week         []DayJSON `json:"week"`
+	WeekTotals   DayJSON   `json:"week_totals"`
+	WeekAverages struct {
+		Keystrokes float64 `json:"keystrokes"`
+		Words      float64 `json:"words"`
+	} `json:"week_averages"`
+	Speed SpeedJSON `json:"speed"`
+
+	// Devices is an additive, optional map of external-device feeds keyed by
+	// device_id. It is omitted entirely when no devices are registered, so the
+	// macOS watchdog's existing contract is byte-unchanged.
+	Devices map[string]DeviceJSON `json:"devices,omitempty"`
+}
+
+// DeviceJSON is one external device's entry in the optional "devices" block.
+// Today reuses the storage counts shape (absolute totals for today's date).
+type DeviceJSON struct {
+	Name     string                  `json:"name"`
+	Today    storage.DeviceDayCounts `json:"today"`
+	LastSeen string                  `json:"last_seen"`
+}
+
+// SpeedJSON is the typing-speed section of `typtel stats --json`. AvgWPM is
+// keyed by rolling window ("day", "week", "month", "year", "all"); Fastest
+// holds the all-time best pace for each of the three tracked methods.
+type SpeedJSON struct {
+	AvgWPM  map[string]float64 `json:"avg_wpm"`
+	Fastest struct {
+		BurstWPM  float64 `json:"burst_wpm"`
+		WindowWPM float64 `json:"window_wpm"`
+		MinuteWPM float64 `json:"minute_wpm"`
+	} `json:"fastest"`
+
+	// Live is the pace as of the last completed word. Only the daemons' API
+	// has it; the CLI omits the key.
+	Live *LiveSpeedJSON `json:"live,omitempty"`
+}
+
+// LiveSpeedJSON is the most recent word's speed sample.
+type LiveSpeedJSON struct {
+	BurstWPM   float64 `json:"burst_wpm"`
+	WindowWPM  float64 `json:"window_wpm"`
+	MinuteWPM  float64 `json:"minute_wpm"`
+	LastWordAt string  `json:"last_word_at,omitempty"` // RFC3339; empty before the first word
+}
+
+// HourJSON is one hour of a day's keystrokes.
+type HourJSON struct {
+	Hour       int   `json:"hour"`
+	Keystrokes int64 `json:"keystrokes"`
+}
+
+// HourlyJSON is a day's keystrokes by hour, all 24 hours present.
+type HourlyJSON struct {
+	Date  string     `json:"date"`
+	Hours []HourJSON `json:"hours"`
+}
+
+// OdometerJSON is the odometer session: counts since it started if one is
+// running, otherwise just active=false.
+type OdometerJSON struct {
+	Active      bool    `json:"active"`
+	Label       string  `json:"label,omitempty"`
+	StartTime   string  `json:"start_time,omitempty"` // RFC3339
+	Keystrokes  int64   `json:"keystrokes"`
+	Words       int64   `json:"words"`
+	Clicks      int64   `json:"clicks"`
+	DistancePx  float64 `json:"distance_px"`
+	DistanceM   float64 `json:"distance_m"`
+	DurationSec int64   `json:"duration_sec"`
+}
+
+// Live is speed data a capture daemon holds in memory and hasn't flushed to
+// the store yet. A nil *Live adds nothing.
+type Live struct {
+	Date       string              // the day ActiveMs and Fastest belong to
+	ActiveMs   int64               // active typing time not yet written
+	Fastest    speedtracker.Sample // maxima of the samples not yet written
+	Last       speedtracker.Sample // the most recent word's sample
+	LastWordAt time.Time
+}
+
+// PendingFor returns the unflushed numbers that belong to date.
+func (l *Live) PendingFor(date string) (activeMs int64, fastest speedtracker.Sample) {
+	if l == nil || l.Date != date {
+		return 0, speedtracker.Sample{}
+	}
+	return l.ActiveMs, l.Fastest
+}
+
+// PixelsToMeters converts a mouse distance for the JSON documents.
+func PixelsToMeters(px float64) float64 {
+	return (px / PixelsPerInch) * metersPerInch
+}
+
+// Today assembles the today payload from storage. Errors from the mouse and
+// hourly queries are swallowed (with zero defaults) so that an empty install
+// — no mouse data, no hourly data — still produces a valid JSON document.
+// Keystroke/word failure is fatal because that's the primary signal.
+func Today(store *storage.Store, now time.Time, live *Live) (TodayJSON, error) {
+	// Ensure historical active time exists so avg_wpm is meaningful even if the
+	// menubar hasn't run since upgrading. Guarded — runs at most once.
+	if err := store.BackfillActiveTime(); err != nil {
+		return TodayJSON{}, fmt.Errorf("backfill active time: %w", err)
+	}
+
+	date := now.Format("2006-01-02")
+	day, err := store.GetTodayStats()
+	if err != nil {
+		return TodayJSON{}, fmt.Errorf("get today stats: %w", err)
+	}
+
+	out := TodayJSON{
+		Date:       date,
+		Keystrokes: day.Keystrokes,
+		Words:      day.Words,
+		Letters:    day.Letters,
+		Modifiers:  day.Modifiers,
+		Special:    day.Special,
+	}
+
+	if mouse, err := store.GetTodayMouseStats(); err == nil && mouse != nil {
+		out.MouseClicks = mouse.ClickCount
+		out.MouseDistancePx = mouse.TotalDistance
+		out.MouseDistanceM = PixelsToMeters(mouse.TotalDistance)
+	}
+
+	if hourly, err := store.GetHourlyStats(date); err == nil {
+		for _, h := range hourly {
+			if h.Keystrokes > 0 {
+				out.ActiveHours++
+			}
+		}
+	}
+
+	if speed, err := store.GetSpeedAggregate(date); err == nil {
+		pendingMs, _ := live.PendingFor(date)
+		out.AvgWPM = stats.AverageWPM(speed.Words, speed.ActiveMs+pendingMs)
+	}
+
+	report, err := goals.Build(store, now)
+	if err != nil {
+		return TodayJSON{}, fmt.Errorf("get goal progress: %w", err)
+	}
+	if !report.IsZero() {
+		out.Goals = report
+	}
+
+	return out, nil
+}
+
+// Stats assembles the `typtel stats --json` document.
+func Stats(store *storage.Store, now time.Time, live *Live) (StatsJSON, error) {
+	today, err := Today(store, now, live)
+	if err != nil {
+		return StatsJSON{}, err
+	}
+
+	week, err := store.GetWeekStats()
+	if err != nil {
+		return StatsJSON{}, fmt.Errorf("get week stats: %w", err)
+	}
+
+	out := StatsJSON{Today: today}
+	out.Week = make([]DayJSON, 0, len(week))
+	var totalK, totalW int64
+	for _, d := range week {
+		out.Week = append(out.Week, DayJSON{
+			Date:       d.Date,
+			Keystrokes: d.Keystrokes,
+			Words:      d.Words,
+		})
+		totalK += d.Keystrokes
+		totalW += d.Words
+	}
+	out.WeekTotals = DayJSON{Keystrokes: totalK, Words: totalW}
+	if n := len(week); n > 0 {
+		out.WeekAverages.Keystrokes = float64(totalK) / float64(n)
+		out.WeekAverages.Words = float64(totalW) / float64(n)
+	}
+
+	speed, err := Speed(store, now, live)
+	if err != nil {
+		return StatsJSON{}, fmt.Errorf("get speed stats: %w", err)
+	}
+	out.Speed = speed
+
+	devices, err := Devices(store, now)
+	if err != nil {
+		return StatsJSON{}, fmt.Errorf("get device stats: %w", err)
+	}
+	out.Devices = devices
+	return out, nil
+}
+
+// Devices assembles the optional "devices" block. It returns nil when no
+// devices are registered so the key is omitted entirely (omitempty),
+// preserving the byte-for-byte JSON contract for installs that never use the
+// ingest API. Each device's "today" is its absolute counts for today's date.
+func Devices(store *storage.Store, now time.Time) (map[string]DeviceJSON, error) {
+	infos, err := store.ListDevices()
+	if err != nil {
+		return nil, err
+	}
+	if len(infos) == 0 {
+		return nil, nil
+	}
+
+	today := now.Format("2006-01-02")
+	out := make(map[string]DeviceJSON, len(infos))
+	for _, info := range infos {
+		entry := DeviceJSON{Name: info.Name, LastSeen: info.LastSeen}
+		if c, err := store.GetDeviceDay(info.DeviceID, today); err != nil {
+			return nil, err
+		} else if c != nil {
+			entry.Today = *c
+		}
+		out[info.DeviceID] = entry
+	}
+	return out, nil
+}
+
+// Speed assembles the typing-speed section: average WPM over rolling windows
+// and the all-time fastest paces. Windows mirror the menubar (today, trailing
+// 7/30/365 days, all-time); every window includes today, so each takes the
+// unflushed active time too.
+func Speed(store *storage.Store, now time.Time, live *Live) (SpeedJSON, error) {
+	date := now.Format("2006-01-02")
+	windows := []struct {
+		key   string
+		since string
+	}{
+		{"day", date},
+		{"week", now.AddDate(0, 0, -6).Format("2006-01-02")},
+		{"month", now.AddDate(0, 0, -29).Format("2006-01-02")},
+		{"year", now.AddDate(0, 0, -364).Format("2006-01-02")},
+		{"all", ""},
+	}
+
+	pendingMs, pendingFastest := live.PendingFor(date)
+	out := SpeedJSON{AvgWPM: make(map[string]float64, len(windows))}
+	var all storage.SpeedAggregate
+	for _, w := range windows {
+		agg, err := store.GetSpeedAggregate(w.since)
+		if err != nil {
+			return SpeedJSON{}, err
+		}
+		out.AvgWPM[w.key] = stats.AverageWPM(agg.Words, agg.ActiveMs+pendingMs)
+		if w.key == "all" {
+			all = agg
+		}
+	}
+	out.Fastest.BurstWPM = max(all.FastestBurstWPM, pendingFastest.Burst)
+	out.Fastest.WindowWPM = max(all.FastestWindowWPM, pendingFastest.Window)
+	out.Fastest.MinuteWPM = max(all.FastestMinuteWPM, pendingFastest.Minute)
+
+	if live != nil {
+		out.Live = &LiveSpeedJSON{
+			BurstWPM:  live.Last.Burst,
+			WindowWPM: live.Last.Window,
+			MinuteWPM: live.Last.Minute,
+		}
+		if !live.LastWordAt.IsZero() {
+			out.Live.LastWordAt = live.LastWordAt.Format(time.RFC3339)
+		}
+	}
+	return out, nil
+}
+
+// Hourly assembles a day's keystrokes by hour.
+func Hourly(store *storage.Store, date string) (HourlyJSON, error) {
+	hourly, err := store.GetHourlyStats(date)
+	if err != nil {
+		return HourlyJSON{}, err
+	}
+	out := HourlyJSON{Date: date, Hours: make([]HourJSON, 0, len(hourly))}
+	for _, h := range hourly {
+		out.Hours = append(out.Hours, HourJSON{Hour: h.Hour, Keystrokes: h.Keystrokes})
+	}
+	return out, nil
+}
+
+// Odometer assembles the odometer session document.
+func Odometer(store *storage.Store, now time.Time) (OdometerJSON, error) {
+	session, err := store.GetOdometerSession()
+	if err != nil {
+		return OdometerJSON{}, err
+	}
+	if !session.IsActive {
+		return OdometerJSON{}, nil
+	}
+	distance := session.CurrentDistance - session.StartDistance
+	return OdometerJSON{
+		Active:      true,
+		Label:       session.Label,
+		StartTime:   session.StartTime.Format(time.RFC3339),
+		Keystrokes:  session.CurrentKeystrokes - session.StartKeystrokes,
+		Words:       session.CurrentWords - session.StartWords,
+		Clicks:      session.CurrentClicks - session.StartClicks,
+		DistancePx:  distance,
+		DistanceM:   PixelsToMeters(distance),
+		DurationSec: int64(now.Sub(session.StartTime).Seconds()),
+	}, nil
+}
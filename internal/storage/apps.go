@@ -0,0 +1,164 @@
+package storage
+
+// Per-application typing statistics. The capture daemons attribute each
+// keystroke, completed word and credited stretch of active time to the
+// frontmost app (appfilter.Frontmost: a bundle ID on macOS, a WM_CLASS class
+// on Linux) and add them to app_daily, one row per (date, app_id). Like the
+// speed columns, the counts are buffered in memory — see AppUsageBuffer — and
+// written on the daemon's flush tick rather than per keystroke.
+
+import (
+	"sort"
+	"sync"
+)
+
+// AppUsage is one application's typing totals over some period.
+type AppUsage struct {
+	AppID      string
+	Keystrokes int64
+	Words      int64
+	ActiveMs   int64
+}
+
+// AddAppUsage adds each entry's counts to app_daily for date, in one
+// transaction. Entries with an empty AppID are skipped.
+func (s *Store) AddAppUsage(date string, usage []AppUsage) error {
+	if len(usage) == 0 {
+		return nil
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO app_daily (date, app_id, keystrokes, words, active_ms)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(date, app_id) DO UPDATE SET
+			keystrokes = keystrokes + excluded.keystrokes,
+			words = words + excluded.words,
+			active_ms = active_ms + excluded.active_ms
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, u := range usage {
+		if u.AppID == "" {
+			continue
+		}
+		if _, err := stmt.Exec(date, u.AppID, u.Keystrokes, u.Words, u.ActiveMs); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetAppUsage returns per-app totals for dates on or after since
+// ("2006-01-02"; empty for all time), most keystrokes first.
+func (s *Store) GetAppUsage(since string) ([]AppUsage, error) {
+	rows, err := s.db.Query(`
+		SELECT app_id, SUM(keystrokes), SUM(words), SUM(active_ms)
+		FROM app_daily
+		WHERE date >= ?
+		GROUP BY app_id`,
+		since,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var usage []AppUsage
+	for rows.Next() {
+		var u AppUsage
+		if err := rows.Scan(&u.AppID, &u.Keystrokes, &u.Words, &u.ActiveMs); err != nil {
+			return nil, err
+		}
+		usage = append(usage, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(usage, func(i, j int) bool {
+		if usage[i].Keystrokes != usage[j].Keystrokes {
+			return usage[i].Keystrokes > usage[j].Keystrokes
+		}
+		return usage[i].AppID < usage[j].AppID
+	})
+	return usage, nil
+}
+
+type appDay struct{ date, app string }
+
+// AppUsageBuffer accumulates per-app counts in memory until Flush writes them
+// with AddAppUsage. Events for an empty app id (frontmost app unknown) are
+// ignored. It is safe for concurrent use.
+type AppUsageBuffer struct {
+	mu      sync.Mutex
+	pending map[appDay]*AppUsage
+}
+
+// NewAppUsageBuffer returns an empty buffer.
+func NewAppUsageBuffer() *AppUsageBuffer {
+	return &AppUsageBuffer{pending: make(map[appDay]*AppUsage)}
+}
+
+func (b *AppUsageBuffer) add(date, app string, keystrokes, words, activeMs int64) {
+	if app == "" {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	k := appDay{date, app}
+	u, ok := b.pending[k]
+	if !ok {
+		u = &AppUsage{AppID: app}
+		b.pending[k] = u
+	}
+	u.Keystrokes += keystrokes
+	u.Words += words
+	u.ActiveMs += activeMs
+}
+
+// AddKeystroke counts one keystroke in app on date.
+func (b *AppUsageBuffer) AddKeystroke(date, app string) { b.add(date, app, 1, 0, 0) }
+
+// AddWord counts one completed word in app on date.
+func (b *AppUsageBuffer) AddWord(date, app string) { b.add(date, app, 0, 1, 0) }
+
+// AddActive credits ms of active typing time to app on date.
+func (b *AppUsageBuffer) AddActive(date, app string, ms int64) {
+	if ms > 0 {
+		b.add(date, app, 0, 0, ms)
+	}
+}
+
+// Flush writes the buffered counts to s. Days that fail to write stay
+// buffered and are retried on the next Flush; the first error is returned.
+func (b *AppUsageBuffer) Flush(s *Store) error {
+	b.mu.Lock()
+	pending := b.pending
+	b.pending = make(map[appDay]*AppUsage)
+	b.mu.Unlock()
+
+	byDate := make(map[string][]AppUsage)
+	for k, u := range pending {
+		byDate[k.date] = append(byDate[k.date], *u)
+	}
+	var firstErr error
+	for date, usage := range byDate {
+		if err := s.AddAppUsage(date, usage); err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			for _, u := range usage {
+				b.add(date, u.AppID, u.Keystrokes, u.Words, u.ActiveMs)
+			}
+		}
+	}
+	return firstErr
+}
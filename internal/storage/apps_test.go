@@ -0,0 +1,47 @@
+package storage
+
+import "testing"
+
+func TestAppUsage(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	buf := NewAppUsageBuffer()
+	for i := 0; i < 5; i++ {
+		buf.AddKeystroke("2025-03-01", "kitty")
+	}
+	buf.AddWord("2025-03-01", "kitty")
+	buf.AddActive("2025-03-01", "kitty", 1200)
+	buf.AddKeystroke("2025-03-01", "firefox")
+	buf.AddKeystroke("2025-03-01", "") // unknown app: not attributed
+	buf.AddKeystroke("2025-01-01", "firefox")
+	buf.AddKeystroke("2025-01-01", "firefox")
+	if err := buf.Flush(store); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	// A second flush adds to the same rows.
+	buf.AddKeystroke("2025-03-01", "kitty")
+	if err := buf.Flush(store); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	all, err := store.GetAppUsage("")
+	if err != nil {
+		t.Fatalf("GetAppUsage: %v", err)
+	}
+	want := []AppUsage{
+		{AppID: "kitty", Keystrokes: 6, Words: 1, ActiveMs: 1200},
+		{AppID: "firefox", Keystrokes: 3},
+	}
+	if len(all) != len(want) || all[0] != want[0] || all[1] != want[1] {
+		t.Fatalf("all time: want %v, got %v", want, all)
+	}
+
+	recent, err := store.GetAppUsage("2025-02-01")
+	if err != nil {
+		t.Fatalf("GetAppUsage(since): %v", err)
+	}
+	if len(recent) != 2 || recent[1] != (AppUsage{AppID: "firefox", Keystrokes: 1}) {
+		t.Fatalf("since 2025-02-01: got %v", recent)
+	}
+}
@@ -0,0 +1,340 @@
+package storage
+
+// Write-behind batching for the capture path. RecordKeystroke opens a
+// transaction per key (an INSERT plus a daily_summary UPSERT) and
+// IncrementWordCount adds a second write per word; on a slow disk that is
+// enough to back up the keylogger channel until it drops keys. A Batcher
+// absorbs key and word events in memory and commits them together in one
+// transaction every FlushInterval or MaxEvents, whichever comes first.
+// Bigram latency samples and newly seen apps ride along in the same
+// transaction.
+//
+// Events are timestamped when they are queued, not when they are flushed, so
+// keystrokes.timestamp / date / hour are the same as a direct RecordKeystroke.
+// The in-memory buffer is bounded: if the DB stays unwritable long enough to
+// fill it, new events are dropped and counted rather than growing without limit.
+
+import (
+	"sync"
+	"time"
+
+	"github.com/aayushbajaj/typing-telemetry/internal/layout"
+)
+
+// Batcher defaults, used for zero-valued BatcherConfig fields.
+const (
+	DefaultBatchInterval  = 500 * time.Millisecond
+	DefaultBatchMaxEvents = 256
+	DefaultBatchCapacity  = 100000
+)
+
+// BatcherConfig tunes a Batcher. Zero values take the defaults above.
+type BatcherConfig struct {
+	FlushInterval time.Duration // commit at least this often while events are pending
+	MaxEvents     int           // commit early once this many events are pending
+	Capacity      int           // drop new events beyond this many pending
+}
+
+// BatcherStats is a snapshot of a Batcher's counters.
+type BatcherStats struct {
+	Buffered int    // events waiting for the next commit
+	Dropped  uint64 // events discarded because the buffer was full or closed
+	Flushed  uint64 // events committed so far
+}
+
+type pendingKey struct {
+	keycode int
+	ts      string // UTC "2006-01-02 15:04:05", the CURRENT_TIMESTAMP format
+	date    string
+	hour    int
+}
+
+// Batcher is a write-behind buffer in front of RecordKeystroke and
+// IncrementWordCount. It is safe for concurrent use.
+type Batcher struct {
+	store *Store
+	cfg   BatcherConfig
+
+	mu      sync.Mutex
+	keys    []pendingKey
+	words   map[string]int64 // date -> pending word increments
+	nWords  int
+	bigrams []BigramSample
+	apps    []string        // seen apps waiting for the next commit
+	seen    map[string]bool // apps queued or committed by this Batcher
+	dropped uint64
+	flushed uint64
+	closed  bool
+
+	kick chan struct{}
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewBatcher starts a Batcher that commits to s in the background. Call Close
+// to stop it and flush whatever is still pending.
+func (s *Store) NewBatcher(cfg BatcherConfig) *Batcher {
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = DefaultBatchInterval
+	}
+	if cfg.MaxEvents <= 0 {
+		cfg.MaxEvents = DefaultBatchMaxEvents
+	}
+	if cfg.Capacity <= 0 {
+		cfg.Capacity = DefaultBatchCapacity
+	}
+	b := &Batcher{
+		store: s,
+		cfg:   cfg,
+		words: make(map[string]int64),
+		seen:  make(map[string]bool),
+		kick:  make(chan struct{}, 1),
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	go b.loop()
+	return b
+}
+
+// RecordKeystroke queues a keystroke stamped with the current time. It never
+// blocks on the database.
+func (b *Batcher) RecordKeystroke(keycode int) {
+	b.recordKeystrokeAt(keycode, time.Now())
+}
+
+func (b *Batcher) recordKeystrokeAt(keycode int, now time.Time) {
+	b.mu.Lock()
+	if !b.acceptLocked() {
+		b.mu.Unlock()
+		return
+	}
+	b.keys = append(b.keys, pendingKey{
+		keycode: keycode,
+		ts:      now.UTC().Format("2006-01-02 15:04:05"),
+		date:    now.Format("2006-01-02"),
+		hour:    now.Hour(),
+	})
+	full := b.pendingLocked() >= b.cfg.MaxEvents
+	b.mu.Unlock()
+	if full {
+		b.signal()
+	}
+}
+
+// IncrementWordCount queues one completed word for date.
+func (b *Batcher) IncrementWordCount(date string) {
+	b.mu.Lock()
+	if !b.acceptLocked() {
+		b.mu.Unlock()
+		return
+	}
+	b.words[date]++
+	b.nWords++
+	full := b.pendingLocked() >= b.cfg.MaxEvents
+	b.mu.Unlock()
+	if full {
+		b.signal()
+	}
+}
+
+// RecordBigram queues one key-to-key latency sample.
+func (b *Batcher) RecordBigram(prev, next int, ms int64) {
+	b.mu.Lock()
+	if !b.acceptLocked() {
+		b.mu.Unlock()
+		return
+	}
+	b.bigrams = append(b.bigrams, BigramSample{Prev: prev, Next: next, Ms: ms})
+	full := b.pendingLocked() >= b.cfg.MaxEvents
+	b.mu.Unlock()
+	if full {
+		b.signal()
+	}
+}
+
+// RecordSeenApp queues app for the apps-seen list (see Store.RecordSeenApp).
+// Each app is written at most once per Batcher, with the next commit; it
+// doesn't count towards MaxEvents.
+func (b *Batcher) RecordSeenApp(app string) {
+	if app == "" {
+		return
+	}
+	b.mu.Lock()
+	if !b.closed && !b.seen[app] {
+		b.seen[app] = true
+		b.apps = append(b.apps, app)
+	}
+	b.mu.Unlock()
+}
+
+// acceptLocked reports whether one more event fits, counting it as dropped if
+// not. Caller holds b.mu.
+func (b *Batcher) acceptLocked() bool {
+	if b.closed || b.pendingLocked() >= b.cfg.Capacity {
+		b.dropped++
+		return false
+	}
+	return true
+}
+
+func (b *Batcher) pendingLocked() int {
+	return len(b.keys) + b.nWords + len(b.bigrams)
+}
+
+func (b *Batcher) signal() {
+	select {
+	case b.kick <- struct{}{}:
+	default:
+	}
+}
+
+// Stats returns the current buffered / dropped / flushed counts.
+func (b *Batcher) Stats() BatcherStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return BatcherStats{Buffered: b.pendingLocked(), Dropped: b.dropped, Flushed: b.flushed}
+}
+
+func (b *Batcher) loop() {
+	defer close(b.done)
+	t := time.NewTicker(b.cfg.FlushInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+		case <-b.kick:
+		case <-b.stop:
+			return
+		}
+		// Errors leave the events queued for the next attempt; the caller
+		// sees persistent failure as a growing Buffered count, then Dropped.
+		_ = b.Flush()
+	}
+}
+
+// Flush commits every pending event in one transaction. On error the events
+// stay buffered and are retried on the next flush.
+func (b *Batcher) Flush() error {
+	b.mu.Lock()
+	keys, words, nWords, bigrams, apps := b.keys, b.words, b.nWords, b.bigrams, b.apps
+	b.keys, b.words, b.nWords, b.bigrams, b.apps = nil, make(map[string]int64), 0, nil, nil
+	b.mu.Unlock()
+
+	if len(keys) == 0 && nWords == 0 && len(bigrams) == 0 && len(apps) == 0 {
+		return nil
+	}
+
+	if err := b.store.commitBatch(keys, words, bigrams, apps); err != nil {
+		// Put the events back in front of anything queued meanwhile.
+		b.mu.Lock()
+		b.keys = append(keys, b.keys...)
+		for date, n := range words {
+			b.words[date] += n
+		}
+		b.nWords += nWords
+		b.bigrams = append(bigrams, b.bigrams...)
+		b.apps = append(apps, b.apps...)
+		b.mu.Unlock()
+		return err
+	}
+
+	b.mu.Lock()
+	b.flushed += uint64(len(keys) + nWords + len(bigrams))
+	b.mu.Unlock()
+	return nil
+}
+
+// Close stops the background loop and flushes what is pending. Events queued
+// after Close are dropped.
+func (b *Batcher) Close() error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+	b.closed = true
+	b.mu.Unlock()
+
+	close(b.stop)
+	<-b.done
+	return b.Flush()
+}
+
+// summaryDelta is one day's pending daily_summary increments.
+type summaryDelta struct {
+	keystrokes, letters, modifiers, special, words int64
+}
+
+// commitBatch writes queued keystrokes, word increments, bigram samples and
+// seen apps in a single transaction: one keystrokes row per key, one
+// daily_summary upsert per date carrying the summed increments, one
+// bigram_latency merge per key pair, and at most one apps-seen update.
+func (s *Store) commitBatch(keys []pendingKey, words map[string]int64, bigrams []BigramSample, apps []string) error {
+	lay := s.KeyboardLayout()
+	deltas := make(map[string]*summaryDelta)
+	delta := func(date string) *summaryDelta {
+		d, ok := deltas[date]
+		if !ok {
+			d = &summaryDelta{}
+			deltas[date] = d
+		}
+		return d
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare("INSERT INTO keystrokes (timestamp, keycode, date, hour) VALUES (?, ?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, k := range keys {
+		if _, err := stmt.Exec(k.ts, k.keycode, k.date, k.hour); err != nil {
+			return err
+		}
+		d := delta(k.date)
+		d.keystrokes++
+		switch lay.Class(k.keycode) {
+		case layout.Letter:
+			d.letters++
+		case layout.Modifier:
+			d.modifiers++
+		default:
+			d.special++
+		}
+	}
+	for date, n := range words {
+		delta(date).words += n
+	}
+
+	for date, d := range deltas {
+		_, err := tx.Exec(`
+			INSERT INTO daily_summary (date, keystrokes, letters, modifiers, special, words)
+			VALUES (?, ?, ?, ?, ?, ?)
+			ON CONFLICT(date) DO UPDATE SET
+				keystrokes = keystrokes + excluded.keystrokes,
+				letters = letters + excluded.letters,
+				modifiers = modifiers + excluded.modifiers,
+				special = special + excluded.special,
+				words = words + excluded.words,
+				updated_at = CURRENT_TIMESTAMP
+		`, date, d.keystrokes, d.letters, d.modifiers, d.special, d.words)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := addBigramLatenciesTx(tx, bigrams); err != nil {
+		return err
+	}
+	if err := addSeenAppsTx(tx, apps); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
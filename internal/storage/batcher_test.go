@@ -0,0 +1,193 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// newIdleBatcher returns a Batcher whose timer never fires during a test, so
+// commits happen only on explicit Flush/Close or the MaxEvents trigger.
+func newIdleBatcher(s *Store, maxEvents, capacity int) *Batcher {
+	return s.NewBatcher(BatcherConfig{FlushInterval: time.Hour, MaxEvents: maxEvents, Capacity: capacity})
+}
+
+func TestBatcherFlushCommitsKeysAndWords(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	b := newIdleBatcher(store, 1000, 1000)
+	defer b.Close()
+
+	now := time.Date(2025, 5, 6, 14, 30, 0, 0, time.Local)
+	b.recordKeystrokeAt(0, now)  // a: letter
+	b.recordKeystrokeAt(56, now) // shift: modifier
+	b.recordKeystrokeAt(49, now) // space: special
+	b.IncrementWordCount("2025-05-06")
+
+	if st := b.Stats(); st.Buffered != 4 {
+		t.Fatalf("buffered before flush: want 4, got %d", st.Buffered)
+	}
+	day, _ := store.GetDayStats("2025-05-06")
+	if day.Keystrokes != 0 {
+		t.Fatalf("nothing should be written before flush, got %d keystrokes", day.Keystrokes)
+	}
+
+	if err := b.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	day, err := store.GetDayStats("2025-05-06")
+	if err != nil {
+		t.Fatalf("GetDayStats: %v", err)
+	}
+	if day.Keystrokes != 3 || day.Letters != 1 || day.Modifiers != 1 || day.Special != 1 || day.Words != 1 {
+		t.Fatalf("day after flush: %+v", day)
+	}
+	hourly, err := store.GetHourlyStats("2025-05-06")
+	if err != nil {
+		t.Fatalf("GetHourlyStats: %v", err)
+	}
+	if hourly[14].Keystrokes != 3 {
+		t.Fatalf("hour 14: want 3, got %d", hourly[14].Keystrokes)
+	}
+
+	st := b.Stats()
+	if st.Buffered != 0 || st.Flushed != 4 || st.Dropped != 0 {
+		t.Fatalf("stats after flush: %+v", st)
+	}
+}
+
+func TestBatcherMatchesRecordKeystroke(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	// Two keys through the direct path, two through the batcher: the daily
+	// totals must agree with four direct writes.
+	if err := store.RecordKeystroke(0); err != nil {
+		t.Fatalf("RecordKeystroke: %v", err)
+	}
+	if err := store.RecordKeystroke(56); err != nil {
+		t.Fatalf("RecordKeystroke: %v", err)
+	}
+	b := newIdleBatcher(store, 1000, 1000)
+	b.RecordKeystroke(0)
+	b.RecordKeystroke(56)
+	if err := b.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	day, err := store.GetTodayStats()
+	if err != nil {
+		t.Fatalf("GetTodayStats: %v", err)
+	}
+	if day.Keystrokes != 4 || day.Letters != 2 || day.Modifiers != 2 {
+		t.Fatalf("today: %+v", day)
+	}
+}
+
+func TestBatcherDropsWhenFull(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	// MaxEvents above Capacity so the early-flush trigger never fires.
+	b := newIdleBatcher(store, 100, 3)
+	defer b.Close()
+
+	for i := 0; i < 5; i++ {
+		b.RecordKeystroke(0)
+	}
+	st := b.Stats()
+	if st.Buffered != 3 || st.Dropped != 2 {
+		t.Fatalf("want 3 buffered / 2 dropped, got %+v", st)
+	}
+}
+
+func TestBatcherFlushesAtMaxEvents(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	b := newIdleBatcher(store, 2, 100)
+	defer b.Close()
+
+	b.RecordKeystroke(0)
+	b.RecordKeystroke(0)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for b.Stats().Flushed < 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("MaxEvents did not trigger a flush: %+v", b.Stats())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestBatcherCloseFlushesAndRejectsLateEvents(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	b := newIdleBatcher(store, 1000, 1000)
+	b.IncrementWordCount("2025-05-06")
+	if err := b.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	day, _ := store.GetDayStats("2025-05-06")
+	if day.Words != 1 {
+		t.Fatalf("Close must flush pending words, got %d", day.Words)
+	}
+
+	b.IncrementWordCount("2025-05-06")
+	if st := b.Stats(); st.Dropped != 1 || st.Buffered != 0 {
+		t.Fatalf("events after Close should be dropped: %+v", st)
+	}
+	// Closing twice is harmless.
+	if err := b.Close(); err != nil {
+		t.Fatalf("second Close: %v", err)
+	}
+}
+
+func TestBatcherCommitsBigrams(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	b := newIdleBatcher(store, 1000, 1000)
+	b.RecordBigram(17, 4, 90)
+	b.RecordBigram(17, 4, 110)
+	if err := b.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	stats, _ := store.GetBigramStats(1)
+	if len(stats) != 1 || stats[0].Count != 2 || stats[0].MeanMs != 100 {
+		t.Fatalf("bigrams after flush: %+v", stats)
+	}
+	if st := b.Stats(); st.Flushed != 2 {
+		t.Fatalf("flushed: want 2, got %d", st.Flushed)
+	}
+}
+
+func TestBatcherRecordsSeenApps(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+	if err := store.RecordSeenApp("kitty"); err != nil {
+		t.Fatalf("RecordSeenApp: %v", err)
+	}
+
+	b := newIdleBatcher(store, 1000, 1000)
+	defer b.Close()
+	for _, app := range []string{"firefox", "", "kitty", "firefox", "code"} {
+		b.RecordSeenApp(app)
+	}
+	if st := b.Stats(); st.Buffered != 0 {
+		t.Fatalf("seen apps shouldn't count as buffered events: %+v", st)
+	}
+	if got := store.GetWordCountAppsSeen(); len(got) != 1 {
+		t.Fatalf("nothing should be written before flush, got %q", got)
+	}
+	if err := b.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	got := strings.Join(store.GetWordCountAppsSeen(), ",")
+	if got != "kitty,firefox,code" {
+		t.Fatalf("apps seen = %q, want kitty,firefox,code", got)
+	}
+}
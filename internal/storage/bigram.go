@@ -0,0 +1,210 @@
+package storage
+
+// Bigram latency: the time between two consecutive keystrokes, aggregated per
+// (prev, next) keycode pair. Each row keeps an exact count and total (for the
+// mean) plus a sparse histogram of BigramBucketMs-wide buckets, from which
+// p90_ms is recomputed whenever samples are merged in. The histogram is
+// stored as "bucket:count,bucket:count" text — a pair rarely spans more than
+// a few dozen buckets, so this stays small without a child table.
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// BigramBucketMs is the histogram resolution.
+	BigramBucketMs = 10
+	// BigramMaxMs is the longest gap counted as a transition; anything slower
+	// is a pause, not a bigram, and callers should not record it.
+	BigramMaxMs = 1000
+)
+
+// BigramSample is one observed transition from Prev to Next.
+type BigramSample struct {
+	Prev, Next int
+	Ms         int64
+}
+
+// BigramStats is the aggregate latency of one key pair.
+type BigramStats struct {
+	Prev, Next int
+	Count      int64
+	MeanMs     float64
+	P90Ms      int64
+}
+
+type bigramPair struct{ prev, next int }
+
+// latencyHistogram maps bucket index -> sample count.
+type latencyHistogram map[int]int64
+
+func bigramBucket(ms int64) int {
+	if ms < 0 {
+		ms = 0
+	}
+	if ms > BigramMaxMs {
+		ms = BigramMaxMs
+	}
+	return int(ms / BigramBucketMs)
+}
+
+func parseHistogram(s string) latencyHistogram {
+	h := latencyHistogram{}
+	for _, part := range strings.Split(s, ",") {
+		b, c, ok := strings.Cut(part, ":")
+		if !ok {
+			continue
+		}
+		bi, err1 := strconv.Atoi(b)
+		ci, err2 := strconv.ParseInt(c, 10, 64)
+		if err1 == nil && err2 == nil {
+			h[bi] += ci
+		}
+	}
+	return h
+}
+
+func (h latencyHistogram) String() string {
+	buckets := make([]int, 0, len(h))
+	for b := range h {
+		buckets = append(buckets, b)
+	}
+	sort.Ints(buckets)
+	parts := make([]string, len(buckets))
+	for i, b := range buckets {
+		parts[i] = fmt.Sprintf("%d:%d", b, h[b])
+	}
+	return strings.Join(parts, ",")
+}
+
+// percentile returns the midpoint of the bucket holding the p-th percentile
+// sample (0 < p <= 1), in milliseconds.
+func (h latencyHistogram) percentile(p float64) int64 {
+	var total int64
+	buckets := make([]int, 0, len(h))
+	for b, n := range h {
+		total += n
+		buckets = append(buckets, b)
+	}
+	if total == 0 {
+		return 0
+	}
+	sort.Ints(buckets)
+	rank := int64(p*float64(total) + 0.999999) // ceil, so p90 of 10 samples is the 9th
+	var seen int64
+	for _, b := range buckets {
+		seen += h[b]
+		if seen >= rank {
+			return int64(b)*BigramBucketMs + BigramBucketMs/2
+		}
+	}
+	return int64(buckets[len(buckets)-1])*BigramBucketMs + BigramBucketMs/2
+}
+
+// AddBigramLatencies merges samples into bigram_latency in one transaction.
+func (s *Store) AddBigramLatencies(samples []BigramSample) error {
+	if len(samples) == 0 {
+		return nil
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := addBigramLatenciesTx(tx, samples); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func addBigramLatenciesTx(tx *sql.Tx, samples []BigramSample) error {
+	type delta struct {
+		count, totalMs int64
+		hist           latencyHistogram
+	}
+	deltas := make(map[bigramPair]*delta)
+	for _, smp := range samples {
+		if smp.Ms < 0 || smp.Ms > BigramMaxMs {
+			continue
+		}
+		k := bigramPair{smp.Prev, smp.Next}
+		d, ok := deltas[k]
+		if !ok {
+			d = &delta{hist: latencyHistogram{}}
+			deltas[k] = d
+		}
+		d.count++
+		d.totalMs += smp.Ms
+		d.hist[bigramBucket(smp.Ms)]++
+	}
+
+	for k, d := range deltas {
+		var count, totalMs int64
+		var histText string
+		err := tx.QueryRow("SELECT count, total_ms, histogram FROM bigram_latency WHERE prev = ? AND next = ?",
+			k.prev, k.next).Scan(&count, &totalMs, &histText)
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+		hist := parseHistogram(histText)
+		for b, n := range d.hist {
+			hist[b] += n
+		}
+		_, err = tx.Exec(`
+			INSERT INTO bigram_latency (prev, next, count, total_ms, p90_ms, histogram)
+			VALUES (?, ?, ?, ?, ?, ?)
+			ON CONFLICT(prev, next) DO UPDATE SET
+				count = excluded.count,
+				total_ms = excluded.total_ms,
+				p90_ms = excluded.p90_ms,
+				histogram = excluded.histogram
+		`, k.prev, k.next, count+d.count, totalMs+d.totalMs, hist.percentile(0.9), hist.String())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetBigramStats returns every pair seen at least minCount times, slowest p90
+// first (ties broken by mean).
+func (s *Store) GetBigramStats(minCount int64) ([]BigramStats, error) {
+	rows, err := s.db.Query(`
+		SELECT prev, next, count, total_ms, p90_ms FROM bigram_latency
+		WHERE count >= ? AND count > 0
+		ORDER BY p90_ms DESC, CAST(total_ms AS REAL) / count DESC, prev, next`,
+		minCount,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []BigramStats
+	for rows.Next() {
+		var b BigramStats
+		var totalMs int64
+		if err := rows.Scan(&b.Prev, &b.Next, &b.Count, &totalMs, &b.P90Ms); err != nil {
+			return nil, err
+		}
+		b.MeanMs = float64(totalMs) / float64(b.Count)
+		out = append(out, b)
+	}
+	return out, rows.Err()
+}
+
+// GetSlowestBigrams returns at most limit pairs from GetBigramStats.
+func (s *Store) GetSlowestBigrams(limit int, minCount int64) ([]BigramStats, error) {
+	all, err := s.GetBigramStats(minCount)
+	if err != nil {
+		return nil, err
+	}
+	if limit > 0 && len(all) > limit {
+		all = all[:limit]
+	}
+	return all, nil
+}
@@ -0,0 +1,65 @@
+package storage
+
+import "testing"
+
+func TestLatencyHistogramRoundTripAndPercentile(t *testing.T) {
+	h := latencyHistogram{}
+	for ms := int64(10); ms <= 100; ms += 10 { // 10 samples, 10..100ms
+		h[bigramBucket(ms)]++
+	}
+	parsed := parseHistogram(h.String())
+	if parsed.String() != h.String() {
+		t.Fatalf("round trip: %q != %q", parsed.String(), h.String())
+	}
+	// The 9th of 10 samples is 90ms, in bucket 9 (90-99ms) -> midpoint 95.
+	if got := parsed.percentile(0.9); got != 95 {
+		t.Fatalf("p90: want 95, got %d", got)
+	}
+	if got := (latencyHistogram{}).percentile(0.9); got != 0 {
+		t.Fatalf("p90 of empty histogram: want 0, got %d", got)
+	}
+}
+
+func TestAddBigramLatenciesMerges(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	// t->h: fast and consistent. e->d: slow tail.
+	var first []BigramSample
+	for i := 0; i < 10; i++ {
+		first = append(first, BigramSample{Prev: 17, Next: 4, Ms: 100})
+		first = append(first, BigramSample{Prev: 14, Next: 2, Ms: 120})
+	}
+	first = append(first, BigramSample{Prev: 14, Next: 2, Ms: BigramMaxMs + 1}) // a pause: ignored
+	if err := store.AddBigramLatencies(first); err != nil {
+		t.Fatalf("AddBigramLatencies: %v", err)
+	}
+	// A second flush lands on the existing rows.
+	var second []BigramSample
+	for i := 0; i < 10; i++ {
+		second = append(second, BigramSample{Prev: 14, Next: 2, Ms: 400})
+	}
+	if err := store.AddBigramLatencies(second); err != nil {
+		t.Fatalf("second AddBigramLatencies: %v", err)
+	}
+
+	stats, err := store.GetBigramStats(1)
+	if err != nil {
+		t.Fatalf("GetBigramStats: %v", err)
+	}
+	if len(stats) != 2 {
+		t.Fatalf("want 2 pairs, got %+v", stats)
+	}
+	ed, th := stats[0], stats[1]
+	if ed.Prev != 14 || ed.Next != 2 || ed.Count != 20 || ed.MeanMs != 260 || ed.P90Ms != 405 {
+		t.Fatalf("e->d (slowest first): %+v", ed)
+	}
+	if th.Count != 10 || th.MeanMs != 100 || th.P90Ms != 105 {
+		t.Fatalf("t->h: %+v", th)
+	}
+
+	slowest, _ := store.GetSlowestBigrams(1, 15)
+	if len(slowest) != 1 || slowest[0].Prev != 14 {
+		t.Fatalf("GetSlowestBigrams(1, 15): %+v", slowest)
+	}
+}
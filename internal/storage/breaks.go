@@ -0,0 +1,155 @@
+package storage
+
+// Break reminders. The capture daemons run an rsiguard.Guard over keyboard
+// and mouse activity; its settings live here, and each outcome — a prompted
+// break that was taken or skipped, or a long break taken unprompted — is a
+// row in rsi_breaks. Compliance is read back per kind.
+
+import (
+	"fmt"
+	"time"
+)
+
+// Break kinds, matching rsiguard.Kind.
+const (
+	BreakMicro = "micro"
+	BreakLong  = "long"
+)
+
+// RSISettings configures break reminders. A zero MicroAfter or LongAfter
+// disables reminders of that kind.
+type RSISettings struct {
+	Enabled    bool
+	MicroAfter time.Duration
+	MicroBreak time.Duration
+	LongAfter  time.Duration
+	LongBreak  time.Duration
+}
+
+// DefaultRSISettings mirrors rsiguard.DefaultConfig, off until enabled.
+var DefaultRSISettings = RSISettings{
+	MicroAfter: 20 * time.Minute,
+	MicroBreak: 20 * time.Second,
+	LongAfter:  60 * time.Minute,
+	LongBreak:  5 * time.Minute,
+}
+
+// GetRSISettings returns the break-reminder settings, with defaults for
+// unset or unparseable values.
+func (s *Store) GetRSISettings() RSISettings {
+	r := DefaultRSISettings
+	r.Enabled = s.GetSettingBool(SettingRSIEnabled)
+	get := func(key string, d *time.Duration) {
+		if val, _ := s.GetSetting(key); val != "" {
+			if v, err := time.ParseDuration(val); err == nil && v >= 0 {
+				*d = v
+			}
+		}
+	}
+	get(SettingRSIMicroAfter, &r.MicroAfter)
+	get(SettingRSIMicroBreak, &r.MicroBreak)
+	get(SettingRSILongAfter, &r.LongAfter)
+	get(SettingRSILongBreak, &r.LongBreak)
+	return r
+}
+
+// SetRSIEnabled turns break reminders on or off.
+func (s *Store) SetRSIEnabled(enabled bool) error {
+	return s.SetSettingBool(SettingRSIEnabled, enabled)
+}
+
+// SetRSIBreak sets when a break of kind is due (after; 0 disables it) and how
+// long a pause counts as one (length).
+func (s *Store) SetRSIBreak(kind string, after, length time.Duration) error {
+	if after < 0 {
+		return fmt.Errorf("break interval must not be negative")
+	}
+	if length <= 0 {
+		return fmt.Errorf("break length must be positive")
+	}
+	cur := s.GetRSISettings()
+	var afterKey, lengthKey string
+	switch kind {
+	case BreakMicro:
+		if length >= cur.LongBreak {
+			return fmt.Errorf("a micro-break must be shorter than a long break (%s)", cur.LongBreak)
+		}
+		afterKey, lengthKey = SettingRSIMicroAfter, SettingRSIMicroBreak
+	case BreakLong:
+		if length <= cur.MicroBreak {
+			return fmt.Errorf("a long break must be longer than a micro-break (%s)", cur.MicroBreak)
+		}
+		afterKey, lengthKey = SettingRSILongAfter, SettingRSILongBreak
+	default:
+		return fmt.Errorf("unknown break kind %q (want micro or long)", kind)
+	}
+	if err := s.SetSetting(afterKey, after.String()); err != nil {
+		return err
+	}
+	return s.SetSetting(lengthKey, length.String())
+}
+
+// RecordBreak stores one break outcome. dueAt is when it was prompted (zero
+// if it wasn't); takenAt is when it began (zero if it was skipped); worked is
+// the continuous work before it.
+func (s *Store) RecordBreak(kind string, dueAt, takenAt time.Time, worked time.Duration) error {
+	at := takenAt
+	if at.IsZero() {
+		at = dueAt
+	}
+	_, err := s.db.Exec(`
+		INSERT INTO rsi_breaks (date, kind, due_at, taken_at, worked_ms)
+		VALUES (?, ?, ?, ?, ?)
+	`, at.Format("2006-01-02"), kind, nullTime(dueAt), nullTime(takenAt), worked.Milliseconds())
+	return err
+}
+
+func nullTime(t time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+	return t.Format(time.RFC3339)
+}
+
+// BreakCompliance summarises one kind of break over a period.
+type BreakCompliance struct {
+	Kind       string
+	Prompted   int64         // reminders that were resolved, taken or skipped
+	Taken      int64         // prompted breaks that were taken
+	Skipped    int64         // prompted breaks worked through
+	Unprompted int64         // breaks taken before a reminder was due
+	AvgWorked  time.Duration // mean work before the breaks taken
+}
+
+// Rate returns the share of prompted breaks that were taken, 0-1.
+func (c BreakCompliance) Rate() float64 {
+	if c.Prompted == 0 {
+		return 0
+	}
+	return float64(c.Taken) / float64(c.Prompted)
+}
+
+// GetBreakCompliance returns compliance for micro and long breaks on dates on
+// or after since ("2006-01-02"; empty for all time).
+func (s *Store) GetBreakCompliance(since string) ([]BreakCompliance, error) {
+	out := []BreakCompliance{{Kind: BreakMicro}, {Kind: BreakLong}}
+	for i := range out {
+		c := &out[i]
+		var avgMs float64
+		err := s.db.QueryRow(`
+			SELECT
+				COALESCE(SUM(due_at IS NOT NULL AND taken_at IS NOT NULL), 0),
+				COALESCE(SUM(taken_at IS NULL), 0),
+				COALESCE(SUM(due_at IS NULL), 0),
+				COALESCE(AVG(CASE WHEN taken_at IS NOT NULL THEN worked_ms END), 0)
+			FROM rsi_breaks WHERE kind = ? AND date >= ?`,
+			c.Kind, since,
+		).Scan(&c.Taken, &c.Skipped, &c.Unprompted, &avgMs)
+		if err != nil {
+			return nil, err
+		}
+		c.Prompted = c.Taken + c.Skipped
+		c.AvgWorked = time.Duration(avgMs) * time.Millisecond
+	}
+	return out, nil
+}
@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRSISettings(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	if got := store.GetRSISettings(); got != DefaultRSISettings {
+		t.Fatalf("defaults: %+v", got)
+	}
+	if err := store.SetRSIEnabled(true); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SetRSIBreak(BreakMicro, 15*time.Minute, 30*time.Second); err != nil {
+		t.Fatalf("SetRSIBreak: %v", err)
+	}
+	if err := store.SetRSIBreak(BreakMicro, 15*time.Minute, 10*time.Minute); err == nil {
+		t.Error("a micro-break as long as a long break should be rejected")
+	}
+	if err := store.SetRSIBreak("lunch", time.Hour, time.Hour); err == nil {
+		t.Error("unknown kind should be rejected")
+	}
+	got := store.GetRSISettings()
+	if !got.Enabled || got.MicroAfter != 15*time.Minute || got.MicroBreak != 30*time.Second || got.LongAfter != time.Hour {
+		t.Errorf("settings: %+v", got)
+	}
+}
+
+func TestBreakCompliance(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	day := time.Date(2025, 3, 1, 10, 0, 0, 0, time.Local)
+	records := []struct {
+		kind           string
+		dueAt, takenAt time.Time
+		worked         time.Duration
+	}{
+		{BreakMicro, day, day.Add(time.Minute), 20 * time.Minute},
+		{BreakMicro, day.Add(time.Hour), time.Time{}, 40 * time.Minute},
+		{BreakMicro, day.Add(2 * time.Hour), day.Add(2 * time.Hour), 30 * time.Minute},
+		{BreakLong, time.Time{}, day.Add(3 * time.Hour), 50 * time.Minute},
+		{BreakMicro, day.AddDate(0, 0, -10), time.Time{}, time.Hour},
+	}
+	for _, r := range records {
+		if err := store.RecordBreak(r.kind, r.dueAt, r.takenAt, r.worked); err != nil {
+			t.Fatalf("RecordBreak: %v", err)
+		}
+	}
+
+	got, err := store.GetBreakCompliance("2025-03-01")
+	if err != nil {
+		t.Fatalf("GetBreakCompliance: %v", err)
+	}
+	micro, long := got[0], got[1]
+	if micro.Prompted != 3 || micro.Taken != 2 || micro.Skipped != 1 || micro.Unprompted != 0 || micro.AvgWorked != 25*time.Minute {
+		t.Errorf("micro: %+v", micro)
+	}
+	if long.Prompted != 0 || long.Unprompted != 1 || long.AvgWorked != 50*time.Minute {
+		t.Errorf("long: %+v", long)
+	}
+
+	all, _ := store.GetBreakCompliance("")
+	if all[0].Skipped != 2 {
+		t.Errorf("all time micro: %+v", all[0])
+	}
+}
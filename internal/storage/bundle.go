@@ -0,0 +1,1106 @@
+package storage
+
+// Portable export/import bundles. A bundle is JSON lines: a header record
+// followed by one record per row, each {"kind": ..., "data": {...}}. It carries
+// the aggregate tables a user would want on a new machine — daily_summary,
+// mouse_daily, app_daily, odometer_history, focus_sessions, rsi_breaks,
+// typing-test results and history, bigram_latency, the device tables and
+// settings — but not the raw keystrokes table, which only feeds hourly charts.
+//
+// Import merges rather than replaces, so restoring an old backup onto a
+// machine that has kept recording never clobbers newer data: absolute
+// per-date counters take the max of both sides, odometer sessions are deduped
+// on (start_time, end_time), focus sessions on (tag, start_time), breaks on
+// (kind, due_at, taken_at), typing tests on (completed_at, mode) — a test's
+// per-character counts come in only with the test itself — bigram rows come
+// from whichever side has more samples, and local settings win unless
+// overwriting is asked for. Importing the same bundle twice is a no-op.
+
+import (
+	"bufio"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// BundleFormat and BundleVersion identify the bundle header. Bump the version
+// when a record's shape changes incompatibly; Import rejects newer bundles.
+const (
+	BundleFormat  = "typtel-bundle"
+	BundleVersion = 1
+)
+
+// Bundle record kinds, in the order Export writes them.
+const (
+	BundleKindHeader          = "header"
+	BundleKindDailySummary    = "daily_summary"
+	BundleKindMouseDaily      = "mouse_daily"
+	BundleKindAppDaily        = "app_daily"
+	BundleKindOdometerHistory = "odometer_history"
+	BundleKindFocusSession    = "focus_session"
+	BundleKindBreak           = "rsi_break"
+	BundleKindTypingTest      = "typing_test"
+	BundleKindTypingTestRun   = "typing_test_result"
+	BundleKindBigram          = "bigram_latency"
+	BundleKindDevice          = "device"
+	BundleKindDeviceDay       = "device_day"
+	BundleKindSetting         = "setting"
+)
+
+// bundleRecord is one JSON line.
+type bundleRecord struct {
+	Kind string          `json:"kind"`
+	Data json.RawMessage `json:"data"`
+}
+
+type bundleHeader struct {
+	Format        string `json:"format"`
+	Version       int    `json:"version"`
+	SchemaVersion int    `json:"schema_version"`
+	ExportedAt    string `json:"exported_at"`
+}
+
+type bundleDaily struct {
+	Date             string  `json:"date"`
+	Keystrokes       int64   `json:"keystrokes"`
+	Words            int64   `json:"words"`
+	Letters          int64   `json:"letters"`
+	Modifiers        int64   `json:"modifiers"`
+	Special          int64   `json:"special"`
+	ActiveMs         int64   `json:"active_ms"`
+	FastestBurstWPM  float64 `json:"fastest_burst_wpm"`
+	FastestWindowWPM float64 `json:"fastest_window_wpm"`
+	FastestMinuteWPM float64 `json:"fastest_minute_wpm"`
+}
+
+type bundleMouse struct {
+	Date          string  `json:"date"`
+	TotalDistance float64 `json:"total_distance"`
+	MidnightX     float64 `json:"midnight_x"`
+	MidnightY     float64 `json:"midnight_y"`
+	CurrentX      float64 `json:"current_x"`
+	CurrentY      float64 `json:"current_y"`
+	SumAbsError   float64 `json:"sum_abs_error"`
+	MovementCount int64   `json:"movement_count"`
+	ClickCount    int64   `json:"click_count"`
+}
+
+type bundleAppDay struct {
+	Date       string `json:"date"`
+	AppID      string `json:"app_id"`
+	Keystrokes int64  `json:"keystrokes"`
+	Words      int64  `json:"words"`
+	ActiveMs   int64  `json:"active_ms"`
+}
+
+// bundleFocusSession is one finished focus session; a running one stays on
+// the machine it runs on.
+type bundleFocusSession struct {
+	Tag           string  `json:"tag"`
+	StartTime     string  `json:"start_time"`
+	EndTime       string  `json:"end_time"`
+	TargetKind    string  `json:"target_kind,omitempty"`
+	Target        int64   `json:"target,omitempty"`
+	Keystrokes    int64   `json:"keystrokes"`
+	Words         int64   `json:"words"`
+	ActiveMs      int64   `json:"active_ms"`
+	PeakBurstWPM  float64 `json:"peak_burst_wpm"`
+	GoalReachedAt string  `json:"goal_reached_at,omitempty"`
+}
+
+// bundleBreak is one rsi_breaks row. DueAt is empty for an unprompted
+// break and TakenAt for a skipped one.
+type bundleBreak struct {
+	Date     string `json:"date"`
+	Kind     string `json:"kind"`
+	DueAt    string `json:"due_at,omitempty"`
+	TakenAt  string `json:"taken_at,omitempty"`
+	WorkedMs int64  `json:"worked_ms"`
+}
+
+type bundleBigram struct {
+	Prev      int    `json:"prev"`
+	Next      int    `json:"next"`
+	Count     int64  `json:"count"`
+	TotalMs   int64  `json:"total_ms"`
+	P90Ms     int64  `json:"p90_ms"`
+	Histogram string `json:"histogram"`
+}
+
+type bundleOdometer struct {
+	Label      string  `json:"label,omitempty"`
+	StartTime  string  `json:"start_time"`
+	EndTime    string  `json:"end_time"`
+	Keystrokes int64   `json:"keystrokes"`
+	Words      int64   `json:"words"`
+	Clicks     int64   `json:"clicks"`
+	Distance   float64 `json:"distance"`
+}
+
+// bundleTypingTest is one typing-test result bucket; Mode is empty for the
+// global bucket and a TypingTestMode.ModeKey() otherwise.
+type bundleTypingTest struct {
+	Mode         string  `json:"mode"`
+	PersonalBest float64 `json:"personal_best"`
+	AverageWPM   float64 `json:"average_wpm"`
+	TestCount    int     `json:"test_count"`
+}
+
+// bundleTypingTestRun is one completed test from the typing_tests history,
+// with its replay data.
+type bundleTypingTestRun struct {
+	CompletedAt       string             `json:"completed_at"`
+	Mode              string             `json:"mode"`
+	TestType          string             `json:"test_type"`
+	Layout            string             `json:"layout"`
+	Language          string             `json:"language"`
+	DurationMs        int64              `json:"duration_ms"`
+	RawWPM            float64            `json:"raw_wpm"`
+	NetWPM            float64            `json:"net_wpm"`
+	Accuracy          float64            `json:"accuracy"`
+	CPM               int                `json:"cpm"`
+	Errors            int                `json:"errors"`
+	UncorrectedErrors int                `json:"uncorrected_errors"`
+	WPMSeries         []float64          `json:"wpm_series,omitempty"`
+	TargetText        string             `json:"target_text,omitempty"`
+	Keystrokes        []Keystroke        `json:"timeline,omitempty"`
+	Chars             []bundleTypingChar `json:"chars,omitempty"`
+}
+
+// bundleTypingChar is one of a test's typing_test_chars rows.
+type bundleTypingChar struct {
+	Expected string `json:"expected"`
+	Typed    string `json:"typed"`
+	Count    int64  `json:"count"`
+}
+
+type bundleDevice struct {
+	DeviceID  string `json:"device_id"`
+	Name      string `json:"name"`
+	CreatedAt string `json:"created_at"`
+	LastSeen  string `json:"last_seen"`
+}
+
+type bundleDeviceDay struct {
+	DeviceID string `json:"device_id"`
+	DeviceDay
+}
+
+type bundleSetting struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// ExportOptions tunes Export.
+type ExportOptions struct {
+	// IncludeSecrets exports bearer tokens (device_ingest_token, push_token).
+	// Off by default so a bundle can be shared or stored without leaking them.
+	IncludeSecrets bool
+}
+
+// ImportOptions tunes Import.
+type ImportOptions struct {
+	// OverwriteSettings lets bundle settings replace local values. By default
+	// only settings absent locally are imported.
+	OverwriteSettings bool
+	// DryRun parses and merges inside a transaction that is rolled back.
+	DryRun bool
+}
+
+// BundleSummary counts records per kind. For Import, Applied counts records
+// that changed the database and Skipped those that were already covered.
+type BundleSummary struct {
+	Applied map[string]int
+	Skipped map[string]int
+}
+
+func newBundleSummary() BundleSummary {
+	return BundleSummary{Applied: make(map[string]int), Skipped: make(map[string]int)}
+}
+
+// Kinds returns the record kinds present in the summary, in bundle order.
+func (b BundleSummary) Kinds() []string {
+	order := []string{BundleKindDailySummary, BundleKindMouseDaily, BundleKindAppDaily,
+		BundleKindOdometerHistory, BundleKindFocusSession, BundleKindBreak, BundleKindTypingTest,
+		BundleKindTypingTestRun, BundleKindBigram, BundleKindDevice,
+		BundleKindDeviceDay, BundleKindSetting}
+	var out []string
+	for _, k := range order {
+		if b.Applied[k] > 0 || b.Skipped[k] > 0 {
+			out = append(out, k)
+		}
+	}
+	return out
+}
+
+// settingSecrets are never exported unless ExportOptions.IncludeSecrets.
+var settingSecrets = map[string]bool{
+	SettingDeviceIngestToken: true,
+	SettingPushToken:         true,
+}
+
+// settingInternal are machine-local and never travel: importing
+// speed_backfill_done, say, would skip the target's own backfill, and
+// push_device_id would have two machines push as the same device.
+var settingInternal = map[string]bool{
+	settingBackfillDone:    true,
+	SettingPushDeviceID:    true,
+	SettingPushDeviceName:  true,
+	SettingCaptureBackend:  true,
+	SettingEvdevDevices:    true,
+	SettingLocalAPIEnabled: true,
+	SettingLocalAPIAddr:    true,
+	SettingLocalAPIEvents:  true,
+	SettingLocalAPIMetrics: true,
+}
+
+// isTypingTestResultKey reports whether a settings key holds typing-test
+// results (exported as typing_test records rather than raw settings), and if
+// so which bucket and field it belongs to.
+func isTypingTestResultKey(key string) (mode, field string, ok bool) {
+	for _, f := range []string{SettingTypingTestPB, SettingTypingTestAvgWPM, SettingTypingTestCount} {
+		if key == f {
+			return "", f, true
+		}
+		if strings.HasPrefix(key, f+"_mode_") {
+			return strings.TrimPrefix(key, f+"_"), f, true
+		}
+	}
+	return "", "", false
+}
+
+// typingTestKey returns the settings key for a typing-test field and bucket.
+func typingTestKey(field, mode string) string {
+	if mode == "" {
+		return field
+	}
+	return field + "_" + mode
+}
+
+// Export writes a bundle of every exportable table to w.
+func (s *Store) Export(w io.Writer, opts ExportOptions) (BundleSummary, error) {
+	sum := newBundleSummary()
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+
+	emit := func(kind string, v any) error {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		if kind != BundleKindHeader {
+			sum.Applied[kind]++
+		}
+		return enc.Encode(bundleRecord{Kind: kind, Data: data})
+	}
+
+	schema, err := s.SchemaVersion()
+	if err != nil {
+		return sum, err
+	}
+	if err := emit(BundleKindHeader, bundleHeader{
+		Format:        BundleFormat,
+		Version:       BundleVersion,
+		SchemaVersion: schema,
+		ExportedAt:    time.Now().Format(time.RFC3339),
+	}); err != nil {
+		return sum, err
+	}
+
+	if err := s.exportDaily(emit); err != nil {
+		return sum, fmt.Errorf("daily_summary: %w", err)
+	}
+	if err := s.exportMouse(emit); err != nil {
+		return sum, fmt.Errorf("mouse_daily: %w", err)
+	}
+	if err := s.exportAppDaily(emit); err != nil {
+		return sum, fmt.Errorf("app_daily: %w", err)
+	}
+	if err := s.exportOdometer(emit); err != nil {
+		return sum, fmt.Errorf("odometer_history: %w", err)
+	}
+	if err := s.exportFocusSessions(emit); err != nil {
+		return sum, fmt.Errorf("focus_sessions: %w", err)
+	}
+	if err := s.exportBreaks(emit); err != nil {
+		return sum, fmt.Errorf("rsi_breaks: %w", err)
+	}
+	if err := s.exportTypingTests(emit); err != nil {
+		return sum, fmt.Errorf("typing_tests: %w", err)
+	}
+	if err := s.exportBigrams(emit); err != nil {
+		return sum, fmt.Errorf("bigram_latency: %w", err)
+	}
+	if err := s.exportDevices(emit); err != nil {
+		return sum, fmt.Errorf("devices: %w", err)
+	}
+	if err := s.exportSettings(emit, opts); err != nil {
+		return sum, fmt.Errorf("settings: %w", err)
+	}
+
+	return sum, bw.Flush()
+}
+
+type emitFunc func(kind string, v any) error
+
+func (s *Store) exportDaily(emit emitFunc) error {
+	rows, err := s.db.Query(`
+		SELECT date, COALESCE(keystrokes, 0), COALESCE(words, 0), COALESCE(letters, 0),
+			COALESCE(modifiers, 0), COALESCE(special, 0), COALESCE(active_ms, 0),
+			COALESCE(fastest_burst_wpm, 0), COALESCE(fastest_window_wpm, 0),
+			COALESCE(fastest_minute_wpm, 0)
+		FROM daily_summary ORDER BY date`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var d bundleDaily
+		if err := rows.Scan(&d.Date, &d.Keystrokes, &d.Words, &d.Letters, &d.Modifiers, &d.Special,
+			&d.ActiveMs, &d.FastestBurstWPM, &d.FastestWindowWPM, &d.FastestMinuteWPM); err != nil {
+			return err
+		}
+		if err := emit(BundleKindDailySummary, d); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (s *Store) exportMouse(emit emitFunc) error {
+	rows, err := s.db.Query(`
+		SELECT date, COALESCE(total_distance, 0), COALESCE(midnight_x, 0), COALESCE(midnight_y, 0),
+			COALESCE(current_x, 0), COALESCE(current_y, 0), COALESCE(sum_abs_error, 0),
+			COALESCE(movement_count, 0), COALESCE(click_count, 0)
+		FROM mouse_daily ORDER BY date`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var m bundleMouse
+		if err := rows.Scan(&m.Date, &m.TotalDistance, &m.MidnightX, &m.MidnightY, &m.CurrentX,
+			&m.CurrentY, &m.SumAbsError, &m.MovementCount, &m.ClickCount); err != nil {
+			return err
+		}
+		if err := emit(BundleKindMouseDaily, m); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (s *Store) exportOdometer(emit emitFunc) error {
+	rows, err := s.db.Query(`
+		SELECT COALESCE(label, ''), start_time, end_time, COALESCE(keystrokes, 0), COALESCE(words, 0),
+			COALESCE(clicks, 0), COALESCE(distance, 0)
+		FROM odometer_history ORDER BY start_time`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var o bundleOdometer
+		if err := rows.Scan(&o.Label, &o.StartTime, &o.EndTime, &o.Keystrokes, &o.Words, &o.Clicks, &o.Distance); err != nil {
+			return err
+		}
+		if err := emit(BundleKindOdometerHistory, o); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (s *Store) exportAppDaily(emit emitFunc) error {
+	rows, err := s.db.Query(`
+		SELECT date, app_id, COALESCE(keystrokes, 0), COALESCE(words, 0), COALESCE(active_ms, 0)
+		FROM app_daily ORDER BY date, app_id`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var a bundleAppDay
+		if err := rows.Scan(&a.Date, &a.AppID, &a.Keystrokes, &a.Words, &a.ActiveMs); err != nil {
+			return err
+		}
+		if err := emit(BundleKindAppDaily, a); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (s *Store) exportFocusSessions(emit emitFunc) error {
+	rows, err := s.db.Query(`
+		SELECT tag, start_time, end_time, COALESCE(target_kind, ''), COALESCE(target, 0),
+			COALESCE(keystrokes, 0), COALESCE(words, 0), COALESCE(active_ms, 0),
+			COALESCE(peak_burst_wpm, 0), COALESCE(goal_reached_at, '')
+		FROM focus_sessions WHERE end_time IS NOT NULL ORDER BY start_time, id`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var f bundleFocusSession
+		if err := rows.Scan(&f.Tag, &f.StartTime, &f.EndTime, &f.TargetKind, &f.Target, &f.Keystrokes,
+			&f.Words, &f.ActiveMs, &f.PeakBurstWPM, &f.GoalReachedAt); err != nil {
+			return err
+		}
+		if err := emit(BundleKindFocusSession, f); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (s *Store) exportBreaks(emit emitFunc) error {
+	rows, err := s.db.Query(`
+		SELECT date, kind, COALESCE(due_at, ''), COALESCE(taken_at, ''), COALESCE(worked_ms, 0)
+		FROM rsi_breaks ORDER BY date, id`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var b bundleBreak
+		if err := rows.Scan(&b.Date, &b.Kind, &b.DueAt, &b.TakenAt, &b.WorkedMs); err != nil {
+			return err
+		}
+		if err := emit(BundleKindBreak, b); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (s *Store) exportBigrams(emit emitFunc) error {
+	rows, err := s.db.Query(`
+		SELECT prev, next, COALESCE(count, 0), COALESCE(total_ms, 0), COALESCE(p90_ms, 0),
+			COALESCE(histogram, '')
+		FROM bigram_latency ORDER BY prev, next`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var b bundleBigram
+		if err := rows.Scan(&b.Prev, &b.Next, &b.Count, &b.TotalMs, &b.P90Ms, &b.Histogram); err != nil {
+			return err
+		}
+		if err := emit(BundleKindBigram, b); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (s *Store) exportTypingTests(emit emitFunc) error {
+	chars, err := s.typingTestCharRows()
+	if err != nil {
+		return err
+	}
+
+	rows, err := s.db.Query("SELECT " + typingTestReplayColumns + " FROM typing_tests ORDER BY completed_at, id")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		r, err := scanTypingTest(rows, true)
+		if err != nil {
+			return err
+		}
+		if err := emit(BundleKindTypingTestRun, bundleTypingTestRun{
+			CompletedAt: r.CompletedAt.Format(time.RFC3339), Mode: r.Mode, TestType: r.TestType,
+			Layout: r.Layout, Language: r.Language, DurationMs: r.Duration.Milliseconds(),
+			RawWPM: r.RawWPM, NetWPM: r.NetWPM, Accuracy: r.Accuracy, CPM: r.CPM,
+			Errors: r.Errors, UncorrectedErrors: r.UncorrectedErrors, WPMSeries: r.WPMSeries,
+			TargetText: r.TargetText, Keystrokes: r.Keystrokes, Chars: chars[r.ID],
+		}); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// typingTestCharRows returns typing_test_chars grouped by test id.
+func (s *Store) typingTestCharRows() (map[int64][]bundleTypingChar, error) {
+	rows, err := s.db.Query(`
+		SELECT test_id, expected, typed, COALESCE(count, 0) FROM typing_test_chars
+		ORDER BY test_id, expected, typed`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	chars := make(map[int64][]bundleTypingChar)
+	for rows.Next() {
+		var id int64
+		var c bundleTypingChar
+		if err := rows.Scan(&id, &c.Expected, &c.Typed, &c.Count); err != nil {
+			return nil, err
+		}
+		chars[id] = append(chars[id], c)
+	}
+	return chars, rows.Err()
+}
+
+func (s *Store) exportDevices(emit emitFunc) error {
+	rows, err := s.db.Query(`
+		SELECT device_id, COALESCE(name, ''), COALESCE(created_at, ''), COALESCE(last_seen, '')
+		FROM devices ORDER BY device_id`)
+	if err != nil {
+		return err
+	}
+	var devices []bundleDevice
+	for rows.Next() {
+		var d bundleDevice
+		if err := rows.Scan(&d.DeviceID, &d.Name, &d.CreatedAt, &d.LastSeen); err != nil {
+			rows.Close()
+			return err
+		}
+		devices = append(devices, d)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return err
+	}
+	rows.Close()
+
+	for _, d := range devices {
+		if err := emit(BundleKindDevice, d); err != nil {
+			return err
+		}
+	}
+
+	rows, err = s.db.Query(`
+		SELECT device_id, date, keystrokes, letters, modifiers, special, words, active_ms
+		FROM device_daily_summary ORDER BY device_id, date`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var d bundleDeviceDay
+		if err := rows.Scan(&d.DeviceID, &d.Date, &d.Keystrokes, &d.Letters, &d.Modifiers,
+			&d.Special, &d.Words, &d.ActiveMs); err != nil {
+			return err
+		}
+		if err := emit(BundleKindDeviceDay, d); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// exportSettings writes plain settings, and regroups the typing-test result
+// keys into one typing_test record per mode bucket.
+func (s *Store) exportSettings(emit emitFunc, opts ExportOptions) error {
+	rows, err := s.db.Query("SELECT key, COALESCE(value, '') FROM settings ORDER BY key")
+	if err != nil {
+		return err
+	}
+	var settings []bundleSetting
+	tests := make(map[string]*bundleTypingTest)
+	for rows.Next() {
+		var kv bundleSetting
+		if err := rows.Scan(&kv.Key, &kv.Value); err != nil {
+			rows.Close()
+			return err
+		}
+		if mode, field, ok := isTypingTestResultKey(kv.Key); ok {
+			t, seen := tests[mode]
+			if !seen {
+				t = &bundleTypingTest{Mode: mode}
+				tests[mode] = t
+			}
+			switch field {
+			case SettingTypingTestPB:
+				t.PersonalBest, _ = parseFloat(kv.Value)
+			case SettingTypingTestAvgWPM:
+				t.AverageWPM, _ = parseFloat(kv.Value)
+			case SettingTypingTestCount:
+				t.TestCount, _ = parseInt(kv.Value)
+			}
+			continue
+		}
+		if settingInternal[kv.Key] || (settingSecrets[kv.Key] && !opts.IncludeSecrets) {
+			continue
+		}
+		settings = append(settings, kv)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return err
+	}
+	rows.Close()
+
+	modes := make([]string, 0, len(tests))
+	for mode := range tests {
+		modes = append(modes, mode)
+	}
+	sort.Strings(modes)
+	for _, mode := range modes {
+		if err := emit(BundleKindTypingTest, tests[mode]); err != nil {
+			return err
+		}
+	}
+	for _, kv := range settings {
+		if err := emit(BundleKindSetting, kv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Import merges a bundle read from r into the store in a single transaction.
+func (s *Store) Import(r io.Reader, opts ImportOptions) (BundleSummary, error) {
+	sum := newBundleSummary()
+
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return sum, err
+	}
+	defer tx.Rollback()
+
+	line := 0
+	sawHeader := false
+	for sc.Scan() {
+		line++
+		raw := strings.TrimSpace(sc.Text())
+		if raw == "" {
+			continue
+		}
+		var rec bundleRecord
+		if err := json.Unmarshal([]byte(raw), &rec); err != nil {
+			return sum, fmt.Errorf("line %d: %w", line, err)
+		}
+		if !sawHeader {
+			if rec.Kind != BundleKindHeader {
+				return sum, fmt.Errorf("line %d: not a %s (missing header)", line, BundleFormat)
+			}
+			var h bundleHeader
+			if err := json.Unmarshal(rec.Data, &h); err != nil {
+				return sum, fmt.Errorf("line %d: header: %w", line, err)
+			}
+			if h.Format != BundleFormat {
+				return sum, fmt.Errorf("line %d: unknown bundle format %q", line, h.Format)
+			}
+			if h.Version > BundleVersion {
+				return sum, fmt.Errorf("bundle version %d is newer than this typtel supports (%d)", h.Version, BundleVersion)
+			}
+			sawHeader = true
+			continue
+		}
+
+		applied, err := importRecord(tx, rec, opts)
+		if err != nil {
+			return sum, fmt.Errorf("line %d (%s): %w", line, rec.Kind, err)
+		}
+		if applied {
+			sum.Applied[rec.Kind]++
+		} else {
+			sum.Skipped[rec.Kind]++
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return sum, err
+	}
+	if !sawHeader {
+		return sum, fmt.Errorf("empty bundle")
+	}
+
+	if opts.DryRun {
+		return sum, nil
+	}
+	return sum, tx.Commit()
+}
+
+// importRecord merges one record and reports whether it changed anything.
+// Unknown kinds are skipped so older builds can read bundles that grew new
+// record types without a version bump.
+func importRecord(tx *sql.Tx, rec bundleRecord, opts ImportOptions) (bool, error) {
+	switch rec.Kind {
+	case BundleKindDailySummary:
+		var d bundleDaily
+		if err := json.Unmarshal(rec.Data, &d); err != nil {
+			return false, err
+		}
+		return importDaily(tx, d)
+	case BundleKindMouseDaily:
+		var m bundleMouse
+		if err := json.Unmarshal(rec.Data, &m); err != nil {
+			return false, err
+		}
+		return importMouse(tx, m)
+	case BundleKindAppDaily:
+		var a bundleAppDay
+		if err := json.Unmarshal(rec.Data, &a); err != nil {
+			return false, err
+		}
+		return importAppDay(tx, a)
+	case BundleKindOdometerHistory:
+		var o bundleOdometer
+		if err := json.Unmarshal(rec.Data, &o); err != nil {
+			return false, err
+		}
+		return importOdometer(tx, o)
+	case BundleKindFocusSession:
+		var f bundleFocusSession
+		if err := json.Unmarshal(rec.Data, &f); err != nil {
+			return false, err
+		}
+		return importFocusSession(tx, f)
+	case BundleKindBreak:
+		var b bundleBreak
+		if err := json.Unmarshal(rec.Data, &b); err != nil {
+			return false, err
+		}
+		return importBreak(tx, b)
+	case BundleKindTypingTest:
+		var t bundleTypingTest
+		if err := json.Unmarshal(rec.Data, &t); err != nil {
+			return false, err
+		}
+		return importTypingTest(tx, t)
+	case BundleKindTypingTestRun:
+		var r bundleTypingTestRun
+		if err := json.Unmarshal(rec.Data, &r); err != nil {
+			return false, err
+		}
+		return importTypingTestRun(tx, r)
+	case BundleKindBigram:
+		var b bundleBigram
+		if err := json.Unmarshal(rec.Data, &b); err != nil {
+			return false, err
+		}
+		return importBigram(tx, b)
+	case BundleKindDevice:
+		var d bundleDevice
+		if err := json.Unmarshal(rec.Data, &d); err != nil {
+			return false, err
+		}
+		return importDevice(tx, d)
+	case BundleKindDeviceDay:
+		var d bundleDeviceDay
+		if err := json.Unmarshal(rec.Data, &d); err != nil {
+			return false, err
+		}
+		return importDeviceDay(tx, d)
+	case BundleKindSetting:
+		var kv bundleSetting
+		if err := json.Unmarshal(rec.Data, &kv); err != nil {
+			return false, err
+		}
+		return importSetting(tx, kv, opts)
+	}
+	return false, nil
+}
+
+// changed reports whether the last statement touched any row.
+func changed(res sql.Result) (bool, error) {
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func importDaily(tx *sql.Tx, d bundleDaily) (bool, error) {
+	res, err := tx.Exec(`
+		INSERT INTO daily_summary (date, keystrokes, words, letters, modifiers, special,
+			active_ms, fastest_burst_wpm, fastest_window_wpm, fastest_minute_wpm)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(date) DO UPDATE SET
+			keystrokes = MAX(keystrokes, excluded.keystrokes),
+			words = MAX(words, excluded.words),
+			letters = MAX(COALESCE(letters, 0), excluded.letters),
+			modifiers = MAX(COALESCE(modifiers, 0), excluded.modifiers),
+			special = MAX(COALESCE(special, 0), excluded.special),
+			active_ms = MAX(COALESCE(active_ms, 0), excluded.active_ms),
+			fastest_burst_wpm = MAX(COALESCE(fastest_burst_wpm, 0), excluded.fastest_burst_wpm),
+			fastest_window_wpm = MAX(COALESCE(fastest_window_wpm, 0), excluded.fastest_window_wpm),
+			fastest_minute_wpm = MAX(COALESCE(fastest_minute_wpm, 0), excluded.fastest_minute_wpm),
+			updated_at = CURRENT_TIMESTAMP
+		WHERE excluded.keystrokes > keystrokes OR excluded.words > words
+			OR excluded.letters > COALESCE(letters, 0) OR excluded.modifiers > COALESCE(modifiers, 0)
+			OR excluded.special > COALESCE(special, 0) OR excluded.active_ms > COALESCE(active_ms, 0)
+			OR excluded.fastest_burst_wpm > COALESCE(fastest_burst_wpm, 0)
+			OR excluded.fastest_window_wpm > COALESCE(fastest_window_wpm, 0)
+			OR excluded.fastest_minute_wpm > COALESCE(fastest_minute_wpm, 0)
+	`, d.Date, d.Keystrokes, d.Words, d.Letters, d.Modifiers, d.Special,
+		d.ActiveMs, d.FastestBurstWPM, d.FastestWindowWPM, d.FastestMinuteWPM)
+	if err != nil {
+		return false, err
+	}
+	return changed(res)
+}
+
+// importMouse takes the max of the day's counters. Positions describe a single
+// session's pointer, so they come from whichever side saw more movement.
+func importMouse(tx *sql.Tx, m bundleMouse) (bool, error) {
+	res, err := tx.Exec(`
+		INSERT INTO mouse_daily (date, total_distance, midnight_x, midnight_y, current_x, current_y,
+			sum_abs_error, movement_count, click_count)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(date) DO UPDATE SET
+			midnight_x = CASE WHEN excluded.movement_count > movement_count THEN excluded.midnight_x ELSE midnight_x END,
+			midnight_y = CASE WHEN excluded.movement_count > movement_count THEN excluded.midnight_y ELSE midnight_y END,
+			current_x = CASE WHEN excluded.movement_count > movement_count THEN excluded.current_x ELSE current_x END,
+			current_y = CASE WHEN excluded.movement_count > movement_count THEN excluded.current_y ELSE current_y END,
+			total_distance = MAX(total_distance, excluded.total_distance),
+			sum_abs_error = MAX(sum_abs_error, excluded.sum_abs_error),
+			movement_count = MAX(movement_count, excluded.movement_count),
+			click_count = MAX(COALESCE(click_count, 0), excluded.click_count),
+			updated_at = CURRENT_TIMESTAMP
+		WHERE excluded.total_distance > total_distance OR excluded.sum_abs_error > sum_abs_error
+			OR excluded.movement_count > movement_count OR excluded.click_count > COALESCE(click_count, 0)
+	`, m.Date, m.TotalDistance, m.MidnightX, m.MidnightY, m.CurrentX, m.CurrentY,
+		m.SumAbsError, m.MovementCount, m.ClickCount)
+	if err != nil {
+		return false, err
+	}
+	return changed(res)
+}
+
+// importOdometer inserts a session unless one with the same start and end
+// already exists.
+func importOdometer(tx *sql.Tx, o bundleOdometer) (bool, error) {
+	res, err := tx.Exec(`
+		INSERT INTO odometer_history (start_time, end_time, keystrokes, words, clicks, distance, label)
+		SELECT ?, ?, ?, ?, ?, ?, ?
+		WHERE NOT EXISTS (SELECT 1 FROM odometer_history WHERE start_time = ? AND end_time = ?)
+	`, o.StartTime, o.EndTime, o.Keystrokes, o.Words, o.Clicks, o.Distance, o.Label, o.StartTime, o.EndTime)
+	if err != nil {
+		return false, err
+	}
+	return changed(res)
+}
+
+func importAppDay(tx *sql.Tx, a bundleAppDay) (bool, error) {
+	res, err := tx.Exec(`
+		INSERT INTO app_daily (date, app_id, keystrokes, words, active_ms)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(date, app_id) DO UPDATE SET
+			keystrokes = MAX(keystrokes, excluded.keystrokes),
+			words = MAX(words, excluded.words),
+			active_ms = MAX(active_ms, excluded.active_ms)
+		WHERE excluded.keystrokes > keystrokes OR excluded.words > words
+			OR excluded.active_ms > active_ms
+	`, a.Date, a.AppID, a.Keystrokes, a.Words, a.ActiveMs)
+	if err != nil {
+		return false, err
+	}
+	return changed(res)
+}
+
+// importFocusSession inserts a finished session unless one with the same tag
+// already started at the same second.
+func importFocusSession(tx *sql.Tx, f bundleFocusSession) (bool, error) {
+	res, err := tx.Exec(`
+		INSERT INTO focus_sessions (tag, start_time, end_time, target_kind, target, keystrokes,
+			words, active_ms, peak_burst_wpm, goal_reached_at)
+		SELECT ?, ?, ?, ?, ?, ?, ?, ?, ?, NULLIF(?, '')
+		WHERE NOT EXISTS (SELECT 1 FROM focus_sessions WHERE tag = ? AND start_time = ?)
+	`, f.Tag, f.StartTime, f.EndTime, f.TargetKind, f.Target, f.Keystrokes, f.Words, f.ActiveMs,
+		f.PeakBurstWPM, f.GoalReachedAt, f.Tag, f.StartTime)
+	if err != nil {
+		return false, err
+	}
+	return changed(res)
+}
+
+// importBreak inserts a break unless the same one — kind, due and taken
+// times — is already logged.
+func importBreak(tx *sql.Tx, b bundleBreak) (bool, error) {
+	res, err := tx.Exec(`
+		INSERT INTO rsi_breaks (date, kind, due_at, taken_at, worked_ms)
+		SELECT ?, ?, NULLIF(?, ''), NULLIF(?, ''), ?
+		WHERE NOT EXISTS (
+			SELECT 1 FROM rsi_breaks WHERE kind = ?
+				AND COALESCE(due_at, '') = ? AND COALESCE(taken_at, '') = ?)
+	`, b.Date, b.Kind, b.DueAt, b.TakenAt, b.WorkedMs, b.Kind, b.DueAt, b.TakenAt)
+	if err != nil {
+		return false, err
+	}
+	return changed(res)
+}
+
+// importBigram takes the whole row from whichever side has more samples:
+// both may hold the same history, and a histogram can't be max-merged.
+func importBigram(tx *sql.Tx, b bundleBigram) (bool, error) {
+	res, err := tx.Exec(`
+		INSERT INTO bigram_latency (prev, next, count, total_ms, p90_ms, histogram)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(prev, next) DO UPDATE SET
+			count = excluded.count, total_ms = excluded.total_ms,
+			p90_ms = excluded.p90_ms, histogram = excluded.histogram
+		WHERE excluded.count > count
+	`, b.Prev, b.Next, b.Count, b.TotalMs, b.P90Ms, b.Histogram)
+	if err != nil {
+		return false, err
+	}
+	return changed(res)
+}
+
+// importTypingTest keeps the higher personal best, and takes the running
+// average and count from whichever side has completed more tests — the two
+// histories may overlap, so they cannot be summed.
+func importTypingTest(tx *sql.Tx, t bundleTypingTest) (bool, error) {
+	get := func(key string) string {
+		var v string
+		_ = tx.QueryRow("SELECT value FROM settings WHERE key = ?", key).Scan(&v)
+		return v
+	}
+	set := func(key, value string) error {
+		_, err := tx.Exec(`
+			INSERT INTO settings (key, value) VALUES (?, ?)
+			ON CONFLICT(key) DO UPDATE SET value = excluded.value
+		`, key, value)
+		return err
+	}
+
+	applied := false
+	localPB, _ := parseFloat(get(typingTestKey(SettingTypingTestPB, t.Mode)))
+	if t.PersonalBest > localPB {
+		if err := set(typingTestKey(SettingTypingTestPB, t.Mode), floatToString(t.PersonalBest)); err != nil {
+			return false, err
+		}
+		applied = true
+	}
+	localCount, _ := parseInt(get(typingTestKey(SettingTypingTestCount, t.Mode)))
+	if t.TestCount > localCount {
+		if err := set(typingTestKey(SettingTypingTestCount, t.Mode), intToString(t.TestCount)); err != nil {
+			return false, err
+		}
+		if err := set(typingTestKey(SettingTypingTestAvgWPM, t.Mode), floatToString(t.AverageWPM)); err != nil {
+			return false, err
+		}
+		applied = true
+	}
+	return applied, nil
+}
+
+// importTypingTestRun inserts a test unless one of the same mode completed at
+// the same second already exists, along with its per-character counts. An
+// existing test keeps its own counts, and takes the bundle's replay data if
+// it has no timeline.
+func importTypingTestRun(tx *sql.Tx, r bundleTypingTestRun) (bool, error) {
+	series, err := marshalList(r.WPMSeries)
+	if err != nil {
+		return false, err
+	}
+	timeline, err := marshalList(r.Keystrokes)
+	if err != nil {
+		return false, err
+	}
+	res, err := tx.Exec(`
+		INSERT INTO typing_tests (completed_at, mode, test_type, layout, language, duration_ms,
+			raw_wpm, net_wpm, accuracy, cpm, errors, uncorrected_errors, wpm_series,
+			target_text, timeline)
+		SELECT ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+		WHERE NOT EXISTS (SELECT 1 FROM typing_tests WHERE completed_at = ? AND mode = ?)
+	`, r.CompletedAt, r.Mode, r.TestType, r.Layout, r.Language, r.DurationMs,
+		r.RawWPM, r.NetWPM, r.Accuracy, r.CPM, r.Errors, r.UncorrectedErrors, series,
+		r.TargetText, timeline, r.CompletedAt, r.Mode)
+	if err != nil {
+		return false, err
+	}
+	inserted, err := changed(res)
+	if err != nil {
+		return false, err
+	}
+	if inserted {
+		id, err := res.LastInsertId()
+		if err != nil {
+			return false, err
+		}
+		counts := make(map[CharPair]int64, len(r.Chars))
+		for _, c := range r.Chars {
+			counts[CharPair{Expected: c.Expected, Typed: c.Typed}] += c.Count
+		}
+		return true, addTypingTestChars(tx, id, counts)
+	}
+	if timeline == "" {
+		return false, nil
+	}
+	res, err = tx.Exec(`
+		UPDATE typing_tests SET target_text = ?, timeline = ?
+		WHERE completed_at = ? AND mode = ? AND timeline = ''
+	`, r.TargetText, timeline, r.CompletedAt, r.Mode)
+	if err != nil {
+		return false, err
+	}
+	return changed(res)
+}
+
+// importDevice registers a device, filling in a missing name and keeping the
+// later last_seen.
+func importDevice(tx *sql.Tx, d bundleDevice) (bool, error) {
+	res, err := tx.Exec(`
+		INSERT INTO devices (device_id, name, created_at, last_seen)
+		VALUES (?, ?, COALESCE(NULLIF(?, ''), CURRENT_TIMESTAMP), NULLIF(?, ''))
+		ON CONFLICT(device_id) DO UPDATE SET
+			name = CASE WHEN COALESCE(name, '') = '' THEN excluded.name ELSE name END,
+			last_seen = MAX(COALESCE(last_seen, ''), COALESCE(excluded.last_seen, ''))
+		WHERE (COALESCE(name, '') = '' AND excluded.name != '')
+			OR COALESCE(excluded.last_seen, '') > COALESCE(last_seen, '')
+	`, d.DeviceID, d.Name, d.CreatedAt, d.LastSeen)
+	if err != nil {
+		return false, err
+	}
+	return changed(res)
+}
+
+func importDeviceDay(tx *sql.Tx, d bundleDeviceDay) (bool, error) {
+	res, err := tx.Exec(`
+		INSERT INTO device_daily_summary
+			(device_id, date, keystrokes, letters, modifiers, special, words, active_ms, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(device_id, date) DO UPDATE SET
+			keystrokes = MAX(keystrokes, excluded.keystrokes),
+			letters = MAX(letters, excluded.letters),
+			modifiers = MAX(modifiers, excluded.modifiers),
+			special = MAX(special, excluded.special),
+			words = MAX(words, excluded.words),
+			active_ms = MAX(active_ms, excluded.active_ms),
+			updated_at = excluded.updated_at
+		WHERE excluded.keystrokes > keystrokes OR excluded.letters > letters
+			OR excluded.modifiers > modifiers OR excluded.special > special
+			OR excluded.words > words OR excluded.active_ms > active_ms
+	`, d.DeviceID, d.Date, d.Keystrokes, d.Letters, d.Modifiers, d.Special, d.Words, d.ActiveMs,
+		time.Now().Format(time.RFC3339))
+	if err != nil {
+		return false, err
+	}
+	if _, err := tx.Exec(`INSERT OR IGNORE INTO devices (device_id) VALUES (?)`, d.DeviceID); err != nil {
+		return false, err
+	}
+	return changed(res)
+}
+
+func importSetting(tx *sql.Tx, kv bundleSetting, opts ImportOptions) (bool, error) {
+	if settingInternal[kv.Key] {
+		return false, nil
+	}
+	var (
+		res sql.Result
+		err error
+	)
+	if opts.OverwriteSettings {
+		res, err = tx.Exec(`
+			INSERT INTO settings (key, value) VALUES (?, ?)
+			ON CONFLICT(key) DO UPDATE SET value = excluded.value WHERE value IS NOT excluded.value
+		`, kv.Key, kv.Value)
+	} else {
+		res, err = tx.Exec(`INSERT OR IGNORE INTO settings (key, value) VALUES (?, ?)`, kv.Key, kv.Value)
+	}
+	if err != nil {
+		return false, err
+	}
+	return changed(res)
+}
@@ -0,0 +1,365 @@
+package storage
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func exportBundle(t *testing.T, s *Store, opts ExportOptions) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	if _, err := s.Export(&buf, opts); err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	return &buf
+}
+
+func TestBundleRoundTrip(t *testing.T) {
+	src, cleanupSrc := newTestStore(t)
+	defer cleanupSrc()
+	dst, cleanupDst := newTestStore(t)
+	defer cleanupDst()
+
+	if _, err := src.db.Exec(`INSERT INTO daily_summary (date, keystrokes, words, letters, active_ms, fastest_burst_wpm)
+		VALUES ('2025-01-01', 1000, 150, 700, 60000, 95.5)`); err != nil {
+		t.Fatalf("seed daily: %v", err)
+	}
+	if _, err := src.db.Exec(`INSERT INTO mouse_daily (date, total_distance, movement_count, click_count)
+		VALUES ('2025-01-01', 5000, 40, 12)`); err != nil {
+		t.Fatalf("seed mouse: %v", err)
+	}
+	if _, err := src.db.Exec(`INSERT INTO odometer_history (start_time, end_time, keystrokes)
+		VALUES ('2025-01-01T09:00:00Z', '2025-01-01T10:00:00Z', 300)`); err != nil {
+		t.Fatalf("seed odometer: %v", err)
+	}
+	if err := src.UpsertDeviceDay("rm2", "2025-01-01", DeviceDayCounts{Keystrokes: 42, Words: 7}); err != nil {
+		t.Fatalf("seed device: %v", err)
+	}
+	if err := src.SaveTypingTestResultForMode(88, TypingTestMode{WordCount: 25, Punctuation: true}); err != nil {
+		t.Fatalf("seed typing test: %v", err)
+	}
+	if err := src.SetDistanceUnit(DistanceUnitCars); err != nil {
+		t.Fatalf("seed setting: %v", err)
+	}
+	run := TypingTestResult{
+		CompletedAt: time.Date(2025, 1, 1, 9, 30, 0, 0, time.UTC), Mode: "mode_25_punct",
+		TestType: "normal", Layout: "qwerty", Language: "us", Duration: 20 * time.Second,
+		RawWPM: 90, NetWPM: 88, Accuracy: 97.5, CPM: 450, Errors: 3, UncorrectedErrors: 1,
+		WPMSeries: []float64{80, 86, 88}, TargetText: "ab",
+		Keystrokes: []Keystroke{{Ms: 0, Char: "a", Correct: true}, {Ms: 150, Char: "b", Correct: true}},
+	}
+	runID, err := src.AddTypingTest(run)
+	if err != nil {
+		t.Fatalf("seed typing test history: %v", err)
+	}
+	if err := src.AddTypingTestChars(runID, map[CharPair]int64{{"a", "a"}: 30, {"a", "s"}: 3}); err != nil {
+		t.Fatalf("seed typing test chars: %v", err)
+	}
+
+	sum, err := dst.Import(exportBundle(t, src, ExportOptions{}), ImportOptions{})
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if sum.Applied[BundleKindDailySummary] != 1 || sum.Applied[BundleKindOdometerHistory] != 1 {
+		t.Fatalf("import summary: %+v", sum)
+	}
+
+	day, _ := dst.GetDayStats("2025-01-01")
+	if day.Keystrokes != 1000 || day.Words != 150 || day.Letters != 700 || day.ActiveMs != 60000 || day.FastestBurstWPM != 95.5 {
+		t.Fatalf("daily after import: %+v", day)
+	}
+	mouse, _ := dst.GetMouseDailyStats("2025-01-01")
+	if mouse.TotalDistance != 5000 || mouse.ClickCount != 12 {
+		t.Fatalf("mouse after import: %+v", mouse)
+	}
+	hist, _ := dst.GetOdometerHistory()
+	if len(hist) != 1 || hist[0].Keystrokes != 300 {
+		t.Fatalf("odometer after import: %+v", hist)
+	}
+	dev, _ := dst.GetDeviceDay("rm2", "2025-01-01")
+	if dev == nil || dev.Keystrokes != 42 {
+		t.Fatalf("device day after import: %+v", dev)
+	}
+	tt := dst.GetTypingTestStatsForMode(TypingTestMode{WordCount: 25, Punctuation: true})
+	if tt.PersonalBest != 88 || tt.TestCount != 1 {
+		t.Fatalf("typing test after import: %+v", tt)
+	}
+	if got := dst.GetDistanceUnit(); got != DistanceUnitCars {
+		t.Fatalf("distance unit after import: %q", got)
+	}
+	tests, _ := dst.GetTypingTests("", "", 0)
+	if len(tests) != 1 {
+		t.Fatalf("typing test history after import: %+v", tests)
+	}
+	got := tests[0]
+	if !got.CompletedAt.Equal(run.CompletedAt) || got.Mode != run.Mode || got.NetWPM != run.NetWPM ||
+		got.Duration != run.Duration || got.UncorrectedErrors != 1 || len(got.WPMSeries) != 3 {
+		t.Fatalf("typing test after import = %+v, want %+v", got, run)
+	}
+
+	replay, _ := dst.GetBestTypingTest(run.Mode)
+	if replay == nil || replay.TargetText != "ab" || len(replay.Keystrokes) != 2 || replay.Keystrokes[1].Ms != 150 {
+		t.Fatalf("replay data after import: %+v", replay)
+	}
+
+	chars, _ := dst.GetTypingCharStats()
+	if len(chars) != 1 || chars[0].Attempts != 33 || chars[0].Misses != 3 {
+		t.Fatalf("typing test chars after import: %+v", chars)
+	}
+
+	// A second import finds the test already there.
+	sum, err = dst.Import(exportBundle(t, src, ExportOptions{}), ImportOptions{})
+	if err != nil {
+		t.Fatalf("re-Import: %v", err)
+	}
+	if sum.Applied[BundleKindTypingTestRun] != 0 || sum.Skipped[BundleKindTypingTestRun] != 1 {
+		t.Fatalf("re-import summary: %+v", sum)
+	}
+	if tests, _ := dst.GetTypingTests("", "", 0); len(tests) != 1 {
+		t.Fatalf("re-import duplicated the test history: %d tests", len(tests))
+	}
+	if chars, _ := dst.GetTypingCharStats(); len(chars) != 1 || chars[0].Attempts != 33 {
+		t.Fatalf("re-import double-counted typing test chars: %+v", chars)
+	}
+}
+
+func TestBundleImportTakesMaxAndDedupes(t *testing.T) {
+	src, cleanupSrc := newTestStore(t)
+	defer cleanupSrc()
+	dst, cleanupDst := newTestStore(t)
+	defer cleanupDst()
+
+	// The bundle is an old backup: an older, smaller day and a session the
+	// target already has.
+	src.db.Exec(`INSERT INTO daily_summary (date, keystrokes, words) VALUES ('2025-01-01', 100, 50)`)
+	src.db.Exec(`INSERT INTO odometer_history (start_time, end_time, keystrokes)
+		VALUES ('2025-01-01T09:00:00Z', '2025-01-01T10:00:00Z', 300)`)
+	// The target kept recording: more keystrokes, fewer words (say, a
+	// stricter word filter), and the same session.
+	dst.db.Exec(`INSERT INTO daily_summary (date, keystrokes, words) VALUES ('2025-01-01', 400, 20)`)
+	dst.db.Exec(`INSERT INTO odometer_history (start_time, end_time, keystrokes)
+		VALUES ('2025-01-01T09:00:00Z', '2025-01-01T10:00:00Z', 300)`)
+
+	bundle := exportBundle(t, src, ExportOptions{}).String()
+	sum, err := dst.Import(strings.NewReader(bundle), ImportOptions{})
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if sum.Skipped[BundleKindOdometerHistory] != 1 {
+		t.Fatalf("duplicate odometer session should be skipped: %+v", sum)
+	}
+
+	day, _ := dst.GetDayStats("2025-01-01")
+	if day.Keystrokes != 400 || day.Words != 50 {
+		t.Fatalf("want per-column max 400/50, got %d/%d", day.Keystrokes, day.Words)
+	}
+	hist, _ := dst.GetOdometerHistory()
+	if len(hist) != 1 {
+		t.Fatalf("odometer sessions: want 1, got %d", len(hist))
+	}
+
+	// Re-importing the same bundle changes nothing.
+	sum, err = dst.Import(strings.NewReader(bundle), ImportOptions{})
+	if err != nil {
+		t.Fatalf("second Import: %v", err)
+	}
+	for kind, n := range sum.Applied {
+		if n != 0 {
+			t.Errorf("second import applied %d %s records", n, kind)
+		}
+	}
+}
+
+func TestBundleHistoryTables(t *testing.T) {
+	src, cleanupSrc := newTestStore(t)
+	defer cleanupSrc()
+	dst, cleanupDst := newTestStore(t)
+	defer cleanupDst()
+
+	for _, q := range []string{
+		`INSERT INTO app_daily (date, app_id, keystrokes, words, active_ms) VALUES ('2025-01-01', 'code', 500, 80, 90000)`,
+		`INSERT INTO focus_sessions (tag, start_time, end_time, target_kind, target, words, goal_reached_at)
+			VALUES ('rfc', '2025-01-01T09:00:00Z', '2025-01-01T10:00:00Z', 'words', 500, 600, '2025-01-01T09:50:00Z')`,
+		`INSERT INTO focus_sessions (tag, start_time) VALUES ('running', '2025-01-02T09:00:00Z')`,
+		`INSERT INTO rsi_breaks (date, kind, due_at, taken_at, worked_ms)
+			VALUES ('2025-01-01', 'micro', '2025-01-01T09:10:00Z', '2025-01-01T09:11:00Z', 600000)`,
+		`INSERT INTO rsi_breaks (date, kind, due_at, worked_ms) VALUES ('2025-01-01', 'long', '2025-01-01T10:00:00Z', 3600000)`,
+		`INSERT INTO bigram_latency (prev, next, count, total_ms, p90_ms, histogram) VALUES (4, 5, 10, 1200, 150, '10:9,15:1')`,
+	} {
+		if _, err := src.db.Exec(q); err != nil {
+			t.Fatalf("seed: %v", err)
+		}
+	}
+	// The destination already has fewer bigram samples and more app words.
+	if _, err := dst.db.Exec(`INSERT INTO bigram_latency (prev, next, count, total_ms, p90_ms, histogram) VALUES (4, 5, 2, 300, 160, '15:2')`); err != nil {
+		t.Fatalf("seed dst bigram: %v", err)
+	}
+	if _, err := dst.db.Exec(`INSERT INTO app_daily (date, app_id, keystrokes, words, active_ms) VALUES ('2025-01-01', 'code', 100, 90, 0)`); err != nil {
+		t.Fatalf("seed dst app: %v", err)
+	}
+
+	bundle := exportBundle(t, src, ExportOptions{}).String()
+	if strings.Contains(bundle, "running") {
+		t.Fatal("a running focus session must not be exported")
+	}
+	sum, err := dst.Import(strings.NewReader(bundle), ImportOptions{})
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	for kind, want := range map[string]int{BundleKindAppDaily: 1, BundleKindFocusSession: 1, BundleKindBreak: 2, BundleKindBigram: 1} {
+		if sum.Applied[kind] != want {
+			t.Errorf("applied %s = %d, want %d", kind, sum.Applied[kind], want)
+		}
+	}
+
+	var keys, words int64
+	dst.db.QueryRow(`SELECT keystrokes, words FROM app_daily WHERE date = '2025-01-01' AND app_id = 'code'`).Scan(&keys, &words)
+	if keys != 500 || words != 90 {
+		t.Errorf("app_daily = %d keys, %d words; want the max of each", keys, words)
+	}
+	var count, p90 int64
+	dst.db.QueryRow(`SELECT count, p90_ms FROM bigram_latency WHERE prev = 4 AND next = 5`).Scan(&count, &p90)
+	if count != 10 || p90 != 150 {
+		t.Errorf("bigram = %d samples, p90 %d; want the side with more samples", count, p90)
+	}
+	var goal string
+	dst.db.QueryRow(`SELECT COALESCE(goal_reached_at, '') FROM focus_sessions WHERE tag = 'rfc'`).Scan(&goal)
+	if goal != "2025-01-01T09:50:00Z" {
+		t.Errorf("focus session goal_reached_at = %q", goal)
+	}
+	var skipped int
+	dst.db.QueryRow(`SELECT COUNT(*) FROM rsi_breaks WHERE kind = 'long' AND taken_at IS NULL`).Scan(&skipped)
+	if skipped != 1 {
+		t.Errorf("skipped long break should import with no taken_at, got %d rows", skipped)
+	}
+
+	sum, err = dst.Import(strings.NewReader(bundle), ImportOptions{})
+	if err != nil {
+		t.Fatalf("re-Import: %v", err)
+	}
+	for _, kind := range []string{BundleKindAppDaily, BundleKindFocusSession, BundleKindBreak, BundleKindBigram} {
+		if sum.Applied[kind] != 0 {
+			t.Errorf("re-import applied %d %s records, want 0", sum.Applied[kind], kind)
+		}
+	}
+}
+
+func TestBundleSettingsAndSecrets(t *testing.T) {
+	src, cleanupSrc := newTestStore(t)
+	defer cleanupSrc()
+	dst, cleanupDst := newTestStore(t)
+	defer cleanupDst()
+
+	src.SetSetting(SettingPushToken, "secret")
+	src.SetSetting(SettingDistanceUnit, DistanceUnitCars)
+	src.SetSetting(settingBackfillDone, "1")
+	dst.SetSetting(SettingDistanceUnit, DistanceUnitFrisbee)
+
+	bundle := exportBundle(t, src, ExportOptions{}).String()
+	if strings.Contains(bundle, "secret") {
+		t.Fatal("tokens must not be exported without IncludeSecrets")
+	}
+	if strings.Contains(bundle, settingBackfillDone) {
+		t.Fatal("internal bookkeeping settings must not be exported")
+	}
+
+	if _, err := dst.Import(strings.NewReader(bundle), ImportOptions{}); err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if got := dst.GetDistanceUnit(); got != DistanceUnitFrisbee {
+		t.Fatalf("local setting must win by default, got %q", got)
+	}
+
+	if _, err := dst.Import(strings.NewReader(bundle), ImportOptions{OverwriteSettings: true}); err != nil {
+		t.Fatalf("Import overwrite: %v", err)
+	}
+	if got := dst.GetDistanceUnit(); got != DistanceUnitCars {
+		t.Fatalf("OverwriteSettings should replace the local value, got %q", got)
+	}
+
+	withSecrets := exportBundle(t, src, ExportOptions{IncludeSecrets: true}).String()
+	if !strings.Contains(withSecrets, "secret") {
+		t.Fatal("IncludeSecrets should export tokens")
+	}
+}
+
+func TestBundleKeepsMachineLocalSettings(t *testing.T) {
+	src, cleanupSrc := newTestStore(t)
+	defer cleanupSrc()
+	dst, cleanupDst := newTestStore(t)
+	defer cleanupDst()
+
+	local := map[string]string{
+		SettingPushDeviceID:    "laptop-1234",
+		SettingCaptureBackend:  "evdev",
+		SettingEvdevDevices:    "/dev/input/event3",
+		SettingLocalAPIEnabled: "true",
+		SettingLocalAPIAddr:    "127.0.0.1:9999",
+	}
+	for k, v := range local {
+		src.SetSetting(k, v)
+	}
+	src.SetSetting(SettingDistanceUnit, DistanceUnitCars)
+
+	bundle := exportBundle(t, src, ExportOptions{IncludeSecrets: true}).String()
+	for k := range local {
+		if strings.Contains(bundle, `"`+k+`"`) {
+			t.Errorf("machine-local %s must not be exported", k)
+		}
+	}
+	// A bundle from an older build may still carry them; import drops them.
+	for k, v := range local {
+		bundle += `{"kind":"setting","data":{"key":"` + k + `","value":"` + v + `"}}` + "\n"
+	}
+
+	if _, err := dst.Import(strings.NewReader(bundle), ImportOptions{OverwriteSettings: true}); err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	for k := range local {
+		if v, _ := dst.GetSetting(k); v != "" {
+			t.Errorf("machine-local %s imported as %q", k, v)
+		}
+	}
+	if got := dst.GetDistanceUnit(); got != DistanceUnitCars {
+		t.Fatalf("shared settings should still import, got %q", got)
+	}
+}
+
+func TestBundleImportDryRun(t *testing.T) {
+	src, cleanupSrc := newTestStore(t)
+	defer cleanupSrc()
+	dst, cleanupDst := newTestStore(t)
+	defer cleanupDst()
+
+	src.db.Exec(`INSERT INTO daily_summary (date, keystrokes) VALUES ('2025-01-01', 100)`)
+	sum, err := dst.Import(exportBundle(t, src, ExportOptions{}), ImportOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if sum.Applied[BundleKindDailySummary] != 1 {
+		t.Fatalf("dry run should report what would apply: %+v", sum)
+	}
+	if day, _ := dst.GetDayStats("2025-01-01"); day.Keystrokes != 0 {
+		t.Fatalf("dry run must not write, got %d keystrokes", day.Keystrokes)
+	}
+}
+
+func TestBundleImportRejectsBadInput(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	cases := map[string]string{
+		"empty":      "",
+		"no header":  `{"kind":"daily_summary","data":{"date":"2025-01-01"}}`,
+		"bad format": `{"kind":"header","data":{"format":"other","version":1}}`,
+		"too new":    `{"kind":"header","data":{"format":"typtel-bundle","version":99}}`,
+		"bad json":   `{"kind":"header","data":{"format":"typtel-bundle","version":1}}` + "\n{not json",
+	}
+	for name, input := range cases {
+		if _, err := store.Import(strings.NewReader(input), ImportOptions{}); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
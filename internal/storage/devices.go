@@ -76,6 +76,44 @@ func (s *Store) UpsertDeviceDay(deviceID, date string, c DeviceDayCounts) error
 	return s.UpsertDevice(deviceID, "")
 }
 
+// UpsertDeviceDays is UpsertDeviceDay for many days in one transaction: either
+// every day is replaced or none is.
+func (s *Store) UpsertDeviceDays(deviceID string, days []DeviceDay) error {
+	now := time.Now().Format(time.RFC3339)
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT OR REPLACE INTO device_daily_summary
+			(device_id, date, keystrokes, letters, modifiers, special, words, active_ms, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, d := range days {
+		if _, err := stmt.Exec(deviceID, d.Date, d.Keystrokes, d.Letters, d.Modifiers,
+			d.Special, d.Words, d.ActiveMs, now); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(
+		`INSERT OR IGNORE INTO devices (device_id, name, last_seen) VALUES (?, '', ?)`,
+		deviceID, now,
+	); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`UPDATE devices SET last_seen = ? WHERE device_id = ?`, now, deviceID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
 // GetDeviceDay returns the counts for one device-day, or nil if absent.
 func (s *Store) GetDeviceDay(deviceID, date string) (*DeviceDayCounts, error) {
 	var c DeviceDayCounts
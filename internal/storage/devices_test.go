@@ -205,3 +205,35 @@ func TestDeleteDeviceDay(t *testing.T) {
 		t.Fatalf("expected device still registered, got %+v", devices)
 	}
 }
+
+func TestUpsertDeviceDays(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	if err := store.UpsertDeviceDay("ferrari", "2026-06-12", DeviceDayCounts{Keystrokes: 5}); err != nil {
+		t.Fatal(err)
+	}
+	days := []DeviceDay{
+		{Date: "2026-06-12", DeviceDayCounts: DeviceDayCounts{Keystrokes: 100, Words: 20}},
+		{Date: "2026-06-13", DeviceDayCounts: DeviceDayCounts{Keystrokes: 200, Words: 40}},
+	}
+	if err := store.UpsertDeviceDays("ferrari", days); err != nil {
+		t.Fatalf("UpsertDeviceDays: %v", err)
+	}
+	got, err := store.GetDeviceDays("ferrari", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0] != days[1] || got[1] != days[0] {
+		t.Fatalf("GetDeviceDays = %+v", got)
+	}
+
+	// A new device self-registers.
+	if err := store.UpsertDeviceDays("kali", days[:1]); err != nil {
+		t.Fatal(err)
+	}
+	devices, err := store.ListDevices()
+	if err != nil || len(devices) != 2 {
+		t.Fatalf("ListDevices = %+v, %v", devices, err)
+	}
+}
@@ -0,0 +1,429 @@
+package storage
+
+// Tagged focus sessions: a named stretch of work ("writing RFC", "code
+// review") with an optional target in words or active minutes. At most one
+// session runs at a time — the focus_sessions row with no end_time. The CLI
+// starts and stops sessions; the capture daemons credit keystrokes, words,
+// active time and burst pace to the running one through a FocusBuffer, which
+// also reports when its goal is reached and when it ends.
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Focus session target kinds.
+const (
+	FocusTargetNone    = ""
+	FocusTargetWords   = "words"
+	FocusTargetMinutes = "minutes" // active typing minutes
+)
+
+var (
+	// ErrFocusActive is returned when starting a session while one runs.
+	ErrFocusActive = errors.New("a focus session is already running")
+	// ErrNoFocusSession is returned when stopping with no session running.
+	ErrNoFocusSession = errors.New("no focus session is running")
+)
+
+// FocusSession is one focus session, running or finished.
+type FocusSession struct {
+	ID            int64
+	Tag           string
+	StartTime     time.Time
+	EndTime       time.Time // zero while running
+	TargetKind    string
+	Target        int64
+	Keystrokes    int64
+	Words         int64
+	ActiveMs      int64
+	PeakBurstWPM  float64
+	GoalReachedAt time.Time // zero until the target is met
+}
+
+// Running reports whether the session has not been stopped.
+func (f *FocusSession) Running() bool { return f.EndTime.IsZero() }
+
+// Progress returns how far the session is toward its target, in the
+// target's unit (words or active minutes). It is 0 with no target.
+func (f *FocusSession) Progress() int64 {
+	switch f.TargetKind {
+	case FocusTargetWords:
+		return f.Words
+	case FocusTargetMinutes:
+		return f.ActiveMs / 60000
+	}
+	return 0
+}
+
+// GoalMet reports whether the session has a target and has reached it.
+func (f *FocusSession) GoalMet() bool {
+	return f.TargetKind != FocusTargetNone && f.Target > 0 && f.Progress() >= f.Target
+}
+
+// ValidateFocusTarget checks a target kind and amount for StartFocusSession.
+func ValidateFocusTarget(kind string, target int64) error {
+	switch kind {
+	case FocusTargetNone:
+		if target != 0 {
+			return errors.New("a target amount needs a target kind")
+		}
+	case FocusTargetWords, FocusTargetMinutes:
+		if target <= 0 {
+			return fmt.Errorf("%s target must be positive", kind)
+		}
+	default:
+		return fmt.Errorf("unknown focus target %q (want words or minutes)", kind)
+	}
+	return nil
+}
+
+const focusColumns = `id, tag, start_time, COALESCE(end_time, ''), COALESCE(target_kind, ''),
+	COALESCE(target, 0), COALESCE(keystrokes, 0), COALESCE(words, 0), COALESCE(active_ms, 0),
+	COALESCE(peak_burst_wpm, 0), COALESCE(goal_reached_at, '')`
+
+type rowScanner interface{ Scan(dest ...any) error }
+
+func scanFocusSession(row rowScanner) (*FocusSession, error) {
+	var f FocusSession
+	var start, end, reached string
+	if err := row.Scan(&f.ID, &f.Tag, &start, &end, &f.TargetKind, &f.Target,
+		&f.Keystrokes, &f.Words, &f.ActiveMs, &f.PeakBurstWPM, &reached); err != nil {
+		return nil, err
+	}
+	f.StartTime, _ = time.Parse(time.RFC3339, start)
+	if end != "" {
+		f.EndTime, _ = time.Parse(time.RFC3339, end)
+	}
+	if reached != "" {
+		f.GoalReachedAt, _ = time.Parse(time.RFC3339, reached)
+	}
+	return &f, nil
+}
+
+// StartFocusSession starts a session tagged tag with an optional target.
+// It fails with ErrFocusActive if a session is already running.
+func (s *Store) StartFocusSession(tag, targetKind string, target int64) (*FocusSession, error) {
+	tag = strings.TrimSpace(tag)
+	if tag == "" {
+		return nil, errors.New("a focus session needs a tag")
+	}
+	if err := ValidateFocusTarget(targetKind, target); err != nil {
+		return nil, err
+	}
+	if active, err := s.ActiveFocusSession(); err != nil {
+		return nil, err
+	} else if active != nil {
+		return nil, fmt.Errorf("%w (%q)", ErrFocusActive, active.Tag)
+	}
+
+	now := time.Now().Format(time.RFC3339)
+	res, err := s.db.Exec(`
+		INSERT INTO focus_sessions (tag, start_time, target_kind, target)
+		VALUES (?, ?, ?, ?)
+	`, tag, now, targetKind, target)
+	if err != nil {
+		return nil, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	return s.GetFocusSession(id)
+}
+
+// GetFocusSession returns the session with the given id.
+func (s *Store) GetFocusSession(id int64) (*FocusSession, error) {
+	return scanFocusSession(s.db.QueryRow(
+		"SELECT "+focusColumns+" FROM focus_sessions WHERE id = ?", id))
+}
+
+// ActiveFocusSession returns the running session, or nil if there is none.
+func (s *Store) ActiveFocusSession() (*FocusSession, error) {
+	f, err := scanFocusSession(s.db.QueryRow(
+		"SELECT " + focusColumns + " FROM focus_sessions WHERE end_time IS NULL ORDER BY id DESC LIMIT 1"))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return f, err
+}
+
+// StopFocusSession ends the running session and returns it as finished.
+func (s *Store) StopFocusSession() (*FocusSession, error) {
+	f, err := s.ActiveFocusSession()
+	if err != nil {
+		return nil, err
+	}
+	if f == nil {
+		return nil, ErrNoFocusSession
+	}
+	now := time.Now()
+	if _, err := s.db.Exec(`UPDATE focus_sessions SET end_time = ? WHERE id = ?`,
+		now.Format(time.RFC3339), f.ID); err != nil {
+		return nil, err
+	}
+	if f.GoalMet() && f.GoalReachedAt.IsZero() {
+		if err := s.markFocusGoalReached(f.ID, now); err != nil {
+			return nil, err
+		}
+	}
+	return s.GetFocusSession(f.ID)
+}
+
+// AddFocusProgress adds counts to a session and raises its peak burst pace.
+// It applies to finished sessions too, so counts buffered just before a stop
+// still land on the right session.
+func (s *Store) AddFocusProgress(id, keystrokes, words, activeMs int64, burstWPM float64) error {
+	_, err := s.db.Exec(`
+		UPDATE focus_sessions SET
+			keystrokes = keystrokes + ?,
+			words = words + ?,
+			active_ms = active_ms + ?,
+			peak_burst_wpm = MAX(peak_burst_wpm, ?)
+		WHERE id = ?
+	`, keystrokes, words, activeMs, burstWPM, id)
+	return err
+}
+
+func (s *Store) markFocusGoalReached(id int64, at time.Time) error {
+	_, err := s.db.Exec(`UPDATE focus_sessions SET goal_reached_at = ? WHERE id = ? AND goal_reached_at IS NULL`,
+		at.Format(time.RFC3339), id)
+	return err
+}
+
+// GetFocusSessions returns sessions, most recent first. tag filters by exact
+// tag when non-empty; since ("2006-01-02") keeps sessions started on or after
+// that date when non-empty; limit caps the count when positive.
+func (s *Store) GetFocusSessions(tag, since string, limit int) ([]FocusSession, error) {
+	query := "SELECT " + focusColumns + " FROM focus_sessions WHERE 1 = 1"
+	var args []any
+	if tag != "" {
+		query += " AND tag = ?"
+		args = append(args, tag)
+	}
+	if since != "" {
+		query += " AND substr(start_time, 1, 10) >= ?"
+		args = append(args, since)
+	}
+	query += " ORDER BY start_time DESC, id DESC"
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []FocusSession
+	for rows.Next() {
+		f, err := scanFocusSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, *f)
+	}
+	return sessions, rows.Err()
+}
+
+// FocusTagStats aggregates the finished and running sessions of one tag.
+type FocusTagStats struct {
+	Tag          string
+	Sessions     int64
+	GoalsMet     int64 // sessions with a target that reached it
+	WithTarget   int64 // sessions that had a target
+	Keystrokes   int64
+	Words        int64
+	ActiveMs     int64
+	PeakBurstWPM float64
+	Elapsed      time.Duration // wall-clock time across sessions
+}
+
+// GetFocusTagStats aggregates sessions per tag, most words first. tag and
+// since filter as in GetFocusSessions.
+func (s *Store) GetFocusTagStats(tag, since string) ([]FocusTagStats, error) {
+	sessions, err := s.GetFocusSessions(tag, since, 0)
+	if err != nil {
+		return nil, err
+	}
+	byTag := make(map[string]*FocusTagStats)
+	var order []string
+	now := time.Now()
+	for _, f := range sessions {
+		st, ok := byTag[f.Tag]
+		if !ok {
+			st = &FocusTagStats{Tag: f.Tag}
+			byTag[f.Tag] = st
+			order = append(order, f.Tag)
+		}
+		st.Sessions++
+		st.Keystrokes += f.Keystrokes
+		st.Words += f.Words
+		st.ActiveMs += f.ActiveMs
+		if f.PeakBurstWPM > st.PeakBurstWPM {
+			st.PeakBurstWPM = f.PeakBurstWPM
+		}
+		if f.TargetKind != FocusTargetNone {
+			st.WithTarget++
+			if !f.GoalReachedAt.IsZero() || f.GoalMet() {
+				st.GoalsMet++
+			}
+		}
+		end := f.EndTime
+		if f.Running() {
+			end = now
+		}
+		st.Elapsed += end.Sub(f.StartTime)
+	}
+
+	out := make([]FocusTagStats, 0, len(order))
+	for _, t := range order {
+		out = append(out, *byTag[t])
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Words != out[j].Words {
+			return out[i].Words > out[j].Words
+		}
+		return out[i].Tag < out[j].Tag
+	})
+	return out, nil
+}
+
+// FocusEventKind says what happened to a focus session.
+type FocusEventKind int
+
+const (
+	// FocusGoalReached: the running session just met its target.
+	FocusGoalReached FocusEventKind = iota + 1
+	// FocusEnded: the session the buffer was crediting has been stopped.
+	FocusEnded
+)
+
+// FocusEvent is reported by FocusBuffer.Flush.
+type FocusEvent struct {
+	Kind    FocusEventKind
+	Session FocusSession
+}
+
+type focusDelta struct {
+	keystrokes, words, activeMs int64
+	burst                       float64
+}
+
+// FocusBuffer credits a capture daemon's keystrokes, words, active time and
+// burst pace to the running focus session. Counts are held in memory and
+// written by Flush, which the daemon calls on its stats tick; Flush also
+// picks up sessions started or stopped by the CLI. Counts arriving while no
+// session is known to run are dropped. It is safe for concurrent use.
+type FocusBuffer struct {
+	mu       sync.Mutex
+	activeID int64
+	pending  map[int64]*focusDelta
+}
+
+// NewFocusBuffer returns an empty buffer. It learns the running session on
+// the first Flush.
+func NewFocusBuffer() *FocusBuffer {
+	return &FocusBuffer{pending: make(map[int64]*focusDelta)}
+}
+
+func (b *FocusBuffer) deltaLocked() *focusDelta {
+	if b.activeID == 0 {
+		return nil
+	}
+	d, ok := b.pending[b.activeID]
+	if !ok {
+		d = &focusDelta{}
+		b.pending[b.activeID] = d
+	}
+	return d
+}
+
+// AddKeystroke counts one keystroke and ms of credited active time.
+func (b *FocusBuffer) AddKeystroke(activeMs int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if d := b.deltaLocked(); d != nil {
+		d.keystrokes++
+		if activeMs > 0 {
+			d.activeMs += activeMs
+		}
+	}
+}
+
+// AddWord counts one completed word with its burst-pace candidate (0 if
+// none).
+func (b *FocusBuffer) AddWord(burstWPM float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if d := b.deltaLocked(); d != nil {
+		d.words++
+		if burstWPM > d.burst {
+			d.burst = burstWPM
+		}
+	}
+}
+
+// Flush writes buffered counts, then reports whether the running session
+// just reached its goal and whether the previously running one has ended.
+// Counts that fail to write stay buffered for the next Flush.
+func (b *FocusBuffer) Flush(s *Store) ([]FocusEvent, error) {
+	b.mu.Lock()
+	pending := b.pending
+	b.pending = make(map[int64]*focusDelta)
+	prevID := b.activeID
+	b.mu.Unlock()
+
+	var firstErr error
+	for id, d := range pending {
+		if err := s.AddFocusProgress(id, d.keystrokes, d.words, d.activeMs, d.burst); err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			b.mu.Lock()
+			if cur, ok := b.pending[id]; ok {
+				cur.keystrokes += d.keystrokes
+				cur.words += d.words
+				cur.activeMs += d.activeMs
+				cur.burst = max(cur.burst, d.burst)
+			} else {
+				b.pending[id] = d
+			}
+			b.mu.Unlock()
+		}
+	}
+
+	active, err := s.ActiveFocusSession()
+	if err != nil {
+		return nil, err
+	}
+	var events []FocusEvent
+	if prevID != 0 && (active == nil || active.ID != prevID) {
+		if ended, err := s.GetFocusSession(prevID); err == nil {
+			events = append(events, FocusEvent{Kind: FocusEnded, Session: *ended})
+		}
+	}
+	var activeID int64
+	if active != nil {
+		activeID = active.ID
+		if active.GoalMet() && active.GoalReachedAt.IsZero() {
+			now := time.Now()
+			if err := s.markFocusGoalReached(active.ID, now); err != nil {
+				return events, err
+			}
+			active.GoalReachedAt = now
+			events = append(events, FocusEvent{Kind: FocusGoalReached, Session: *active})
+		}
+	}
+	b.mu.Lock()
+	b.activeID = activeID
+	b.mu.Unlock()
+	return events, firstErr
+}
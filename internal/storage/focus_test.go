@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFocusSessions(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	if _, err := store.StopFocusSession(); !errors.Is(err, ErrNoFocusSession) {
+		t.Fatalf("stop with nothing running: want ErrNoFocusSession, got %v", err)
+	}
+	if _, err := store.StartFocusSession("rfc", "pages", 3); err == nil {
+		t.Error("unknown target kind should be rejected")
+	}
+
+	buf := NewFocusBuffer()
+	buf.AddKeystroke(100) // nothing running yet: dropped
+	if events, err := buf.Flush(store); err != nil || len(events) != 0 {
+		t.Fatalf("idle Flush: events %v, err %v", events, err)
+	}
+
+	f, err := store.StartFocusSession("rfc", FocusTargetWords, 3)
+	if err != nil {
+		t.Fatalf("StartFocusSession: %v", err)
+	}
+	if _, err := store.StartFocusSession("review", FocusTargetNone, 0); !errors.Is(err, ErrFocusActive) {
+		t.Fatalf("second start: want ErrFocusActive, got %v", err)
+	}
+	if _, err := buf.Flush(store); err != nil { // picks up the new session
+		t.Fatalf("Flush: %v", err)
+	}
+
+	for i := 0; i < 10; i++ {
+		buf.AddKeystroke(200)
+	}
+	buf.AddWord(0)
+	buf.AddWord(95)
+	buf.AddWord(80)
+	events, err := buf.Flush(store)
+	if err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if len(events) != 1 || events[0].Kind != FocusGoalReached || events[0].Session.ID != f.ID {
+		t.Fatalf("want one goal-reached event, got %+v", events)
+	}
+	// The goal is reported once.
+	buf.AddWord(0)
+	if events, _ := buf.Flush(store); len(events) != 0 {
+		t.Fatalf("goal reported again: %+v", events)
+	}
+
+	stopped, err := store.StopFocusSession()
+	if err != nil {
+		t.Fatalf("StopFocusSession: %v", err)
+	}
+	if stopped.Running() || stopped.Words != 4 || stopped.Keystrokes != 10 ||
+		stopped.ActiveMs != 2000 || stopped.PeakBurstWPM != 95 || stopped.GoalReachedAt.IsZero() {
+		t.Fatalf("stopped session: %+v", stopped)
+	}
+	events, _ = buf.Flush(store)
+	if len(events) != 1 || events[0].Kind != FocusEnded || events[0].Session.Words != 4 {
+		t.Fatalf("want one ended event, got %+v", events)
+	}
+
+	if _, err := store.StartFocusSession("rfc", FocusTargetMinutes, 30); err != nil {
+		t.Fatalf("StartFocusSession: %v", err)
+	}
+	if err := store.AddFocusProgress(f.ID+1, 5, 2, 60000, 70); err != nil {
+		t.Fatalf("AddFocusProgress: %v", err)
+	}
+	if _, err := store.StartFocusSession("review", FocusTargetNone, 0); !errors.Is(err, ErrFocusActive) {
+		t.Fatalf("want ErrFocusActive, got %v", err)
+	}
+	if _, err := store.StopFocusSession(); err != nil {
+		t.Fatalf("StopFocusSession: %v", err)
+	}
+	if _, err := store.StartFocusSession("review", FocusTargetNone, 0); err != nil {
+		t.Fatalf("StartFocusSession: %v", err)
+	}
+
+	rfc, err := store.GetFocusSessions("rfc", "", 0)
+	if err != nil || len(rfc) != 2 {
+		t.Fatalf("GetFocusSessions(rfc): %v, %v", rfc, err)
+	}
+	st, err := store.GetFocusTagStats("rfc", "")
+	if err != nil || len(st) != 1 {
+		t.Fatalf("GetFocusTagStats: %v, %v", st, err)
+	}
+	if got := st[0]; got.Sessions != 2 || got.Words != 6 || got.Keystrokes != 15 ||
+		got.ActiveMs != 62000 || got.PeakBurstWPM != 95 || got.GoalsMet != 1 || got.WithTarget != 2 {
+		t.Fatalf("rfc stats: %+v", got)
+	}
+	all, _ := store.GetFocusTagStats("", "")
+	if len(all) != 2 || all[0].Tag != "rfc" {
+		t.Fatalf("all tags: %+v", all)
+	}
+}
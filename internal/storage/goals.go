@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/aayushbajaj/typing-telemetry/pkg/stats"
+)
+
+// Goal periods.
+const (
+	GoalPeriodDaily  = "daily"
+	GoalPeriodWeekly = "weekly" // Monday to Sunday
+)
+
+// Goal metrics.
+const (
+	GoalMetricKeystrokes    = "keystrokes"
+	GoalMetricWords         = "words"
+	GoalMetricActiveMinutes = "active_minutes"
+)
+
+// GoalMetrics lists the metrics in display order.
+var GoalMetrics = []string{GoalMetricWords, GoalMetricKeystrokes, GoalMetricActiveMinutes}
+
+// GoalTarget returns g's target for metric.
+func GoalTarget(g stats.Goals, metric string) int64 {
+	switch metric {
+	case GoalMetricKeystrokes:
+		return g.Keystrokes
+	case GoalMetricWords:
+		return g.Words
+	case GoalMetricActiveMinutes:
+		return g.ActiveMinutes
+	}
+	return 0
+}
+
+func goalSettingKey(period, metric string) (string, error) {
+	switch period {
+	case GoalPeriodDaily, GoalPeriodWeekly:
+	default:
+		return "", fmt.Errorf("unknown goal period %q (want daily or weekly)", period)
+	}
+	switch metric {
+	case GoalMetricKeystrokes, GoalMetricWords, GoalMetricActiveMinutes:
+	default:
+		return "", fmt.Errorf("unknown goal metric %q (want words, keystrokes or active_minutes)", metric)
+	}
+	return settingGoalPrefix + period + "_" + metric, nil
+}
+
+// GetGoals returns the targets configured for period. Unset or unparseable
+// values read as no goal.
+func (s *Store) GetGoals(period string) stats.Goals {
+	get := func(metric string) int64 {
+		key, err := goalSettingKey(period, metric)
+		if err != nil {
+			return 0
+		}
+		val, _ := s.GetSetting(key)
+		n, err := strconv.ParseInt(val, 10, 64)
+		if err != nil || n < 0 {
+			return 0
+		}
+		return n
+	}
+	return stats.Goals{
+		Keystrokes:    get(GoalMetricKeystrokes),
+		Words:         get(GoalMetricWords),
+		ActiveMinutes: get(GoalMetricActiveMinutes),
+	}
+}
+
+// SetGoal sets the target for one period and metric; 0 clears it.
+func (s *Store) SetGoal(period, metric string, target int64) error {
+	key, err := goalSettingKey(period, metric)
+	if err != nil {
+		return err
+	}
+	if target < 0 {
+		return fmt.Errorf("goal must be zero or positive, got %d", target)
+	}
+	if target == 0 {
+		return s.SetSetting(key, "")
+	}
+	return s.SetSetting(key, strconv.FormatInt(target, 10))
+}
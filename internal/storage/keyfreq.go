@@ -0,0 +1,47 @@
+package storage
+
+import "sort"
+
+// KeycodeFrequency is how many times one keycode was pressed.
+type KeycodeFrequency struct {
+	Keycode int
+	Count   int64
+}
+
+// GetKeycodeFrequencies returns press counts per keycode for dates on or after
+// since ("2006-01-02"; empty for all time), most-pressed first. Both raw
+// keystrokes and the compacted hourly rollup are counted.
+func (s *Store) GetKeycodeFrequencies(since string) ([]KeycodeFrequency, error) {
+	rows, err := s.db.Query(`
+		SELECT keycode, SUM(n) FROM (
+			SELECT keycode, COUNT(*) AS n FROM keystrokes WHERE date >= ? GROUP BY keycode
+			UNION ALL
+			SELECT keycode, SUM(count) AS n FROM keystroke_hourly WHERE date >= ? GROUP BY keycode
+		) GROUP BY keycode`,
+		since, since,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var freqs []KeycodeFrequency
+	for rows.Next() {
+		var f KeycodeFrequency
+		if err := rows.Scan(&f.Keycode, &f.Count); err != nil {
+			return nil, err
+		}
+		freqs = append(freqs, f)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(freqs, func(i, j int) bool {
+		if freqs[i].Count != freqs[j].Count {
+			return freqs[i].Count > freqs[j].Count
+		}
+		return freqs[i].Keycode < freqs[j].Keycode
+	})
+	return freqs, nil
+}
@@ -0,0 +1,42 @@
+package storage
+
+import "testing"
+
+func TestGetKeycodeFrequencies(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	// Old rows get compacted into the rollup; new ones stay raw. Both count.
+	insertRawKeystroke(t, store, "2025-01-01", 9, 0)
+	insertRawKeystroke(t, store, "2025-01-01", 9, 0)
+	insertRawKeystroke(t, store, "2025-01-01", 9, 14)
+	if _, err := store.compactBefore("2025-02-01", false); err != nil {
+		t.Fatalf("compactBefore: %v", err)
+	}
+	insertRawKeystroke(t, store, "2025-03-01", 10, 0)
+	insertRawKeystroke(t, store, "2025-03-01", 10, 49)
+	insertRawKeystroke(t, store, "2025-03-01", 11, 49)
+	insertRawKeystroke(t, store, "2025-03-01", 11, 49)
+
+	all, err := store.GetKeycodeFrequencies("")
+	if err != nil {
+		t.Fatalf("GetKeycodeFrequencies: %v", err)
+	}
+	want := []KeycodeFrequency{{0, 3}, {49, 3}, {14, 1}}
+	if len(all) != len(want) {
+		t.Fatalf("all time: want %v, got %v", want, all)
+	}
+	for i := range want {
+		if all[i] != want[i] {
+			t.Fatalf("all time: want %v, got %v", want, all)
+		}
+	}
+
+	recent, err := store.GetKeycodeFrequencies("2025-02-01")
+	if err != nil {
+		t.Fatalf("GetKeycodeFrequencies(since): %v", err)
+	}
+	if len(recent) != 2 || recent[0] != (KeycodeFrequency{49, 3}) || recent[1] != (KeycodeFrequency{0, 1}) {
+		t.Fatalf("since 2025-02-01: got %v", recent)
+	}
+}
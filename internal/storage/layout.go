@@ -0,0 +1,174 @@
+package storage
+
+// The keyboard layout decides which physical keys count as letters in
+// daily_summary and which grow a word in internal/wordcounter. It is chosen by
+// the keyboard_layout setting: a built-in name from internal/layout, or the
+// name of a user layout stored as JSON in <data root>/layouts/<name>.json.
+// User layouts live in the data root rather than a profile so every profile
+// typed on the same keyboard can share them.
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/aayushbajaj/typing-telemetry/internal/layout"
+)
+
+// LayoutDir returns (creating if needed) the directory holding user layouts.
+func LayoutDir() (string, error) {
+	root, err := DataRoot()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(root, "layouts")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// ResolveKeyboardLayout returns the built-in or user layout with this name.
+func ResolveKeyboardLayout(name string) (*layout.Layout, error) {
+	if l, err := layout.Get(name); err == nil {
+		return l, nil
+	}
+	if err := layout.ValidateName(name); err != nil {
+		return nil, err
+	}
+	dir, err := LayoutDir()
+	if err != nil {
+		return nil, err
+	}
+	b, err := os.ReadFile(filepath.Join(dir, name+".json"))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%q: %w", name, layout.ErrUnknownLayout)
+	}
+	if err != nil {
+		return nil, err
+	}
+	l, err := layout.Parse(b)
+	if err != nil {
+		return nil, fmt.Errorf("%s.json: %w", name, err)
+	}
+	if l.Name != name {
+		return nil, fmt.Errorf("%s.json: declares name %q", name, l.Name)
+	}
+	return l, nil
+}
+
+// KeyboardLayouts returns every layout that can be selected: the built-ins,
+// then user layouts by name. User files that fail to load are reported in the
+// error alongside the layouts that did load.
+func KeyboardLayouts() ([]*layout.Layout, error) {
+	layouts := layout.Builtins()
+	dir, err := LayoutDir()
+	if err != nil {
+		return layouts, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return layouts, err
+	}
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".json") {
+			names = append(names, strings.TrimSuffix(e.Name(), ".json"))
+		}
+	}
+	sort.Strings(names)
+
+	var errs []error
+	for _, name := range names {
+		l, err := ResolveKeyboardLayout(name)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if !l.Builtin {
+			layouts = append(layouts, l)
+		}
+	}
+	return layouts, errors.Join(errs...)
+}
+
+// ImportKeyboardLayout validates a user layout file and copies it into
+// LayoutDir, replacing any earlier import with the same name.
+func ImportKeyboardLayout(path string) (*layout.Layout, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	l, err := layout.Parse(b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	dir, err := LayoutDir()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, l.Name+".json"), b, 0644); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// KeyboardLayout returns the layout keystrokes are classified with, loading
+// it from settings on first use. A layout that fails to load falls back to
+// QWERTY; daemons call ReloadKeyboardLayout at startup to report why.
+func (s *Store) KeyboardLayout() *layout.Layout {
+	if l := s.kbLayout.Load(); l != nil {
+		return l
+	}
+	s.ReloadKeyboardLayout()
+	if l := s.kbLayout.Load(); l != nil {
+		return l
+	}
+	return layout.QWERTY()
+}
+
+// GetKeyboardLayoutName returns the configured layout name. Default: "qwerty".
+func (s *Store) GetKeyboardLayoutName() string {
+	val, _ := s.GetSetting(SettingKeyboardLayout)
+	if val == "" {
+		return layout.Default
+	}
+	return val
+}
+
+// SetKeyboardLayout selects a layout by name and starts classifying with it.
+// Other processes (the tray daemon) pick it up on their next
+// ReloadKeyboardLayout.
+func (s *Store) SetKeyboardLayout(name string) error {
+	l, err := ResolveKeyboardLayout(name)
+	if err != nil {
+		return err
+	}
+	if err := s.SetSetting(SettingKeyboardLayout, name); err != nil {
+		return err
+	}
+	s.kbLayout.Store(l)
+	s.kbLayoutName.Store(name)
+	return nil
+}
+
+// ReloadKeyboardLayout re-reads the keyboard_layout setting and, if it
+// changed, switches to that layout. A layout that can't be loaded falls back
+// to QWERTY and is reported once, not on every call.
+func (s *Store) ReloadKeyboardLayout() error {
+	name := s.GetKeyboardLayoutName()
+	if prev, _ := s.kbLayoutName.Load().(string); prev == name {
+		return nil
+	}
+	l, err := ResolveKeyboardLayout(name)
+	if err != nil {
+		l = layout.QWERTY()
+		err = fmt.Errorf("keyboard layout: %w; using %s", err, layout.Default)
+	}
+	s.kbLayout.Store(l)
+	s.kbLayoutName.Store(name)
+	return err
+}
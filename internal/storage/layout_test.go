@@ -0,0 +1,163 @@
+package storage
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aayushbajaj/typing-telemetry/internal/layout"
+)
+
+func TestKeyboardLayoutDefaultsToQWERTY(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	if got := store.KeyboardLayout().Name; got != layout.Default {
+		t.Fatalf("KeyboardLayout = %q, want %q", got, layout.Default)
+	}
+	if got := store.GetKeyboardLayoutName(); got != layout.Default {
+		t.Fatalf("GetKeyboardLayoutName = %q, want %q", got, layout.Default)
+	}
+}
+
+func TestRecordKeystrokeUsesActiveLayout(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	if err := store.SetKeyboardLayout("dvorak"); err != nil {
+		t.Fatalf("SetKeyboardLayout: %v", err)
+	}
+	// On Dvorak the QWERTY ";" key types "s" and the QWERTY "Q" key types "'".
+	store.RecordKeystroke(41)
+	store.RecordKeystroke(12)
+
+	stats, err := store.GetTodayStats()
+	if err != nil {
+		t.Fatalf("GetTodayStats: %v", err)
+	}
+	if stats.Letters != 1 || stats.Special != 1 {
+		t.Fatalf("want 1 letter and 1 special, got %+v", stats)
+	}
+}
+
+func TestBatcherUsesActiveLayout(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	if err := store.SetKeyboardLayout("colemak"); err != nil {
+		t.Fatalf("SetKeyboardLayout: %v", err)
+	}
+	b := newIdleBatcher(store, 1000, 1000)
+	defer b.Close()
+
+	now := time.Date(2025, 5, 6, 14, 30, 0, 0, time.Local)
+	b.recordKeystrokeAt(41, now) // Colemak "o"
+	b.recordKeystrokeAt(35, now) // Colemak ";"
+	if err := b.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	day, _ := store.GetDayStats("2025-05-06")
+	if day.Letters != 1 || day.Special != 1 {
+		t.Fatalf("want 1 letter and 1 special, got %+v", day)
+	}
+}
+
+func TestSetKeyboardLayoutRejectsUnknown(t *testing.T) {
+	useTempDataRoot(t)
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	if err := store.SetKeyboardLayout("nope"); !errors.Is(err, layout.ErrUnknownLayout) {
+		t.Fatalf("want ErrUnknownLayout, got %v", err)
+	}
+	if got := store.GetKeyboardLayoutName(); got != layout.Default {
+		t.Fatalf("a rejected layout must not be saved, got %q", got)
+	}
+}
+
+func TestReloadKeyboardLayoutFollowsSetting(t *testing.T) {
+	useTempDataRoot(t)
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	if store.KeyboardLayout().Name != layout.Default {
+		t.Fatal("expected QWERTY before the setting changes")
+	}
+	// Another process (the CLI) changes the setting behind our back.
+	store.SetSetting(SettingKeyboardLayout, "dvorak")
+	if err := store.ReloadKeyboardLayout(); err != nil {
+		t.Fatalf("ReloadKeyboardLayout: %v", err)
+	}
+	if got := store.KeyboardLayout().Name; got != "dvorak" {
+		t.Fatalf("after reload: %q", got)
+	}
+
+	store.SetSetting(SettingKeyboardLayout, "missing")
+	if err := store.ReloadKeyboardLayout(); err == nil {
+		t.Fatal("a missing layout should be reported")
+	}
+	if got := store.KeyboardLayout().Name; got != layout.Default {
+		t.Fatalf("a missing layout should fall back to QWERTY, got %q", got)
+	}
+	if err := store.ReloadKeyboardLayout(); err != nil {
+		t.Fatalf("the same failure should only be reported once, got %v", err)
+	}
+}
+
+func TestImportKeyboardLayout(t *testing.T) {
+	root := useTempDataRoot(t)
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	src := filepath.Join(t.TempDir(), "mine.json")
+	doc := `{"name": "mine", "base": "dvorak", "keys": {"10": "ñ"}}`
+	if err := os.WriteFile(src, []byte(doc), 0644); err != nil {
+		t.Fatal(err)
+	}
+	l, err := ImportKeyboardLayout(src)
+	if err != nil {
+		t.Fatalf("ImportKeyboardLayout: %v", err)
+	}
+	if l.Name != "mine" {
+		t.Fatalf("imported %q", l.Name)
+	}
+	if _, err := os.Stat(filepath.Join(root, "layouts", "mine.json")); err != nil {
+		t.Fatalf("layout file not stored: %v", err)
+	}
+
+	layouts, err := KeyboardLayouts()
+	if err != nil {
+		t.Fatalf("KeyboardLayouts: %v", err)
+	}
+	if last := layouts[len(layouts)-1]; last.Name != "mine" {
+		t.Fatalf("user layouts should follow the built-ins, last = %q", last.Name)
+	}
+
+	if err := store.SetKeyboardLayout("mine"); err != nil {
+		t.Fatalf("SetKeyboardLayout: %v", err)
+	}
+	store.RecordKeystroke(10) // ñ
+	store.RecordKeystroke(41) // Dvorak "s", from the base
+	stats, _ := store.GetTodayStats()
+	if stats.Letters != 2 {
+		t.Fatalf("want 2 letters, got %+v", stats)
+	}
+}
+
+func TestKeyboardLayoutsReportsBrokenFiles(t *testing.T) {
+	root := useTempDataRoot(t)
+	dir := filepath.Join(root, "layouts")
+	os.MkdirAll(dir, 0755)
+	os.WriteFile(filepath.Join(dir, "broken.json"), []byte("{"), 0644)
+	os.WriteFile(filepath.Join(dir, "renamed.json"), []byte(`{"name": "other"}`), 0644)
+
+	layouts, err := KeyboardLayouts()
+	if err == nil {
+		t.Fatal("broken layout files should be reported")
+	}
+	if len(layouts) != len(layout.Builtins()) {
+		t.Fatalf("only the built-ins should load, got %d layouts", len(layouts))
+	}
+}
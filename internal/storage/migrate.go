@@ -0,0 +1,412 @@
+package storage
+
+// Versioned schema migrations. initSchema creates the original base tables
+// with CREATE TABLE IF NOT EXISTS; everything added since is a numbered step
+// in the migrations list below. Each step runs in its own transaction together
+// with the schema_version row that records it, so a DB is never left
+// half-migrated and its version is always known. Steps are append-only: never
+// renumber or edit a released step, add a new one instead.
+//
+// The early steps replace the old "ALTER TABLE ... ADD COLUMN, ignore the
+// error" calls. Databases that already ran those have the columns but no
+// schema_version rows, so column additions go through addColumnIfMissing and
+// are safe to replay.
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// ErrSchemaTooNew is returned when the database was written by a newer typtel
+// than this build; opening it could silently drop data the newer version
+// relies on.
+var ErrSchemaTooNew = errors.New("database schema is newer than this typtel")
+
+type migration struct {
+	version int
+	name    string
+	up      func(tx *sql.Tx) error
+}
+
+var migrations = []migration{
+	{1, "mouse_daily.click_count", func(tx *sql.Tx) error {
+		return addColumnIfMissing(tx, "mouse_daily", "click_count", "INTEGER DEFAULT 0")
+	}},
+	{2, "daily_summary key-type columns", func(tx *sql.Tx) error {
+		for _, col := range []string{"letters", "modifiers", "special"} {
+			if err := addColumnIfMissing(tx, "daily_summary", col, "INTEGER DEFAULT 0"); err != nil {
+				return err
+			}
+		}
+		return nil
+	}},
+	{3, "daily_summary typing-speed columns", func(tx *sql.Tx) error {
+		cols := []struct{ name, decl string }{
+			{"active_ms", "INTEGER DEFAULT 0"},
+			{"fastest_burst_wpm", "REAL DEFAULT 0"},
+			{"fastest_window_wpm", "REAL DEFAULT 0"},
+			{"fastest_minute_wpm", "REAL DEFAULT 0"},
+		}
+		for _, c := range cols {
+			if err := addColumnIfMissing(tx, "daily_summary", c.name, c.decl); err != nil {
+				return err
+			}
+		}
+		return nil
+	}},
+	{4, "odometer_session singleton row", func(tx *sql.Tx) error {
+		_, err := tx.Exec("INSERT OR IGNORE INTO odometer_session (id, is_active) VALUES (1, 0)")
+		return err
+	}},
+	{5, "keystroke_hourly rollup table", func(tx *sql.Tx) error {
+		// Raw keystrokes rows older than the retention window are compacted
+		// into one row per (date, hour, keycode). See rollup.go.
+		_, err := tx.Exec(`
+			CREATE TABLE IF NOT EXISTS keystroke_hourly (
+				date    TEXT NOT NULL,
+				hour    INTEGER NOT NULL,
+				keycode INTEGER NOT NULL,
+				count   INTEGER DEFAULT 0,
+				PRIMARY KEY (date, hour, keycode)
+			)`)
+		return err
+	}},
+	{6, "bigram_latency table", func(tx *sql.Tx) error {
+		// Inter-key latency per consecutive key pair, with a sparse
+		// histogram so p90 can be kept current as samples are merged in.
+		// See bigram.go.
+		_, err := tx.Exec(`
+			CREATE TABLE IF NOT EXISTS bigram_latency (
+				prev      INTEGER NOT NULL,
+				next      INTEGER NOT NULL,
+				count     INTEGER DEFAULT 0,
+				total_ms  INTEGER DEFAULT 0,
+				p90_ms    INTEGER DEFAULT 0,
+				histogram TEXT DEFAULT '',
+				PRIMARY KEY (prev, next)
+			)`)
+		return err
+	}},
+	{7, "app_daily table", func(tx *sql.Tx) error {
+		// Per-application keystrokes, words and active time per day, keyed
+		// by the appfilter app id. See apps.go.
+		_, err := tx.Exec(`
+			CREATE TABLE IF NOT EXISTS app_daily (
+				date       TEXT NOT NULL,
+				app_id     TEXT NOT NULL,
+				keystrokes INTEGER DEFAULT 0,
+				words      INTEGER DEFAULT 0,
+				active_ms  INTEGER DEFAULT 0,
+				PRIMARY KEY (date, app_id)
+			)`)
+		return err
+	}},
+	{8, "odometer labels", func(tx *sql.Tx) error {
+		for _, table := range []string{"odometer_session", "odometer_history"} {
+			if err := addColumnIfMissing(tx, table, "label", "TEXT DEFAULT ''"); err != nil {
+				return err
+			}
+		}
+		return nil
+	}},
+	{9, "focus_sessions table", func(tx *sql.Tx) error {
+		// Tagged focus sessions with an optional words / active-minutes
+		// target. A row with no end_time is the running session. See
+		// focus.go.
+		_, err := tx.Exec(`
+			CREATE TABLE IF NOT EXISTS focus_sessions (
+				id              INTEGER PRIMARY KEY AUTOINCREMENT,
+				tag             TEXT NOT NULL,
+				start_time      TEXT NOT NULL,
+				end_time        TEXT,
+				target_kind     TEXT DEFAULT '',
+				target          INTEGER DEFAULT 0,
+				keystrokes      INTEGER DEFAULT 0,
+				words           INTEGER DEFAULT 0,
+				active_ms       INTEGER DEFAULT 0,
+				peak_burst_wpm  REAL DEFAULT 0,
+				goal_reached_at TEXT
+			);
+			CREATE INDEX IF NOT EXISTS idx_focus_sessions_tag ON focus_sessions(tag, start_time)`)
+		return err
+	}},
+	{10, "rsi_breaks table", func(tx *sql.Tx) error {
+		// Break-reminder outcomes: prompted breaks taken or skipped, and
+		// unprompted long breaks. See breaks.go.
+		_, err := tx.Exec(`
+			CREATE TABLE IF NOT EXISTS rsi_breaks (
+				id        INTEGER PRIMARY KEY AUTOINCREMENT,
+				date      TEXT NOT NULL,
+				kind      TEXT NOT NULL,
+				due_at    TEXT,
+				taken_at  TEXT,
+				worked_ms INTEGER DEFAULT 0
+			);
+			CREATE INDEX IF NOT EXISTS idx_rsi_breaks_date ON rsi_breaks(date)`)
+		return err
+	}},
+	{11, "push_days table", func(tx *sql.Tx) error {
+		// Outbound push state per local date: the counts the host last
+		// acknowledged and the retry schedule after failures. See pushdays.go.
+		_, err := tx.Exec(`
+			CREATE TABLE IF NOT EXISTS push_days (
+				date         TEXT PRIMARY KEY,
+				target       TEXT NOT NULL DEFAULT '',
+				hash         TEXT NOT NULL DEFAULT '',
+				pushed_at    TEXT,
+				attempts     INTEGER DEFAULT 0,
+				next_attempt TEXT,
+				last_error   TEXT NOT NULL DEFAULT ''
+			)`)
+		return err
+	}},
+	{12, "typing_tests table", func(tx *sql.Tx) error {
+		// One row per completed typing test, with its per-second WPM series
+		// as a JSON array. See typingtests.go.
+		_, err := tx.Exec(`
+			CREATE TABLE IF NOT EXISTS typing_tests (
+				id                 INTEGER PRIMARY KEY AUTOINCREMENT,
+				completed_at       TEXT NOT NULL,
+				mode               TEXT NOT NULL,
+				test_type          TEXT NOT NULL DEFAULT '',
+				layout             TEXT NOT NULL DEFAULT '',
+				language           TEXT NOT NULL DEFAULT '',
+				duration_ms        INTEGER DEFAULT 0,
+				raw_wpm            REAL DEFAULT 0,
+				net_wpm            REAL DEFAULT 0,
+				accuracy           REAL DEFAULT 0,
+				cpm                INTEGER DEFAULT 0,
+				errors             INTEGER DEFAULT 0,
+				uncorrected_errors INTEGER DEFAULT 0,
+				wpm_series         TEXT NOT NULL DEFAULT ''
+			);
+			CREATE INDEX IF NOT EXISTS idx_typing_tests_completed ON typing_tests(completed_at)`)
+		return err
+	}},
+	{13, "typing_tests replay columns", func(tx *sql.Tx) error {
+		// The text a test asked for and its keystroke timeline (JSON), for
+		// replays and the ghost pace caret.
+		for _, col := range []string{"target_text", "timeline"} {
+			if err := addColumnIfMissing(tx, "typing_tests", col, "TEXT NOT NULL DEFAULT ''"); err != nil {
+				return err
+			}
+		}
+		return nil
+	}},
+	{14, "typing_test_chars table", func(tx *sql.Tx) error {
+		// What was typed for each expected character in each typing test,
+		// keyed by the typing_tests id so a bundle can carry a test's counts
+		// with it; typed = expected counts the hits. See typingchars.go.
+		_, err := tx.Exec(`
+			CREATE TABLE IF NOT EXISTS typing_test_chars (
+				test_id  INTEGER NOT NULL,
+				expected TEXT NOT NULL,
+				typed    TEXT NOT NULL,
+				count    INTEGER DEFAULT 0,
+				PRIMARY KEY (test_id, expected, typed)
+			)`)
+		return err
+	}},
+}
+
+// LatestSchemaVersion is the schema version this build migrates to.
+func LatestSchemaVersion() int {
+	return migrations[len(migrations)-1].version
+}
+
+// MigrationInfo describes one migration step and whether it has been applied.
+type MigrationInfo struct {
+	Version   int
+	Name      string
+	Applied   bool
+	AppliedAt string // RFC3339; empty when pending
+}
+
+// addColumnIfMissing adds a column unless the table already has it, so steps
+// that predate schema_version can replay against DBs that already ran the old
+// unconditional ALTERs.
+func addColumnIfMissing(tx *sql.Tx, table, column, decl string) error {
+	rows, err := tx.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return err
+	}
+	found := false
+	for rows.Next() {
+		var (
+			cid     int
+			name    string
+			ctype   string
+			notNull int
+			dflt    sql.NullString
+			pk      int
+		)
+		if err := rows.Scan(&cid, &name, &ctype, &notNull, &dflt, &pk); err != nil {
+			rows.Close()
+			return err
+		}
+		if name == column {
+			found = true
+		}
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return err
+	}
+	rows.Close()
+	if found {
+		return nil
+	}
+	_, err = tx.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, decl))
+	return err
+}
+
+func ensureVersionTable(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_version (
+			version    INTEGER PRIMARY KEY,
+			name       TEXT NOT NULL,
+			applied_at TEXT NOT NULL
+		)`)
+	return err
+}
+
+// appliedVersions returns version -> applied_at for every recorded step. A DB
+// without a schema_version table has nothing applied.
+func appliedVersions(db *sql.DB) (map[int]string, error) {
+	applied := make(map[int]string)
+	if db == nil {
+		return applied, nil
+	}
+	var exists int
+	err := db.QueryRow(
+		"SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'schema_version'",
+	).Scan(&exists)
+	if err != nil || exists == 0 {
+		return applied, err
+	}
+
+	rows, err := db.Query("SELECT version, applied_at FROM schema_version")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var v int
+		var at string
+		if err := rows.Scan(&v, &at); err != nil {
+			return nil, err
+		}
+		applied[v] = at
+	}
+	return applied, rows.Err()
+}
+
+func checkNotTooNew(applied map[int]string) error {
+	latest := LatestSchemaVersion()
+	for v := range applied {
+		if v > latest {
+			return fmt.Errorf("%w: database is at version %d, this build supports up to %d — upgrade typtel",
+				ErrSchemaTooNew, v, latest)
+		}
+	}
+	return nil
+}
+
+// migrate applies every pending step in order, each in its own transaction.
+// It refuses to touch a DB that records a version newer than this build knows.
+// Another process may be migrating the same file: with the store's immediate
+// transactions the steps serialise, and applyMigration skips any step that
+// was recorded while it waited for the lock.
+func migrate(db *sql.DB) error {
+	if err := ensureVersionTable(db); err != nil {
+		return err
+	}
+	applied, err := appliedVersions(db)
+	if err != nil {
+		return err
+	}
+	if err := checkNotTooNew(applied); err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		if _, ok := applied[m.version]; ok {
+			continue
+		}
+		if err := applyMigration(db, m); err != nil {
+			return fmt.Errorf("migration %d (%s): %w", m.version, m.name, err)
+		}
+	}
+	return nil
+}
+
+func applyMigration(db *sql.DB, m migration) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var done bool
+	err = tx.QueryRow("SELECT EXISTS (SELECT 1 FROM schema_version WHERE version = ?)", m.version).Scan(&done)
+	if err != nil || done {
+		return err
+	}
+	if err := m.up(tx); err != nil {
+		return err
+	}
+	_, err = tx.Exec(
+		"INSERT INTO schema_version (version, name, applied_at) VALUES (?, ?, ?)",
+		m.version, m.name, time.Now().Format(time.RFC3339),
+	)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func migrationStatus(db *sql.DB) ([]MigrationInfo, error) {
+	applied, err := appliedVersions(db)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkNotTooNew(applied); err != nil {
+		return nil, err
+	}
+	infos := make([]MigrationInfo, 0, len(migrations))
+	for _, m := range migrations {
+		at, ok := applied[m.version]
+		infos = append(infos, MigrationInfo{Version: m.version, Name: m.name, Applied: ok, AppliedAt: at})
+	}
+	return infos, nil
+}
+
+// MigrationStatus reports every known migration step for the database on
+// disk without applying anything (storage.New would migrate on open). It
+// returns ErrSchemaTooNew if the DB was written by a newer build.
+func MigrationStatus() ([]MigrationInfo, error) {
+	dbPath, err := DBPath()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+		// Fresh install: nothing applied yet.
+		return migrationStatus(nil)
+	}
+	db, err := sql.Open("sqlite3", "file:"+dbPath+"?mode=ro")
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	return migrationStatus(db)
+}
+
+// SchemaVersion returns the highest applied migration version.
+func (s *Store) SchemaVersion() (int, error) {
+	var v sql.NullInt64
+	err := s.db.QueryRow("SELECT MAX(version) FROM schema_version").Scan(&v)
+	return int(v.Int64), err
+}
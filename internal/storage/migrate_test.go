@@ -0,0 +1,193 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func openRawDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func hasColumn(t *testing.T, db *sql.DB, table, column string) bool {
+	t.Helper()
+	var n int
+	err := db.QueryRow("SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?", table, column).Scan(&n)
+	if err != nil {
+		t.Fatalf("pragma_table_info: %v", err)
+	}
+	return n > 0
+}
+
+func TestInitSchemaAppliesAllMigrations(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	v, err := store.SchemaVersion()
+	if err != nil {
+		t.Fatalf("SchemaVersion: %v", err)
+	}
+	if v != LatestSchemaVersion() {
+		t.Fatalf("version: want %d, got %d", LatestSchemaVersion(), v)
+	}
+
+	infos, err := migrationStatus(store.db)
+	if err != nil {
+		t.Fatalf("migrationStatus: %v", err)
+	}
+	for _, m := range infos {
+		if !m.Applied || m.AppliedAt == "" {
+			t.Fatalf("migration %d (%s) not applied", m.Version, m.Name)
+		}
+	}
+}
+
+func TestInitSchemaIsIdempotent(t *testing.T) {
+	db := openRawDB(t)
+	if err := initSchema(db); err != nil {
+		t.Fatalf("first initSchema: %v", err)
+	}
+	if err := initSchema(db); err != nil {
+		t.Fatalf("second initSchema: %v", err)
+	}
+	var rows int
+	if err := db.QueryRow("SELECT COUNT(*) FROM schema_version").Scan(&rows); err != nil {
+		t.Fatalf("count: %v", err)
+	}
+	if rows != len(migrations) {
+		t.Fatalf("schema_version rows: want %d, got %d", len(migrations), rows)
+	}
+}
+
+// A DB from before schema_version already has the ALTERed columns; the early
+// migrations must replay over it without failing on duplicate columns.
+func TestMigrateLegacyDatabase(t *testing.T) {
+	db := openRawDB(t)
+	_, err := db.Exec(`
+		CREATE TABLE daily_summary (date TEXT PRIMARY KEY, keystrokes INTEGER DEFAULT 0,
+			words INTEGER DEFAULT 0, updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			letters INTEGER DEFAULT 0, modifiers INTEGER DEFAULT 0);
+		INSERT INTO daily_summary (date, keystrokes, letters) VALUES ('2025-01-01', 10, 7);
+	`)
+	if err != nil {
+		t.Fatalf("legacy schema: %v", err)
+	}
+
+	if err := initSchema(db); err != nil {
+		t.Fatalf("initSchema on legacy DB: %v", err)
+	}
+	for _, col := range []string{"letters", "modifiers", "special", "active_ms", "fastest_minute_wpm"} {
+		if !hasColumn(t, db, "daily_summary", col) {
+			t.Errorf("daily_summary missing column %q", col)
+		}
+	}
+
+	store := &Store{db: db}
+	day, err := store.GetDayStats("2025-01-01")
+	if err != nil {
+		t.Fatalf("GetDayStats: %v", err)
+	}
+	if day.Keystrokes != 10 || day.Letters != 7 {
+		t.Fatalf("legacy data lost: %+v", day)
+	}
+}
+
+func TestMigrateRefusesNewerSchema(t *testing.T) {
+	db := openRawDB(t)
+	if err := initSchema(db); err != nil {
+		t.Fatalf("initSchema: %v", err)
+	}
+	future := LatestSchemaVersion() + 1
+	_, err := db.Exec("INSERT INTO schema_version (version, name, applied_at) VALUES (?, 'from the future', '2099-01-01T00:00:00Z')", future)
+	if err != nil {
+		t.Fatalf("insert future version: %v", err)
+	}
+
+	if err := initSchema(db); !errors.Is(err, ErrSchemaTooNew) {
+		t.Fatalf("initSchema: want ErrSchemaTooNew, got %v", err)
+	}
+	if _, err := migrationStatus(db); !errors.Is(err, ErrSchemaTooNew) {
+		t.Fatalf("migrationStatus: want ErrSchemaTooNew, got %v", err)
+	}
+}
+
+func TestMigrationStatusReportsPending(t *testing.T) {
+	infos, err := migrationStatus(nil)
+	if err != nil {
+		t.Fatalf("migrationStatus: %v", err)
+	}
+	if len(infos) != len(migrations) {
+		t.Fatalf("want %d steps, got %d", len(migrations), len(infos))
+	}
+	for i, m := range infos {
+		if m.Applied {
+			t.Errorf("step %d should be pending on a fresh DB", m.Version)
+		}
+		if m.Version != i+1 {
+			t.Errorf("steps must be numbered consecutively from 1: index %d has version %d", i, m.Version)
+		}
+	}
+}
+
+func TestApplyMigrationSkipsRecordedStep(t *testing.T) {
+	db := openRawDB(t)
+	if err := initSchema(db); err != nil {
+		t.Fatalf("initSchema: %v", err)
+	}
+	// Another process recorded the step between our read and our lock.
+	ran := false
+	step := migrations[len(migrations)-1]
+	step.up = func(tx *sql.Tx) error { ran = true; return nil }
+	if err := applyMigration(db, step); err != nil {
+		t.Fatalf("applyMigration: %v", err)
+	}
+	if ran {
+		t.Fatal("a recorded step should not run again")
+	}
+}
+
+func TestConcurrentOpenMigratesOnce(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "typtel.db")
+	var wg sync.WaitGroup
+	errs := make(chan error, 4)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s, err := openPath(path)
+			if err == nil {
+				s.Close()
+			}
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("concurrent open: %v", err)
+		}
+	}
+
+	s, err := openPath(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer s.Close()
+	var n int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM schema_version").Scan(&n); err != nil {
+		t.Fatalf("count schema_version: %v", err)
+	}
+	if n != len(migrations) {
+		t.Fatalf("schema_version rows: want %d, got %d", len(migrations), n)
+	}
+}
@@ -0,0 +1,304 @@
+package storage
+
+// Profiles keep separate histories (say, "work" and "personal") on one machine.
+// Each profile is a directory with its own typtel.db and logs/ under the data
+// root:
+//
+//	<root>/typtel.db                   the "default" profile (the pre-profile location)
+//	<root>/profiles/<name>/typtel.db   every other profile
+//	<root>/active_profile              profile chosen by `typtel profile switch`
+//	<root>/tray.json                   profile and pid of the running tray daemon
+//
+// <root> is $TYPTEL_DATA_DIR when set, otherwise ~/.local/share/typtel. A
+// process picks its profile once at startup: UseProfile (the --profile flag)
+// wins over active_profile, which wins over "default".
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// DataDirEnv names the environment variable that overrides the data root.
+const DataDirEnv = "TYPTEL_DATA_DIR"
+
+// DefaultProfile is the profile stored directly in the data root.
+const DefaultProfile = "default"
+
+var (
+	ErrInvalidProfileName = errors.New("profile names are 1-32 characters of a-z, 0-9, '-' or '_'")
+	ErrProfileNotFound    = errors.New("profile does not exist")
+	ErrProfileExists      = errors.New("profile already exists")
+)
+
+var profileNameRe = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+// selectedProfile is the profile forced by UseProfile; empty means "read
+// active_profile".
+var selectedProfile string
+
+// ProfileInfo describes one profile on disk.
+type ProfileInfo struct {
+	Name   string
+	DBPath string
+	Size   int64 // database size in bytes; 0 if it has not been created yet
+}
+
+// TrayStatus is what a running tray daemon (typtel-tray or typtel-menubar)
+// records about itself.
+type TrayStatus struct {
+	Program string    `json:"program"`
+	Profile string    `json:"profile"`
+	PID     int       `json:"pid"`
+	Started time.Time `json:"started"`
+}
+
+// ValidateProfileName reports whether name can be used as a profile.
+func ValidateProfileName(name string) error {
+	if !profileNameRe.MatchString(name) {
+		return fmt.Errorf("%q: %w", name, ErrInvalidProfileName)
+	}
+	return nil
+}
+
+// DataRoot returns (creating if needed) the directory holding every profile.
+func DataRoot() (string, error) {
+	root := os.Getenv(DataDirEnv)
+	if root == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			// Fallback: get home dir from user.Current()
+			if u, userErr := user.Current(); userErr == nil {
+				home = u.HomeDir
+			} else {
+				return "", err
+			}
+		}
+		root = filepath.Join(home, ".local", "share", "typtel")
+	}
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return "", err
+	}
+	return root, nil
+}
+
+func profileDir(root, name string) string {
+	if name == DefaultProfile {
+		return root
+	}
+	return filepath.Join(root, "profiles", name)
+}
+
+func profileExists(root, name string) bool {
+	if name == DefaultProfile {
+		return true
+	}
+	info, err := os.Stat(profileDir(root, name))
+	return err == nil && info.IsDir()
+}
+
+// UseProfile makes this process read and write the named profile, overriding
+// active_profile. Call it before the first New().
+func UseProfile(name string) error {
+	if err := ValidateProfileName(name); err != nil {
+		return err
+	}
+	root, err := DataRoot()
+	if err != nil {
+		return err
+	}
+	if !profileExists(root, name) {
+		return fmt.Errorf("%q: %w (create it with: typtel profile create %s)", name, ErrProfileNotFound, name)
+	}
+	selectedProfile = name
+	return nil
+}
+
+// ActiveProfile returns the profile this process uses.
+func ActiveProfile() string {
+	if selectedProfile != "" {
+		return selectedProfile
+	}
+	return switchedProfile()
+}
+
+// switchedProfile returns the profile set by `typtel profile switch`,
+// ignoring any UseProfile override. A missing or stale active_profile falls
+// back to "default".
+func switchedProfile() string {
+	root, err := DataRoot()
+	if err != nil {
+		return DefaultProfile
+	}
+	b, err := os.ReadFile(filepath.Join(root, "active_profile"))
+	if err != nil {
+		return DefaultProfile
+	}
+	name := strings.TrimSpace(string(b))
+	if ValidateProfileName(name) != nil || !profileExists(root, name) {
+		return DefaultProfile
+	}
+	return name
+}
+
+// ListProfiles returns every profile, "default" first and the rest by name.
+func ListProfiles() ([]ProfileInfo, error) {
+	root, err := DataRoot()
+	if err != nil {
+		return nil, err
+	}
+	names := []string{}
+	entries, err := os.ReadDir(filepath.Join(root, "profiles"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, e := range entries {
+		if e.IsDir() && ValidateProfileName(e.Name()) == nil && e.Name() != DefaultProfile {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+	names = append([]string{DefaultProfile}, names...)
+
+	profiles := make([]ProfileInfo, 0, len(names))
+	for _, name := range names {
+		p := ProfileInfo{Name: name, DBPath: filepath.Join(profileDir(root, name), "typtel.db")}
+		if info, err := os.Stat(p.DBPath); err == nil {
+			p.Size = info.Size()
+		}
+		profiles = append(profiles, p)
+	}
+	return profiles, nil
+}
+
+// CreateProfile creates an empty profile with an initialised database.
+func CreateProfile(name string) error {
+	if err := ValidateProfileName(name); err != nil {
+		return err
+	}
+	root, err := DataRoot()
+	if err != nil {
+		return err
+	}
+	if profileExists(root, name) {
+		return fmt.Errorf("%q: %w", name, ErrProfileExists)
+	}
+	dir := profileDir(root, name)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	s, err := openPath(filepath.Join(dir, "typtel.db"))
+	if err != nil {
+		return err
+	}
+	return s.Close()
+}
+
+// SwitchProfile makes name the profile used by processes started without
+// --profile. Running processes keep the profile they started with.
+func SwitchProfile(name string) error {
+	if err := ValidateProfileName(name); err != nil {
+		return err
+	}
+	root, err := DataRoot()
+	if err != nil {
+		return err
+	}
+	if !profileExists(root, name) {
+		return fmt.Errorf("%q: %w", name, ErrProfileNotFound)
+	}
+	path := filepath.Join(root, "active_profile")
+	if name == DefaultProfile {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	return os.WriteFile(path, []byte(name+"\n"), 0644)
+}
+
+func trayStatusPath() (string, error) {
+	root, err := DataRoot()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(root, "tray.json"), nil
+}
+
+// WriteTrayStatus records that this process is the tray daemon writing to the
+// active profile. `typtel profile list` reads it back via RunningTray.
+func WriteTrayStatus() error {
+	path, err := trayStatusPath()
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(TrayStatus{Program: filepath.Base(os.Args[0]), Profile: ActiveProfile(), PID: os.Getpid(), Started: time.Now()})
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0644)
+}
+
+// ClearTrayStatus removes the tray status file if this process wrote it.
+func ClearTrayStatus() error {
+	path, err := trayStatusPath()
+	if err != nil {
+		return err
+	}
+	st, err := readTrayStatus(path)
+	if err != nil || st == nil || st.PID != os.Getpid() {
+		return err
+	}
+	return os.Remove(path)
+}
+
+// RunningTray returns the running tray daemon's status, or nil if none is
+// running. A status file left behind by a crashed daemon counts as not running.
+func RunningTray() (*TrayStatus, error) {
+	path, err := trayStatusPath()
+	if err != nil {
+		return nil, err
+	}
+	st, err := readTrayStatus(path)
+	if err != nil || st == nil {
+		return nil, err
+	}
+	if !processAlive(st.PID) {
+		return nil, nil
+	}
+	return st, nil
+}
+
+func readTrayStatus(path string) (*TrayStatus, error) {
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var st TrayStatus
+	if err := json.Unmarshal(b, &st); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &st, nil
+}
+
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	return p.Signal(syscall.Signal(0)) == nil
+}
@@ -0,0 +1,135 @@
+package storage
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// useTempDataRoot points the data root at a scratch directory and clears any
+// profile selected by an earlier test.
+func useTempDataRoot(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	t.Setenv(DataDirEnv, root)
+	selectedProfile = ""
+	t.Cleanup(func() { selectedProfile = "" })
+	return root
+}
+
+func TestDataDirEnvOverride(t *testing.T) {
+	root := useTempDataRoot(t)
+
+	path, err := DBPath()
+	if err != nil {
+		t.Fatalf("DBPath: %v", err)
+	}
+	if want := filepath.Join(root, "typtel.db"); path != want {
+		t.Fatalf("DBPath = %q, want %q", path, want)
+	}
+}
+
+func TestProfilesHaveSeparateDatabases(t *testing.T) {
+	root := useTempDataRoot(t)
+
+	if err := CreateProfile("work"); err != nil {
+		t.Fatalf("CreateProfile: %v", err)
+	}
+	if err := CreateProfile("work"); !errors.Is(err, ErrProfileExists) {
+		t.Fatalf("second CreateProfile: want ErrProfileExists, got %v", err)
+	}
+
+	if err := UseProfile("work"); err != nil {
+		t.Fatalf("UseProfile: %v", err)
+	}
+	work, err := New()
+	if err != nil {
+		t.Fatalf("New(work): %v", err)
+	}
+	defer work.Close()
+	if err := work.RecordKeystroke(0); err != nil {
+		t.Fatalf("RecordKeystroke: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "profiles", "work", "typtel.db")); err != nil {
+		t.Fatalf("work database not in its profile directory: %v", err)
+	}
+
+	if err := UseProfile(DefaultProfile); err != nil {
+		t.Fatalf("UseProfile(default): %v", err)
+	}
+	def, err := New()
+	if err != nil {
+		t.Fatalf("New(default): %v", err)
+	}
+	defer def.Close()
+	if day, _ := def.GetTodayStats(); day.Keystrokes != 0 {
+		t.Fatalf("default profile should not see work keystrokes, got %d", day.Keystrokes)
+	}
+}
+
+func TestSwitchProfile(t *testing.T) {
+	useTempDataRoot(t)
+
+	if err := SwitchProfile("missing"); !errors.Is(err, ErrProfileNotFound) {
+		t.Fatalf("SwitchProfile(missing): want ErrProfileNotFound, got %v", err)
+	}
+	if err := UseProfile("Bad Name"); !errors.Is(err, ErrInvalidProfileName) {
+		t.Fatalf("UseProfile(bad): want ErrInvalidProfileName, got %v", err)
+	}
+
+	CreateProfile("personal")
+	if err := SwitchProfile("personal"); err != nil {
+		t.Fatalf("SwitchProfile: %v", err)
+	}
+	if got := ActiveProfile(); got != "personal" {
+		t.Fatalf("ActiveProfile after switch = %q", got)
+	}
+
+	// --profile beats the switched default for this process only.
+	UseProfile(DefaultProfile)
+	if got := ActiveProfile(); got != DefaultProfile {
+		t.Fatalf("UseProfile should override the switch, got %q", got)
+	}
+	selectedProfile = ""
+
+	if err := SwitchProfile(DefaultProfile); err != nil {
+		t.Fatalf("SwitchProfile(default): %v", err)
+	}
+	if got := ActiveProfile(); got != DefaultProfile {
+		t.Fatalf("ActiveProfile after switching back = %q", got)
+	}
+
+	profiles, err := ListProfiles()
+	if err != nil {
+		t.Fatalf("ListProfiles: %v", err)
+	}
+	if len(profiles) != 2 || profiles[0].Name != DefaultProfile || profiles[1].Name != "personal" {
+		t.Fatalf("ListProfiles = %+v", profiles)
+	}
+}
+
+func TestTrayStatus(t *testing.T) {
+	root := useTempDataRoot(t)
+
+	if st, err := RunningTray(); err != nil || st != nil {
+		t.Fatalf("no tray yet: got %+v, %v", st, err)
+	}
+	if err := WriteTrayStatus(); err != nil {
+		t.Fatalf("WriteTrayStatus: %v", err)
+	}
+	st, err := RunningTray()
+	if err != nil || st == nil {
+		t.Fatalf("RunningTray: %+v, %v", st, err)
+	}
+	if st.PID != os.Getpid() || st.Profile != DefaultProfile {
+		t.Fatalf("tray status: %+v", st)
+	}
+
+	if err := ClearTrayStatus(); err != nil {
+		t.Fatalf("ClearTrayStatus: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "tray.json")); !os.IsNotExist(err) {
+		t.Fatalf("tray.json should be removed, stat err = %v", err)
+	}
+}
@@ -0,0 +1,100 @@
+package storage
+
+// Outbound push state. internal/push sends absolute day totals to a host; a
+// push_days row per local date remembers which counts the host last
+// acknowledged (as a hash, for one target) and, after failures, when to try
+// again. A day is clean once its current counts hash to what was acknowledged
+// by the current target.
+
+import "time"
+
+// PushDay is the push state of one local date.
+type PushDay struct {
+	Date        string
+	Target      string    // host and device id the hash was acknowledged by
+	Hash        string    // hash of the counts last acknowledged; empty if never
+	PushedAt    time.Time // zero if never acknowledged
+	Attempts    int       // failures since the last success
+	NextAttempt time.Time // zero unless backing off
+	LastError   string
+}
+
+// Acked reports whether target has acknowledged counts hashing to hash.
+func (d PushDay) Acked(target, hash string) bool {
+	return d.Hash != "" && d.Target == target && d.Hash == hash
+}
+
+// GetPushDays returns the push state of every date on or after sinceDate
+// (empty = all), keyed by date.
+func (s *Store) GetPushDays(sinceDate string) (map[string]PushDay, error) {
+	rows, err := s.db.Query(`
+		SELECT date, target, hash, COALESCE(pushed_at, ''), attempts,
+			COALESCE(next_attempt, ''), last_error
+		FROM push_days WHERE date >= ?`, sinceDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make(map[string]PushDay)
+	for rows.Next() {
+		var d PushDay
+		var pushedAt, nextAttempt string
+		if err := rows.Scan(&d.Date, &d.Target, &d.Hash, &pushedAt, &d.Attempts,
+			&nextAttempt, &d.LastError); err != nil {
+			return nil, err
+		}
+		d.PushedAt, _ = time.Parse(time.RFC3339, pushedAt)
+		d.NextAttempt, _ = time.Parse(time.RFC3339, nextAttempt)
+		out[d.Date] = d
+	}
+	return out, rows.Err()
+}
+
+// MarkDayPushed records that target acknowledged date's counts, clearing any
+// retry schedule.
+func (s *Store) MarkDayPushed(date, target, hash string, at time.Time) error {
+	_, err := s.db.Exec(`
+		INSERT INTO push_days (date, target, hash, pushed_at, attempts, next_attempt, last_error)
+		VALUES (?, ?, ?, ?, 0, NULL, '')
+		ON CONFLICT(date) DO UPDATE SET
+			target = excluded.target, hash = excluded.hash, pushed_at = excluded.pushed_at,
+			attempts = 0, next_attempt = NULL, last_error = ''`,
+		date, target, hash, at.Format(time.RFC3339))
+	return err
+}
+
+// MarkDayPushFailed records a failed push of date and when to retry it. What
+// was last acknowledged is kept.
+func (s *Store) MarkDayPushFailed(date string, attempts int, next time.Time, errMsg string) error {
+	_, err := s.db.Exec(`
+		INSERT INTO push_days (date, attempts, next_attempt, last_error)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(date) DO UPDATE SET
+			attempts = excluded.attempts, next_attempt = excluded.next_attempt,
+			last_error = excluded.last_error`,
+		date, attempts, nullTime(next), errMsg)
+	return err
+}
+
+// DefaultPushRetryDays is the push retry window when push_retry_days is unset.
+const DefaultPushRetryDays = 7
+
+// GetPushRetryDays returns how many days back, today included, the push loop
+// re-sends days the host hasn't acknowledged.
+func (s *Store) GetPushRetryDays() int {
+	val, _ := s.GetSetting(SettingPushRetryDays)
+	if val == "" {
+		return DefaultPushRetryDays
+	}
+	days, err := parseInt(val)
+	if err != nil || days < 1 {
+		return DefaultPushRetryDays
+	}
+	return days
+}
+
+// SetPushRetryDays sets the push retry window.
+func (s *Store) SetPushRetryDays(days int) error {
+	return s.SetSetting(SettingPushRetryDays, intToString(days))
+}
@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPushDays(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	at := time.Date(2026, 1, 5, 10, 0, 0, 0, time.UTC)
+	if err := store.MarkDayPushFailed("2026-01-04", 1, at.Add(time.Minute), "connection refused"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.MarkDayPushed("2026-01-05", "kali@http://host", "abc", at); err != nil {
+		t.Fatal(err)
+	}
+	// A later failure keeps what the host last acknowledged.
+	if err := store.MarkDayPushFailed("2026-01-05", 2, at.Add(4*time.Minute), "timeout"); err != nil {
+		t.Fatal(err)
+	}
+
+	days, err := store.GetPushDays("2026-01-01")
+	if err != nil {
+		t.Fatalf("GetPushDays: %v", err)
+	}
+	if len(days) != 2 {
+		t.Fatalf("got %d days, want 2", len(days))
+	}
+	d := days["2026-01-05"]
+	if !d.Acked("kali@http://host", "abc") || d.Acked("kali@http://other", "abc") || d.Acked("kali@http://host", "def") {
+		t.Errorf("Acked wrong for %+v", d)
+	}
+	if d.Attempts != 2 || d.LastError != "timeout" || !d.NextAttempt.Equal(at.Add(4*time.Minute)) || !d.PushedAt.Equal(at) {
+		t.Errorf("2026-01-05 = %+v", d)
+	}
+	if d := days["2026-01-04"]; d.Hash != "" || d.Attempts != 1 || !d.PushedAt.IsZero() {
+		t.Errorf("2026-01-04 = %+v", d)
+	}
+
+	// Success clears the retry schedule.
+	if err := store.MarkDayPushed("2026-01-05", "kali@http://host", "def", at.Add(5*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	days, _ = store.GetPushDays("2026-01-05")
+	if d := days["2026-01-05"]; len(days) != 1 || d.Attempts != 0 || d.LastError != "" || !d.NextAttempt.IsZero() || d.Hash != "def" {
+		t.Errorf("after success = %+v", d)
+	}
+}
+
+func TestPushRetryDays(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	if got := store.GetPushRetryDays(); got != DefaultPushRetryDays {
+		t.Errorf("default = %d, want %d", got, DefaultPushRetryDays)
+	}
+	if err := store.SetPushRetryDays(30); err != nil {
+		t.Fatal(err)
+	}
+	if got := store.GetPushRetryDays(); got != 30 {
+		t.Errorf("got %d, want 30", got)
+	}
+	store.SetSetting(SettingPushRetryDays, "0")
+	if got := store.GetPushRetryDays(); got != DefaultPushRetryDays {
+		t.Errorf("0 should fall back to the default, got %d", got)
+	}
+}
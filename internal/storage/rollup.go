@@ -0,0 +1,152 @@
+package storage
+
+// Keystroke retention and rollup. RecordKeystroke writes one keystrokes row
+// per key forever, but the raw rows are only ever read back as per-hour
+// counts. Compaction folds rows older than the retention window into
+// keystroke_hourly — one row per (date, hour, keycode) — and deletes the
+// originals in the same transaction, so a crash can never double-count or
+// lose a day. GetHourlyStats sums both tables, so readers never notice.
+
+import (
+	"time"
+)
+
+// Rough on-disk cost of one row, including index entries. Used only for the
+// dry-run size estimate; SQLite gives no cheap exact per-row figure.
+const (
+	approxRawRowBytes    = 96
+	approxRollupRowBytes = 40
+)
+
+// CompactReport summarises a compaction (or, for a dry run, what a compaction
+// would do). Cutoff is exclusive: only dates strictly before it are touched.
+type CompactReport struct {
+	Cutoff         string
+	Days           int64 // distinct dates with raw rows before Cutoff
+	RawRows        int64 // raw keystrokes rows folded into the rollup
+	RollupRows     int64 // (date, hour, keycode) rows they collapse into
+	DBBytes        int64 // current database file size (page_count * page_size)
+	EstimatedSaved int64 // approximate bytes freed once the file is vacuumed
+	DryRun         bool
+}
+
+// GetKeystrokeRetentionDays returns the configured raw-keystroke retention in
+// days. Retention is opt-in: unset, zero or negative means raw keystrokes are
+// kept forever and the daemons never compact on their own.
+func (s *Store) GetKeystrokeRetentionDays() int {
+	val, _ := s.GetSetting(SettingKeystrokeRetentionDays)
+	days, err := parseInt(val)
+	if err != nil {
+		return 0
+	}
+	return days
+}
+
+// SetKeystrokeRetentionDays sets the raw-keystroke retention window.
+func (s *Store) SetKeystrokeRetentionDays(days int) error {
+	return s.SetSetting(SettingKeystrokeRetentionDays, intToString(days))
+}
+
+// retentionCutoff returns the first date that is kept raw when retaining
+// olderThanDays days. Today is always kept raw.
+func retentionCutoff(now time.Time, olderThanDays int) string {
+	if olderThanDays < 1 {
+		olderThanDays = 1
+	}
+	return now.AddDate(0, 0, -olderThanDays).Format("2006-01-02")
+}
+
+// PlanCompaction reports what CompactKeystrokes(olderThanDays) would do
+// without changing anything.
+func (s *Store) PlanCompaction(olderThanDays int) (CompactReport, error) {
+	return s.compactBefore(retentionCutoff(time.Now(), olderThanDays), true)
+}
+
+// CompactKeystrokes folds raw keystrokes rows older than olderThanDays into
+// the keystroke_hourly rollup and deletes them. A non-positive olderThanDays
+// is a no-op. The one-time active-time backfill reads raw timestamps, so it is
+// run first to make sure that history is captured before it is discarded.
+// Deleted pages are reused by SQLite but the file only shrinks after Vacuum.
+func (s *Store) CompactKeystrokes(olderThanDays int) (CompactReport, error) {
+	if olderThanDays <= 0 {
+		return CompactReport{}, nil
+	}
+	if err := s.BackfillActiveTime(); err != nil {
+		return CompactReport{}, err
+	}
+	return s.compactBefore(retentionCutoff(time.Now(), olderThanDays), false)
+}
+
+func (s *Store) compactBefore(cutoff string, dryRun bool) (CompactReport, error) {
+	report := CompactReport{Cutoff: cutoff, DryRun: dryRun}
+
+	err := s.db.QueryRow(`
+		SELECT COUNT(*), COUNT(DISTINCT date) FROM keystrokes WHERE date < ?`,
+		cutoff,
+	).Scan(&report.RawRows, &report.Days)
+	if err != nil {
+		return report, err
+	}
+	err = s.db.QueryRow(`
+		SELECT COUNT(*) FROM (
+			SELECT 1 FROM keystrokes WHERE date < ? GROUP BY date, hour, keycode
+		)`,
+		cutoff,
+	).Scan(&report.RollupRows)
+	if err != nil {
+		return report, err
+	}
+	if report.DBBytes, err = s.DatabaseSize(); err != nil {
+		return report, err
+	}
+	report.EstimatedSaved = report.RawRows*approxRawRowBytes - report.RollupRows*approxRollupRowBytes
+	if report.EstimatedSaved < 0 {
+		report.EstimatedSaved = 0
+	}
+
+	if dryRun || report.RawRows == 0 {
+		return report, nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return report, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		INSERT INTO keystroke_hourly (date, hour, keycode, count)
+		SELECT date, hour, keycode, COUNT(*) FROM keystrokes
+		WHERE date < ? GROUP BY date, hour, keycode
+		ON CONFLICT(date, hour, keycode) DO UPDATE SET count = count + excluded.count
+	`, cutoff)
+	if err != nil {
+		return report, err
+	}
+	if _, err := tx.Exec("DELETE FROM keystrokes WHERE date < ?", cutoff); err != nil {
+		return report, err
+	}
+
+	return report, tx.Commit()
+}
+
+// DatabaseSize returns the size of the database file in bytes, as SQLite sees
+// it (page_count * page_size).
+func (s *Store) DatabaseSize() (int64, error) {
+	var pages, pageSize int64
+	if err := s.db.QueryRow("PRAGMA page_count").Scan(&pages); err != nil {
+		return 0, err
+	}
+	if err := s.db.QueryRow("PRAGMA page_size").Scan(&pageSize); err != nil {
+		return 0, err
+	}
+	return pages * pageSize, nil
+}
+
+// Vacuum rebuilds the database file so space freed by compaction is returned
+// to the filesystem. It needs an exclusive lock and fails with SQLITE_BUSY if
+// a daemon is mid-write; callers can simply retry later.
+func (s *Store) Vacuum() error {
+	_, err := s.db.Exec("VACUUM")
+	return err
+}
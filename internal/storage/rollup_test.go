@@ -0,0 +1,152 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+// insertRawKeystroke writes a keystrokes row for an arbitrary date, bypassing
+// RecordKeystroke (which always uses the current time).
+func insertRawKeystroke(t *testing.T, s *Store, date string, hour, keycode int) {
+	t.Helper()
+	_, err := s.db.Exec("INSERT INTO keystrokes (keycode, date, hour) VALUES (?, ?, ?)", keycode, date, hour)
+	if err != nil {
+		t.Fatalf("insert keystroke: %v", err)
+	}
+}
+
+func countRaw(t *testing.T, s *Store, date string) int64 {
+	t.Helper()
+	var n int64
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM keystrokes WHERE date = ?", date).Scan(&n); err != nil {
+		t.Fatalf("count keystrokes: %v", err)
+	}
+	return n
+}
+
+func TestCompactBeforeFoldsOldRows(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	const oldDay, newDay = "2025-01-01", "2025-03-01"
+	insertRawKeystroke(t, store, oldDay, 9, 0)
+	insertRawKeystroke(t, store, oldDay, 9, 0)
+	insertRawKeystroke(t, store, oldDay, 9, 1)
+	insertRawKeystroke(t, store, oldDay, 14, 0)
+	insertRawKeystroke(t, store, newDay, 10, 0)
+
+	report, err := store.compactBefore("2025-02-01", false)
+	if err != nil {
+		t.Fatalf("compactBefore: %v", err)
+	}
+	if report.RawRows != 4 || report.RollupRows != 3 || report.Days != 1 {
+		t.Fatalf("report: want 4 raw / 3 rollup / 1 day, got %+v", report)
+	}
+
+	if n := countRaw(t, store, oldDay); n != 0 {
+		t.Fatalf("old raw rows: want 0, got %d", n)
+	}
+	if n := countRaw(t, store, newDay); n != 1 {
+		t.Fatalf("new raw rows must be kept: want 1, got %d", n)
+	}
+
+	hourly, err := store.GetHourlyStats(oldDay)
+	if err != nil {
+		t.Fatalf("GetHourlyStats: %v", err)
+	}
+	if hourly[9].Keystrokes != 3 || hourly[14].Keystrokes != 1 {
+		t.Fatalf("hourly after compaction: hour9=%d hour14=%d", hourly[9].Keystrokes, hourly[14].Keystrokes)
+	}
+}
+
+func TestCompactBeforeDryRunChangesNothing(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	insertRawKeystroke(t, store, "2025-01-01", 9, 0)
+	insertRawKeystroke(t, store, "2025-01-01", 9, 0)
+
+	report, err := store.compactBefore("2025-02-01", true)
+	if err != nil {
+		t.Fatalf("compactBefore: %v", err)
+	}
+	if !report.DryRun || report.RawRows != 2 || report.RollupRows != 1 {
+		t.Fatalf("dry-run report: %+v", report)
+	}
+	if report.DBBytes <= 0 {
+		t.Fatalf("DBBytes should be positive, got %d", report.DBBytes)
+	}
+	if n := countRaw(t, store, "2025-01-01"); n != 2 {
+		t.Fatalf("dry run must not delete: want 2, got %d", n)
+	}
+}
+
+func TestHourlyStatsMergesRawAndRollup(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	const date = "2025-01-01"
+	insertRawKeystroke(t, store, date, 9, 0)
+	if _, err := store.compactBefore("2025-01-02", false); err != nil {
+		t.Fatalf("compactBefore: %v", err)
+	}
+	// A late raw row for the same day (e.g. written after a clock change)
+	// must add to, not replace, the rollup.
+	insertRawKeystroke(t, store, date, 9, 0)
+	insertRawKeystroke(t, store, date, 9, 0)
+
+	hourly, err := store.GetHourlyStats(date)
+	if err != nil {
+		t.Fatalf("GetHourlyStats: %v", err)
+	}
+	if hourly[9].Keystrokes != 3 {
+		t.Fatalf("hour 9: want 3, got %d", hourly[9].Keystrokes)
+	}
+
+	// Compacting again merges into the existing rollup rows.
+	if _, err := store.compactBefore("2025-01-02", false); err != nil {
+		t.Fatalf("compactBefore: %v", err)
+	}
+	hourly, err = store.GetHourlyStats(date)
+	if err != nil {
+		t.Fatalf("GetHourlyStats: %v", err)
+	}
+	if hourly[9].Keystrokes != 3 {
+		t.Fatalf("hour 9 after re-compaction: want 3, got %d", hourly[9].Keystrokes)
+	}
+}
+
+func TestKeystrokeRetentionDays(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	if got := store.GetKeystrokeRetentionDays(); got != 0 {
+		t.Fatalf("default: want 0 (off), got %d", got)
+	}
+	if err := store.SetKeystrokeRetentionDays(30); err != nil {
+		t.Fatalf("SetKeystrokeRetentionDays: %v", err)
+	}
+	if got := store.GetKeystrokeRetentionDays(); got != 30 {
+		t.Fatalf("want 30, got %d", got)
+	}
+}
+
+func TestCompactKeystrokesKeepsToday(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	if err := store.RecordKeystroke(0); err != nil {
+		t.Fatalf("RecordKeystroke: %v", err)
+	}
+	report, err := store.CompactKeystrokes(1)
+	if err != nil {
+		t.Fatalf("CompactKeystrokes: %v", err)
+	}
+	if report.RawRows != 0 {
+		t.Fatalf("today's rows must never be compacted, got %d", report.RawRows)
+	}
+	today := time.Now().Format("2006-01-02")
+	if n := countRaw(t, store, today); n != 1 {
+		t.Fatalf("today raw rows: want 1, got %d", n)
+	}
+}
@@ -2,24 +2,32 @@ package storage
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"os"
-	"os/user"
 	"path/filepath"
+	"slices"
+	"sync/atomic"
 	"time"
 
+	"github.com/aayushbajaj/typing-telemetry/internal/layout"
 	_ "github.com/mattn/go-sqlite3"
 )
 
 type Store struct {
 	db *sql.DB
+
+	// kbLayout is the active keyboard layout (see layout.go); kbLayoutName
+	// is the setting it was resolved from.
+	kbLayout     atomic.Pointer[layout.Layout]
+	kbLayoutName atomic.Value
 }
 
 type DailyStats struct {
 	Date       string
 	Keystrokes int64
 	Words      int64
-	Letters    int64 // a-z, A-Z
+	Letters    int64 // Letter keys on the active keyboard layout
 	Modifiers  int64 // Shift, Ctrl, Alt/Option, Cmd
 	Special    int64 // Numbers, punctuation, function keys, etc.
 
@@ -68,8 +76,9 @@ type MouseLeaderboardEntry struct {
 	Rank          int
 }
 
-// LogDir returns (creating if needed) the typtel logs directory
-// (~/.local/share/typtel/logs), used for generated artifacts like charts.html.
+// LogDir returns (creating if needed) the active profile's logs directory
+// (~/.local/share/typtel/logs by default), used for generated artifacts like
+// charts.html.
 func LogDir() (string, error) {
 	dataDir, err := getDataDir()
 	if err != nil {
@@ -82,36 +91,49 @@ func LogDir() (string, error) {
 	return logDir, nil
 }
 
+// getDataDir returns (creating if needed) the active profile's directory.
 func getDataDir() (string, error) {
-	home, err := os.UserHomeDir()
+	root, err := DataRoot()
 	if err != nil {
-		// Fallback: get home dir from user.Current()
-		if u, userErr := user.Current(); userErr == nil {
-			home = u.HomeDir
-		} else {
-			return "", err
-		}
+		return "", err
 	}
-	dataDir := filepath.Join(home, ".local", "share", "typtel")
+	dataDir := profileDir(root, ActiveProfile())
 	if err := os.MkdirAll(dataDir, 0755); err != nil {
 		return "", err
 	}
 	return dataDir, nil
 }
 
-func New() (*Store, error) {
+// DBPath returns the path of the active profile's SQLite database.
+func DBPath() (string, error) {
 	dataDir, err := getDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dataDir, "typtel.db"), nil
+}
+
+// New opens the active profile's database, creating and migrating it as needed.
+func New() (*Store, error) {
+	dbPath, err := DBPath()
 	if err != nil {
 		return nil, err
 	}
+	return openPath(dbPath)
+}
 
-	dbPath := filepath.Join(dataDir, "typtel.db")
-	db, err := sql.Open("sqlite3", dbPath)
+func openPath(dbPath string) (*Store, error) {
+	// Every transaction here writes, and the daemons and the CLI share the
+	// file: take the write lock at BEGIN so two processes can't both read
+	// and then fail to upgrade, and so concurrent migrations run one at a
+	// time.
+	db, err := sql.Open("sqlite3", dbPath+"?_txlock=immediate")
 	if err != nil {
 		return nil, err
 	}
 
 	if err := initSchema(db); err != nil {
+		db.Close()
 		return nil, err
 	}
 
@@ -207,63 +229,20 @@ func initSchema(db *sql.DB) error {
 		PRIMARY KEY (device_id, date)
 	);
 	`
-	_, err := db.Exec(schema)
-	if err != nil {
+	if _, err := db.Exec(schema); err != nil {
 		return err
 	}
 
-	// Add click_count column if it doesn't exist (migration for existing DBs)
-	_, _ = db.Exec("ALTER TABLE mouse_daily ADD COLUMN click_count INTEGER DEFAULT 0")
-
-	// Add key type tracking columns (migration for existing DBs)
-	_, _ = db.Exec("ALTER TABLE daily_summary ADD COLUMN letters INTEGER DEFAULT 0")
-	_, _ = db.Exec("ALTER TABLE daily_summary ADD COLUMN modifiers INTEGER DEFAULT 0")
-	_, _ = db.Exec("ALTER TABLE daily_summary ADD COLUMN special INTEGER DEFAULT 0")
-
-	// Typing-speed columns (migration for existing DBs, added v1.4)
-	_, _ = db.Exec("ALTER TABLE daily_summary ADD COLUMN active_ms INTEGER DEFAULT 0")
-	_, _ = db.Exec("ALTER TABLE daily_summary ADD COLUMN fastest_burst_wpm REAL DEFAULT 0")
-	_, _ = db.Exec("ALTER TABLE daily_summary ADD COLUMN fastest_window_wpm REAL DEFAULT 0")
-	_, _ = db.Exec("ALTER TABLE daily_summary ADD COLUMN fastest_minute_wpm REAL DEFAULT 0")
-
-	// Ensure odometer session row exists (singleton pattern)
-	_, _ = db.Exec("INSERT OR IGNORE INTO odometer_session (id, is_active) VALUES (1, 0)")
-
-	return nil
+	// Columns and tables added after the base schema are applied as numbered,
+	// transactional migrations. See migrate.go.
+	return migrate(db)
 }
 
-// ClassifyKeycode returns the key type for a macOS keycode
-// Returns: "letter", "modifier", or "special"
+// ClassifyKeycode returns the key type for a macOS keycode on the default
+// QWERTY layout: "letter", "modifier", or "special". Recording goes through
+// the store's active layout instead; see KeyboardLayout.
 func ClassifyKeycode(keycode int) string {
-	// Physical letter keys on macOS (ANSI keyboard layout)
-	// These are physical key positions, not characters
-	switch keycode {
-	// Row 1: Q W E R T Y U I O P
-	case 12, 13, 14, 15, 17, 16, 32, 34, 31, 35:
-		return "letter"
-	// Row 2: A S D F G H J K L
-	case 0, 1, 2, 3, 5, 4, 38, 40, 37:
-		return "letter"
-	// Row 3: Z X C V B N M
-	case 6, 7, 8, 9, 11, 45, 46:
-		return "letter"
-	// Modifier keys
-	case 56, 60: // Left/Right Shift
-		return "modifier"
-	case 59, 62: // Left/Right Control
-		return "modifier"
-	case 58, 61: // Left/Right Option/Alt
-		return "modifier"
-	case 55, 54: // Left/Right Command
-		return "modifier"
-	case 63: // fn key
-		return "modifier"
-	case 57: // Caps Lock
-		return "modifier"
-	default:
-		// Everything else: numbers, punctuation, function keys, arrows, etc.
-		return "special"
-	}
+	return string(layout.QWERTY().Class(keycode))
 }
 
 func (s *Store) RecordKeystroke(keycode int) error {
@@ -285,13 +264,12 @@ func (s *Store) RecordKeystroke(keycode int) error {
 		return err
 	}
 
-	// Classify the key type
-	keyType := ClassifyKeycode(keycode)
+	// Classify the key type on the active layout
 	var letterInc, modifierInc, specialInc int
-	switch keyType {
-	case "letter":
+	switch s.KeyboardLayout().Class(keycode) {
+	case layout.Letter:
 		letterInc = 1
-	case "modifier":
+	case layout.Modifier:
 		modifierInc = 1
 	default:
 		specialInc = 1
@@ -379,6 +357,29 @@ func (s *Store) GetSpeedAggregate(sinceDate string) (SpeedAggregate, error) {
 	return agg, err
 }
 
+// Totals are lifetime sums over every recorded day.
+type Totals struct {
+	Keystrokes  int64
+	Words       int64
+	Letters     int64
+	Modifiers   int64
+	Special     int64
+	MouseClicks int64
+}
+
+// GetTotals sums the daily summaries and mouse clicks over all days.
+func (s *Store) GetTotals() (Totals, error) {
+	var t Totals
+	err := s.db.QueryRow(`SELECT
+			COALESCE(SUM(keystrokes), 0), COALESCE(SUM(words), 0),
+			COALESCE(SUM(letters), 0), COALESCE(SUM(modifiers), 0),
+			COALESCE(SUM(special), 0),
+			(SELECT COALESCE(SUM(click_count), 0) FROM mouse_daily)
+		FROM daily_summary`,
+	).Scan(&t.Keystrokes, &t.Words, &t.Letters, &t.Modifiers, &t.Special, &t.MouseClicks)
+	return t, err
+}
+
 // settingBackfillDone marks that the one-time active-time backfill has run.
 const settingBackfillDone = "speed_backfill_done"
 
@@ -489,9 +490,15 @@ func (s *Store) GetHourlyStats(date string) ([]HourlyStats, error) {
 		stats[i].Hour = i
 	}
 
-	rows, err := s.db.Query(
-		"SELECT hour, COUNT(*) FROM keystrokes WHERE date = ? GROUP BY hour",
-		date,
+	// Sum the raw table and the compacted rollup; a day that straddles the
+	// retention cutoff can have rows in both.
+	rows, err := s.db.Query(`
+		SELECT hour, SUM(n) FROM (
+			SELECT hour, COUNT(*) AS n FROM keystrokes WHERE date = ? GROUP BY hour
+			UNION ALL
+			SELECT hour, SUM(count) AS n FROM keystroke_hourly WHERE date = ? GROUP BY hour
+		) GROUP BY hour`,
+		date, date,
 	)
 	if err != nil {
 		return nil, err
@@ -529,6 +536,35 @@ func (s *Store) GetHistoricalStats(days int) ([]DailyStats, error) {
 	return stats, nil
 }
 
+// GetDayStatsRange returns the days from from through to ("2006-01-02") that
+// have a daily_summary row, oldest first, in one query. Days with no row are
+// left out, so callers wanting every day must fill the gaps.
+func (s *Store) GetDayStatsRange(from, to string) ([]DailyStats, error) {
+	rows, err := s.db.Query(
+		`SELECT date, COALESCE(keystrokes, 0), COALESCE(words, 0), COALESCE(letters, 0),
+			COALESCE(modifiers, 0), COALESCE(special, 0), COALESCE(active_ms, 0),
+			COALESCE(fastest_burst_wpm, 0), COALESCE(fastest_window_wpm, 0),
+			COALESCE(fastest_minute_wpm, 0)
+		FROM daily_summary WHERE date >= ? AND date <= ? ORDER BY date`,
+		from, to,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []DailyStats
+	for rows.Next() {
+		var d DailyStats
+		if err := rows.Scan(&d.Date, &d.Keystrokes, &d.Words, &d.Letters, &d.Modifiers, &d.Special,
+			&d.ActiveMs, &d.FastestBurstWPM, &d.FastestWindowWPM, &d.FastestMinuteWPM); err != nil {
+			return nil, err
+		}
+		stats = append(stats, d)
+	}
+	return stats, rows.Err()
+}
+
 // GetAllHourlyStatsForDays returns hourly stats for multiple days (for heatmap)
 func (s *Store) GetAllHourlyStatsForDays(days int) (map[string][]HourlyStats, error) {
 	result := make(map[string][]HourlyStats)
@@ -764,6 +800,36 @@ const (
 	SettingPushToken      = "push_token"
 	SettingPushDeviceID   = "push_device_id"
 	SettingPushDeviceName = "push_device_name"
+	// How many days back (today included) the push loop keeps re-sending
+	// until the host has acknowledged their final counts.
+	SettingPushRetryDays = "push_retry_days"
+	// Local stats API (see internal/localapi): read-only JSON served by the
+	// capture daemon on a loopback port or a unix socket. Disabled by default.
+	SettingLocalAPIEnabled = "local_api_enabled"
+	SettingLocalAPIAddr    = "local_api_addr"
+	// The live event stream (GET /v1/events) is a separate opt-in.
+	SettingLocalAPIEvents = "local_api_events"
+	// As is the Prometheus endpoint (GET /metrics).
+	SettingLocalAPIMetrics = "local_api_metrics"
+	// Keystroke retention: raw keystrokes rows older than this many days are
+	// compacted into the hourly rollup. See rollup.go.
+	SettingKeystrokeRetentionDays = "keystroke_retention_days"
+	// Keyboard layout used to classify physical keys. See layout.go.
+	SettingKeyboardLayout = "keyboard_layout"
+	// Linux capture backend for typtel-tray (see internal/keylogger) and,
+	// for evdev, the device nodes to read (empty: every keyboard found).
+	SettingCaptureBackend = "capture_backend"
+	SettingEvdevDevices   = "evdev_devices"
+	// Goals are stored one key per period and metric, "goal_daily_words" and
+	// so on; see goals.go.
+	settingGoalPrefix = "goal_"
+	// Break reminders (RSI guard) run by the capture daemons. Durations are
+	// stored as Go duration strings ("20m"). See breaks.go.
+	SettingRSIEnabled    = "rsi_enabled"
+	SettingRSIMicroAfter = "rsi_micro_after"
+	SettingRSIMicroBreak = "rsi_micro_break"
+	SettingRSILongAfter  = "rsi_long_after"
+	SettingRSILongBreak  = "rsi_long_break"
 )
 
 // Distance unit options
@@ -773,6 +839,13 @@ const (
 	DistanceUnitFrisbee = "frisbee" // ultimate frisbee field ~330ft
 )
 
+// Capture backend options (Linux only; macOS always uses its event tap).
+const (
+	CaptureBackendAuto  = "auto"  // evdev under Wayland or with no X display, else x11 (default)
+	CaptureBackendX11   = "x11"   // poll the X server; no extra permissions
+	CaptureBackendEvdev = "evdev" // read /dev/input; needs the input group
+)
+
 // Inertia max speed options (capped at what terminals/editors can handle)
 const (
 	InertiaSpeedUltraFast  = "ultra_fast"  // Cap at ~140 keys/sec (pushing limits)
@@ -911,6 +984,37 @@ func (s *Store) SetDistanceUnit(unit string) error {
 	return s.SetSetting(SettingDistanceUnit, unit)
 }
 
+// GetCaptureBackend returns the configured Linux capture backend.
+// Default: "auto".
+func (s *Store) GetCaptureBackend() string {
+	val, _ := s.GetSetting(SettingCaptureBackend)
+	if val == "" {
+		return CaptureBackendAuto
+	}
+	return val
+}
+
+// SetCaptureBackend sets the Linux capture backend.
+func (s *Store) SetCaptureBackend(backend string) error {
+	switch backend {
+	case CaptureBackendAuto, CaptureBackendX11, CaptureBackendEvdev:
+		return s.SetSetting(SettingCaptureBackend, backend)
+	}
+	return fmt.Errorf("unknown capture backend %q (want auto, x11 or evdev)", backend)
+}
+
+// GetEvdevDevices returns the evdev device paths to capture from. Empty means
+// every keyboard the backend can find.
+func (s *Store) GetEvdevDevices() []string {
+	val, _ := s.GetSetting(SettingEvdevDevices)
+	return splitNewlineList(val)
+}
+
+// SetEvdevDevices replaces the evdev device list.
+func (s *Store) SetEvdevDevices(paths []string) error {
+	return s.SetSetting(SettingEvdevDevices, joinNewlineList(paths))
+}
+
 // IsStrictWordCountEnabled returns whether per-app word-count filtering is active.
 // Default: false. When false, the smarter keystroke heuristics still apply;
 // only the per-app allowlist filter is gated by this setting.
@@ -949,14 +1053,43 @@ func (s *Store) RecordSeenApp(bundleID string) error {
 	if bundleID == "" {
 		return nil
 	}
-	seen := s.GetWordCountAppsSeen()
-	for _, id := range seen {
-		if id == bundleID {
-			return nil
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := addSeenAppsTx(tx, []string{bundleID}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// addSeenAppsTx appends the apps not already in the apps-seen list, writing
+// the setting only if something was added.
+func addSeenAppsTx(tx *sql.Tx, apps []string) error {
+	if len(apps) == 0 {
+		return nil
+	}
+	var val string
+	err := tx.QueryRow("SELECT COALESCE(value, '') FROM settings WHERE key = ?", SettingWordCountAppsSeen).Scan(&val)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	seen := splitNewlineList(val)
+	n := len(seen)
+	for _, app := range apps {
+		if app != "" && !slices.Contains(seen, app) {
+			seen = append(seen, app)
 		}
 	}
-	seen = append(seen, bundleID)
-	return s.SetSetting(SettingWordCountAppsSeen, joinNewlineList(seen))
+	if len(seen) == n {
+		return nil
+	}
+	_, err = tx.Exec(`
+		INSERT INTO settings (key, value) VALUES (?, ?)
+		ON CONFLICT(key) DO UPDATE SET value = excluded.value
+	`, SettingWordCountAppsSeen, joinNewlineList(seen))
+	return err
 }
 
 func splitNewlineList(s string) []string {
@@ -1093,14 +1226,22 @@ type TypingTestStats struct {
 type TypingTestMode struct {
 	WordCount   int
 	Punctuation bool
+	Seconds     int  // time limit of a time test; WordCount is ignored when set
+	Code        bool // a code test; all code tests share one mode
 }
 
 // ModeKey generates a unique key for a typing test mode
 func (m TypingTestMode) ModeKey() string {
+	if m.Code {
+		return "mode_code"
+	}
 	punct := "no_punct"
 	if m.Punctuation {
 		punct = "punct"
 	}
+	if m.Seconds > 0 {
+		return fmt.Sprintf("mode_%ds_%s", m.Seconds, punct)
+	}
 	return fmt.Sprintf("mode_%d_%s", m.WordCount, punct)
 }
 
@@ -1255,6 +1396,7 @@ func (s *Store) SetTypingTestLanguage(language string) error {
 // OdometerSession represents the current odometer session state
 type OdometerSession struct {
 	IsActive          bool
+	Label             string // optional; carried into odometer_history
 	StartTime         time.Time
 	StartKeystrokes   int64
 	StartWords        int64
@@ -1273,13 +1415,13 @@ func (s *Store) GetOdometerSession() (*OdometerSession, error) {
 	var startTimeStr string
 
 	err := s.db.QueryRow(`
-		SELECT COALESCE(is_active, 0), COALESCE(start_time, ''),
+		SELECT COALESCE(is_active, 0), COALESCE(label, ''), COALESCE(start_time, ''),
 		       COALESCE(start_keystrokes, 0), COALESCE(start_words, 0),
 		       COALESCE(start_clicks, 0), COALESCE(start_distance, 0),
 		       COALESCE(current_keystrokes, 0), COALESCE(current_words, 0),
 		       COALESCE(current_clicks, 0), COALESCE(current_distance, 0)
 		FROM odometer_session WHERE id = 1
-	`).Scan(&isActive, &startTimeStr, &session.StartKeystrokes, &session.StartWords,
+	`).Scan(&isActive, &session.Label, &startTimeStr, &session.StartKeystrokes, &session.StartWords,
 		&session.StartClicks, &session.StartDistance, &session.CurrentKeystrokes,
 		&session.CurrentWords, &session.CurrentClicks, &session.CurrentDistance)
 
@@ -1296,6 +1438,12 @@ func (s *Store) GetOdometerSession() (*OdometerSession, error) {
 
 // StartOdometer starts a new odometer session with current totals as baseline
 func (s *Store) StartOdometer() error {
+	return s.StartLabeledOdometer("")
+}
+
+// StartLabeledOdometer starts a new odometer session like StartOdometer,
+// tagging it with label (may be empty).
+func (s *Store) StartLabeledOdometer(label string) error {
 	// Get current totals to set as baseline
 	todayStats, _ := s.GetTodayStats()
 	mouseStats, _ := s.GetTodayMouseStats()
@@ -1317,6 +1465,7 @@ func (s *Store) StartOdometer() error {
 	_, err := s.db.Exec(`
 		UPDATE odometer_session SET
 			is_active = 1,
+			label = ?,
 			start_time = ?,
 			start_keystrokes = ?,
 			start_words = ?,
@@ -1328,7 +1477,7 @@ func (s *Store) StartOdometer() error {
 			current_distance = ?,
 			updated_at = CURRENT_TIMESTAMP
 		WHERE id = 1
-	`, time.Now().Format(time.RFC3339),
+	`, label, time.Now().Format(time.RFC3339),
 		keystrokeBaseline, wordBaseline, clickBaseline, distanceBaseline,
 		keystrokeBaseline, wordBaseline, clickBaseline, distanceBaseline)
 	return err
@@ -1352,10 +1501,10 @@ func (s *Store) StopOdometer() error {
 		// Only save if there's meaningful activity
 		if keystrokes > 0 || words > 0 || clicks > 0 || distance > 0 {
 			_, err = s.db.Exec(`
-				INSERT INTO odometer_history (start_time, end_time, keystrokes, words, clicks, distance)
-				VALUES (?, ?, ?, ?, ?, ?)
+				INSERT INTO odometer_history (start_time, end_time, keystrokes, words, clicks, distance, label)
+				VALUES (?, ?, ?, ?, ?, ?, ?)
 			`, session.StartTime.Format(time.RFC3339), time.Now().Format(time.RFC3339),
-				keystrokes, words, clicks, distance)
+				keystrokes, words, clicks, distance, session.Label)
 			if err != nil {
 				return err
 			}
@@ -1380,11 +1529,54 @@ func (s *Store) UpdateOdometerCurrent(keystrokes, words, clicks int64, distance
 	return err
 }
 
+// RefreshOdometer sets the active session's current values from today's
+// keystroke, word and mouse totals. It does nothing when no session is
+// active, so capture daemons can call it on every tick.
+func (s *Store) RefreshOdometer() error {
+	session, err := s.GetOdometerSession()
+	if err != nil || !session.IsActive {
+		return err
+	}
+
+	var keystrokes, words, clicks int64
+	var distance float64
+	if todayStats, err := s.GetTodayStats(); err == nil && todayStats != nil {
+		keystrokes = todayStats.Keystrokes
+		words = todayStats.Words
+	}
+	if mouseStats, err := s.GetTodayMouseStats(); err == nil && mouseStats != nil {
+		clicks = mouseStats.ClickCount
+		distance = mouseStats.TotalDistance
+	}
+	return s.UpdateOdometerCurrent(keystrokes, words, clicks, distance)
+}
+
+// ErrOdometerInactive is returned by operations that need a running
+// odometer session when none is.
+var ErrOdometerInactive = errors.New("no odometer session is running")
+
+// SetOdometerLabel relabels the active session. It is an error if no
+// session is active.
+func (s *Store) SetOdometerLabel(label string) error {
+	res, err := s.db.Exec(`
+		UPDATE odometer_session SET label = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = 1 AND is_active = 1
+	`, label)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrOdometerInactive
+	}
+	return nil
+}
+
 // ResetOdometer resets the odometer to inactive state with zero values
 func (s *Store) ResetOdometer() error {
 	_, err := s.db.Exec(`
 		UPDATE odometer_session SET
 			is_active = 0,
+			label = '',
 			start_time = NULL,
 			start_keystrokes = 0,
 			start_words = 0,
@@ -1417,6 +1609,7 @@ func (s *Store) SetOdometerHotkey(hotkey string) error {
 // OdometerHistoryEntry represents a completed odometer session
 type OdometerHistoryEntry struct {
 	ID         int64
+	Label      string
 	StartTime  time.Time
 	EndTime    time.Time
 	Keystrokes int64
@@ -1428,7 +1621,7 @@ type OdometerHistoryEntry struct {
 // GetOdometerHistory returns all odometer history entries, most recent first
 func (s *Store) GetOdometerHistory() ([]OdometerHistoryEntry, error) {
 	rows, err := s.db.Query(`
-		SELECT id, start_time, end_time, keystrokes, words, clicks, distance
+		SELECT id, COALESCE(label, ''), start_time, end_time, keystrokes, words, clicks, distance
 		FROM odometer_history
 		ORDER BY start_time DESC
 	`)
@@ -1441,7 +1634,7 @@ func (s *Store) GetOdometerHistory() ([]OdometerHistoryEntry, error) {
 	for rows.Next() {
 		var entry OdometerHistoryEntry
 		var startTimeStr, endTimeStr string
-		err := rows.Scan(&entry.ID, &startTimeStr, &endTimeStr, &entry.Keystrokes,
+		err := rows.Scan(&entry.ID, &entry.Label, &startTimeStr, &endTimeStr, &entry.Keystrokes,
 			&entry.Words, &entry.Clicks, &entry.Distance)
 		if err != nil {
 			continue
@@ -352,6 +352,32 @@ func TestDistanceUnit(t *testing.T) {
 	}
 }
 
+func TestCaptureBackendSettings(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	if got := store.GetCaptureBackend(); got != CaptureBackendAuto {
+		t.Errorf("Expected default backend %q, got %q", CaptureBackendAuto, got)
+	}
+	if err := store.SetCaptureBackend(CaptureBackendEvdev); err != nil {
+		t.Fatalf("SetCaptureBackend: %v", err)
+	}
+	if got := store.GetCaptureBackend(); got != CaptureBackendEvdev {
+		t.Errorf("Expected backend %q, got %q", CaptureBackendEvdev, got)
+	}
+	if err := store.SetCaptureBackend("wayland"); err == nil {
+		t.Error("Expected an unknown backend to be rejected")
+	}
+
+	if d := store.GetEvdevDevices(); len(d) != 0 {
+		t.Errorf("Expected no devices by default, got %v", d)
+	}
+	store.SetEvdevDevices([]string{"/dev/input/event3", "/dev/input/event12"})
+	if d := store.GetEvdevDevices(); len(d) != 2 || d[1] != "/dev/input/event12" {
+		t.Errorf("Unexpected devices: %v", d)
+	}
+}
+
 func TestRecordMouseMovement(t *testing.T) {
 	store, cleanup := newTestStore(t)
 	defer cleanup()
@@ -410,6 +436,30 @@ func TestRecordMouseClick(t *testing.T) {
 	}
 }
 
+func TestGetTotals(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	if _, err := store.db.Exec(`INSERT INTO daily_summary (date, keystrokes, words, letters, modifiers, special)
+		VALUES ('2025-01-01', 100, 20, 80, 5, 15), ('2025-01-02', 50, 10, 40, 2, 8)`); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := store.RecordMouseClick(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := store.GetTotals()
+	if err != nil {
+		t.Fatalf("GetTotals failed: %v", err)
+	}
+	want := Totals{Keystrokes: 150, Words: 30, Letters: 120, Modifiers: 7, Special: 23, MouseClicks: 3}
+	if got != want {
+		t.Errorf("GetTotals = %+v, want %+v", got, want)
+	}
+}
+
 func TestMouseLeaderboard(t *testing.T) {
 	store, cleanup := newTestStore(t)
 	defer cleanup()
@@ -509,6 +559,9 @@ func TestTypingTestModeKey(t *testing.T) {
 		{TypingTestMode{WordCount: 10, Punctuation: true}, "mode_10_punct"},
 		{TypingTestMode{WordCount: 25, Punctuation: false}, "mode_25_no_punct"},
 		{TypingTestMode{WordCount: 100, Punctuation: true}, "mode_100_punct"},
+		{TypingTestMode{Seconds: 30}, "mode_30s_no_punct"},
+		{TypingTestMode{WordCount: 25, Punctuation: true, Seconds: 60}, "mode_60s_punct"},
+		{TypingTestMode{WordCount: 25, Punctuation: true, Code: true}, "mode_code"},
 	}
 
 	for _, tt := range tests {
@@ -1143,6 +1196,50 @@ func TestOdometerSession(t *testing.T) {
 	}
 }
 
+// TestOdometerLabelsAndRefresh tests labelled sessions and RefreshOdometer
+func TestOdometerLabelsAndRefresh(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	if err := store.SetOdometerLabel("x"); err != ErrOdometerInactive {
+		t.Errorf("SetOdometerLabel with no session: want ErrOdometerInactive, got %v", err)
+	}
+	// Refreshing an inactive odometer is a no-op.
+	if err := store.RefreshOdometer(); err != nil {
+		t.Fatalf("RefreshOdometer (inactive): %v", err)
+	}
+
+	if err := store.StartLabeledOdometer("draft"); err != nil {
+		t.Fatalf("StartLabeledOdometer failed: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := store.RecordKeystroke(0); err != nil {
+			t.Fatalf("RecordKeystroke: %v", err)
+		}
+	}
+	if err := store.RefreshOdometer(); err != nil {
+		t.Fatalf("RefreshOdometer: %v", err)
+	}
+	session, _ := store.GetOdometerSession()
+	if session.Label != "draft" {
+		t.Errorf("Expected label 'draft', got %q", session.Label)
+	}
+	if got := session.CurrentKeystrokes - session.StartKeystrokes; got != 3 {
+		t.Errorf("Expected 3 session keystrokes, got %d", got)
+	}
+
+	if err := store.SetOdometerLabel("final draft"); err != nil {
+		t.Fatalf("SetOdometerLabel: %v", err)
+	}
+	if err := store.StopOdometer(); err != nil {
+		t.Fatalf("StopOdometer failed: %v", err)
+	}
+	history, _ := store.GetOdometerHistory()
+	if len(history) != 1 || history[0].Label != "final draft" || history[0].Keystrokes != 3 {
+		t.Errorf("Expected one 'final draft' session with 3 keystrokes, got %+v", history)
+	}
+}
+
 // TestOdometerHotkey tests the odometer hotkey settings
 func TestOdometerHotkey(t *testing.T) {
 	store, cleanup := newTestStore(t)
@@ -1255,3 +1352,21 @@ func TestOdometerUpdateOnlyWhenActive(t *testing.T) {
 		t.Errorf("Expected current keystrokes 0 when inactive, got %d", session.CurrentKeystrokes)
 	}
 }
+
+func TestGetDayStatsRange(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	for _, date := range []string{"2025-01-01", "2025-01-03", "2025-01-05"} {
+		if err := store.IncrementWordCount(date); err != nil {
+			t.Fatalf("IncrementWordCount: %v", err)
+		}
+	}
+	days, err := store.GetDayStatsRange("2025-01-02", "2025-01-05")
+	if err != nil {
+		t.Fatalf("GetDayStatsRange: %v", err)
+	}
+	if len(days) != 2 || days[0].Date != "2025-01-03" || days[1].Date != "2025-01-05" || days[1].Words != 1 {
+		t.Errorf("days = %+v, want 2025-01-03 and 2025-01-05", days)
+	}
+}
@@ -0,0 +1,126 @@
+package storage
+
+// Per-character typing test errors. Every character typed in a completed
+// test is counted against the character the test expected at that position,
+// so a row with typed = expected counts hits and every other row is a
+// substitution (expected 'e', typed 'r'). Rows are kept per test; summed
+// across tests, they give each character's miss rate — the weakest keys.
+
+import (
+	"database/sql"
+	"sort"
+)
+
+// CharPair is a character a test expected and what was typed in its place.
+type CharPair struct {
+	Expected string
+	Typed    string
+}
+
+// CharSub is one character typed in place of another, and how often.
+type CharSub struct {
+	Typed string
+	Count int64
+}
+
+// CharStat is how one expected character has fared across typing tests.
+type CharStat struct {
+	Char     string
+	Attempts int64
+	Misses   int64
+	Subs     []CharSub // what was typed instead, most frequent first
+}
+
+// MissRate is the share of attempts that were missed, 0..1.
+func (c CharStat) MissRate() float64 {
+	if c.Attempts == 0 {
+		return 0
+	}
+	return float64(c.Misses) / float64(c.Attempts)
+}
+
+// AddTypingTestChars stores the counts of the test with the given
+// typing_tests id.
+func (s *Store) AddTypingTestChars(testID int64, counts map[CharPair]int64) error {
+	if len(counts) == 0 {
+		return nil
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := addTypingTestChars(tx, testID, counts); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func addTypingTestChars(tx *sql.Tx, testID int64, counts map[CharPair]int64) error {
+	stmt, err := tx.Prepare(`
+		INSERT INTO typing_test_chars (test_id, expected, typed, count) VALUES (?, ?, ?, ?)
+		ON CONFLICT(test_id, expected, typed) DO UPDATE SET count = count + excluded.count`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for p, n := range counts {
+		if _, err := stmt.Exec(testID, p.Expected, p.Typed, n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetTypingCharStats returns every expected character seen in typing tests,
+// in character order.
+func (s *Store) GetTypingCharStats() ([]CharStat, error) {
+	rows, err := s.db.Query(`
+		SELECT expected, typed, SUM(count) AS n FROM typing_test_chars
+		GROUP BY expected, typed
+		ORDER BY expected, n DESC, typed`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []CharStat
+	for rows.Next() {
+		var p CharPair
+		var n int64
+		if err := rows.Scan(&p.Expected, &p.Typed, &n); err != nil {
+			return nil, err
+		}
+		if len(stats) == 0 || stats[len(stats)-1].Char != p.Expected {
+			stats = append(stats, CharStat{Char: p.Expected})
+		}
+		c := &stats[len(stats)-1]
+		c.Attempts += n
+		if p.Typed != p.Expected {
+			c.Misses += n
+			c.Subs = append(c.Subs, CharSub{Typed: p.Typed, Count: n})
+		}
+	}
+	return stats, rows.Err()
+}
+
+// WeakestChars returns up to n of stats with at least minAttempts attempts
+// and a miss, highest miss rate first (n <= 0 = all).
+func WeakestChars(stats []CharStat, minAttempts int64, n int) []CharStat {
+	var weak []CharStat
+	for _, c := range stats {
+		if c.Attempts >= minAttempts && c.Misses > 0 {
+			weak = append(weak, c)
+		}
+	}
+	sort.SliceStable(weak, func(i, j int) bool {
+		if ri, rj := weak[i].MissRate(), weak[j].MissRate(); ri != rj {
+			return ri > rj
+		}
+		return weak[i].Misses > weak[j].Misses
+	})
+	if n > 0 && len(weak) > n {
+		weak = weak[:n]
+	}
+	return weak
+}
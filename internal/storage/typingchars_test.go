@@ -0,0 +1,46 @@
+package storage
+
+import "testing"
+
+func TestTypingCharStats(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	if err := store.AddTypingTestChars(1, map[CharPair]int64{
+		{"e", "e"}: 18, {"e", "r"}: 1, {"e", "w"}: 1,
+		{"t", "t"}: 30,
+		{"a", "a"}: 3, {"a", "s"}: 2,
+	}); err != nil {
+		t.Fatalf("AddTypingTestChars: %v", err)
+	}
+	// A second test adds to the totals.
+	store.AddTypingTestChars(2, map[CharPair]int64{{"e", "r"}: 2, {"e", "e"}: 18})
+
+	stats, err := store.GetTypingCharStats()
+	if err != nil {
+		t.Fatalf("GetTypingCharStats: %v", err)
+	}
+	if len(stats) != 3 || stats[0].Char != "a" || stats[1].Char != "e" || stats[2].Char != "t" {
+		t.Fatalf("stats = %+v, want a, e, t", stats)
+	}
+	e := stats[1]
+	if e.Attempts != 40 || e.Misses != 4 || e.MissRate() != 0.1 {
+		t.Errorf("e = %+v", e)
+	}
+	if len(e.Subs) != 2 || e.Subs[0] != (CharSub{"r", 3}) || e.Subs[1] != (CharSub{"w", 1}) {
+		t.Errorf("e substitutions = %+v, want r×3 then w×1", e.Subs)
+	}
+	if stats[2].Misses != 0 || stats[2].Subs != nil {
+		t.Errorf("t = %+v, want no misses", stats[2])
+	}
+
+	// a misses more often but has too few attempts; t never misses.
+	weak := WeakestChars(stats, 10, 0)
+	if len(weak) != 1 || weak[0].Char != "e" {
+		t.Errorf("WeakestChars(10) = %+v, want just e", weak)
+	}
+	weak = WeakestChars(stats, 1, 1)
+	if len(weak) != 1 || weak[0].Char != "a" {
+		t.Errorf("WeakestChars(1, 1) = %+v, want a", weak)
+	}
+}
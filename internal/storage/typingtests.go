@@ -0,0 +1,173 @@
+package storage
+
+// Typing test history. The settings-backed TypingTestStats keep only a
+// personal best and running average per mode; every completed test is also
+// kept here in full so progress can be listed and charted over time. A test
+// also keeps the text it asked for and its keystroke timeline, so it can be
+// replayed, and the fastest test of a mode raced as a ghost.
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// TypingTestResult is one completed typing test.
+type TypingTestResult struct {
+	ID                int64
+	CompletedAt       time.Time
+	Mode              string // TypingTestMode.ModeKey()
+	TestType          string // "normal", "custom", "bigrams", "time" or "code"
+	Layout            string
+	Language          string
+	Duration          time.Duration
+	RawWPM            float64
+	NetWPM            float64
+	Accuracy          float64 // percent
+	CPM               int
+	Errors            int // every mistyped keystroke, corrected or not
+	UncorrectedErrors int
+	WPMSeries         []float64 // net WPM sampled once per second
+
+	// Replay data, loaded by GetTypingTest and GetBestTypingTest only.
+	TargetText string
+	Keystrokes []Keystroke
+}
+
+// Keystroke is one input event of a typing test: a typed character, or a
+// deletion of Back characters (backspace, or a whole word). Auto marks text
+// the test filled in itself, such as skipped code indentation.
+type Keystroke struct {
+	Ms      int64  `json:"t"` // since the test started
+	Char    string `json:"c,omitempty"`
+	Correct bool   `json:"ok,omitempty"`
+	Back    int    `json:"b,omitempty"`
+	Auto    bool   `json:"a,omitempty"`
+}
+
+const typingTestColumns = `id, completed_at, mode, test_type, layout, language, duration_ms,
+	raw_wpm, net_wpm, accuracy, cpm, errors, uncorrected_errors, wpm_series`
+
+// typingTestReplayColumns adds the replay data, which lists leave out.
+const typingTestReplayColumns = typingTestColumns + ", target_text, timeline"
+
+func scanTypingTest(row rowScanner, replay bool) (*TypingTestResult, error) {
+	var r TypingTestResult
+	var completed, series, timeline string
+	var durationMs int64
+	dest := []any{&r.ID, &completed, &r.Mode, &r.TestType, &r.Layout, &r.Language,
+		&durationMs, &r.RawWPM, &r.NetWPM, &r.Accuracy, &r.CPM, &r.Errors,
+		&r.UncorrectedErrors, &series}
+	if replay {
+		dest = append(dest, &r.TargetText, &timeline)
+	}
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	r.CompletedAt, _ = time.Parse(time.RFC3339, completed)
+	r.Duration = time.Duration(durationMs) * time.Millisecond
+	if series != "" {
+		_ = json.Unmarshal([]byte(series), &r.WPMSeries)
+	}
+	if timeline != "" {
+		_ = json.Unmarshal([]byte(timeline), &r.Keystrokes)
+	}
+	return &r, nil
+}
+
+// marshalList encodes a slice as JSON, or "" when it is empty.
+func marshalList[T any](v []T) (string, error) {
+	if len(v) == 0 {
+		return "", nil
+	}
+	b, err := json.Marshal(v)
+	return string(b), err
+}
+
+// AddTypingTest stores a completed test and returns its id.
+func (s *Store) AddTypingTest(r TypingTestResult) (int64, error) {
+	series, err := marshalList(r.WPMSeries)
+	if err != nil {
+		return 0, err
+	}
+	timeline, err := marshalList(r.Keystrokes)
+	if err != nil {
+		return 0, err
+	}
+	res, err := s.db.Exec(`
+		INSERT INTO typing_tests (completed_at, mode, test_type, layout, language, duration_ms,
+			raw_wpm, net_wpm, accuracy, cpm, errors, uncorrected_errors, wpm_series,
+			target_text, timeline)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		r.CompletedAt.Format(time.RFC3339), r.Mode, r.TestType, r.Layout, r.Language,
+		r.Duration.Milliseconds(), r.RawWPM, r.NetWPM, r.Accuracy, r.CPM, r.Errors,
+		r.UncorrectedErrors, series, r.TargetText, timeline)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// GetTypingTest returns the test with the given id, with its replay data, or
+// nil if there is none.
+func (s *Store) GetTypingTest(id int64) (*TypingTestResult, error) {
+	r, err := scanTypingTest(s.db.QueryRow(
+		"SELECT "+typingTestReplayColumns+" FROM typing_tests WHERE id = ?", id), true)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return r, err
+}
+
+// GetBestTypingTest returns the fastest test of a mode that has a recorded
+// timeline, with its replay data: the mode's PB replay. It returns nil if the
+// mode has none.
+func (s *Store) GetBestTypingTest(mode string) (*TypingTestResult, error) {
+	r, err := scanTypingTest(s.db.QueryRow(
+		"SELECT "+typingTestReplayColumns+` FROM typing_tests
+		WHERE mode = ? AND timeline != ''
+		ORDER BY net_wpm DESC, id DESC LIMIT 1`, mode), true)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return r, err
+}
+
+// GetTypingTests returns completed tests, most recent first, without their
+// replay data. mode filters by exact mode key when non-empty; since
+// ("2006-01-02") keeps tests completed on or after that date when non-empty;
+// limit caps the count when positive.
+func (s *Store) GetTypingTests(mode, since string, limit int) ([]TypingTestResult, error) {
+	query := "SELECT " + typingTestColumns + " FROM typing_tests WHERE 1 = 1"
+	var args []any
+	if mode != "" {
+		query += " AND mode = ?"
+		args = append(args, mode)
+	}
+	if since != "" {
+		query += " AND substr(completed_at, 1, 10) >= ?"
+		args = append(args, since)
+	}
+	query += " ORDER BY completed_at DESC, id DESC"
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tests []TypingTestResult
+	for rows.Next() {
+		r, err := scanTypingTest(rows, false)
+		if err != nil {
+			return nil, err
+		}
+		tests = append(tests, *r)
+	}
+	return tests, rows.Err()
+}
@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTypingTests(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	mode25 := TypingTestMode{WordCount: 25}.ModeKey()
+	mode50 := TypingTestMode{WordCount: 50, Punctuation: true}.ModeKey()
+	at := time.Date(2026, 3, 10, 9, 0, 0, 0, time.Local)
+	first, err := store.AddTypingTest(TypingTestResult{
+		CompletedAt: at, Mode: mode25, TestType: "normal", Layout: "qwerty", Language: "us",
+		Duration: 12500 * time.Millisecond, RawWPM: 82.5, NetWPM: 80, Accuracy: 97.5,
+		CPM: 410, Errors: 3, UncorrectedErrors: 1, WPMSeries: []float64{60, 75, 80},
+	})
+	if err != nil {
+		t.Fatalf("AddTypingTest: %v", err)
+	}
+	store.AddTypingTest(TypingTestResult{CompletedAt: at.AddDate(0, 0, 1), Mode: mode50, NetWPM: 70})
+	store.AddTypingTest(TypingTestResult{CompletedAt: at.AddDate(0, 0, 2), Mode: mode25, NetWPM: 85})
+
+	got, err := store.GetTypingTest(first)
+	if err != nil || got == nil {
+		t.Fatalf("GetTypingTest: %v, %v", got, err)
+	}
+	if !got.CompletedAt.Equal(at) || got.Mode != mode25 || got.Layout != "qwerty" || got.Language != "us" ||
+		got.Duration != 12500*time.Millisecond || got.RawWPM != 82.5 || got.NetWPM != 80 ||
+		got.Accuracy != 97.5 || got.CPM != 410 || got.Errors != 3 || got.UncorrectedErrors != 1 {
+		t.Errorf("round trip = %+v", got)
+	}
+	if len(got.WPMSeries) != 3 || got.WPMSeries[2] != 80 {
+		t.Errorf("WPMSeries = %v", got.WPMSeries)
+	}
+	if missing, err := store.GetTypingTest(999); missing != nil || err != nil {
+		t.Errorf("missing id = %v, %v; want nil, nil", missing, err)
+	}
+
+	all, _ := store.GetTypingTests("", "", 0)
+	if len(all) != 3 || all[0].NetWPM != 85 || all[2].ID != first {
+		t.Errorf("all = %+v, want newest first", all)
+	}
+	if len(all[1].WPMSeries) != 0 {
+		t.Errorf("test without samples has series %v", all[1].WPMSeries)
+	}
+	if tests, _ := store.GetTypingTests(mode25, "", 0); len(tests) != 2 {
+		t.Errorf("mode filter: got %d, want 2", len(tests))
+	}
+	if tests, _ := store.GetTypingTests("", "2026-03-11", 0); len(tests) != 2 {
+		t.Errorf("since filter: got %d, want 2", len(tests))
+	}
+	if tests, _ := store.GetTypingTests("", "", 1); len(tests) != 1 || tests[0].NetWPM != 85 {
+		t.Errorf("limit: got %+v", tests)
+	}
+}
+
+func TestTypingTestReplay(t *testing.T) {
+	store, cleanup := newTestStore(t)
+	defer cleanup()
+
+	mode := TypingTestMode{WordCount: 10}.ModeKey()
+	keys := []Keystroke{
+		{Ms: 0, Char: "h", Correct: true},
+		{Ms: 120, Char: "x"},
+		{Ms: 300, Back: 1},
+		{Ms: 410, Char: "i", Correct: true},
+	}
+	at := time.Date(2026, 3, 10, 9, 0, 0, 0, time.Local)
+	store.AddTypingTest(TypingTestResult{CompletedAt: at, Mode: mode, NetWPM: 95}) // no timeline
+	best, _ := store.AddTypingTest(TypingTestResult{CompletedAt: at, Mode: mode, NetWPM: 80, TargetText: "hi", Keystrokes: keys})
+	store.AddTypingTest(TypingTestResult{CompletedAt: at, Mode: mode, NetWPM: 60, TargetText: "hi", Keystrokes: keys[:1]})
+
+	got, err := store.GetTypingTest(best)
+	if err != nil || got == nil {
+		t.Fatalf("GetTypingTest: %v, %v", got, err)
+	}
+	if got.TargetText != "hi" || len(got.Keystrokes) != 4 || got.Keystrokes[2] != keys[2] || got.Keystrokes[3] != keys[3] {
+		t.Errorf("replay = %q %+v", got.TargetText, got.Keystrokes)
+	}
+
+	pb, err := store.GetBestTypingTest(mode)
+	if err != nil || pb == nil || pb.ID != best {
+		t.Fatalf("GetBestTypingTest = %+v, %v; want test %d, the fastest with a timeline", pb, err, best)
+	}
+	if none, err := store.GetBestTypingTest(TypingTestMode{Seconds: 30}.ModeKey()); none != nil || err != nil {
+		t.Errorf("mode without tests = %v, %v; want nil, nil", none, err)
+	}
+
+	list, _ := store.GetTypingTests(mode, "", 0)
+	for _, r := range list {
+		if r.TargetText != "" || r.Keystrokes != nil {
+			t.Errorf("GetTypingTests loaded replay data for test %d", r.ID)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package tui
+
+import (
+	"math/rand"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/aayushbajaj/typing-telemetry/internal/layout"
+	"github.com/aayushbajaj/typing-telemetry/internal/storage"
+)
+
+// The "bigrams" test type draws words that exercise the user's slowest
+// key-to-key transitions, as recorded by the capture daemon.
+const (
+	// slowBigramCount is how many of the slowest letter pairs to drill.
+	slowBigramCount = 20
+	// slowBigramMinCount ignores pairs with too few samples to trust.
+	slowBigramMinCount = 20
+	// slowBigramBoost is the extra weight per slow-pair occurrence in a word.
+	slowBigramBoost = 8.0
+)
+
+// slowBigramWeights turns recorded latencies into a weight per two-letter
+// string: the slowest letter pairs get weight p90/median, so a pair twice as
+// slow as usual counts twice as much. Keycodes are read as characters on the
+// user's keyboard layout; pairs involving non-letter keys are left out — the
+// word lists only contain letters.
+func slowBigramWeights(stats []storage.BigramStats, lay *layout.Layout) map[string]float64 {
+	type letterPair struct {
+		text string
+		p90  int64
+	}
+	var pairs []letterPair
+	for _, s := range stats {
+		a, okA := keycodeLetter(lay, s.Prev)
+		b, okB := keycodeLetter(lay, s.Next)
+		if okA && okB {
+			pairs = append(pairs, letterPair{string([]rune{a, b}), s.P90Ms})
+		}
+	}
+	if len(pairs) == 0 {
+		return nil
+	}
+
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i].p90 > pairs[j].p90 })
+	median := pairs[len(pairs)/2].p90
+	if median <= 0 {
+		median = 1
+	}
+	if len(pairs) > slowBigramCount {
+		pairs = pairs[:slowBigramCount]
+	}
+
+	weights := make(map[string]float64, len(pairs))
+	for _, p := range pairs {
+		weights[p.text] = float64(p.p90) / float64(median)
+	}
+	return weights
+}
+
+// keycodeLetter returns the lowercase letter a keycode types on lay.
+func keycodeLetter(lay *layout.Layout, code int) (rune, bool) {
+	if lay.Class(code) != layout.Letter {
+		return 0, false
+	}
+	char := lay.Char(code)
+	r, size := utf8.DecodeRuneInString(char)
+	if size == 0 || size != len(char) || !unicode.IsLetter(r) {
+		return 0, false
+	}
+	return unicode.ToLower(r), true
+}
+
+// bigramWordWeight scores a word by how many slow pairs it contains.
+func bigramWordWeight(word string, weights map[string]float64) float64 {
+	w := 1.0
+	lower := []rune(strings.ToLower(word))
+	for i := 0; i+1 < len(lower); i++ {
+		w += slowBigramBoost * weights[string(lower[i:i+2])]
+	}
+	return w
+}
+
+// pickBigramWords draws n words from words, each with probability
+// proportional to bigramWordWeight.
+func pickBigramWords(words []string, weights map[string]float64, n int) []string {
+	return pickWeightedWords(words, n, func(w string) float64 { return bigramWordWeight(w, weights) })
+}
+
+// pickWeightedWords draws n words from words, each with probability
+// proportional to weight(word).
+func pickWeightedWords(words []string, n int, weight func(string) float64) []string {
+	if len(words) == 0 || n <= 0 {
+		return nil
+	}
+	cumulative := make([]float64, len(words))
+	total := 0.0
+	for i, w := range words {
+		total += weight(w)
+		cumulative[i] = total
+	}
+	picked := make([]string, n)
+	for i := range picked {
+		r := rand.Float64() * total
+		picked[i] = words[sort.SearchFloat64s(cumulative, r)]
+	}
+	return picked
+}
@@ -0,0 +1,75 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aayushbajaj/typing-telemetry/internal/layout"
+	"github.com/aayushbajaj/typing-telemetry/internal/storage"
+)
+
+func TestSlowBigramWeights(t *testing.T) {
+	stats := []storage.BigramStats{
+		{Prev: 14, Next: 2, P90Ms: 400},  // e -> d
+		{Prev: 17, Next: 4, P90Ms: 200},  // t -> h
+		{Prev: 0, Next: 1, P90Ms: 100},   // a -> s
+		{Prev: 49, Next: 17, P90Ms: 900}, // space -> t: not a letter pair
+	}
+	w := slowBigramWeights(stats, layout.QWERTY())
+	if len(w) != 3 {
+		t.Fatalf("only letter pairs should be weighted, got %v", w)
+	}
+	// Median p90 of the letter pairs is 200ms.
+	if w["ed"] != 2 || w["th"] != 1 || w["as"] != 0.5 {
+		t.Fatalf("weights: %v", w)
+	}
+	if slowBigramWeights(nil, layout.QWERTY()) != nil {
+		t.Fatal("no stats should give no weights")
+	}
+}
+
+func TestSlowBigramWeightsFollowLayout(t *testing.T) {
+	dvorak, err := layout.Get("dvorak")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats := []storage.BigramStats{
+		{Prev: 1, Next: 41, P90Ms: 300}, // QWERTY s -> ; is Dvorak o -> s
+		{Prev: 12, Next: 0, P90Ms: 100}, // QWERTY q -> a is Dvorak ' -> a
+	}
+	w := slowBigramWeights(stats, dvorak)
+	if len(w) != 1 || w["os"] == 0 {
+		t.Fatalf("weights: %v", w)
+	}
+}
+
+func TestPickBigramWordsFavoursSlowPairs(t *testing.T) {
+	weights := map[string]float64{"ed": 3}
+	words := []string{"red", "cat", "dog", "sun"}
+	picked := pickBigramWords(words, weights, 1000)
+	if len(picked) != 1000 {
+		t.Fatalf("want 1000 words, got %d", len(picked))
+	}
+	n := 0
+	for _, w := range picked {
+		if w == "red" {
+			n++
+		}
+	}
+	// "red" weighs 1+8*3 = 25 against 1 for each other word: ~89%.
+	if n < 750 {
+		t.Fatalf("words with slow pairs should dominate, got %d/1000", n)
+	}
+}
+
+func TestSetTestTypeBigrams(t *testing.T) {
+	model := NewTypingTest("", 30)
+	model.slowBigrams = map[string]float64{"zz": 5}
+	model.SetTestType("bigrams")
+	if model.options.TestType != "bigrams" {
+		t.Fatalf("TestType = %q", model.options.TestType)
+	}
+	if got := len(strings.Fields(model.targetText)); got != 30 {
+		t.Fatalf("want 30 words, got %d", got)
+	}
+}
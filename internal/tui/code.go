@@ -0,0 +1,213 @@
+package tui
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// The "code" test type has the user type real source: brackets, operators,
+// line breaks and indentation. Snippets come from the embedded samples below
+// or from the user's own files.
+
+//go:embed snippets/*.txt
+var codeSampleFiles embed.FS
+
+// CodeLanguages are the languages with embedded samples, each in
+// snippets/<language>.txt with snippets separated by "\n---\n".
+var CodeLanguages = []string{"go", "python", "js", "shell"}
+
+const (
+	// codeSnippetMaxLines caps the length of a snippet cut from a file.
+	codeSnippetMaxLines = 15
+	// codeSnippetMinChars drops fragments too short to be worth a test.
+	codeSnippetMinChars = 20
+	// codeFileMaxBytes skips files too large to be hand-written source.
+	codeFileMaxBytes = 1 << 20
+	// codeTabWidth is how many spaces a tab becomes; Tab restarts the test,
+	// so it can't be typed.
+	codeTabWidth = 4
+)
+
+// codeExtensions are the files picked up when --code names a directory.
+var codeExtensions = map[string]bool{
+	".go": true, ".py": true, ".js": true, ".jsx": true, ".ts": true, ".tsx": true,
+	".sh": true, ".bash": true, ".zsh": true, ".rb": true, ".rs": true, ".c": true,
+	".h": true, ".cc": true, ".cpp": true, ".java": true, ".kt": true, ".swift": true,
+	".lua": true, ".php": true, ".cs": true, ".scala": true, ".ex": true, ".hs": true,
+}
+
+// CodeSamples returns the embedded snippets for one language, or for all of
+// them when language is "" or "all". An unknown language gives nil.
+func CodeSamples(language string) []string {
+	langs := CodeLanguages
+	if language != "" && language != "all" {
+		if !slices.Contains(CodeLanguages, language) {
+			return nil
+		}
+		langs = []string{language}
+	}
+	var snippets []string
+	for _, lang := range langs {
+		data, err := codeSampleFiles.ReadFile("snippets/" + lang + ".txt")
+		if err != nil {
+			continue
+		}
+		for _, s := range strings.Split(string(data), "\n---\n") {
+			if s = normaliseCode(s); s != "" {
+				snippets = append(snippets, s)
+			}
+		}
+	}
+	return snippets
+}
+
+// LoadCodeSnippets resolves a --code source: a file or a directory of source
+// files, cut into snippets of up to codeSnippetMaxLines lines, or else the
+// name of an embedded language ("all" for every one).
+func LoadCodeSnippets(source string) ([]string, error) {
+	info, err := os.Stat(source)
+	if errors.Is(err, fs.ErrNotExist) {
+		if snippets := CodeSamples(source); snippets != nil {
+			return snippets, nil
+		}
+		return nil, fmt.Errorf("%s is not a file, a directory, or one of all, %s",
+			source, strings.Join(CodeLanguages, ", "))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var snippets []string
+	if !info.IsDir() {
+		// A file named explicitly is used whatever its extension.
+		data, err := os.ReadFile(source)
+		if err != nil {
+			return nil, err
+		}
+		snippets = splitCode(string(data))
+	} else {
+		err = filepath.WalkDir(source, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			name := d.Name()
+			if d.IsDir() {
+				if path != source && (strings.HasPrefix(name, ".") || name == "node_modules" || name == "vendor") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !codeExtensions[filepath.Ext(name)] {
+				return nil
+			}
+			if info, err := d.Info(); err != nil || info.Size() > codeFileMaxBytes {
+				return nil
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			snippets = append(snippets, splitCode(string(data))...)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(snippets) == 0 {
+		return nil, fmt.Errorf("no code snippets found in %s", source)
+	}
+	return snippets, nil
+}
+
+// splitCode cuts a source file into snippets at blank lines, packing
+// consecutive blocks together up to codeSnippetMaxLines lines and cutting
+// longer blocks into pieces of that size.
+func splitCode(src string) []string {
+	var snippets []string
+	var cur []string
+	flush := func() {
+		if s := normaliseCode(strings.Join(cur, "\n")); len(s) >= codeSnippetMinChars {
+			snippets = append(snippets, s)
+		}
+		cur = nil
+	}
+
+	var block []string
+	addBlock := func() {
+		for len(block) > codeSnippetMaxLines {
+			flush()
+			cur = block[:codeSnippetMaxLines]
+			flush()
+			block = block[codeSnippetMaxLines:]
+		}
+		if len(block) == 0 {
+			return
+		}
+		if len(cur) > 0 && len(cur)+1+len(block) > codeSnippetMaxLines {
+			flush()
+		}
+		if len(cur) > 0 {
+			cur = append(cur, "")
+		}
+		cur = append(cur, block...)
+		block = nil
+	}
+
+	for _, line := range strings.Split(expandCode(src), "\n") {
+		if strings.TrimSpace(line) == "" {
+			addBlock()
+			continue
+		}
+		block = append(block, line)
+	}
+	addBlock()
+	flush()
+	return snippets
+}
+
+// expandCode normalises line endings and tabs and strips trailing spaces.
+func expandCode(src string) string {
+	src = strings.ReplaceAll(src, "\r\n", "\n")
+	src = strings.ReplaceAll(src, "\t", strings.Repeat(" ", codeTabWidth))
+	lines := strings.Split(src, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// normaliseCode makes a snippet typeable: tabs become spaces, the common
+// indentation is removed, and surrounding blank lines are trimmed. Snippets
+// with characters beyond printable ASCII give "", since the test compares
+// bytes.
+func normaliseCode(s string) string {
+	lines := strings.Split(strings.Trim(expandCode(s), "\n"), "\n")
+	indent := -1
+	for _, line := range lines {
+		if line == "" {
+			continue
+		}
+		n := len(line) - len(strings.TrimLeft(line, " "))
+		if indent < 0 || n < indent {
+			indent = n
+		}
+	}
+	for i, line := range lines {
+		if len(line) >= indent && indent > 0 {
+			lines[i] = line[indent:]
+		}
+		for _, c := range []byte(lines[i]) {
+			if c < ' ' || c > '~' {
+				return ""
+			}
+		}
+	}
+	return strings.Join(lines, "\n")
+}
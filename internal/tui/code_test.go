@@ -0,0 +1,174 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestCodeSamples(t *testing.T) {
+	all := CodeSamples("all")
+	total := 0
+	for _, lang := range CodeLanguages {
+		snippets := CodeSamples(lang)
+		if len(snippets) == 0 {
+			t.Errorf("no embedded %s samples", lang)
+		}
+		for _, s := range snippets {
+			if s != normaliseCode(s) || strings.Contains(s, "\t") {
+				t.Errorf("%s sample isn't normalised:\n%s", lang, s)
+			}
+		}
+		total += len(snippets)
+	}
+	if len(all) != total || len(CodeSamples("")) != total {
+		t.Errorf("all samples = %d, want %d", len(all), total)
+	}
+	if CodeSamples("cobol") != nil {
+		t.Error("an unknown language should have no samples")
+	}
+}
+
+func TestNormaliseCode(t *testing.T) {
+	got := normaliseCode("\n\tif x {\r\n\t\ty()  \r\n\t}\n\n")
+	if want := "if x {\n    y()\n}"; got != want {
+		t.Errorf("normaliseCode = %q, want %q", got, want)
+	}
+	if normaliseCode("s := \"naïve\"") != "" {
+		t.Error("non-ASCII code should be dropped")
+	}
+}
+
+func TestSplitCode(t *testing.T) {
+	var src strings.Builder
+	src.WriteString("package main\n\nimport \"fmt\"\n\n")
+	for i := 0; i < 20; i++ {
+		src.WriteString("\tfmt.Println(\"a long block line\")\n")
+	}
+	src.WriteString("\nfunc a() {}\nfunc b() {}\n")
+
+	snippets := splitCode(src.String())
+	// The two short blocks pack together; the 20-line block is cut at
+	// codeSnippetMaxLines, and its last 5 lines pack with the tail.
+	if len(snippets) != 3 {
+		t.Fatalf("got %d snippets, want 3:\n%s", len(snippets), strings.Join(snippets, "\n~~~\n"))
+	}
+	if snippets[0] != "package main\n\nimport \"fmt\"" {
+		t.Errorf("first snippet = %q", snippets[0])
+	}
+	for _, s := range snippets {
+		if n := strings.Count(s, "\n") + 1; n > codeSnippetMaxLines {
+			t.Errorf("snippet has %d lines, want at most %d", n, codeSnippetMaxLines)
+		}
+		if !strings.HasPrefix(s, "package") && !strings.HasPrefix(s, "fmt") && !strings.Contains(s, "\nfunc") {
+			t.Errorf("snippet should be dedented to its least indented line: %q", s)
+		}
+	}
+}
+
+func TestLoadCodeSnippets(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, body string) {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0o755)
+		if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("main.go", "func main() {\n\tfmt.Println(\"hello\")\n}\n")
+	write("notes.txt", "not code, not picked up from a directory\n")
+	write(".git/hook.sh", "echo skipped hidden directory\n")
+	write("lib/util.py", "def util():\n    return 42\n")
+	write("docs/readme.md", "# no code in here\n")
+
+	snippets, err := LoadCodeSnippets(dir)
+	if err != nil {
+		t.Fatalf("LoadCodeSnippets(dir): %v", err)
+	}
+	if len(snippets) != 2 {
+		t.Errorf("got %q, want main.go and lib/util.py", snippets)
+	}
+
+	snippets, err = LoadCodeSnippets(filepath.Join(dir, "notes.txt"))
+	if err != nil || len(snippets) != 1 {
+		t.Errorf("a file named explicitly should load whatever its extension: %q, %v", snippets, err)
+	}
+	if snippets, err := LoadCodeSnippets("python"); err != nil || len(snippets) == 0 {
+		t.Errorf("LoadCodeSnippets(python) = %d snippets, %v", len(snippets), err)
+	}
+	if _, err := LoadCodeSnippets(filepath.Join(dir, "missing")); err == nil {
+		t.Error("a missing path that isn't a language should fail")
+	}
+	if _, err := LoadCodeSnippets(filepath.Join(dir, "docs")); err == nil {
+		t.Error("a directory with no code should fail")
+	}
+}
+
+func typeCode(m TypingTestModel, keys ...tea.KeyMsg) TypingTestModel {
+	for _, k := range keys {
+		updated, _ := m.Update(k)
+		m = updated.(TypingTestModel)
+	}
+	return m
+}
+
+func runes(s string) []tea.KeyMsg {
+	var keys []tea.KeyMsg
+	for _, r := range s {
+		keys = append(keys, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	return keys
+}
+
+func TestCodeSkipIndent(t *testing.T) {
+	model := NewTypingTest("", 10)
+	model.SetCodeSnippets([]string{"if x {\n    y()\n}"})
+	if model.targetText != "if x {\n    y()\n}" || !model.multiline() {
+		t.Fatalf("targetText = %q, want the snippet", model.targetText)
+	}
+	if model.mode().ModeKey() != "mode_code" {
+		t.Errorf("mode = %q, want mode_code", model.mode().ModeKey())
+	}
+
+	enter := tea.KeyMsg{Type: tea.KeyEnter}
+	model = typeCode(model, runes("if x {")...)
+	model = typeCode(model, enter)
+	if model.typed != "if x {\n    " {
+		t.Fatalf("typed = %q, want the indentation filled in", model.typed)
+	}
+	model = typeCode(model, runes("y()")...)
+	model = typeCode(model, enter)
+	model = typeCode(model, runes("}")...)
+	if model.state != StateFinished {
+		t.Fatalf("state = %v, want finished", model.state)
+	}
+	if model.skippedIndent() != 4 || model.rawInputCnt != len(model.targetText)-4 {
+		t.Errorf("skipped %d, typed %d keys; want 4 skipped", model.skippedIndent(), model.rawInputCnt)
+	}
+	if n := len(model.timeline); n != len(model.targetText)-4+1 || !model.timeline[7].Auto {
+		t.Errorf("timeline should record the indentation as one auto entry: %+v", model.timeline)
+	}
+
+	// With Skip Indent off, the indentation is typed like anything else.
+	model = NewTypingTest("", 10)
+	model.SetSkipIndent(false)
+	model.SetCodeSnippets([]string{"if x {\n    y()\n}"})
+	model = typeCode(model, runes("if x {")...)
+	model = typeCode(model, enter)
+	if model.typed != "if x {\n" || model.skippedIndent() != 0 {
+		t.Errorf("typed = %q, want no indentation filled in", model.typed)
+	}
+}
+
+func TestCodeSkipIndentAfterMistake(t *testing.T) {
+	model := NewTypingTest("", 10)
+	model.SetCodeSnippets([]string{"a {\n  b\n}"})
+	// A space where the line break belongs doesn't trigger the skip.
+	model = typeCode(model, runes("a { ")...)
+	if model.typed != "a { " {
+		t.Errorf("typed = %q, want no indentation filled in", model.typed)
+	}
+}
@@ -0,0 +1,217 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aayushbajaj/typing-telemetry/internal/storage"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// replayFrame is how often a replay redraws.
+const replayFrame = 50 * time.Millisecond
+
+type replayTickMsg time.Time
+
+func replayTick() tea.Cmd {
+	return tea.Tick(replayFrame, func(t time.Time) tea.Msg { return replayTickMsg(t) })
+}
+
+// typedLenAt is how many characters a recorded timeline had typed at offset at
+// into the test: where the ghost pace caret sits.
+func typedLenAt(timeline []storage.Keystroke, at time.Duration) int {
+	n := 0
+	for _, k := range timeline {
+		if time.Duration(k.Ms)*time.Millisecond > at {
+			break
+		}
+		if k.Back > 0 {
+			n = max(n-k.Back, 0)
+		} else {
+			n += len(k.Char)
+		}
+	}
+	return n
+}
+
+// applyKeystroke replays one recorded input event, counting it as the live
+// test did.
+func (m *TypingTestModel) applyKeystroke(k storage.Keystroke) {
+	if k.Back > 0 {
+		m.typed = m.typed[:max(len(m.typed)-k.Back, 0)]
+		return
+	}
+	m.typed += k.Char
+	if k.Auto {
+		return
+	}
+	m.rawInputCnt++
+	if !k.Correct {
+		m.errors++
+	}
+}
+
+// ReplayModel animates a recorded typing test keystroke by keystroke, at the
+// speed it was typed, and ends on the test's results.
+type ReplayModel struct {
+	result   *storage.TypingTestResult
+	test     TypingTestModel // holds the replayed state and renders it
+	next     int             // index of the next keystroke to apply
+	start    time.Time       // zero until the first frame
+	paused   bool
+	pausedAt time.Time
+}
+
+// NewReplay returns a model replaying r, which must carry its replay data
+// (see storage.Store.GetTypingTest).
+func NewReplay(r *storage.TypingTestResult) ReplayModel {
+	m := ReplayModel{result: r}
+	m.reset()
+	return m
+}
+
+func (m *ReplayModel) reset() {
+	r := m.result
+	options := TestOptions{
+		Layout:   r.Layout,
+		LiveWPM:  true,
+		TestType: r.TestType,
+		Language: r.Language,
+	}
+	if r.TestType == "time" {
+		options.TimeLimit = int(r.Duration.Round(time.Second) / time.Second)
+	}
+	if r.TestType == "code" {
+		options.SkipIndent = slices.ContainsFunc(r.Keystrokes, func(k storage.Keystroke) bool { return k.Auto })
+	}
+	// The stored series ends with the final WPM, which the results graph
+	// appends itself.
+	var series []float64
+	if len(r.WPMSeries) > 0 {
+		series = r.WPMSeries[:len(r.WPMSeries)-1]
+	}
+	m.test = TypingTestModel{
+		targetText:    r.TargetText,
+		state:         StateRunning,
+		options:       options,
+		wpmEachSecond: series,
+		width:         m.test.width,
+		height:        m.test.height,
+	}
+	m.next = 0
+	m.start = time.Time{}
+	m.paused = false
+}
+
+func (m ReplayModel) Init() tea.Cmd {
+	return replayTick()
+}
+
+func (m ReplayModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c", "q", "esc":
+			return m, tea.Quit
+		case " ":
+			if m.test.state != StateRunning || m.start.IsZero() {
+				return m, nil
+			}
+			if m.paused {
+				// Shift the clock by the pause so playback resumes in place.
+				m.start = m.start.Add(time.Since(m.pausedAt))
+				m.test.startTime = m.start
+			} else {
+				m.pausedAt = time.Now()
+			}
+			m.paused = !m.paused
+			return m, nil
+		case "r", "enter":
+			finished := m.test.state == StateFinished
+			m.reset()
+			if finished {
+				return m, replayTick()
+			}
+			return m, nil
+		}
+
+	case replayTickMsg:
+		if m.test.state != StateRunning {
+			return m, nil
+		}
+		if m.paused {
+			return m, replayTick()
+		}
+		now := time.Time(msg)
+		if m.start.IsZero() {
+			m.start = now
+			m.test.startTime = now
+		}
+		m.advance(now.Sub(m.start))
+		if m.test.state == StateFinished {
+			return m, nil
+		}
+		return m, replayTick()
+
+	case tea.WindowSizeMsg:
+		m.test.width = msg.Width
+		m.test.height = msg.Height
+	}
+	return m, nil
+}
+
+// advance applies every keystroke up to elapsed, and finishes the replay
+// once all of them are in and the test's duration has passed.
+func (m *ReplayModel) advance(elapsed time.Duration) {
+	keys := m.result.Keystrokes
+	for m.next < len(keys) && time.Duration(keys[m.next].Ms)*time.Millisecond <= elapsed {
+		m.test.applyKeystroke(keys[m.next])
+		m.next++
+	}
+	if m.next == len(keys) && elapsed >= m.result.Duration {
+		m.test.state = StateFinished
+		m.test.endTime = m.start.Add(m.result.Duration)
+	}
+}
+
+func (m ReplayModel) View() string {
+	r := m.result
+	var content strings.Builder
+	content.WriteString(promptStyle.Render(fmt.Sprintf("Replay of test #%d  •  %s  •  %s",
+		r.ID, r.Mode, r.CompletedAt.Local().Format("Jan 2 2006 15:04"))))
+	content.WriteString("\n\n")
+	content.WriteString(m.test.renderText())
+	content.WriteString("\n\n")
+
+	if m.test.state == StateFinished {
+		content.WriteString(m.test.renderResults())
+	} else {
+		elapsed := time.Duration(0)
+		if !m.start.IsZero() {
+			elapsed = time.Since(m.start)
+			if m.paused {
+				elapsed = m.pausedAt.Sub(m.start)
+			}
+		}
+		status := ""
+		if m.paused {
+			status = "  (paused)"
+		}
+		content.WriteString(fmt.Sprintf("%s %.0f  %s %.0f%%  %s %.0fs%s",
+			resultLabelStyle.Render("WPM:"), m.test.netWPM(elapsed.Minutes()),
+			resultLabelStyle.Render("Acc:"), m.test.accuracy(),
+			resultLabelStyle.Render("Time:"), elapsed.Seconds(), status))
+	}
+
+	box := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color(CurrentTheme.Border)).
+		Padding(1, 2).
+		Width(m.test.boxWidth()).
+		Render(content.String())
+	help := helpStyle.Render("space: pause • r: restart • q: quit")
+	return m.test.centerContent(box + "\n\n" + help)
+}
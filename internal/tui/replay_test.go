@@ -0,0 +1,159 @@
+package tui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aayushbajaj/typing-telemetry/internal/storage"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestTimelineRecorded(t *testing.T) {
+	model := NewTypingTest("", 10)
+	model.targetText = "ab cd"
+
+	for _, msg := range []tea.KeyMsg{
+		{Type: tea.KeyRunes, Runes: []rune{'a'}},
+		{Type: tea.KeyRunes, Runes: []rune{'x'}},
+		{Type: tea.KeyBackspace},
+		{Type: tea.KeyRunes, Runes: []rune{'b'}},
+		{Type: tea.KeySpace},
+		{Type: tea.KeyRunes, Runes: []rune{'c'}},
+		{Type: tea.KeyCtrlW},
+	} {
+		updated, _ := model.Update(msg)
+		model = updated.(TypingTestModel)
+	}
+
+	want := []storage.Keystroke{
+		{Char: "a", Correct: true},
+		{Char: "x"},
+		{Back: 1},
+		{Char: "b", Correct: true},
+		{Char: " ", Correct: true},
+		{Char: "c", Correct: true},
+		{Back: 1},
+	}
+	if len(model.timeline) != len(want) {
+		t.Fatalf("timeline = %+v, want %d events", model.timeline, len(want))
+	}
+	for i, k := range model.timeline {
+		k.Ms = 0
+		if k != want[i] {
+			t.Errorf("event %d = %+v, want %+v", i, k, want[i])
+		}
+	}
+
+	model.resetTest()
+	if model.timeline != nil {
+		t.Error("resetTest should clear the timeline")
+	}
+}
+
+func TestTypedLenAt(t *testing.T) {
+	timeline := []storage.Keystroke{
+		{Ms: 0, Char: "a"},
+		{Ms: 100, Char: "b"},
+		{Ms: 200, Back: 5},
+		{Ms: 300, Char: "c"},
+	}
+	cases := map[time.Duration]int{
+		0:                      1,
+		150 * time.Millisecond: 2,
+		250 * time.Millisecond: 0, // deletions never go below zero
+		time.Second:            1,
+	}
+	for at, want := range cases {
+		if got := typedLenAt(timeline, at); got != want {
+			t.Errorf("typedLenAt(%v) = %d, want %d", at, got, want)
+		}
+	}
+}
+
+func TestGhostPaceCaret(t *testing.T) {
+	model := NewTypingTest("", 10)
+	model.targetText = "abcdef"
+	model.options.PaceCaret = PaceGhost
+	model.state = StateRunning
+	model.startTime = time.Now().Add(-time.Second)
+	model.ghost = []storage.Keystroke{{Ms: 0, Char: "a"}, {Ms: 500, Char: "b"}, {Ms: 5000, Char: "c"}}
+
+	// The ghost has typed two characters a second in: its caret is on the third.
+	if got := model.pacePosition(); got != 2 {
+		t.Errorf("pacePosition() = %d, want 2", got)
+	}
+	model.ghost = nil
+	if got := model.pacePosition(); got != -1 {
+		t.Errorf("pacePosition() without a recorded run = %d, want -1", got)
+	}
+}
+
+func TestReplayPlaysTimeline(t *testing.T) {
+	r := &storage.TypingTestResult{
+		ID:         7,
+		Mode:       "mode_10_punct",
+		TestType:   "normal",
+		TargetText: "hi",
+		Duration:   600 * time.Millisecond,
+		Keystrokes: []storage.Keystroke{
+			{Ms: 0, Char: "h", Correct: true},
+			{Ms: 200, Char: "o"},
+			{Ms: 300, Back: 1},
+			{Ms: 600, Char: "i", Correct: true},
+		},
+		WPMSeries: []float64{40},
+	}
+	m := NewReplay(r)
+	start := time.Now()
+
+	updated, cmd := m.Update(replayTickMsg(start))
+	m = updated.(ReplayModel)
+	if m.test.typed != "h" || cmd == nil {
+		t.Fatalf("first frame: typed %q, cmd %v", m.test.typed, cmd)
+	}
+
+	updated, _ = m.Update(replayTickMsg(start.Add(250 * time.Millisecond)))
+	m = updated.(ReplayModel)
+	if m.test.typed != "ho" || m.test.errors != 1 {
+		t.Errorf("at 250ms: typed %q, errors %d", m.test.typed, m.test.errors)
+	}
+
+	updated, cmd = m.Update(replayTickMsg(start.Add(600 * time.Millisecond)))
+	m = updated.(ReplayModel)
+	if m.test.typed != "hi" || m.test.state != StateFinished || cmd != nil {
+		t.Errorf("at the end: typed %q, state %v, cmd %v", m.test.typed, m.test.state, cmd)
+	}
+	if m.test.uncorrectedErrors() != 0 || m.test.rawInputCnt != 3 {
+		t.Errorf("replayed counts: uncorrected %d, raw %d", m.test.uncorrectedErrors(), m.test.rawInputCnt)
+	}
+
+	// r starts over.
+	updated, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'r'}})
+	m = updated.(ReplayModel)
+	if m.test.typed != "" || m.test.state != StateRunning || cmd == nil {
+		t.Errorf("after restart: typed %q, state %v", m.test.typed, m.test.state)
+	}
+}
+
+func TestReplaySkippedIndent(t *testing.T) {
+	r := &storage.TypingTestResult{
+		TestType:   "code",
+		TargetText: "{\n  x\n}",
+		Duration:   time.Second,
+		Keystrokes: []storage.Keystroke{
+			{Ms: 0, Char: "{", Correct: true},
+			{Ms: 100, Char: "\n", Correct: true},
+			{Ms: 100, Char: "  ", Correct: true, Auto: true},
+			{Ms: 200, Char: "x", Correct: true},
+		},
+	}
+	m := NewReplay(r)
+	if !m.test.options.SkipIndent {
+		t.Fatal("a timeline with auto entries should replay with Skip Indent on")
+	}
+	m.advance(time.Second)
+	if m.test.typed != "{\n  x" || m.test.rawInputCnt != 3 || m.test.skippedIndent() != 2 {
+		t.Errorf("typed %q in %d keys, %d skipped; want 3 keys and 2 skipped",
+			m.test.typed, m.test.rawInputCnt, m.test.skippedIndent())
+	}
+}
@@ -3,7 +3,9 @@ package tui
 import (
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/aayushbajaj/typing-telemetry/internal/goals"
 	"github.com/aayushbajaj/typing-telemetry/internal/storage"
 	"github.com/aayushbajaj/typing-telemetry/pkg/stats"
 	tea "github.com/charmbracelet/bubbletea"
@@ -27,6 +29,7 @@ type Model struct {
 	hourlyStats        []storage.HourlyStats
 	speedToday         storage.SpeedAggregate
 	speedAll           storage.SpeedAggregate
+	goals              *goals.Report
 	width              int
 	height             int
 	err                error
@@ -40,6 +43,7 @@ type statsMsg struct {
 	hourly     []storage.HourlyStats
 	speedToday storage.SpeedAggregate
 	speedAll   storage.SpeedAggregate
+	goals      *goals.Report
 	err        error
 }
 
@@ -77,7 +81,12 @@ func (m Model) fetchStats() tea.Msg {
 		return statsMsg{err: err}
 	}
 
-	return statsMsg{today: today, week: week, hourly: hourly, speedToday: speedToday, speedAll: speedAll}
+	report, err := goals.Build(m.store, time.Now())
+	if err != nil {
+		return statsMsg{err: err}
+	}
+
+	return statsMsg{today: today, week: week, hourly: hourly, speedToday: speedToday, speedAll: speedAll, goals: report}
 }
 
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -109,6 +118,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.hourlyStats = msg.hourly
 			m.speedToday = msg.speedToday
 			m.speedAll = msg.speedAll
+			m.goals = msg.goals
 		}
 	}
 
@@ -179,6 +189,11 @@ func (m Model) View() string {
 	b.WriteString(boxStyle.Render("Typing Speed\n" + speedContent))
 	b.WriteString("\n\n")
 
+	if m.goals != nil && !m.goals.IsZero() {
+		b.WriteString(boxStyle.Render("Goals\n" + m.renderGoals()))
+		b.WriteString("\n\n")
+	}
+
 	// Hourly graph
 	b.WriteString(statLabelStyle.Render("Today's Activity:"))
 	b.WriteString("\n")
@@ -268,6 +283,34 @@ func (m Model) renderWeeklyGraph() string {
 	return graph.String()
 }
 
+// renderGoals draws a progress bar per goal and the goal streak.
+func (m Model) renderGoals() string {
+	const width = 20
+	var lines []string
+	add := func(period string, p *goals.Period) {
+		if p == nil {
+			return
+		}
+		for _, g := range p.Goals {
+			filled := int(g.Percent / 100 * width)
+			filled = max(0, min(width, filled))
+			bar := graphStyle.Render(strings.Repeat("█", filled)) + strings.Repeat("░", width-filled)
+			lines = append(lines, fmt.Sprintf("%s %s %s",
+				statLabelStyle.Render(fmt.Sprintf("%-6s %-11s", period, goals.Label(g.Metric))),
+				bar,
+				statValueStyle.Render(fmt.Sprintf("%s/%s", formatNumber(g.Current), formatNumber(g.Target)))))
+		}
+	}
+	add("Today", m.goals.Daily)
+	add("Week", m.goals.Weekly)
+	if m.goals.Daily != nil {
+		lines = append(lines, fmt.Sprintf("%s %s",
+			statLabelStyle.Render("Streak:"),
+			statValueStyle.Render(fmt.Sprintf("%d days (best %d)", m.goals.Streak.Current, m.goals.Streak.Longest))))
+	}
+	return strings.Join(lines, "\n")
+}
+
 func formatNumber(n int64) string {
 	if n >= 1000000 {
 		return fmt.Sprintf("%.1fM", float64(n)/1000000)
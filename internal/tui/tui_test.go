@@ -5,6 +5,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/aayushbajaj/typing-telemetry/internal/goals"
 	"github.com/aayushbajaj/typing-telemetry/internal/storage"
 	tea "github.com/charmbracelet/bubbletea"
 )
@@ -230,6 +231,27 @@ func TestModelViewWithStats(t *testing.T) {
 	}
 }
 
+func TestModelViewGoals(t *testing.T) {
+	model := New(nil)
+	model.todayStats = &storage.DailyStats{Words: 750}
+	if strings.Contains(model.View(), "Goals") {
+		t.Error("Goals box should be hidden when no goals are set")
+	}
+
+	model.goals = &goals.Report{
+		Daily: &goals.Period{Goals: []goals.Metric{
+			{Metric: storage.GoalMetricWords, Target: 1500, Current: 750, Percent: 50},
+		}},
+		Streak: goals.Streak{Current: 3, Longest: 9},
+	}
+	view := model.View()
+	for _, want := range []string{"Goals", "750/1.5K", "3 days (best 9)"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("Expected %q in view", want)
+		}
+	}
+}
+
 func TestRenderHourlyGraphEmpty(t *testing.T) {
 	model := New(nil)
 	model.hourlyStats = []storage.HourlyStats{}
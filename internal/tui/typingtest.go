@@ -79,6 +79,7 @@ const (
 	PacePB
 	PaceAverage
 	PaceCustom
+	PaceGhost // replays the mode's fastest recorded test
 )
 
 // TestOptions holds all configurable options
@@ -86,12 +87,15 @@ type TestOptions struct {
 	Layout        string        // "qwerty", "dvorak", "colemak"
 	LiveWPM       bool          // Show live WPM while typing
 	WordCount     int           // Number of words in test
+	TimeLimit     int           // Seconds, for the "time" test type
 	Punctuation   bool          // Include sentence-style punctuation and capitalization
 	PaceCaret     PaceCaretMode // Pace caret mode
 	CustomPaceWPM float64       // Custom pace WPM target
 	Theme         string        // Color theme
-	TestType      string        // "normal" or "custom"
+	TestType      string        // "normal", "custom", "bigrams", "time" or "code"
 	Language      string        // "us" or "au"
+	WeakKeys      bool          // Favour words with the most-missed letters
+	SkipIndent    bool          // Code tests fill in leading indentation
 }
 
 // Option represents a single option in the menu
@@ -130,23 +134,31 @@ type TypingTestModel struct {
 	searchQuery       string
 	inSubMenu         bool
 	subMenuIdx        int
-	personalBest      float64        // Personal best WPM
-	avgWPM            float64        // Average WPM from past tests
-	testCount         int            // Number of tests completed
-	inCustomWPMInput  bool           // Whether we're inputting custom WPM
-	customWPMInput    string         // Buffer for custom WPM input
-	menuFocus         MenuFocus      // Current UI focus
-	menuSelection     int            // Selected menu item (0=stats, 1=custom)
-	showStats         bool           // Show stats panel
-	lastWPM           float64        // Last test WPM (for tab restart counting)
-	resultRecorded    bool           // Whether current result has been recorded
-	store             *storage.Store // Database storage for persistence
-	customTexts       []string       // Custom text snippets
-	showCustomPanel   bool           // Show custom text panel
-	customTextInput   string         // Buffer for custom text input
-	inCustomTextInput bool           // Whether we're inputting custom text
-	rawInputCnt       int            // Total keystrokes entered (never reduced) — for accuracy/CPM
-	wpmEachSecond     []float64      // Net WPM sampled once per second, for the results graph
+	personalBest      float64                    // Personal best WPM
+	avgWPM            float64                    // Average WPM from past tests
+	testCount         int                        // Number of tests completed
+	inCustomWPMInput  bool                       // Whether we're inputting custom WPM
+	customWPMInput    string                     // Buffer for custom WPM input
+	menuFocus         MenuFocus                  // Current UI focus
+	menuSelection     int                        // Selected menu item (0=stats, 1=custom)
+	showStats         bool                       // Show stats panel
+	lastWPM           float64                    // Last test WPM (for tab restart counting)
+	resultRecorded    bool                       // Whether current result has been recorded
+	saveErr           error                      // Why the current result wasn't fully saved, shown with the results
+	store             *storage.Store             // Database storage for persistence
+	customTexts       []string                   // Custom text snippets
+	codeSnippets      []string                   // Code test snippets; nil uses the embedded samples
+	showCustomPanel   bool                       // Show custom text panel
+	customTextInput   string                     // Buffer for custom text input
+	inCustomTextInput bool                       // Whether we're inputting custom text
+	rawInputCnt       int                        // Total keystrokes entered (never reduced) — for accuracy/CPM
+	wpmEachSecond     []float64                  // Net WPM sampled once per second, for the results graph
+	slowBigrams       map[string]float64         // Letter pair -> weight, for the "bigrams" test type
+	timeline          []storage.Keystroke        // Input events of the current test, for replays
+	ghost             []storage.Keystroke        // Timeline the ghost pace caret replays
+	charCounts        map[storage.CharPair]int64 // Expected/typed character counts of the current test
+	weakChars         map[rune]float64           // Letter -> weight, for the weak keys option
+	weakest           []storage.CharStat         // Weakest keys across tests, for the results screen
 }
 
 type tickMsg time.Time
@@ -156,6 +168,20 @@ func secondTick() tea.Cmd {
 	return tea.Tick(time.Second, func(t time.Time) tea.Msg { return tickMsg(t) })
 }
 
+// TimeLimits are the durations, in seconds, offered for the "time" test type.
+var TimeLimits = []int{15, 30, 60, 120}
+
+const (
+	defaultTimeLimit = 30
+	// timeBatchWords is how many words a time test generates at a time; more
+	// are appended whenever fewer than timeLookahead characters remain.
+	timeBatchWords = 50
+	timeLookahead  = 200
+	// timeVisibleLines is how many wrapped lines of a time test are shown:
+	// the line before the cursor, the cursor's line and the next.
+	timeVisibleLines = 3
+)
+
 func NewTypingTest(sourceFile string, wordCount int) TypingTestModel {
 	return NewTypingTestWithStore(sourceFile, wordCount, nil)
 }
@@ -169,12 +195,14 @@ func NewTypingTestWithStore(sourceFile string, wordCount int, store *storage.Sto
 		Layout:        "qwerty",
 		LiveWPM:       true,
 		WordCount:     wordCount,
+		TimeLimit:     defaultTimeLimit,
 		Punctuation:   true, // Enabled by default
 		PaceCaret:     PaceOff,
 		CustomPaceWPM: 60.0,
 		Theme:         "default",
 		TestType:      "normal",
 		Language:      LanguageUS,
+		SkipIndent:    true,
 	}
 
 	allOptions := []Option{
@@ -191,7 +219,7 @@ func NewTypingTestWithStore(sourceFile string, wordCount int, store *storage.Sto
 			Name:        "Test Type",
 			Description: "Word source for test",
 			Type:        "choice",
-			Choices:     []string{"normal", "custom"},
+			Choices:     []string{"normal", "custom", "bigrams", "time", "code"},
 			Value:       "normal",
 		},
 		{
@@ -217,6 +245,14 @@ func NewTypingTestWithStore(sourceFile string, wordCount int, store *storage.Sto
 			Choices:     []string{"10", "25", "50", "100", "200"},
 			Value:       "25",
 		},
+		{
+			ID:          "time_limit",
+			Name:        "Time Limit",
+			Description: "Seconds, for the time test type",
+			Type:        "choice",
+			Choices:     []string{"15", "30", "60", "120"},
+			Value:       "30",
+		},
 		{
 			ID:          "punctuation",
 			Name:        "Punctuation",
@@ -224,6 +260,20 @@ func NewTypingTestWithStore(sourceFile string, wordCount int, store *storage.Sto
 			Type:        "toggle",
 			Value:       true, // Enabled by default
 		},
+		{
+			ID:          "skip_indent",
+			Name:        "Skip Indent",
+			Description: "Code tests: jump over leading indentation",
+			Type:        "toggle",
+			Value:       true,
+		},
+		{
+			ID:          "weak_keys",
+			Name:        "Weak Keys",
+			Description: "Favour words with your most-missed letters",
+			Type:        "toggle",
+			Value:       false,
+		},
 		{
 			ID:          "language",
 			Name:        "Language",
@@ -237,7 +287,7 @@ func NewTypingTestWithStore(sourceFile string, wordCount int, store *storage.Sto
 			Name:        "Pace Caret",
 			Description: "Ghost cursor to pace against",
 			Type:        "submenu",
-			Choices:     []string{"off", "pb", "average", "custom"},
+			Choices:     []string{"off", "pb", "average", "custom", "ghost"},
 			Value:       "off",
 		},
 	}
@@ -290,13 +340,258 @@ func NewTypingTestWithStore(sourceFile string, wordCount int, store *storage.Sto
 		}
 		// Load word list for the language
 		defaultWords = LoadWordListsForLanguage(lang)
+
+		if bigrams, err := store.GetBigramStats(slowBigramMinCount); err == nil {
+			m.slowBigrams = slowBigramWeights(bigrams, store.KeyboardLayout())
+		}
+		m.loadCharStats()
 	}
 
 	m.targetText = m.generateText()
 	return m
 }
 
+// SetTestType switches the word source ("normal", "custom", "bigrams",
+// "time" or "code") and regenerates the text.
+func (m *TypingTestModel) SetTestType(testType string) {
+	m.options.TestType = testType
+	for i := range m.allOptions {
+		if m.allOptions[i].ID == "test_type" {
+			m.allOptions[i].Value = testType
+			break
+		}
+	}
+	m.targetText = m.generateText()
+}
+
+// SetCodeSnippets switches to the "code" test type, drawing from snippets
+// instead of the embedded samples.
+func (m *TypingTestModel) SetCodeSnippets(snippets []string) {
+	m.codeSnippets = snippets
+	m.SetTestType("code")
+}
+
+// SetSkipIndent sets whether code tests fill in leading indentation or have
+// it typed.
+func (m *TypingTestModel) SetSkipIndent(on bool) {
+	m.options.SkipIndent = on
+	for i := range m.allOptions {
+		if m.allOptions[i].ID == "skip_indent" {
+			m.allOptions[i].Value = on
+			break
+		}
+	}
+}
+
+// SetWeakKeys turns the weak keys word bias on or off and regenerates the
+// text.
+func (m *TypingTestModel) SetWeakKeys(on bool) {
+	m.options.WeakKeys = on
+	for i := range m.allOptions {
+		if m.allOptions[i].ID == "weak_keys" {
+			m.allOptions[i].Value = on
+			break
+		}
+	}
+	m.targetText = m.generateText()
+}
+
+// loadCharStats refreshes the weak key weights and the weakest keys list from
+// the per-character totals.
+func (m *TypingTestModel) loadCharStats() {
+	stats, err := m.store.GetTypingCharStats()
+	if err != nil {
+		return
+	}
+	m.weakChars = weakCharWeights(stats)
+	m.weakest = storage.WeakestChars(stats, weakCharMinAttempts, weakKeysShown)
+}
+
+// SetTimeLimit switches to the "time" test type with the given limit in
+// seconds.
+func (m *TypingTestModel) SetTimeLimit(seconds int) {
+	m.options.TimeLimit = seconds
+	for i := range m.allOptions {
+		if m.allOptions[i].ID == "time_limit" {
+			m.allOptions[i].Value = strconv.Itoa(seconds)
+			break
+		}
+	}
+	m.SetTestType("time")
+}
+
+// timeLimit is the length of a time test, or zero for other test types.
+func (m TypingTestModel) timeLimit() time.Duration {
+	if m.options.TestType != "time" {
+		return 0
+	}
+	secs := m.options.TimeLimit
+	if secs <= 0 {
+		secs = defaultTimeLimit
+	}
+	return time.Duration(secs) * time.Second
+}
+
+// mode is the PB/average bucket the current settings record into.
+func (m TypingTestModel) mode() storage.TypingTestMode {
+	if m.options.TestType == "code" {
+		return storage.TypingTestMode{Code: true}
+	}
+	mode := storage.TypingTestMode{
+		WordCount:   m.options.WordCount,
+		Punctuation: m.options.Punctuation,
+	}
+	if limit := m.timeLimit(); limit > 0 {
+		mode.Seconds = int(limit / time.Second)
+	}
+	return mode
+}
+
+// tick schedules the next 1s sample, or, in a time test, the deadline if it
+// comes sooner, so the test ends exactly on time.
+func (m TypingTestModel) tick() tea.Cmd {
+	if limit := m.timeLimit(); limit > 0 {
+		if left := time.Until(m.startTime.Add(limit)); left < time.Second {
+			return tea.Tick(max(left, 0), func(t time.Time) tea.Msg { return tickMsg(t) })
+		}
+	}
+	return secondTick()
+}
+
+// timeUp reports whether a time test's deadline has passed at now.
+func (m TypingTestModel) timeUp(now time.Time) bool {
+	limit := m.timeLimit()
+	return limit > 0 && !now.Before(m.startTime.Add(limit))
+}
+
+// finishTimed ends a time test at its deadline, not when the tick or key
+// that noticed it arrived.
+func (m *TypingTestModel) finishTimed() {
+	m.state = StateFinished
+	m.endTime = m.startTime.Add(m.timeLimit())
+	m.recordTestResult()
+}
+
+// startTest starts the clock on the first keystroke and, for the ghost pace
+// caret, loads the mode's fastest recorded run.
+func (m *TypingTestModel) startTest() {
+	m.state = StateRunning
+	m.startTime = time.Now()
+	m.ghost = nil
+	if m.options.PaceCaret == PaceGhost && m.store != nil {
+		if best, err := m.store.GetBestTypingTest(m.mode().ModeKey()); err == nil && best != nil {
+			m.ghost = best.Keystrokes
+		}
+	}
+	m.skipIndent()
+}
+
+// multiline reports whether the text has line breaks to type with Enter:
+// custom texts and code.
+func (m TypingTestModel) multiline() bool {
+	return (m.options.TestType == "custom" || m.options.TestType == "code") &&
+		strings.Contains(m.targetText, "\n")
+}
+
+// skipsIndent reports whether leading indentation is filled in for the user.
+func (m TypingTestModel) skipsIndent() bool {
+	return m.options.TestType == "code" && m.options.SkipIndent
+}
+
+// skipIndent fills in the indentation at the start of the current line, in
+// a code test with Skip Indent on. It only follows a correct line break, so
+// a missed Enter isn't papered over.
+func (m *TypingTestModel) skipIndent() {
+	n := len(m.typed)
+	if !m.skipsIndent() || n >= len(m.targetText) {
+		return
+	}
+	if n > 0 && (m.typed[n-1] != '\n' || m.targetText[n-1] != '\n') {
+		return
+	}
+	end := n
+	for end < len(m.targetText) && m.targetText[end] == ' ' {
+		end++
+	}
+	if end == n {
+		return
+	}
+	m.typed += m.targetText[n:end]
+	m.timeline = append(m.timeline, storage.Keystroke{
+		Ms:      time.Since(m.startTime).Milliseconds(),
+		Char:    m.targetText[n:end],
+		Correct: true,
+		Auto:    true,
+	})
+}
+
+// skippedIndent counts the characters of m.typed that are line-leading
+// indentation matching the text, which skipIndent fills in: they aren't
+// typed, so they don't count toward WPM.
+func (m TypingTestModel) skippedIndent() int {
+	if !m.skipsIndent() {
+		return 0
+	}
+	skipped := 0
+	lineStart := true
+	for i := 0; i < len(m.typed); i++ {
+		if lineStart && i < len(m.targetText) && m.typed[i] == ' ' && m.targetText[i] == ' ' {
+			skipped++
+			continue
+		}
+		lineStart = m.typed[i] == '\n'
+	}
+	return skipped
+}
+
+// recordKey adds the characters just appended to m.typed to the timeline and
+// counts each against the character the text expected there.
+func (m *TypingTestModel) recordKey(char string) {
+	n := len(m.typed)
+	m.timeline = append(m.timeline, storage.Keystroke{
+		Ms:      time.Since(m.startTime).Milliseconds(),
+		Char:    char,
+		Correct: n <= len(m.targetText) && m.typed[n-1] == m.targetText[n-1],
+	})
+	if m.charCounts == nil {
+		m.charCounts = make(map[storage.CharPair]int64)
+	}
+	for i := n - len(char); i < min(n, len(m.targetText)); i++ {
+		m.charCounts[storage.CharPair{Expected: m.targetText[i : i+1], Typed: m.typed[i : i+1]}]++
+	}
+}
+
+// recordDelete adds a deletion to the timeline, given the length of m.typed
+// before it.
+func (m *TypingTestModel) recordDelete(before int) {
+	if back := before - len(m.typed); back > 0 {
+		m.timeline = append(m.timeline, storage.Keystroke{
+			Ms:   time.Since(m.startTime).Milliseconds(),
+			Back: back,
+		})
+	}
+}
+
+// extendText keeps a time test supplied with words: whenever fewer than
+// timeLookahead characters are left to type, another batch is appended.
+func (m *TypingTestModel) extendText() {
+	if m.timeLimit() == 0 || len(m.targetText)-len(m.typed) >= timeLookahead {
+		return
+	}
+	m.targetText += " " + m.generateText()
+}
+
 func (m *TypingTestModel) generateText() string {
+	if m.options.TestType == "code" {
+		snippets := m.codeSnippets
+		if len(snippets) == 0 {
+			snippets = CodeSamples("")
+		}
+		if len(snippets) > 0 {
+			return snippets[rand.Intn(len(snippets))]
+		}
+	}
+
 	// If using custom test type and custom texts are available, use one directly
 	if m.options.TestType == "custom" && len(m.customTexts) > 0 {
 		// Pick a random custom text
@@ -330,15 +625,26 @@ func (m *TypingTestModel) generateText() string {
 		words = defaultWords
 	}
 
-	// Shuffle and select words
-	rand.Shuffle(len(words), func(i, j int) {
-		words[i], words[j] = words[j], words[i]
-	})
-
 	wordCount := m.options.WordCount
 	if wordCount <= 0 {
 		wordCount = 25
 	}
+	if m.timeLimit() > 0 {
+		wordCount = timeBatchWords
+	}
+
+	if m.options.TestType == "bigrams" && len(m.slowBigrams) > 0 {
+		// Weighted draw toward the user's slowest letter pairs
+		words = pickBigramWords(words, m.slowBigrams, wordCount)
+	} else if m.options.WeakKeys && len(m.weakChars) > 0 {
+		// Weighted draw toward the user's most-missed letters
+		words = pickWeakCharWords(words, m.weakChars, wordCount)
+	} else {
+		// Shuffle and select words
+		rand.Shuffle(len(words), func(i, j int) {
+			words[i], words[j] = words[j], words[i]
+		})
+	}
 
 	// Build the text
 	var result []string
@@ -454,9 +760,12 @@ func (m *TypingTestModel) resetTest() {
 	m.state = StateReady
 	m.errors = 0
 	m.resultRecorded = false
+	m.saveErr = nil
 	m.lastWPM = 0
 	m.rawInputCnt = 0
 	m.wpmEachSecond = nil
+	m.timeline = nil
+	m.charCounts = nil
 }
 
 // uncorrectedErrors counts characters in the final typed string that do not
@@ -485,7 +794,7 @@ func (m TypingTestModel) rawWPM(elapsedMinutes float64) float64 {
 	if elapsedMinutes <= 0 {
 		return 0
 	}
-	return (float64(len(m.typed)) / 5.0) / elapsedMinutes
+	return (float64(len(m.typed)-m.skippedIndent()) / 5.0) / elapsedMinutes
 }
 
 // netWPM penalises uncorrected errors: gross WPM minus one word per
@@ -546,11 +855,34 @@ func (m *TypingTestModel) recordTestResult() {
 
 	// Persist to database if store is available
 	if m.store != nil {
-		mode := storage.TypingTestMode{
-			WordCount:   m.options.WordCount,
-			Punctuation: m.options.Punctuation,
+		mode := m.mode()
+		if err := m.store.SaveTypingTestResultForMode(wpm, mode); err != nil {
+			m.saveErr = err
+		}
+		id, err := m.store.AddTypingTest(storage.TypingTestResult{
+			CompletedAt:       m.endTime,
+			Mode:              mode.ModeKey(),
+			TestType:          m.options.TestType,
+			Layout:            m.options.Layout,
+			Language:          m.options.Language,
+			Duration:          m.endTime.Sub(m.startTime),
+			RawWPM:            m.rawWPM(elapsedMinutes),
+			NetWPM:            wpm,
+			Accuracy:          m.accuracy(),
+			CPM:               m.cpm(elapsedMinutes),
+			Errors:            m.errors,
+			UncorrectedErrors: m.uncorrectedErrors(),
+			WPMSeries:         append(append([]float64{}, m.wpmEachSecond...), wpm),
+			TargetText:        m.targetText,
+			Keystrokes:        m.timeline,
+		})
+		if err == nil {
+			err = m.store.AddTypingTestChars(id, m.charCounts)
+			m.loadCharStats()
+		}
+		if err != nil && m.saveErr == nil {
+			m.saveErr = err
 		}
-		m.store.SaveTypingTestResultForMode(wpm, mode)
 	}
 
 	m.lastWPM = wpm
@@ -609,6 +941,11 @@ func (m *TypingTestModel) applyOption(opt Option, choiceIdx int) {
 		if idx := findOptIdx("layout"); idx >= 0 {
 			m.allOptions[idx].Value = opt.Choices[choiceIdx]
 		}
+	case "skip_indent":
+		m.options.SkipIndent = !m.options.SkipIndent
+		if idx := findOptIdx("skip_indent"); idx >= 0 {
+			m.allOptions[idx].Value = m.options.SkipIndent
+		}
 	case "live_wpm":
 		m.options.LiveWPM = !m.options.LiveWPM
 		if idx := findOptIdx("live_wpm"); idx >= 0 {
@@ -621,6 +958,17 @@ func (m *TypingTestModel) applyOption(opt Option, choiceIdx int) {
 		if idx := findOptIdx("test_length"); idx >= 0 {
 			m.allOptions[idx].Value = opt.Choices[choiceIdx]
 		}
+	case "time_limit":
+		secs, _ := strconv.Atoi(opt.Choices[choiceIdx])
+		m.options.TimeLimit = secs
+		if idx := findOptIdx("time_limit"); idx >= 0 {
+			m.allOptions[idx].Value = opt.Choices[choiceIdx]
+		}
+	case "weak_keys":
+		m.options.WeakKeys = !m.options.WeakKeys
+		if idx := findOptIdx("weak_keys"); idx >= 0 {
+			m.allOptions[idx].Value = m.options.WeakKeys
+		}
 	case "punctuation":
 		m.options.Punctuation = !m.options.Punctuation
 		if idx := findOptIdx("punctuation"); idx >= 0 {
@@ -655,6 +1003,8 @@ func (m *TypingTestModel) applyOption(opt Option, choiceIdx int) {
 			// Enter custom WPM input mode
 			m.inCustomWPMInput = true
 			m.customWPMInput = fmt.Sprintf("%.0f", m.options.CustomPaceWPM)
+		case "ghost":
+			m.options.PaceCaret = PaceGhost
 		}
 		if idx := findOptIdx("pace_caret"); idx >= 0 {
 			m.allOptions[idx].Value = opt.Choices[choiceIdx]
@@ -756,7 +1106,9 @@ func (m TypingTestModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case tea.KeyCtrlW:
 			// Ctrl+W: delete the previous word (matches typioca / shell readline).
 			if len(m.typed) > 0 && m.state == StateRunning {
+				before := len(m.typed)
 				m.typed = deleteLastWord(m.typed)
+				m.recordDelete(before)
 			}
 			return m, nil
 
@@ -764,6 +1116,7 @@ func (m TypingTestModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// Delete, which some terminals send for the physical Backspace key.
 		case tea.KeyBackspace, tea.KeyDelete, tea.KeyCtrlH:
 			if len(m.typed) > 0 && m.state == StateRunning {
+				before := len(m.typed)
 				if msg.Alt {
 					// Alt+Backspace: delete the previous word
 					m.typed = deleteLastWord(m.typed)
@@ -771,6 +1124,7 @@ func (m TypingTestModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					// Regular backspace: delete one character
 					m.typed = m.typed[:len(m.typed)-1]
 				}
+				m.recordDelete(before)
 			}
 			return m, nil
 
@@ -784,10 +1138,12 @@ func (m TypingTestModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.resetTest()
 				return m, nil
 			}
-			// If custom text with newlines, Enter types a newline
-			if m.state == StateRunning && m.options.TestType == "custom" && strings.Contains(m.targetText, "\n") {
+			// If custom text or code with newlines, Enter types a newline
+			if m.state == StateRunning && m.multiline() {
 				m.typed += "\n"
 				m.rawInputCnt++
+				m.recordKey("\n")
+				m.skipIndent()
 				// Check if character is wrong
 				if len(m.typed) <= len(m.targetText) {
 					if m.typed[len(m.typed)-1] != m.targetText[len(m.typed)-1] {
@@ -806,11 +1162,10 @@ func (m TypingTestModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 				return m, nil
 			}
-			// Start test on Enter for custom text mode
-			if m.state == StateReady && m.options.TestType == "custom" && strings.Contains(m.targetText, "\n") {
-				m.state = StateRunning
-				m.startTime = time.Now()
-				return m, secondTick()
+			// Start test on Enter for custom text and code
+			if m.state == StateReady && m.multiline() {
+				m.startTest()
+				return m, m.tick()
 			}
 			return m, nil
 
@@ -822,14 +1177,22 @@ func (m TypingTestModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 			startedNow := false
 			if m.state == StateReady {
-				m.state = StateRunning
-				m.startTime = time.Now()
+				m.startTest()
 				startedNow = true
 			}
 
+			if m.state == StateRunning && m.timeUp(time.Now()) {
+				// The deadline passed before its tick arrived: this key
+				// is too late to count.
+				m.finishTimed()
+				return m, nil
+			}
+
 			if m.state == StateRunning {
 				m.typed += char
 				m.rawInputCnt++
+				m.recordKey(char)
+				m.extendText()
 
 				// Check if character is wrong (only count errors for target length)
 				if len(m.typed) <= len(m.targetText) {
@@ -842,8 +1205,9 @@ func (m TypingTestModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 
 				// Check if finished: test completes when we've typed the exact target length
-				// AND the last character is correct
-				if len(m.typed) == len(m.targetText) {
+				// AND the last character is correct. Time tests only end at
+				// their deadline.
+				if m.timeLimit() == 0 && len(m.typed) == len(m.targetText) {
 					// Check if last character matches
 					if m.typed[len(m.typed)-1] == m.targetText[len(m.typed)-1] {
 						m.state = StateFinished
@@ -854,17 +1218,22 @@ func (m TypingTestModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 			}
 			if startedNow {
-				return m, secondTick()
+				return m, m.tick()
 			}
 			return m, nil
 		}
 
 	case tickMsg:
 		// Sample net WPM once per second while running, for the results graph.
+		// A time test ends on the tick that reaches its deadline.
 		if m.state == StateRunning {
+			if m.timeUp(time.Time(msg)) {
+				m.finishTimed()
+				return m, nil
+			}
 			elapsed := time.Since(m.startTime).Minutes()
 			m.wpmEachSecond = append(m.wpmEachSecond, m.netWPM(elapsed))
-			return m, secondTick()
+			return m, m.tick()
 		}
 		return m, nil
 
@@ -1088,15 +1457,7 @@ func (m TypingTestModel) View() string {
 	}
 
 	var b strings.Builder
-
-	// Calculate box width based on terminal width
-	boxWidth := m.width - 8
-	if boxWidth < 40 {
-		boxWidth = 40
-	}
-	if boxWidth > 100 {
-		boxWidth = 100
-	}
+	boxWidth := m.boxWidth()
 
 	// Only show the menubar when NOT running, but RESERVE its height while
 	// running so the box (and the words) never shift vertically on keystroke 1.
@@ -1140,6 +1501,10 @@ func (m TypingTestModel) View() string {
 	// fills it while running; it's blank (but height-reserved) when ready.
 	if m.state == StateRunning {
 		testContent.WriteString("\n\n")
+		if limit := m.timeLimit(); limit > 0 {
+			left := time.Until(m.startTime.Add(limit)).Round(time.Second)
+			testContent.WriteString(fmt.Sprintf("%s %ds  ", resultLabelStyle.Render("Time:"), int(max(left, 0)/time.Second)))
+		}
 		if m.options.LiveWPM {
 			elapsedMin := time.Since(m.startTime).Minutes()
 			testContent.WriteString(fmt.Sprintf(
@@ -1190,6 +1555,18 @@ func (m TypingTestModel) View() string {
 	return m.centerContent(b.String())
 }
 
+// boxWidth is the width of the typing box for the terminal width.
+func (m TypingTestModel) boxWidth() int {
+	boxWidth := m.width - 8
+	if boxWidth < 40 {
+		boxWidth = 40
+	}
+	if boxWidth > 100 {
+		boxWidth = 100
+	}
+	return boxWidth
+}
+
 // renderMenuBar renders the top menubar
 func (m TypingTestModel) renderMenuBar() string {
 	// Stats button
@@ -1445,21 +1822,18 @@ func (m TypingTestModel) renderOptions() string {
 	return optionsBoxStyle.Render(b.String())
 }
 
-func (m TypingTestModel) renderText() string {
-	var b strings.Builder
-
-	// Use box-appropriate width for wrapping
-	maxWidth := m.width - 16 // Account for box padding and borders
-	if maxWidth <= 0 {
-		maxWidth = 70
-	}
-	if maxWidth > 90 {
-		maxWidth = 90
+// pacePosition is the index of the character the pace caret is on, or -1
+// when it is off or has nothing to pace against.
+func (m TypingTestModel) pacePosition() int {
+	if m.state != StateRunning {
+		return -1
 	}
-
-	// Calculate pace caret position
 	pacePos := -1
-	if m.state == StateRunning && m.options.PaceCaret != PaceOff {
+	if m.options.PaceCaret == PaceGhost {
+		if len(m.ghost) > 0 {
+			pacePos = min(typedLenAt(m.ghost, time.Since(m.startTime)), len(m.targetText)-1)
+		}
+	} else if m.options.PaceCaret != PaceOff {
 		elapsed := time.Since(m.startTime).Seconds()
 		var targetWPM float64
 		switch m.options.PaceCaret {
@@ -1478,12 +1852,28 @@ func (m TypingTestModel) renderText() string {
 			}
 		}
 	}
+	return pacePos
+}
+
+func (m TypingTestModel) renderText() string {
+	var b strings.Builder
+
+	// Use box-appropriate width for wrapping
+	maxWidth := m.width - 16 // Account for box padding and borders
+	if maxWidth <= 0 {
+		maxWidth = 70
+	}
+	if maxWidth > 90 {
+		maxWidth = 90
+	}
+
+	pacePos := m.pacePosition()
 
 	target := m.targetText
 	typed := m.typed
 
-	// Check if custom text mode with newlines - use special rendering
-	if m.options.TestType == "custom" && strings.Contains(target, "\n") {
+	// Custom text or code with newlines keeps its line breaks
+	if m.multiline() {
 		return m.renderCustomTextWithNewlines(maxWidth, pacePos)
 	}
 
@@ -1492,6 +1882,7 @@ func (m TypingTestModel) renderText() string {
 
 	lineLen := 0
 	charIdx := 0
+	line, cursorLine := 0, 0
 
 	for wordIdx, word := range words {
 		wordLen := len(word)
@@ -1506,6 +1897,7 @@ func (m TypingTestModel) renderText() string {
 		if lineLen > 0 && lineLen+spaceNeeded > maxWidth {
 			b.WriteString("\n")
 			lineLen = 0
+			line++
 		}
 
 		// Render each character of the word
@@ -1520,6 +1912,7 @@ func (m TypingTestModel) renderText() string {
 			} else if charIdx == len(typed) {
 				// Cursor position
 				b.WriteString(cursorStyle.Render(string(char)))
+				cursorLine = line
 			} else if charIdx == pacePos {
 				b.WriteString(paceCaretStyle.Render(string(char)))
 			} else {
@@ -1559,6 +1952,7 @@ func (m TypingTestModel) renderText() string {
 				}
 			} else if charIdx == len(typed) {
 				b.WriteString(cursorStyle.Render(spaceChar))
+				cursorLine = line
 			} else if charIdx == pacePos {
 				b.WriteString(paceCaretStyle.Render(spaceChar))
 			} else {
@@ -1581,9 +1975,21 @@ func (m TypingTestModel) renderText() string {
 		}
 	}
 
+	// A time test's text keeps growing: show only the lines around the
+	// cursor, so the box stays the same height.
+	if m.timeLimit() > 0 {
+		return windowLines(b.String(), cursorLine-1, timeVisibleLines)
+	}
 	return b.String()
 }
 
+// windowLines returns n lines of s starting at line first (clamped to 0).
+func windowLines(s string, first, n int) string {
+	lines := strings.Split(s, "\n")
+	first = min(max(first, 0), len(lines))
+	return strings.Join(lines[first:min(first+n, len(lines))], "\n")
+}
+
 // renderCustomTextWithNewlines renders custom text preserving newlines and adding
 // tab indentation for lines that are too long for the terminal
 func (m TypingTestModel) renderCustomTextWithNewlines(maxWidth int, pacePos int) string {
@@ -1676,6 +2082,12 @@ func (m TypingTestModel) renderResults() string {
 	accuracy := m.accuracy()
 	cpm := m.cpm(elapsedMin)
 
+	// A time test's text runs on past what was typed.
+	chars := len(m.targetText)
+	if m.timeLimit() > 0 {
+		chars = len(m.typed)
+	}
+
 	pbIndicator := ""
 	if wpm > m.personalBest && m.personalBest > 0 {
 		pbIndicator = " ** NEW PB! **"
@@ -1696,9 +2108,17 @@ func (m TypingTestModel) renderResults() string {
 		resultLabelStyle.Render("Time:"),
 		resultValueStyle.Render(fmt.Sprintf("%.1fs", duration)),
 		resultLabelStyle.Render("Chars:"),
-		resultValueStyle.Render(fmt.Sprintf("%d", len(m.targetText))),
+		resultValueStyle.Render(fmt.Sprintf("%d", chars)),
 	)
 
+	if m.saveErr != nil {
+		results += "\n\n" + incorrectStyle.Render(fmt.Sprintf("Result not saved: %v", m.saveErr))
+	}
+
+	if weak := m.renderWeakKeys(); weak != "" {
+		results += "\n\n" + weak
+	}
+
 	// WPM-over-time graph (typioca-style), shown when we have enough samples.
 	if graph := m.renderWPMGraph(); graph != "" {
 		results += "\n\n" + graph
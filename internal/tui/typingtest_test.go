@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/aayushbajaj/typing-telemetry/internal/storage"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
@@ -654,6 +655,34 @@ func TestRecordTestResultLogic(t *testing.T) {
 	}
 }
 
+func TestRecordTestResultShowsSaveError(t *testing.T) {
+	t.Setenv(storage.DataDirEnv, t.TempDir())
+	store, err := storage.New()
+	if err != nil {
+		t.Fatalf("storage.New: %v", err)
+	}
+	store.Close() // every write now fails
+
+	model := NewTypingTestWithStore("", 10, store)
+	model.targetText = "test"
+	model.typed = "test"
+	model.state = StateFinished
+	model.startTime = time.Now().Add(-time.Second)
+	model.endTime = time.Now()
+	model.recordTestResult()
+
+	if model.saveErr == nil {
+		t.Fatal("a failed write should be kept as saveErr")
+	}
+	if !strings.Contains(model.renderResults(), "Result not saved") {
+		t.Error("the results screen should say the result wasn't saved")
+	}
+	model.resetTest()
+	if model.saveErr != nil {
+		t.Error("a new test should clear saveErr")
+	}
+}
+
 func TestRecordTestResultNotFinished(t *testing.T) {
 	model := NewTypingTest("", 10)
 	model.state = StateRunning // Not finished
@@ -1675,3 +1704,90 @@ func TestBackspaceAliases(t *testing.T) {
 		}
 	}
 }
+
+func TestTimeModeEndsAtDeadline(t *testing.T) {
+	model := NewTypingTest("", 25)
+	model.SetTimeLimit(15)
+	if model.options.TestType != "time" || model.timeLimit() != 15*time.Second {
+		t.Fatalf("SetTimeLimit: type %q, limit %v", model.options.TestType, model.timeLimit())
+	}
+	if got := model.mode().ModeKey(); got != "mode_15s_punct" {
+		t.Errorf("mode key = %q, want mode_15s_punct", got)
+	}
+
+	model.state = StateRunning
+	model.startTime = time.Now().Add(-10 * time.Second)
+	model.typed = model.targetText[:20]
+
+	// A tick before the deadline samples and keeps going.
+	updated, cmd := model.Update(tickMsg(model.startTime.Add(10 * time.Second)))
+	model = updated.(TypingTestModel)
+	if model.state != StateRunning || cmd == nil || len(model.wpmEachSecond) != 1 {
+		t.Fatalf("tick before deadline: state %v, cmd %v, samples %d", model.state, cmd, len(model.wpmEachSecond))
+	}
+
+	// The tick at the deadline ends the test exactly on time.
+	updated, _ = model.Update(tickMsg(model.startTime.Add(15 * time.Second)))
+	model = updated.(TypingTestModel)
+	if model.state != StateFinished || !model.resultRecorded {
+		t.Fatalf("tick at deadline: state %v, recorded %v", model.state, model.resultRecorded)
+	}
+	if d := model.endTime.Sub(model.startTime); d != 15*time.Second {
+		t.Errorf("test lasted %v, want 15s", d)
+	}
+}
+
+func TestTimeModeLateKeyIgnored(t *testing.T) {
+	model := NewTypingTest("", 25)
+	model.SetTimeLimit(30)
+	model.state = StateRunning
+	model.startTime = time.Now().Add(-31 * time.Second)
+
+	updated, _ := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'a'}})
+	model = updated.(TypingTestModel)
+	if model.state != StateFinished || model.typed != "" {
+		t.Errorf("key after deadline: state %v, typed %q", model.state, model.typed)
+	}
+}
+
+func TestTimeModeExtendsText(t *testing.T) {
+	model := NewTypingTest("", 10)
+	model.SetTimeLimit(60)
+	if n := len(strings.Fields(model.targetText)); n != timeBatchWords {
+		t.Errorf("time test starts with %d words, want %d", n, timeBatchWords)
+	}
+
+	model.state = StateRunning
+	model.startTime = time.Now()
+	start := len(model.targetText)
+	// Typing the whole text never finishes a time test: more words arrive.
+	for i := 0; i < start; i++ {
+		updated, _ := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{rune(model.targetText[i])}})
+		model = updated.(TypingTestModel)
+	}
+	if model.state != StateRunning {
+		t.Fatalf("state = %v after typing the initial text, want running", model.state)
+	}
+	if left := len(model.targetText) - len(model.typed); left < timeLookahead {
+		t.Errorf("only %d characters left to type, want at least %d", left, timeLookahead)
+	}
+}
+
+func TestWindowLines(t *testing.T) {
+	s := "a\nb\nc\nd\ne"
+	cases := []struct {
+		first int
+		want  string
+	}{
+		{-1, "a\nb\nc"},
+		{0, "a\nb\nc"},
+		{2, "c\nd\ne"},
+		{3, "d\ne"},
+		{9, ""},
+	}
+	for _, c := range cases {
+		if got := windowLines(s, c.first, 3); got != c.want {
+			t.Errorf("windowLines(%d) = %q, want %q", c.first, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/aayushbajaj/typing-telemetry/internal/storage"
+)
+
+// The "weak keys" option biases words toward the letters the user misses
+// most in typing tests.
+const (
+	// weakCharCount is how many of the most-missed letters to drill.
+	weakCharCount = 10
+	// weakCharMinAttempts ignores characters typed too few times to trust.
+	weakCharMinAttempts = 20
+	// weakCharBoost is the extra weight per weak-letter occurrence in a word.
+	weakCharBoost = 4.0
+	// weakKeysShown is how many weakest keys the results screen lists.
+	weakKeysShown = 5
+)
+
+// weakCharWeights gives the most-missed letters a weight of their miss rate
+// over the overall letter miss rate, so a letter missed twice as often as
+// usual counts twice as much. Letters are folded to lowercase, since the word
+// lists are; other characters are left out.
+func weakCharWeights(stats []storage.CharStat) map[rune]float64 {
+	folded := make(map[string]int) // index into letters
+	var letters []storage.CharStat
+	var attempts, misses int64
+	for _, c := range stats {
+		r, size := utf8.DecodeRuneInString(c.Char)
+		if size == 0 || size != len(c.Char) || !unicode.IsLetter(r) {
+			continue
+		}
+		key := string(unicode.ToLower(r))
+		if i, ok := folded[key]; ok {
+			letters[i].Attempts += c.Attempts
+			letters[i].Misses += c.Misses
+		} else {
+			folded[key] = len(letters)
+			letters = append(letters, storage.CharStat{Char: key, Attempts: c.Attempts, Misses: c.Misses})
+		}
+		attempts += c.Attempts
+		misses += c.Misses
+	}
+	if misses == 0 {
+		return nil
+	}
+	overall := float64(misses) / float64(attempts)
+
+	weak := storage.WeakestChars(letters, weakCharMinAttempts, weakCharCount)
+	if len(weak) == 0 {
+		return nil
+	}
+	weights := make(map[rune]float64, len(weak))
+	for _, c := range weak {
+		r, _ := utf8.DecodeRuneInString(c.Char)
+		weights[r] = c.MissRate() / overall
+	}
+	return weights
+}
+
+// weakCharWordWeight scores a word by how many weak letters it contains.
+func weakCharWordWeight(word string, weights map[rune]float64) float64 {
+	w := 1.0
+	for _, r := range strings.ToLower(word) {
+		w += weakCharBoost * weights[r]
+	}
+	return w
+}
+
+// pickWeakCharWords draws n words from words, each with probability
+// proportional to weakCharWordWeight.
+func pickWeakCharWords(words []string, weights map[rune]float64, n int) []string {
+	return pickWeightedWords(words, n, func(w string) float64 { return weakCharWordWeight(w, weights) })
+}
+
+// CharLabel names a test character for display: whitespace by name, anything
+// else as itself.
+func CharLabel(c string) string {
+	switch c {
+	case " ":
+		return "space"
+	case "\n":
+		return "enter"
+	case "\t":
+		return "tab"
+	}
+	return c
+}
+
+// renderWeakKeys lists the weakest keys across all tests, each with its miss
+// rate and what is most often typed instead.
+func (m TypingTestModel) renderWeakKeys() string {
+	if len(m.weakest) == 0 {
+		return ""
+	}
+	parts := make([]string, len(m.weakest))
+	for i, c := range m.weakest {
+		part := fmt.Sprintf("%s %.0f%%", CharLabel(c.Char), c.MissRate()*100)
+		if len(c.Subs) > 0 {
+			part += " (→" + CharLabel(c.Subs[0].Typed) + ")"
+		}
+		parts[i] = part
+	}
+	return resultLabelStyle.Render("Weakest keys:") + " " + resultValueStyle.Render(strings.Join(parts, "  "))
+}
@@ -0,0 +1,105 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aayushbajaj/typing-telemetry/internal/storage"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestWeakCharWeights(t *testing.T) {
+	stats := []storage.CharStat{
+		{Char: "e", Attempts: 100, Misses: 10},
+		{Char: "E", Attempts: 20, Misses: 2},  // folded into e
+		{Char: "t", Attempts: 80, Misses: 0},  // never missed
+		{Char: "q", Attempts: 5, Misses: 5},   // too few attempts
+		{Char: " ", Attempts: 95, Misses: 15}, // not a letter
+	}
+	w := weakCharWeights(stats)
+	// Letters: 17 misses in 205 attempts overall; e misses 12 of 120.
+	if len(w) != 1 || w['e'] == 0 {
+		t.Fatalf("weights = %v, want just e", w)
+	}
+	if got, want := w['e'], (12.0/120)/(17.0/205); got != want {
+		t.Errorf("weight of e = %v, want %v", got, want)
+	}
+	if weakCharWeights([]storage.CharStat{{Char: "a", Attempts: 50}}) != nil {
+		t.Error("no misses should give no weights")
+	}
+}
+
+func TestPickWeakCharWordsFavoursWeakLetters(t *testing.T) {
+	weights := map[rune]float64{'z': 3}
+	words := []string{"zoo", "cat", "dog", "sun"}
+	picked := pickWeakCharWords(words, weights, 1000)
+	if len(picked) != 1000 {
+		t.Fatalf("want 1000 words, got %d", len(picked))
+	}
+	n := 0
+	for _, w := range picked {
+		if w == "zoo" {
+			n++
+		}
+	}
+	// "zoo" weighs 1+4*3 = 13 against 1 for each other word: ~81%.
+	if n < 650 {
+		t.Errorf("zoo picked %d/1000 times, want most", n)
+	}
+}
+
+func TestCharCountsRecorded(t *testing.T) {
+	model := NewTypingTest("", 10)
+	model.targetText = "the"
+	for _, r := range "tre" {
+		updated, _ := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		model = updated.(TypingTestModel)
+	}
+	want := map[storage.CharPair]int64{
+		{Expected: "t", Typed: "t"}: 1,
+		{Expected: "h", Typed: "r"}: 1,
+		{Expected: "e", Typed: "e"}: 1,
+	}
+	if len(model.charCounts) != len(want) {
+		t.Fatalf("charCounts = %v, want %v", model.charCounts, want)
+	}
+	for p, n := range want {
+		if model.charCounts[p] != n {
+			t.Errorf("charCounts[%v] = %d, want %d", p, model.charCounts[p], n)
+		}
+	}
+
+	model.resetTest()
+	if model.charCounts != nil {
+		t.Error("resetTest should clear the character counts")
+	}
+}
+
+func TestRenderWeakKeys(t *testing.T) {
+	model := NewTypingTest("", 10)
+	if model.renderWeakKeys() != "" {
+		t.Error("no stats should render nothing")
+	}
+	model.weakest = []storage.CharStat{
+		{Char: "e", Attempts: 100, Misses: 12, Subs: []storage.CharSub{{Typed: "r", Count: 8}}},
+		{Char: " ", Attempts: 100, Misses: 5},
+	}
+	got := model.renderWeakKeys()
+	for _, want := range []string{"Weakest keys:", "e 12% (→r)", "space 5%"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("renderWeakKeys() = %q, want it to contain %q", got, want)
+		}
+	}
+}
+
+func TestApplyOptionWeakKeys(t *testing.T) {
+	model := NewTypingTest("", 10)
+	for _, opt := range model.allOptions {
+		if opt.ID == "weak_keys" {
+			model.applyOption(opt, 0)
+		}
+	}
+	if !model.options.WeakKeys {
+		t.Error("toggling weak_keys should turn the option on")
+	}
+}
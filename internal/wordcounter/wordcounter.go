@@ -15,9 +15,16 @@
 // This is the classic `wc -w` definition (whitespace-delimited tokens), which
 // is what users — and the tools they compare against — expect. Do not reintroduce
 // per-character bookkeeping or backspace rollback.
+//
+// Which keys are "printable" depends on the keyboard layout (see
+// internal/layout); the whitespace and delete keys below sit in the same
+// place on every layout.
 package wordcounter
 
-// macOS virtual keycodes used by the counter.
+import "github.com/aayushbajaj/typing-telemetry/internal/layout"
+
+// macOS virtual keycodes used by the counter and its tests. Positions are
+// physical: kcQ is the key labelled Q on a US QWERTY board.
 const (
 	kcA            = 0
 	kcS            = 1
@@ -79,11 +86,21 @@ type Counter struct {
 	// typingWord is true once a printable character has been seen since the
 	// last committed boundary. It mirrors WordCounter's isTypingWord flag.
 	typingWord bool
+
+	// layout returns the keyboard layout in effect; it is called per key so
+	// a layout switched at runtime applies immediately.
+	layout func() *layout.Layout
 }
 
-// New returns a fresh Counter.
+// New returns a fresh Counter for the QWERTY layout.
 func New() *Counter { return &Counter{} }
 
+// NewWithLayout returns a fresh Counter that asks current for the keyboard
+// layout on every keystroke (typically storage.Store.KeyboardLayout).
+func NewWithLayout(current func() *layout.Layout) *Counter {
+	return &Counter{layout: current}
+}
+
 // Event describes one keystroke as seen by the counter.
 type Event struct {
 	Keycode  int
@@ -117,7 +134,7 @@ func (c *Counter) Observe(e Event) bool {
 		return false
 	}
 
-	if isContentKey(e.Keycode) {
+	if c.isContentKey(e.Keycode) {
 		c.typingWord = true
 	}
 	return false
@@ -127,21 +144,12 @@ func (c *Counter) Observe(e Event) bool {
 // events like app switches when strict mode is enabled.
 func (c *Counter) Reset() { c.typingWord = false }
 
-// isContentKey reports whether a keycode represents a printable character
-// that should grow the in-progress word.
-func isContentKey(keycode int) bool {
-	switch keycode {
-	// Letters
-	case kcA, kcB, kcC, kcD, kcE, kcF, kcG, kcH, kcI, kcJ, kcK, kcL, kcM,
-		kcN, kcO, kcP, kcQ, kcR, kcS, kcT, kcU, kcV, kcW, kcX, kcY, kcZ:
-		return true
-	// Digit row
-	case kc0, kc1, kc2, kc3, kc4, kc5, kc6, kc7, kc8, kc9:
-		return true
-	// Common punctuation that appears mid-word or as part of typed content
-	case kcMinus, kcEqual, kcLeftBracket, kcRightBracket, kcBackslash,
-		kcSemicolon, kcQuote, kcComma, kcPeriod, kcSlash, kcBacktick:
-		return true
+// isContentKey reports whether a keycode types a printable character on the
+// active layout — a letter, digit or punctuation mark — that should grow the
+// in-progress word.
+func (c *Counter) isContentKey(keycode int) bool {
+	if c.layout == nil {
+		return layout.QWERTY().IsContent(keycode)
 	}
-	return false
+	return c.layout().IsContent(keycode)
 }
@@ -1,6 +1,10 @@
 package wordcounter
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/aayushbajaj/typing-telemetry/internal/layout"
+)
 
 // step is a tiny DSL row: a keycode + modifier flags + whether this event
 // should be reported as a completed word by Observe.
@@ -187,3 +191,25 @@ func TestArrowKeysIgnored(t *testing.T) {
 		t.Fatalf("want 1, got %d", got)
 	}
 }
+
+func TestLayoutDecidesContentKeys(t *testing.T) {
+	// The ISO § key (keycode 10) types nothing on US QWERTY but is content on
+	// a British ISO board.
+	const kcISOSection = 10
+	uk, err := layout.Get("qwerty-uk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		counter *Counter
+		want    bool
+	}{
+		{New(), false},
+		{NewWithLayout(func() *layout.Layout { return uk }), true},
+	} {
+		tc.counter.Observe(Event{Keycode: kcISOSection})
+		if got := tc.counter.Observe(Event{Keycode: kcSpace}); got != tc.want {
+			t.Errorf("§ SPACE completed a word = %v, want %v", got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+//go:build linux
+
+package x11
+
+// Pointer and display queries for internal/mousetracker. Like the keyboard
+// Poller, pointer tracking samples state (xproto.QueryPointer) rather than
+// subscribing to events, which needs no grabs and sees the pointer over every
+// window.
+
+import (
+	"time"
+
+	"github.com/jezek/xgb"
+	"github.com/jezek/xgb/randr"
+	"github.com/jezek/xgb/xproto"
+)
+
+// DefaultPointerInterval samples the pointer at ~60 Hz, about the rate macOS
+// delivers mouse-moved events; a click is held far longer than 16 ms.
+const DefaultPointerInterval = 16 * time.Millisecond
+
+// Pointer is the pointer's position on the root window and the mouse buttons
+// held at that moment.
+type Pointer struct {
+	X, Y    int
+	Buttons uint16 // xproto.ButtonMask1..5 bits
+}
+
+// pointerButtonMask keeps the button bits of a QueryPointer state mask.
+const pointerButtonMask = xproto.ButtonMask1 | xproto.ButtonMask2 | xproto.ButtonMask3 |
+	xproto.ButtonMask4 | xproto.ButtonMask5
+
+func queryPointer(c *xgb.Conn) (Pointer, error) {
+	root := xproto.Setup(c).DefaultScreen(c).Root
+	reply, err := xproto.QueryPointer(c, root).Reply()
+	if err != nil {
+		return Pointer{}, err
+	}
+	return Pointer{X: int(reply.RootX), Y: int(reply.RootY), Buttons: reply.Mask & pointerButtonMask}, nil
+}
+
+// QueryPointer returns the current pointer state via a one-shot connection.
+// The boolean is false if the server could not be reached or queried.
+func QueryPointer() (Pointer, bool) {
+	c, err := xgb.NewConn()
+	if err != nil {
+		return Pointer{}, false
+	}
+	defer c.Close()
+	p, err := queryPointer(c)
+	return p, err == nil
+}
+
+// StartPointerPoller begins sampling the pointer every interval (use
+// DefaultPointerInterval). handler is called on a background goroutine with
+// each sample that differs from the previous one. handler must not block.
+func StartPointerPoller(interval time.Duration, handler func(Pointer)) (*Poller, error) {
+	conn, err := xgb.NewConn()
+	if err != nil {
+		return nil, err
+	}
+	p := &Poller{conn: conn, stop: make(chan struct{})}
+	go p.pointerLoop(interval, handler)
+	return p, nil
+}
+
+func (p *Poller) pointerLoop(interval time.Duration, handler func(Pointer)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var prev Pointer
+	first := true
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+		}
+
+		cur, err := queryPointer(p.conn)
+		if err != nil {
+			return // connection closed by Stop, or a fatal protocol error
+		}
+		if first || cur != prev {
+			handler(cur)
+		}
+		prev = cur
+		first = false
+	}
+}
+
+// Display is one monitor's size in pixels and, as reported by the server,
+// in millimetres. The millimetre sizes are 0 when unknown.
+type Display struct {
+	WidthPx, HeightPx int
+	WidthMM, HeightMM int
+}
+
+// Displays lists the active monitors via RandR 1.5 monitors. Servers without
+// it report the default screen as a single display, sized from the screen's
+// physical dimensions.
+func Displays() ([]Display, bool) {
+	c, err := xgb.NewConn()
+	if err != nil {
+		return nil, false
+	}
+	defer c.Close()
+	screen := xproto.Setup(c).DefaultScreen(c)
+
+	if randr.Init(c) == nil {
+		if reply, err := randr.GetMonitors(c, screen.Root, true).Reply(); err == nil && len(reply.Monitors) > 0 {
+			out := make([]Display, 0, len(reply.Monitors))
+			for _, m := range reply.Monitors {
+				out = append(out, Display{
+					WidthPx: int(m.Width), HeightPx: int(m.Height),
+					WidthMM: int(m.WidthInMillimeters), HeightMM: int(m.HeightInMillimeters),
+				})
+			}
+			return out, true
+		}
+	}
+	return []Display{{
+		WidthPx: int(screen.WidthInPixels), HeightPx: int(screen.HeightInPixels),
+		WidthMM: int(screen.WidthInMillimeters), HeightMM: int(screen.HeightInMillimeters),
+	}}, true
+}
@@ -14,6 +14,8 @@
 // neither a usable XRecord reply stream (its cookie reader consumes the
 // EnableContext cookie after the first reply, so the stream deadlocks) nor an
 // xinput package. QueryKeymap is plain core protocol and sidesteps both.
+//
+// The same polling approach backs mouse tracking: see pointer_linux.go.
 package x11
 
 import (
@@ -0,0 +1,95 @@
+package stats
+
+import (
+	"math"
+	"time"
+)
+
+// Goals holds targets for one period (a day or a week). A zero field means no
+// goal for that metric.
+type Goals struct {
+	Keystrokes    int64
+	Words         int64
+	ActiveMinutes int64
+}
+
+// IsZero reports whether no goal is set.
+func (g Goals) IsZero() bool {
+	return g.Keystrokes <= 0 && g.Words <= 0 && g.ActiveMinutes <= 0
+}
+
+// MetBy reports whether d reaches every goal that is set. With no goals set
+// nothing is ever met, so goal streaks stay at zero until goals exist.
+func (g Goals) MetBy(d DayData) bool {
+	if g.IsZero() {
+		return false
+	}
+	if g.Keystrokes > 0 && d.Keystrokes < g.Keystrokes {
+		return false
+	}
+	if g.Words > 0 && d.Words < g.Words {
+		return false
+	}
+	if g.ActiveMinutes > 0 && d.ActiveMs/60000 < g.ActiveMinutes {
+		return false
+	}
+	return true
+}
+
+// GoalProgress is how far a count is toward its target.
+type GoalProgress struct {
+	Current int64
+	Target  int64
+	Percent float64 // may exceed 100
+	Met     bool
+	// Projected is when the target will be reached if the rate since the
+	// period's first activity holds. Zero when already met or when there is
+	// no rate to project from.
+	Projected time.Time
+}
+
+// Progress measures current against target. start is when counting began
+// for the rate — the first active hour of the day, say — and now is the
+// time of the measurement; the projection extrapolates current/(now-start).
+func Progress(current, target int64, start, now time.Time) GoalProgress {
+	p := GoalProgress{Current: current, Target: target}
+	if target <= 0 {
+		return p
+	}
+	p.Percent = float64(current) / float64(target) * 100
+	p.Met = current >= target
+	if p.Met || current <= 0 || !now.After(start) {
+		return p
+	}
+	perSec := float64(current) / now.Sub(start).Seconds()
+	remaining := float64(target-current) / perSec
+	if remaining > math.MaxInt64/float64(time.Second) {
+		return p // effectively never
+	}
+	p.Projected = now.Add(time.Duration(remaining * float64(time.Second)))
+	return p
+}
+
+// GoalStreaks returns the current and longest runs of consecutive days that
+// meet g. days must be chronological (oldest first) with the last entry being
+// today. Today not (yet) meeting the goals does not break the current streak:
+// it is still in progress, so the run ending yesterday counts.
+func GoalStreaks(days []DayData, g Goals) (current, longest int) {
+	run := 0
+	for _, d := range days {
+		if g.MetBy(d) {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	end := len(days)
+	if end > 0 && !g.MetBy(days[end-1]) {
+		end-- // today is still in progress
+	}
+	for i := end - 1; i >= 0 && g.MetBy(days[i]); i-- {
+		current++
+	}
+	return current, longest
+}
@@ -0,0 +1,77 @@
+package stats
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGoalsMetBy(t *testing.T) {
+	g := Goals{Words: 500, ActiveMinutes: 30}
+	tests := []struct {
+		name string
+		day  DayData
+		want bool
+	}{
+		{"both met", DayData{Words: 600, ActiveMs: 31 * 60000}, true},
+		{"words short", DayData{Words: 499, ActiveMs: 40 * 60000}, false},
+		{"time short", DayData{Words: 900, ActiveMs: 29*60000 + 59999}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := g.MetBy(tt.day); got != tt.want {
+				t.Errorf("MetBy(%+v) = %v, want %v", tt.day, got, tt.want)
+			}
+		})
+	}
+	if (Goals{}).MetBy(DayData{Keystrokes: 1}) {
+		t.Error("no goals should never be met")
+	}
+}
+
+func TestProgress(t *testing.T) {
+	start := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	now := start.Add(2 * time.Hour)
+
+	p := Progress(400, 1000, start, now)
+	if p.Met || p.Percent != 40 {
+		t.Fatalf("Progress = %+v", p)
+	}
+	// 200/hour leaves 600 for three more hours.
+	if want := now.Add(3 * time.Hour); !p.Projected.Equal(want) {
+		t.Errorf("Projected = %v, want %v", p.Projected, want)
+	}
+
+	if p := Progress(1200, 1000, start, now); !p.Met || p.Percent != 120 || !p.Projected.IsZero() {
+		t.Errorf("over target: %+v", p)
+	}
+	if p := Progress(0, 1000, start, now); !p.Projected.IsZero() {
+		t.Errorf("no activity should not project: %+v", p)
+	}
+	if p := Progress(10, 0, start, now); p.Percent != 0 || p.Met {
+		t.Errorf("no target: %+v", p)
+	}
+}
+
+func TestGoalStreaks(t *testing.T) {
+	g := Goals{Words: 100}
+	met, miss := DayData{Words: 150}, DayData{Words: 20}
+	tests := []struct {
+		name             string
+		days             []DayData
+		current, longest int
+	}{
+		{"empty", nil, 0, 0},
+		{"today met", []DayData{miss, met, met}, 2, 2},
+		{"today in progress", []DayData{met, met, miss}, 2, 2},
+		{"broken yesterday", []DayData{met, miss, miss}, 0, 1},
+		{"longer earlier run", []DayData{met, met, met, miss, met}, 1, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cur, long := GoalStreaks(tt.days, g)
+			if cur != tt.current || long != tt.longest {
+				t.Errorf("GoalStreaks() = %d, %d, want %d, %d", cur, long, tt.current, tt.longest)
+			}
+		})
+	}
+}
@@ -16,6 +16,7 @@ type DayData struct {
 	Date       time.Time
 	Keystrokes int64
 	Words      int64
+	ActiveMs   int64 // active typing time; used by active-time goals
 }
 
 func CalculateWeeklyAverage(days []DayData) float64 {